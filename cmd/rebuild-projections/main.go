@@ -0,0 +1,7 @@
+package main
+
+import "avito-internship/internal/app"
+
+func main() {
+	app.RebuildProjections()
+}
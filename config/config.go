@@ -10,9 +10,154 @@ import (
 type Config struct {
 	Env      string        `env:"ENV,required"`
 	TokenTTL time.Duration `env:"TOKEN_TTL,required"`
-	Salt     string        `env:"SALT,required"`
 	PgDSN    string        `env:"POSTGRES_DSN,required"`
-	RedisDSN string        `env:"REDIS_DSN,required"`
+	Admins   []string      `env:"ADMINS" envSeparator:","`
+	// AutoRegister creates an account on /api/auth when the username is unknown.
+	AutoRegister bool `env:"AUTO_REGISTER" envDefault:"true"`
+	JWT          JWT
+	Login        Login
+	Password     Password
+	TwoFactor    TwoFactor
+	OIDC         OIDC
+	Cache        Cache
+	Redis        Redis
+	RateLimit    RateLimit
+
+	// ImpersonationTTL is the lifetime of tokens admins act on behalf of users with.
+	ImpersonationTTL time.Duration `env:"IMPERSONATION_TTL" envDefault:"15m"`
+}
+
+// Cache selects the cache backend. The redis and layered backends connect to the Redis group.
+type Cache struct {
+	// Backend is redis, memory or layered. The memory backend is not shared
+	// between instances, so it is meant for local runs and tests. The layered
+	// backend keeps a local memory cache in front of redis and invalidates it
+	// on every instance via redis pub/sub.
+	Backend string `env:"CACHE_BACKEND" envDefault:"redis"`
+	// MemoryMaxEntries bounds the memory backend and the local tier of the layered one,
+	// the least recently used entries are evicted.
+	MemoryMaxEntries int `env:"CACHE_MEMORY_MAX_ENTRIES" envDefault:"10000"`
+	// LocalTTL bounds how long the layered backend serves a local copy, even if an invalidation is lost.
+	LocalTTL time.Duration `env:"CACHE_LOCAL_TTL" envDefault:"5s"`
+	// Namespace prefixes the redis keys of the cache, flushing the cache removes only them.
+	Namespace string `env:"CACHE_NAMESPACE" envDefault:"cache"`
+
+	// BreakerThreshold consecutive redis failures make the service bypass redis.
+	BreakerThreshold int `env:"CACHE_BREAKER_THRESHOLD" envDefault:"5"`
+	// BreakerCooldown is how often bypassed redis is probed and failed deletions are replayed.
+	BreakerCooldown time.Duration `env:"CACHE_BREAKER_COOLDOWN" envDefault:"10s"`
+
+	// WarmUpUsers most active users get their info preloaded at startup, 0 disables the warm-up.
+	WarmUpUsers int `env:"CACHE_WARMUP_USERS" envDefault:"100"`
+	// WarmUpPeriod is how far back the activity of users is counted.
+	WarmUpPeriod time.Duration `env:"CACHE_WARMUP_PERIOD" envDefault:"168h"`
+	// WarmUpConcurrency bounds the user info loads running at once.
+	WarmUpConcurrency int `env:"CACHE_WARMUP_CONCURRENCY" envDefault:"4"`
+}
+
+// Redis configures the connection of the redis and layered cache backends.
+type Redis struct {
+	// Mode is standalone, sentinel or cluster.
+	Mode string `env:"REDIS_MODE" envDefault:"standalone"`
+	// DSN is the server URL in standalone mode, rediss:// enables TLS.
+	DSN string `env:"REDIS_DSN"`
+	// Addrs are the sentinels in sentinel mode and the seed nodes in cluster mode.
+	Addrs []string `env:"REDIS_ADDRS" envSeparator:","`
+	// MasterName is the master monitored by the sentinels.
+	MasterName string `env:"REDIS_MASTER_NAME"`
+	// Username and Password are used in sentinel and cluster modes, a standalone DSN carries its own.
+	Username         string `env:"REDIS_USER"`
+	Password         string `env:"REDIS_PASSWORD"`
+	SentinelPassword string `env:"REDIS_SENTINEL_PASSWORD"`
+	DB               int    `env:"REDIS_DB"`
+
+	// Zero pool settings keep the go-redis defaults.
+	PoolSize     int           `env:"REDIS_POOL_SIZE"`
+	MinIdleConns int           `env:"REDIS_MIN_IDLE_CONNS"`
+	DialTimeout  time.Duration `env:"REDIS_DIAL_TIMEOUT" envDefault:"5s"`
+	ReadTimeout  time.Duration `env:"REDIS_READ_TIMEOUT" envDefault:"3s"`
+	WriteTimeout time.Duration `env:"REDIS_WRITE_TIMEOUT" envDefault:"3s"`
+	PoolTimeout  time.Duration `env:"REDIS_POOL_TIMEOUT" envDefault:"4s"`
+
+	TLS bool `env:"REDIS_TLS"`
+	// TLSCAFile verifies the server against a private CA.
+	TLSCAFile string `env:"REDIS_TLS_CA_FILE"`
+	// TLSCertFile and TLSKeyFile hold the client certificate for mutual TLS.
+	TLSCertFile           string `env:"REDIS_TLS_CERT_FILE"`
+	TLSKeyFile            string `env:"REDIS_TLS_KEY_FILE"`
+	TLSServerName         string `env:"REDIS_TLS_SERVER_NAME"`
+	TLSInsecureSkipVerify bool   `env:"REDIS_TLS_INSECURE_SKIP_VERIFY"`
+
+	// ConnectAttempts pings are made at startup, the backoff between them doubles.
+	ConnectAttempts int           `env:"REDIS_CONNECT_ATTEMPTS" envDefault:"5"`
+	ConnectBackoff  time.Duration `env:"REDIS_CONNECT_BACKOFF" envDefault:"1s"`
+}
+
+// RateLimit limits requests to /api/auth, /api/register, /api/sendCoin and /api/buy per user and per IP.
+// The user of /api/auth and /api/register is the username in the request body.
+// Limits are shared between instances through redis, unless the memory cache backend is used.
+// Zero disables a limit.
+type RateLimit struct {
+	// Window is the period the limits are counted over, the allowance refills evenly within it.
+	Window          time.Duration `env:"RATE_LIMIT_WINDOW" envDefault:"1m"`
+	AuthPerUser     int           `env:"RATE_LIMIT_AUTH_PER_USER" envDefault:"10"`
+	AuthPerIP       int           `env:"RATE_LIMIT_AUTH_PER_IP" envDefault:"30"`
+	SendCoinPerUser int           `env:"RATE_LIMIT_SEND_COIN_PER_USER" envDefault:"60"`
+	SendCoinPerIP   int           `env:"RATE_LIMIT_SEND_COIN_PER_IP" envDefault:"300"`
+	BuyPerUser      int           `env:"RATE_LIMIT_BUY_PER_USER" envDefault:"60"`
+	BuyPerIP        int           `env:"RATE_LIMIT_BUY_PER_IP" envDefault:"300"`
+
+	// FallbackCooldown is how long limits are kept in memory after redis fails.
+	FallbackCooldown time.Duration `env:"RATE_LIMIT_FALLBACK_COOLDOWN" envDefault:"10s"`
+}
+
+// OIDC configures login via a corporate identity provider. It is disabled when Issuer is empty.
+type OIDC struct {
+	Issuer       string `env:"OIDC_ISSUER"`
+	ClientID     string `env:"OIDC_CLIENT_ID"`
+	ClientSecret string `env:"OIDC_CLIENT_SECRET"`
+	// RedirectURL must point to /api/oidc/callback and be registered at the provider.
+	RedirectURL string `env:"OIDC_REDIRECT_URL"`
+	// EmailDomain restricts logins to addresses in the domain. It is required when Issuer is set.
+	EmailDomain string `env:"OIDC_EMAIL_DOMAIN"`
+}
+
+type TwoFactor struct {
+	// Issuer is the account label shown in authenticator apps.
+	Issuer       string        `env:"TWO_FACTOR_ISSUER" envDefault:"Avito shop"`
+	ChallengeTTL time.Duration `env:"TWO_FACTOR_CHALLENGE_TTL" envDefault:"5m"`
+}
+
+// Password configures the policy for new passwords and their hashing.
+type Password struct {
+	MinLength int `env:"PASSWORD_MIN_LENGTH" envDefault:"8"`
+	MaxLength int `env:"PASSWORD_MAX_LENGTH" envDefault:"72"`
+	// BreachedListFile lists rejected passwords, one per line.
+	BreachedListFile string `env:"PASSWORD_BREACHED_LIST_FILE"`
+	BcryptCost       int    `env:"PASSWORD_BCRYPT_COST" envDefault:"10"`
+}
+
+// Login configures brute-force protection of /api/auth.
+// Attempts are tracked in the cache, so LockoutDuration should not exceed its TTL.
+type Login struct {
+	FreeAttempts    int           `env:"LOGIN_FREE_ATTEMPTS" envDefault:"3"`
+	MaxAttempts     int           `env:"LOGIN_MAX_ATTEMPTS" envDefault:"10"`
+	IPFreeAttempts  int           `env:"LOGIN_IP_FREE_ATTEMPTS" envDefault:"10"`
+	IPMaxAttempts   int           `env:"LOGIN_IP_MAX_ATTEMPTS" envDefault:"50"`
+	BaseDelay       time.Duration `env:"LOGIN_BASE_DELAY" envDefault:"1s"`
+	LockoutDuration time.Duration `env:"LOGIN_LOCKOUT_DURATION" envDefault:"15m"`
+}
+
+type JWT struct {
+	// PrivateKeyFile is a PEM encoded RSA or Ed25519 key new tokens are signed with.
+	PrivateKeyFile string `env:"JWT_PRIVATE_KEY_FILE"`
+	// VerificationKeyFiles are PEM encoded keys still accepted during a key rotation.
+	VerificationKeyFiles []string `env:"JWT_VERIFICATION_KEY_FILES" envSeparator:","`
+	// Issuer and Audience are put into the iss and aud claims and required in accepted tokens.
+	Issuer   string `env:"JWT_ISSUER" envDefault:"avito-shop"`
+	Audience string `env:"JWT_AUDIENCE" envDefault:"avito-shop-api"`
+	// ClockSkew is tolerated when checking the token validity period.
+	ClockSkew time.Duration `env:"JWT_CLOCK_SKEW" envDefault:"30s"`
 }
 
 type Kafka struct {
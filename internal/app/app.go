@@ -2,24 +2,41 @@ package app
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"avito-internship/config"
+	"avito-internship/internal/cache"
+	"avito-internship/internal/cache/breaker"
+	"avito-internship/internal/cache/layered"
+	"avito-internship/internal/cache/memory"
 	"avito-internship/internal/cache/redis"
 	v1 "avito-internship/internal/controller/http/v1"
+	"avito-internship/internal/ratelimit"
 	"avito-internship/internal/repository"
 	"avito-internship/internal/service"
+	"avito-internship/internal/utils/jwt"
+	"avito-internship/internal/utils/oidc"
+	"avito-internship/internal/utils/password"
 	"avito-internship/pkg/logger"
 	"avito-internship/pkg/postgres"
 
 	"github.com/gofiber/contrib/fiberzap/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	goredis "github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
+// oidcRequestTimeout bounds the requests to the identity provider.
+const oidcRequestTimeout = 10 * time.Second
+
+// envDev is the ENV of local development, where an ephemeral JWT signing key is allowed.
+const envDev = "dev"
+
 func Run() {
 	// Config init
 	cfg := config.MustLoad()
@@ -35,28 +52,73 @@ func Run() {
 	pg := postgres.NewPostgres(ctx, log, cfg.PgDSN)
 	log.Info("Database initialization: OK.")
 
-	// Cache init
-	log.Info("Cache initialization...")
-	cache := redis.NewRedisCache(cfg.RedisDSN, log)
-	log.Info("Cache initialization: OK.")
+	// JWT keys init
+	log.Info("JWT keys initialization...")
+	keys := mustLoadKeyRing(log, cfg.Env, cfg.JWT)
+	log.Info("JWT keys initialization: OK.", zap.String("kid", keys.SigningKeyID()))
+
+	// Password policy init
+	log.Info("Password policy initialization...")
+	passwordPolicy, err := password.LoadPolicy(cfg.Password.MinLength, cfg.Password.MaxLength, cfg.Password.BreachedListFile)
+	if err != nil {
+		log.Fatal("Failed to load password policy", zap.Error(err))
+	}
+	log.Info("Password policy initialization: OK.")
+
+	// OIDC provider init
+	oidcProvider := mustLoadOIDCProvider(ctx, log, cfg.OIDC)
 
 	// Repositories init
 	log.Info("Repository initialization...")
 	repositories := repository.NewRepositories(pg)
 	log.Info("Repository initialization: OK.")
 
+	// Cache init
+	log.Info("Cache initialization...")
+	cache, redisClient := mustNewCache(ctx, log, cfg, repositories.CacheInvalidation)
+	log.Info("Cache initialization: OK.", zap.String("backend", cfg.Cache.Backend))
+
 	// Services init
 	log.Info("Services initialization...")
 	deps := service.ServicesDependencies{
-		Log:      log,
-		Cache:    cache,
-		Repos:    repositories,
-		TokenTTL: cfg.TokenTTL,
-		Salt:     cfg.Salt,
+		Log:             log,
+		Cache:           cache,
+		Repos:           repositories,
+		TokenTTL:        cfg.TokenTTL,
+		Keys:            keys,
+		AutoRegister:    cfg.AutoRegister,
+		PasswordPolicy:  passwordPolicy,
+		BcryptCost:      cfg.Password.BcryptCost,
+		ChallengeTTL:    cfg.TwoFactor.ChallengeTTL,
+		TOTPIssuer:      cfg.TwoFactor.Issuer,
+		OIDCProvider:    oidcProvider,
+		OIDCEmailDomain: cfg.OIDC.EmailDomain,
+		LoginLimits: service.LoginAttemptsLimits{
+			FreeAttempts:    cfg.Login.FreeAttempts,
+			MaxAttempts:     cfg.Login.MaxAttempts,
+			IPFreeAttempts:  cfg.Login.IPFreeAttempts,
+			IPMaxAttempts:   cfg.Login.IPMaxAttempts,
+			BaseDelay:       cfg.Login.BaseDelay,
+			LockoutDuration: cfg.Login.LockoutDuration,
+		},
+		ImpersonationTTL: cfg.ImpersonationTTL,
+		CacheWarmUp: service.CacheWarmUpConfig{
+			Users:       cfg.Cache.WarmUpUsers,
+			Period:      cfg.Cache.WarmUpPeriod,
+			Concurrency: cfg.Cache.WarmUpConcurrency,
+		},
 	}
 	services := service.NewServices(deps)
 	log.Info("Services initialization: OK.")
 
+	// Cache warm-up runs in the background, requests are served meanwhile.
+	if cfg.Cache.WarmUpUsers > 0 {
+		go func() {
+			// WarmCache logs the outcome itself.
+			_, _ = services.CacheAdmin.WarmCache(ctx, service.WarmCacheInput{})
+		}()
+	}
+
 	// Channel for signals
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
@@ -72,8 +134,10 @@ func Run() {
 	app.Use(fiberzap.New(fiberzap.Config{
 		Logger: log,
 	}))
-	middleware := v1.NewAuthMiddleware(log, services.Auth)
-	v1.InitRouter(ctx, log, app, services, middleware.Auth())
+	middleware := v1.NewAuthMiddleware(log, services.Auth, services.APIKey, services.Impersonation)
+	adminMiddleware := v1.NewAdminMiddleware(log, cfg.Admins)
+	rateLimitMiddleware := v1.NewRateLimitMiddleware(log, newRateLimiter(log, cfg.RateLimit, redisClient))
+	v1.InitRouter(ctx, log, app, services, middleware.Auth(), adminMiddleware.Admin(), rateLimitMiddleware, rateLimits(cfg.RateLimit))
 	go func() {
 		if err := app.Listen(":8080"); err != nil {
 			log.Error("Fiber server error",
@@ -109,3 +173,166 @@ func Run() {
 
 	log.Info("Gracefully stopped")
 }
+
+// mustNewCache creates the cache backend selected in the config, and returns
+// the redis client it uses, nil for the memory backend.
+// Redis is bypassed while it is unavailable, failed deletions are queued in queue and replayed.
+func mustNewCache(ctx context.Context, log *zap.Logger, cfg *config.Config, queue breaker.Queue) (cache.Cache, goredis.UniversalClient) {
+	breakerOpts := breaker.Options{
+		Threshold: cfg.Cache.BreakerThreshold,
+		Cooldown:  cfg.Cache.BreakerCooldown,
+	}
+
+	switch cfg.Cache.Backend {
+	case "redis":
+		redisCache := redis.NewRedisCache(mustConnectRedis(ctx, log, cfg.Redis), cfg.Cache.Namespace, log)
+		return breaker.NewBreakerCache(redisCache, queue, log, breakerOpts), redisCache.Client
+	case "memory":
+		log.Warn("Using the in-memory cache, it is not shared between instances")
+		return memory.NewMemoryCache(cfg.Cache.MemoryMaxEntries, log), nil
+	case "layered":
+		redisCache := redis.NewRedisCache(mustConnectRedis(ctx, log, cfg.Redis), cfg.Cache.Namespace, log)
+		l2 := breaker.NewBreakerCache(redisCache, queue, log, breakerOpts)
+		l1 := memory.NewMemoryCache(cfg.Cache.MemoryMaxEntries, log)
+		return layered.NewLayeredCache(l1, l2, redis.NewInvalidationBus(redisCache.Client, log), cfg.Cache.LocalTTL, log), redisCache.Client
+	default:
+		log.Fatal("Unknown cache backend", zap.String("backend", cfg.Cache.Backend))
+		return nil, nil
+	}
+}
+
+// newRateLimiter keeps the limits in redis when the cache uses it, falling back
+// to per-instance limits while redis is unavailable.
+func newRateLimiter(log *zap.Logger, cfg config.RateLimit, redisClient goredis.UniversalClient) ratelimit.Limiter {
+	if redisClient == nil {
+		log.Warn("Rate limits are kept in memory, they are not shared between instances")
+		return ratelimit.NewMemoryLimiter()
+	}
+
+	return ratelimit.NewFallbackLimiter(
+		ratelimit.NewRedisLimiter(redisClient, "ratelimit:"),
+		ratelimit.NewMemoryLimiter(),
+		cfg.FallbackCooldown,
+		log,
+	)
+}
+
+// rateLimits maps the config to the limits of the route groups.
+func rateLimits(cfg config.RateLimit) v1.RateLimits {
+	limit := func(requests int) ratelimit.Limit {
+		return ratelimit.Limit{Requests: requests, Window: cfg.Window}
+	}
+
+	return v1.RateLimits{
+		Auth: v1.RateLimit{
+			PerUser: limit(cfg.AuthPerUser),
+			PerIP:   limit(cfg.AuthPerIP),
+		},
+		SendCoin: v1.RateLimit{
+			PerUser: limit(cfg.SendCoinPerUser),
+			PerIP:   limit(cfg.SendCoinPerIP),
+		},
+		Buy: v1.RateLimit{
+			PerUser: limit(cfg.BuyPerUser),
+			PerIP:   limit(cfg.BuyPerIP),
+		},
+	}
+}
+
+// mustConnectRedis creates the redis client and waits until redis answers,
+// so a misconfigured or unreachable redis stops the startup.
+func mustConnectRedis(ctx context.Context, log *zap.Logger, cfg config.Redis) goredis.UniversalClient {
+	client, err := redis.NewClient(redis.Options{
+		Mode:             cfg.Mode,
+		DSN:              cfg.DSN,
+		Addrs:            cfg.Addrs,
+		MasterName:       cfg.MasterName,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		SentinelPassword: cfg.SentinelPassword,
+		DB:               cfg.DB,
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+		DialTimeout:      cfg.DialTimeout,
+		ReadTimeout:      cfg.ReadTimeout,
+		WriteTimeout:     cfg.WriteTimeout,
+		PoolTimeout:      cfg.PoolTimeout,
+		TLS: redis.TLSOptions{
+			Enabled:            cfg.TLS,
+			CAFile:             cfg.TLSCAFile,
+			CertFile:           cfg.TLSCertFile,
+			KeyFile:            cfg.TLSKeyFile,
+			ServerName:         cfg.TLSServerName,
+			InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+		},
+	})
+	if err != nil {
+		log.Fatal("Invalid Redis configuration", zap.String("mode", cfg.Mode), zap.Error(err))
+	}
+
+	if err := redis.Ping(ctx, client, cfg.ConnectAttempts, cfg.ConnectBackoff, log); err != nil {
+		log.Fatal("Redis is unreachable", zap.String("mode", cfg.Mode), zap.Error(err))
+	}
+
+	return client
+}
+
+// mustLoadKeyRing loads the JWT keys from files.
+// Without a configured private key an ephemeral one is generated in dev only: each replica
+// would sign with its own key and tokens would not survive a restart.
+func mustLoadKeyRing(log *zap.Logger, env string, cfg config.JWT) *jwt.KeyRing {
+	var (
+		keys *jwt.KeyRing
+		err  error
+	)
+	if cfg.PrivateKeyFile == "" {
+		if env != envDev {
+			log.Fatal("JWT_PRIVATE_KEY_FILE is required unless ENV is dev", zap.String("env", env))
+		}
+		log.Warn("JWT_PRIVATE_KEY_FILE is not set, generating an ephemeral signing key")
+
+		keys, err = jwt.GenerateKeyRing()
+		if err != nil {
+			log.Fatal("Failed to generate JWT signing key", zap.Error(err))
+		}
+	} else {
+		keys, err = jwt.LoadKeyRing(cfg.PrivateKeyFile, cfg.VerificationKeyFiles)
+		if err != nil {
+			log.Fatal("Failed to load JWT keys", zap.Error(err))
+		}
+	}
+
+	keys.SetValidation(jwt.Validation{
+		Issuer:   cfg.Issuer,
+		Audience: cfg.Audience,
+		Leeway:   cfg.ClockSkew,
+	})
+
+	return keys
+}
+
+// mustLoadOIDCProvider discovers the identity provider. It returns nil when OIDC login is not configured.
+func mustLoadOIDCProvider(ctx context.Context, log *zap.Logger, cfg config.OIDC) *oidc.Provider {
+	if cfg.Issuer == "" {
+		log.Info("OIDC_ISSUER is not set, OIDC login is disabled")
+		return nil
+	}
+	// Without a domain any provider user could claim a username by its address.
+	if cfg.EmailDomain == "" {
+		log.Fatal("OIDC_EMAIL_DOMAIN is required when OIDC_ISSUER is set")
+	}
+
+	log.Info("OIDC provider initialization...")
+	provider, err := oidc.NewProvider(ctx, oidc.Config{
+		Issuer:       cfg.Issuer,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+	}, &http.Client{Timeout: oidcRequestTimeout})
+	if err != nil {
+		log.Fatal("Failed to discover OIDC provider", zap.Error(err))
+	}
+	log.Info("OIDC provider initialization: OK.", zap.String("issuer", cfg.Issuer))
+
+	return provider
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+
+	"avito-internship/config"
+	"avito-internship/internal/repository"
+	"avito-internship/pkg/logger"
+	"avito-internship/pkg/postgres"
+
+	"go.uber.org/zap"
+)
+
+// RebuildProjections recomputes the transfer totals read by /api/info from the
+// operations. The migrations fill them, so it only repairs totals that drifted.
+// The service may keep running meanwhile.
+func RebuildProjections() {
+	cfg := config.MustLoad()
+	log := logger.NewZap(cfg.Env)
+	ctx := context.Background()
+
+	pg := postgres.NewPostgres(ctx, log, cfg.PgDSN)
+	defer pg.Close()
+
+	repositories := repository.NewRepositories(pg)
+
+	log.Info("Rebuilding transfer totals...")
+	rows, err := repositories.Operation.RebuildTransferTotals(ctx)
+	if err != nil {
+		log.Fatal("Failed to rebuild transfer totals", zap.Error(err))
+	}
+	log.Info("Rebuilding transfer totals: OK.", zap.Int64("rows", rows))
+}
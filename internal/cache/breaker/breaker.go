@@ -0,0 +1,335 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/entity"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultThreshold is the number of consecutive failures that open the circuit.
+	DefaultThreshold = 5
+	// DefaultCooldown is how often an open circuit probes the cache and pending invalidations are replayed.
+	DefaultCooldown = 10 * time.Second
+
+	// replayBatch is the number of invalidations replayed with a single Del.
+	replayBatch = 100
+	// probeKey is read to check the cache is reachable, it is never set.
+	probeKey = "breaker:probe"
+)
+
+// ErrOpen is returned while the cache is bypassed. For Get it also wraps
+// cache.ErrMiss, so callers fall back to the source of truth.
+var ErrOpen = errors.New("cache: circuit open")
+
+// Queue durably stores the keys whose deletion has to be replayed.
+type Queue interface {
+	AddCacheInvalidations(ctx context.Context, keys []string) error
+	GetCacheInvalidations(ctx context.Context, limit int) ([]entity.CacheInvalidation, error)
+	DeleteCacheInvalidations(ctx context.Context, ids []int64) error
+}
+
+// Options tune a BreakerCache. Zero values select the defaults.
+type Options struct {
+	Threshold int
+	Cooldown  time.Duration
+}
+
+// BreakerCache bypasses a cache that keeps failing. While the circuit is open
+// Get misses, Set is skipped and Del is queued. Deletions that fail while the
+// circuit is closed are queued as well, so a stale value cannot outlive an
+// outage. The circuit only closes after the queue has been replayed.
+type BreakerCache struct {
+	cache     cache.Cache
+	queue     Queue
+	log       *zap.Logger
+	threshold int
+	cooldown  time.Duration
+
+	// mu guards the circuit state. It is held while queueing deletions of an
+	// open circuit, so none is missed by the replay that closes it.
+	mu       sync.Mutex
+	open     bool
+	failures int
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewBreakerCache starts replaying queued deletions every cooldown. Shutdown
+// stops it and shuts down the wrapped cache.
+func NewBreakerCache(c cache.Cache, queue Queue, log *zap.Logger, opts Options) *BreakerCache {
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultThreshold
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = DefaultCooldown
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &BreakerCache{
+		cache:     c,
+		queue:     queue,
+		log:       log,
+		threshold: opts.Threshold,
+		cooldown:  opts.Cooldown,
+		cancel:    cancel,
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.run(ctx)
+	}()
+
+	return b
+}
+
+func (b *BreakerCache) Get(ctx context.Context, key string) (string, error) {
+	if b.isOpen() {
+		return "", fmt.Errorf("%w: %w", cache.ErrMiss, ErrOpen)
+	}
+
+	value, err := b.cache.Get(ctx, key)
+	b.record(ctx, err)
+
+	return value, err
+}
+
+// Set is skipped while the circuit is open, the value is loaded again after recovery.
+func (b *BreakerCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if b.isOpen() {
+		return nil
+	}
+
+	err := b.cache.Set(ctx, key, value, ttl)
+	b.record(ctx, err)
+
+	return err
+}
+
+// SetNewer fails with ErrOpen while the circuit is open, the caller must not
+// take the skipped write for an older version.
+func (b *BreakerCache) SetNewer(ctx context.Context, key string, value interface{}, version int64, ttl time.Duration, existing bool) (bool, error) {
+	if b.isOpen() {
+		return false, ErrOpen
+	}
+
+	stored, err := b.cache.SetNewer(ctx, key, value, version, ttl, existing)
+	b.record(ctx, err)
+
+	return stored, err
+}
+
+// Del only fails when the deletion could neither be done nor queued.
+func (b *BreakerCache) Del(ctx context.Context, keys ...string) error {
+	b.mu.Lock()
+	if b.open {
+		defer b.mu.Unlock()
+		return b.enqueue(ctx, keys)
+	}
+	b.mu.Unlock()
+
+	err := b.cache.Del(ctx, keys...)
+	b.record(ctx, err)
+	if err == nil {
+		return nil
+	}
+
+	if qerr := b.enqueue(ctx, keys); qerr != nil {
+		return errors.Join(err, qerr)
+	}
+	b.log.Warn("Cache deletion failed, queued for replay",
+		zap.Strings("keys", keys),
+		zap.Error(err),
+	)
+
+	return nil
+}
+
+// Incr fails with ErrOpen while the circuit is open.
+func (b *BreakerCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	if b.isOpen() {
+		return 0, ErrOpen
+	}
+
+	value, err := b.cache.Incr(ctx, key, ttl)
+	b.record(ctx, err)
+
+	return value, err
+}
+
+// Decr fails with ErrOpen while the circuit is open.
+func (b *BreakerCache) Decr(ctx context.Context, key string) (int64, error) {
+	if b.isOpen() {
+		return 0, ErrOpen
+	}
+
+	value, err := b.cache.Decr(ctx, key)
+	b.record(ctx, err)
+
+	return value, err
+}
+
+// Inspect fails with ErrOpen while the circuit is open, an admin looking at
+// an entry must not mistake the outage for a miss.
+func (b *BreakerCache) Inspect(ctx context.Context, key string) (cache.Entry, error) {
+	if b.isOpen() {
+		return cache.Entry{}, ErrOpen
+	}
+
+	entry, err := b.cache.Inspect(ctx, key)
+	b.record(ctx, err)
+
+	return entry, err
+}
+
+// Evict fails with ErrOpen while the circuit is open. Patterns cannot be
+// queued for replay like deletions of single keys.
+func (b *BreakerCache) Evict(ctx context.Context, pattern string) (int, error) {
+	if b.isOpen() {
+		return 0, ErrOpen
+	}
+
+	evicted, err := b.cache.Evict(ctx, pattern)
+	b.record(ctx, err)
+
+	return evicted, err
+}
+
+// Flush fails with ErrOpen while the circuit is open.
+func (b *BreakerCache) Flush(ctx context.Context) error {
+	if b.isOpen() {
+		return ErrOpen
+	}
+
+	err := b.cache.Flush(ctx)
+	b.record(ctx, err)
+
+	return err
+}
+
+// Shutdown stops the replay and shuts down the wrapped cache.
+func (b *BreakerCache) Shutdown() error {
+	b.cancel()
+	b.wg.Wait()
+
+	return b.cache.Shutdown()
+}
+
+func (b *BreakerCache) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.open
+}
+
+// record counts consecutive failures. Misses and errors caused by the
+// caller giving up do not say anything about the health of the cache.
+func (b *BreakerCache) record(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil || errors.Is(err, cache.ErrMiss) {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if !b.open && b.failures >= b.threshold {
+		b.open = true
+		b.log.Warn("Cache circuit opened, bypassing the cache",
+			zap.Int("failures", b.failures),
+			zap.Error(err),
+		)
+	}
+}
+
+func (b *BreakerCache) enqueue(ctx context.Context, keys []string) error {
+	// The deletion must be queued even if the request is canceled.
+	return b.queue.AddCacheInvalidations(context.WithoutCancel(ctx), keys)
+}
+
+func (b *BreakerCache) run(ctx context.Context) {
+	ticker := time.NewTicker(b.cooldown)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			tickCtx, cancel := context.WithTimeout(ctx, b.cooldown)
+			b.tick(tickCtx)
+			cancel()
+		}
+	}
+}
+
+// tick replays queued deletions and closes an open circuit once the cache is reachable again.
+func (b *BreakerCache) tick(ctx context.Context) {
+	if !b.isOpen() {
+		if err := b.replay(ctx); err != nil {
+			b.log.Warn("Failed to replay cache invalidations", zap.Error(err))
+		}
+		return
+	}
+
+	if _, err := b.cache.Get(ctx, probeKey); err != nil && !errors.Is(err, cache.ErrMiss) {
+		b.log.Warn("Cache is still unavailable", zap.Error(err))
+		return
+	}
+	if err := b.replay(ctx); err != nil {
+		b.log.Warn("Failed to replay cache invalidations", zap.Error(err))
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Deletions queued since the replay above are replayed before any read reaches the cache.
+	if err := b.replay(ctx); err != nil {
+		b.log.Warn("Failed to replay cache invalidations", zap.Error(err))
+		return
+	}
+	b.open = false
+	b.failures = 0
+	b.log.Info("Cache circuit closed")
+}
+
+func (b *BreakerCache) replay(ctx context.Context) error {
+	for {
+		invalidations, err := b.queue.GetCacheInvalidations(ctx, replayBatch)
+		if err != nil {
+			return err
+		}
+		if len(invalidations) == 0 {
+			return nil
+		}
+
+		keys := make([]string, 0, len(invalidations))
+		ids := make([]int64, 0, len(invalidations))
+		for _, invalidation := range invalidations {
+			keys = append(keys, invalidation.Key)
+			ids = append(ids, invalidation.ID)
+		}
+
+		if err := b.cache.Del(ctx, keys...); err != nil {
+			return err
+		}
+		if err := b.queue.DeleteCacheInvalidations(ctx, ids); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/cache/memory"
+	"avito-internship/internal/entity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var errUnavailable = errors.New("connection refused")
+
+// flakyCache fails every call while down is set.
+type flakyCache struct {
+	cache.Cache
+	mu    sync.Mutex
+	down  bool
+	calls int
+}
+
+func (c *flakyCache) setDown(down bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.down = down
+}
+
+func (c *flakyCache) fail() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	return c.down
+}
+
+func (c *flakyCache) Get(ctx context.Context, key string) (string, error) {
+	if c.fail() {
+		return "", errUnavailable
+	}
+	return c.Cache.Get(ctx, key)
+}
+
+func (c *flakyCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if c.fail() {
+		return errUnavailable
+	}
+	return c.Cache.Set(ctx, key, value, ttl)
+}
+
+func (c *flakyCache) Del(ctx context.Context, keys ...string) error {
+	if c.fail() {
+		return errUnavailable
+	}
+	return c.Cache.Del(ctx, keys...)
+}
+
+type memoryQueue struct {
+	mu            sync.Mutex
+	nextID        int64
+	invalidations []entity.CacheInvalidation
+}
+
+func (q *memoryQueue) AddCacheInvalidations(_ context.Context, keys []string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, key := range keys {
+		q.nextID++
+		q.invalidations = append(q.invalidations, entity.CacheInvalidation{ID: q.nextID, Key: key})
+	}
+	return nil
+}
+
+func (q *memoryQueue) GetCacheInvalidations(_ context.Context, limit int) ([]entity.CacheInvalidation, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.invalidations) < limit {
+		limit = len(q.invalidations)
+	}
+	return append([]entity.CacheInvalidation{}, q.invalidations[:limit]...), nil
+}
+
+func (q *memoryQueue) DeleteCacheInvalidations(_ context.Context, ids []int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	deleted := map[int64]bool{}
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	pending := q.invalidations[:0]
+	for _, invalidation := range q.invalidations {
+		if !deleted[invalidation.ID] {
+			pending = append(pending, invalidation)
+		}
+	}
+	q.invalidations = pending
+	return nil
+}
+
+func (q *memoryQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.invalidations)
+}
+
+func TestBreakerCache(t *testing.T) {
+	ctx := context.Background()
+
+	newBreaker := func(t *testing.T) (*BreakerCache, *flakyCache, *memoryQueue) {
+		inner := &flakyCache{Cache: memory.NewMemoryCache(10, zap.NewNop())}
+		queue := &memoryQueue{}
+		// The cooldown is long, so the tests tick by hand.
+		b := NewBreakerCache(inner, queue, zap.NewNop(), Options{Threshold: 3, Cooldown: time.Hour})
+		t.Cleanup(func() { _ = b.Shutdown() })
+		return b, inner, queue
+	}
+
+	t.Run("Opens after consecutive failures and bypasses the cache", func(t *testing.T) {
+		b, inner, _ := newBreaker(t)
+		inner.setDown(true)
+
+		for i := 0; i < 3; i++ {
+			_, err := b.Get(ctx, "user_info:alice")
+			assert.ErrorIs(t, err, errUnavailable)
+		}
+
+		calls := inner.calls
+		_, err := b.Get(ctx, "user_info:alice")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+		assert.ErrorIs(t, err, ErrOpen)
+		assert.NoError(t, b.Set(ctx, "user_info:alice", 1000, time.Minute))
+		_, err = b.Incr(ctx, "login_failures:user:v1:alice", time.Minute)
+		assert.ErrorIs(t, err, ErrOpen)
+		assert.Equal(t, calls, inner.calls)
+	})
+
+	t.Run("Misses do not count as failures", func(t *testing.T) {
+		b, _, _ := newBreaker(t)
+
+		for i := 0; i < 5; i++ {
+			_, err := b.Get(ctx, "missing")
+			assert.ErrorIs(t, err, cache.ErrMiss)
+		}
+		assert.False(t, b.isOpen())
+	})
+
+	t.Run("Failed deletions are queued and replayed", func(t *testing.T) {
+		b, inner, queue := newBreaker(t)
+		require.NoError(t, b.Set(ctx, "user_info:alice", 1000, time.Minute))
+
+		inner.setDown(true)
+		require.NoError(t, b.Del(ctx, "user_info:alice"))
+		assert.Equal(t, 1, queue.len())
+
+		inner.setDown(false)
+		b.tick(ctx)
+
+		assert.Equal(t, 0, queue.len())
+		_, err := b.Get(ctx, "user_info:alice")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+	})
+
+	t.Run("Closes only after the queue is replayed", func(t *testing.T) {
+		b, inner, queue := newBreaker(t)
+		require.NoError(t, b.Set(ctx, "user_info:alice", 1000, time.Minute))
+
+		inner.setDown(true)
+		for i := 0; i < 3; i++ {
+			_, _ = b.Get(ctx, "user_info:bob")
+		}
+		require.True(t, b.isOpen())
+
+		// Deletions of an open circuit are queued without trying the cache.
+		require.NoError(t, b.Del(ctx, "user_info:alice"))
+		assert.Equal(t, 1, queue.len())
+
+		b.tick(ctx)
+		assert.True(t, b.isOpen())
+		assert.Equal(t, 1, queue.len())
+
+		inner.setDown(false)
+		b.tick(ctx)
+		assert.False(t, b.isOpen())
+		assert.Equal(t, 0, queue.len())
+
+		_, err := b.Get(ctx, "user_info:alice")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+	})
+
+	t.Run("Admin operations fail while open", func(t *testing.T) {
+		b, inner, _ := newBreaker(t)
+		inner.setDown(true)
+		for i := 0; i < 3; i++ {
+			_, _ = b.Get(ctx, "user_info:alice")
+		}
+		require.True(t, b.isOpen())
+
+		_, err := b.Inspect(ctx, "user_info:alice")
+		assert.ErrorIs(t, err, ErrOpen)
+		assert.NotErrorIs(t, err, cache.ErrMiss)
+		_, err = b.Evict(ctx, "user_info:*")
+		assert.ErrorIs(t, err, ErrOpen)
+		assert.ErrorIs(t, b.Flush(ctx), ErrOpen)
+	})
+}
@@ -2,14 +2,42 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
-const Ttl = time.Minute * 30
+// ErrMiss is returned by Get when the key is not cached or has expired.
+var ErrMiss = errors.New("cache: miss")
+
+// Entry is a cached value as stored, for inspection.
+type Entry struct {
+	Key   string
+	Value string
+	// TTL is the remaining lifetime, zero when the entry does not expire.
+	TTL time.Duration
+}
 
 type Cache interface {
 	Get(ctx context.Context, key string) (string, error)
-	Set(ctx context.Context, key string, value interface{}) error
+	// Set stores the JSON encoding of value for ttl.
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	// SetNewer stores the JSON encoding of value for ttl unless key holds a
+	// value whose "version" field is at least version, and reports whether it
+	// did. With existing set a missing key is left missing.
+	SetNewer(ctx context.Context, key string, value interface{}, version int64, ttl time.Duration, existing bool) (bool, error)
 	Del(ctx context.Context, keys ...string) error
+	// Incr atomically increments the integer stored at key, starting from zero
+	// when it is missing, sets its TTL to ttl and returns the new value.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Decr atomically decrements the positive integer stored at key, keeping
+	// its TTL, and returns the new value. A missing key is left missing.
+	Decr(ctx context.Context, key string) (int64, error)
+	// Inspect returns the entry of key, or ErrMiss.
+	Inspect(ctx context.Context, key string) (Entry, error)
+	// Evict removes the keys matching a glob pattern, where * matches any run of
+	// characters and ? a single one, and returns how many were removed.
+	Evict(ctx context.Context, pattern string) (int, error)
+	// Flush removes every key of the cache, and nothing else stored next to it.
+	Flush(ctx context.Context) error
 	Shutdown() error
 }
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Key describes the cache entries of one kind, which all hold a T and live for
+// the same TTL. The version is part of every key name, so after it is bumped
+// for a change of T entries written by older code are never read.
+type Key[T any] struct {
+	name    string
+	version int
+	ttl     time.Duration
+}
+
+func NewKey[T any](name string, version int, ttl time.Duration) Key[T] {
+	return Key[T]{
+		name:    name,
+		version: version,
+		ttl:     ttl,
+	}
+}
+
+// For returns the key of the entry identified by id.
+func (k Key[T]) For(id string) string {
+	return fmt.Sprintf("%s:v%d:%s", k.name, k.version, id)
+}
+
+func (k Key[T]) TTL() time.Duration {
+	return k.ttl
+}
+
+// Get returns the value of the entry identified by id. A value that cannot be
+// decoded into T is reported as a miss.
+func Get[T any](ctx context.Context, c Cache, k Key[T], id string) (T, error) {
+	var value T
+
+	data, err := c.Get(ctx, k.For(id))
+	if err != nil {
+		return value, err
+	}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return value, fmt.Errorf("%w: %w", ErrMiss, err)
+	}
+
+	return value, nil
+}
+
+// Set stores the value of the entry identified by id for the TTL of k.
+func Set[T any](ctx context.Context, c Cache, k Key[T], id string, value T) error {
+	return c.Set(ctx, k.For(id), value, k.ttl)
+}
+
+// Del removes the entries identified by ids.
+func Del[T any](ctx context.Context, c Cache, k Key[T], ids ...string) error {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, k.For(id))
+	}
+
+	return c.Del(ctx, keys...)
+}
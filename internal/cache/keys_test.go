@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type balance struct {
+	Coins int `json:"coins"`
+}
+
+func TestKey(t *testing.T) {
+	ctx := context.Background()
+	key := NewKey[balance]("balance", 2, time.Minute)
+
+	t.Run("Names include the version", func(t *testing.T) {
+		assert.Equal(t, "balance:v2:alice", key.For("alice"))
+	})
+
+	t.Run("Set uses the TTL of the key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockCache := NewMockCache(ctrl)
+		mockCache.EXPECT().Set(gomock.Any(), "balance:v2:alice", balance{Coins: 1000}, time.Minute).Return(nil)
+
+		require.NoError(t, Set(ctx, mockCache, key, "alice", balance{Coins: 1000}))
+	})
+
+	t.Run("Get decodes the value", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockCache := NewMockCache(ctrl)
+		mockCache.EXPECT().Get(gomock.Any(), "balance:v2:alice").Return(`{"coins":1000}`, nil)
+
+		value, err := Get(ctx, mockCache, key, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, balance{Coins: 1000}, value)
+	})
+
+	t.Run("Undecodable values are misses", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockCache := NewMockCache(ctrl)
+		mockCache.EXPECT().Get(gomock.Any(), "balance:v2:alice").Return(`1000`, nil)
+
+		_, err := Get(ctx, mockCache, key, "alice")
+		assert.ErrorIs(t, err, ErrMiss)
+	})
+
+	t.Run("Del removes every id", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockCache := NewMockCache(ctrl)
+		mockCache.EXPECT().Del(gomock.Any(), "balance:v2:alice", "balance:v2:bob").Return(nil)
+
+		require.NoError(t, Del(ctx, mockCache, key, "alice", "bob"))
+	})
+}
@@ -0,0 +1,223 @@
+package layered
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/cache/memory"
+
+	"go.uber.org/zap"
+)
+
+// Bus delivers invalidations between the replicas sharing an L2 cache.
+type Bus interface {
+	// Publish notifies the other replicas that the keys changed.
+	Publish(ctx context.Context, keys []string) error
+	// Listen calls invalidate with the keys published by other replicas until
+	// ctx is done. It calls invalidate with nil keys when messages may have
+	// been lost, e.g. after a reconnect, so the whole L1 must be dropped.
+	Listen(ctx context.Context, invalidate func(keys []string))
+}
+
+// LayeredCache keeps a short-lived local L1 in front of a shared L2. Writes
+// go to L2 and are broadcast over the bus, so every replica drops its L1
+// copy and the next read sees the new value. L1 entries are only trusted for
+// localTTL, which bounds staleness if a broadcast is lost.
+type LayeredCache struct {
+	l1       *memory.MemoryCache
+	l2       cache.Cache
+	bus      Bus
+	localTTL time.Duration
+	log      *zap.Logger
+
+	// generation is increased on every invalidation, so a value read from L2
+	// before an invalidation is not put into L1 after it.
+	generation atomic.Uint64
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewLayeredCache starts listening for invalidations. Shutdown stops it and
+// shuts down both tiers.
+func NewLayeredCache(l1 *memory.MemoryCache, l2 cache.Cache, bus Bus, localTTL time.Duration, log *zap.Logger) *LayeredCache {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &LayeredCache{
+		l1:       l1,
+		l2:       l2,
+		bus:      bus,
+		localTTL: localTTL,
+		log:      log,
+		cancel:   cancel,
+	}
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		bus.Listen(ctx, c.invalidateLocal)
+	}()
+
+	return c
+}
+
+func (c *LayeredCache) Get(ctx context.Context, key string) (string, error) {
+	if value, err := c.l1.Get(ctx, key); err == nil {
+		return value, nil
+	}
+
+	generation := c.generation.Load()
+	value, err := c.l2.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	if c.generation.Load() == generation {
+		// The value is JSON already, RawMessage keeps it from being encoded twice.
+		// Its remaining TTL in L2 is unknown, so it is kept for localTTL.
+		if err := c.l1.Set(ctx, key, json.RawMessage(value), c.localTTL); err != nil {
+			c.log.Warn("Failed to cache value locally",
+				zap.String("key", key),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return value, nil
+}
+
+func (c *LayeredCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		c.log.Error("Failed to marshal value for caching",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if err := c.l2.Set(ctx, key, json.RawMessage(valueBytes), ttl); err != nil {
+		c.invalidateLocal([]string{key})
+		return err
+	}
+
+	c.invalidateLocal([]string{key})
+	if err := c.l1.Set(ctx, key, json.RawMessage(valueBytes), min(ttl, c.localTTL)); err != nil {
+		return err
+	}
+
+	return c.publish(ctx, key)
+}
+
+// Del removes the keys from L2 and from the L1 of every replica.
+func (c *LayeredCache) Del(ctx context.Context, keys ...string) error {
+	c.invalidateLocal(keys)
+
+	if err := c.l2.Del(ctx, keys...); err != nil {
+		return err
+	}
+
+	return c.publish(ctx, keys...)
+}
+
+// Incr increments the counter in L2 and drops it from the L1 of every replica.
+func (c *LayeredCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	c.invalidateLocal([]string{key})
+
+	value, err := c.l2.Incr(ctx, key, ttl)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, c.publish(ctx, key)
+}
+
+// SetNewer compares versions in L2, where the writes of all replicas meet, and
+// drops the key from the L1 of every replica once it is replaced.
+func (c *LayeredCache) SetNewer(ctx context.Context, key string, value interface{}, version int64, ttl time.Duration, existing bool) (bool, error) {
+	c.invalidateLocal([]string{key})
+
+	stored, err := c.l2.SetNewer(ctx, key, value, version, ttl, existing)
+	if err != nil || !stored {
+		return stored, err
+	}
+
+	return true, c.publish(ctx, key)
+}
+
+// Decr decrements the counter in L2 and drops it from the L1 of every replica.
+func (c *LayeredCache) Decr(ctx context.Context, key string) (int64, error) {
+	c.invalidateLocal([]string{key})
+
+	value, err := c.l2.Decr(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, c.publish(ctx, key)
+}
+
+// Inspect returns the shared entry from L2.
+func (c *LayeredCache) Inspect(ctx context.Context, key string) (cache.Entry, error) {
+	return c.l2.Inspect(ctx, key)
+}
+
+// Evict removes the matching keys from L2. The replicas cannot tell which of
+// their L1 keys were removed, so every L1 is dropped.
+func (c *LayeredCache) Evict(ctx context.Context, pattern string) (int, error) {
+	evicted, err := c.l2.Evict(ctx, pattern)
+	c.invalidateLocal(nil)
+	if err != nil {
+		return evicted, err
+	}
+
+	return evicted, c.publish(ctx)
+}
+
+// Flush empties L2 and the L1 of every replica.
+func (c *LayeredCache) Flush(ctx context.Context) error {
+	err := c.l2.Flush(ctx)
+	c.invalidateLocal(nil)
+	if err != nil {
+		return err
+	}
+
+	return c.publish(ctx)
+}
+
+// Shutdown stops listening for invalidations and shuts down both tiers.
+func (c *LayeredCache) Shutdown() error {
+	c.cancel()
+	c.wg.Wait()
+
+	return errors.Join(c.l1.Shutdown(), c.l2.Shutdown())
+}
+
+// publish fails when the other replicas may keep serving stale values until their L1 expires.
+func (c *LayeredCache) publish(ctx context.Context, keys ...string) error {
+	if err := c.bus.Publish(ctx, keys); err != nil {
+		c.log.Error("Failed to broadcast cache invalidation",
+			zap.Strings("keys", keys),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// invalidateLocal drops the keys from L1, or all of it when keys is nil.
+func (c *LayeredCache) invalidateLocal(keys []string) {
+	c.generation.Add(1)
+
+	// MemoryCache.Flush and Del never fail.
+	if keys == nil {
+		_ = c.l1.Flush(context.Background())
+		return
+	}
+	_ = c.l1.Del(context.Background(), keys...)
+}
@@ -0,0 +1,211 @@
+package layered
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/cache/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeBus delivers invalidations synchronously to every other replica.
+type fakeBus struct {
+	peers      *[]*fakeBus
+	err        error
+	ready      chan struct{}
+	invalidate func(keys []string)
+}
+
+func newFakeBuses(n int) []*fakeBus {
+	peers := make([]*fakeBus, 0, n)
+	for i := 0; i < n; i++ {
+		peers = append(peers, &fakeBus{ready: make(chan struct{})})
+	}
+	for _, b := range peers {
+		b.peers = &peers
+	}
+	return peers
+}
+
+func (b *fakeBus) Publish(_ context.Context, keys []string) error {
+	if b.err != nil {
+		return b.err
+	}
+	for _, peer := range *b.peers {
+		if peer != b {
+			peer.deliver(keys)
+		}
+	}
+	return nil
+}
+
+func (b *fakeBus) Listen(ctx context.Context, invalidate func(keys []string)) {
+	b.invalidate = invalidate
+	close(b.ready)
+
+	<-ctx.Done()
+}
+
+func (b *fakeBus) deliver(keys []string) {
+	<-b.ready
+	b.invalidate(keys)
+}
+
+// stubL2 lets a test run code between the L2 read and the L1 write of Get.
+type stubL2 struct {
+	cache.Cache
+	afterGet func()
+}
+
+func (s stubL2) Get(ctx context.Context, key string) (string, error) {
+	value, err := s.Cache.Get(ctx, key)
+	if s.afterGet != nil {
+		s.afterGet()
+	}
+	return value, err
+}
+
+func TestLayeredCache(t *testing.T) {
+	ctx := context.Background()
+	log := zap.NewNop()
+
+	newReplicas := func(t *testing.T, l2 cache.Cache) (*LayeredCache, *LayeredCache) {
+		buses := newFakeBuses(2)
+		a := NewLayeredCache(memory.NewMemoryCache(10, log), l2, buses[0], time.Minute, log)
+		b := NewLayeredCache(memory.NewMemoryCache(10, log), l2, buses[1], time.Minute, log)
+		t.Cleanup(func() {
+			_ = a.Shutdown()
+			_ = b.Shutdown()
+		})
+		return a, b
+	}
+
+	t.Run("Del invalidates other replicas", func(t *testing.T) {
+		a, b := newReplicas(t, memory.NewMemoryCache(10, log))
+
+		require.NoError(t, a.Set(ctx, "user_info:alice", map[string]int{"coins": 1000}, time.Minute))
+		value, err := b.Get(ctx, "user_info:alice")
+		require.NoError(t, err)
+		assert.Equal(t, `{"coins":1000}`, value)
+
+		require.NoError(t, a.Del(ctx, "user_info:alice"))
+		_, err = b.Get(ctx, "user_info:alice")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+	})
+
+	t.Run("Set replaces the value on other replicas", func(t *testing.T) {
+		a, b := newReplicas(t, memory.NewMemoryCache(10, log))
+
+		require.NoError(t, a.Set(ctx, "user_info:alice", 1000, time.Minute))
+		_, err := b.Get(ctx, "user_info:alice")
+		require.NoError(t, err)
+
+		require.NoError(t, a.Set(ctx, "user_info:alice", 900, time.Minute))
+		value, err := b.Get(ctx, "user_info:alice")
+		require.NoError(t, err)
+		assert.Equal(t, "900", value)
+	})
+
+	t.Run("Value read before an invalidation is not cached locally", func(t *testing.T) {
+		l2 := &stubL2{Cache: memory.NewMemoryCache(10, log)}
+		a, b := newReplicas(t, l2)
+
+		require.NoError(t, a.Set(ctx, "user_info:alice", 1000, time.Minute))
+		l2.afterGet = func() {
+			l2.afterGet = nil
+			require.NoError(t, a.Set(ctx, "user_info:alice", 900, time.Minute))
+		}
+
+		value, err := b.Get(ctx, "user_info:alice")
+		require.NoError(t, err)
+		assert.Equal(t, "1000", value)
+
+		value, err = b.Get(ctx, "user_info:alice")
+		require.NoError(t, err)
+		assert.Equal(t, "900", value)
+	})
+
+	t.Run("Nil keys flush the local tier", func(t *testing.T) {
+		l2 := memory.NewMemoryCache(10, log)
+		a, _ := newReplicas(t, l2)
+
+		require.NoError(t, a.Set(ctx, "user_info:alice", 1000, time.Minute))
+		// Changed behind the bus, e.g. while the subscription was down.
+		require.NoError(t, l2.Set(ctx, "user_info:alice", 900, time.Minute))
+
+		value, err := a.Get(ctx, "user_info:alice")
+		require.NoError(t, err)
+		assert.Equal(t, "1000", value)
+
+		a.bus.(*fakeBus).deliver(nil)
+		value, err = a.Get(ctx, "user_info:alice")
+		require.NoError(t, err)
+		assert.Equal(t, "900", value)
+	})
+
+	t.Run("Local copies expire", func(t *testing.T) {
+		l2 := memory.NewMemoryCache(10, log)
+		buses := newFakeBuses(1)
+		a := NewLayeredCache(memory.NewMemoryCache(10, log), l2, buses[0], time.Millisecond, log)
+		t.Cleanup(func() { _ = a.Shutdown() })
+
+		require.NoError(t, a.Set(ctx, "user_info:alice", 1000, time.Minute))
+		require.NoError(t, l2.Set(ctx, "user_info:alice", 900, time.Minute))
+
+		assert.Eventually(t, func() bool {
+			value, err := a.Get(ctx, "user_info:alice")
+			return err == nil && value == "900"
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("Incr invalidates other replicas", func(t *testing.T) {
+		a, b := newReplicas(t, memory.NewMemoryCache(10, log))
+
+		_, err := a.Incr(ctx, "login_failures:user:v1:alice", time.Minute)
+		require.NoError(t, err)
+		value, err := b.Get(ctx, "login_failures:user:v1:alice")
+		require.NoError(t, err)
+		assert.Equal(t, "1", value)
+
+		count, err := b.Incr(ctx, "login_failures:user:v1:alice", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+		value, err = a.Get(ctx, "login_failures:user:v1:alice")
+		require.NoError(t, err)
+		assert.Equal(t, "2", value)
+	})
+
+	t.Run("Publish failure is returned", func(t *testing.T) {
+		a, _ := newReplicas(t, memory.NewMemoryCache(10, log))
+		a.bus.(*fakeBus).err = errors.New("connection refused")
+
+		assert.Error(t, a.Del(ctx, "user_info:alice"))
+	})
+
+	t.Run("Evict and Flush drop the local copies of every replica", func(t *testing.T) {
+		a, b := newReplicas(t, memory.NewMemoryCache(10, log))
+
+		require.NoError(t, a.Set(ctx, "user_info:alice", 1000, time.Minute))
+		require.NoError(t, a.Set(ctx, "session:alice", 1, time.Minute))
+		_, err := b.Get(ctx, "user_info:alice")
+		require.NoError(t, err)
+
+		evicted, err := a.Evict(ctx, "user_info:*")
+		require.NoError(t, err)
+		assert.Equal(t, 1, evicted)
+		_, err = b.Get(ctx, "user_info:alice")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+
+		_, err = b.Get(ctx, "session:alice")
+		require.NoError(t, err)
+		require.NoError(t, a.Flush(ctx))
+		_, err = b.Get(ctx, "session:alice")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+	})
+}
@@ -0,0 +1,327 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"avito-internship/internal/cache"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultJitter shortens every TTL by up to 10%, so keys cached together do not expire together.
+	DefaultJitter = 0.1
+	// DefaultBeta makes early refreshes start roughly one load duration before expiry.
+	DefaultBeta = 1.0
+)
+
+// Options tune a Loader. Zero values select the defaults.
+type Options struct {
+	// Jitter is the largest fraction the TTL of the key is randomly shortened by.
+	Jitter float64
+	// Beta scales how early values are refreshed, values above 1 refresh earlier.
+	Beta float64
+}
+
+// Stats are the counters of a Loader since it was created.
+type Stats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	// Coalesced counts misses that waited for a load started by another request.
+	Coalesced uint64 `json:"coalesced"`
+	// EarlyRefreshes counts hits that triggered a refresh before expiry.
+	EarlyRefreshes uint64 `json:"earlyRefreshes"`
+}
+
+// LoadFunc loads the value to cache. Its error is returned to every waiting caller and nothing is cached.
+type LoadFunc[T any] func(ctx context.Context) (T, error)
+
+// VersionedLoadFunc is a LoadFunc that also returns the version of the value,
+// a per-value number every change of it increments, see Update.
+type VersionedLoadFunc[T any] func(ctx context.Context) (T, int64, error)
+
+// entry is what a Loader stores in the cache.
+type entry[T any] struct {
+	Value T `json:"value"`
+	// Version is zero for values loaded by Fetch.
+	Version   int64     `json:"version"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Delta is how long the value took to load, slow loads are refreshed earlier.
+	Delta time.Duration `json:"delta"`
+}
+
+// pendingLoad is a load in flight, stale once its id was invalidated.
+type pendingLoad struct {
+	stale bool
+}
+
+// Loader is a read-through wrapper around cache.Cache that protects the
+// loader from stampedes: concurrent misses of a key share one load, hot keys
+// are refreshed in the background shortly before they expire (the XFetch
+// algorithm) and TTLs are jittered.
+type Loader[T any] struct {
+	cache  cache.Cache
+	key    cache.Key[entry[T]]
+	ttl    time.Duration
+	log    *zap.Logger
+	jitter float64
+	beta   float64
+	now    func() time.Time
+	rand   func() float64
+	group  singleflight.Group
+
+	// loading holds the loads in flight per id, so Invalidate can stop loads
+	// that read the value before the change from caching it.
+	mu      sync.Mutex
+	loading map[string][]*pendingLoad
+
+	hits           atomic.Uint64
+	misses         atomic.Uint64
+	coalesced      atomic.Uint64
+	earlyRefreshes atomic.Uint64
+}
+
+// NewLoader caches the values under key. Values are served for the TTL of key.
+func NewLoader[T any](c cache.Cache, key cache.Key[T], log *zap.Logger, opts Options) *Loader[T] {
+	if opts.Jitter <= 0 {
+		opts.Jitter = DefaultJitter
+	}
+	if opts.Beta <= 0 {
+		opts.Beta = DefaultBeta
+	}
+
+	return &Loader[T]{
+		cache:   c,
+		key:     cache.Key[entry[T]](key),
+		ttl:     key.TTL(),
+		log:     log,
+		jitter:  opts.Jitter,
+		beta:    opts.Beta,
+		now:     time.Now,
+		rand:    rand.Float64,
+		loading: map[string][]*pendingLoad{},
+	}
+}
+
+// Fetch returns the value identified by id, loading and caching it on a miss.
+// Callers that give up waiting do not cancel a load other callers share.
+func (l *Loader[T]) Fetch(ctx context.Context, id string, load LoadFunc[T]) (T, error) {
+	return l.fetch(ctx, id, func(ctx context.Context) (T, int64, error) {
+		value, err := load(ctx)
+		return value, 0, err
+	}, false)
+}
+
+// FetchVersioned is Fetch for versioned values. A loaded value is only cached
+// over an older version, so a slow load never replaces a value updated since.
+func (l *Loader[T]) FetchVersioned(ctx context.Context, id string, load VersionedLoadFunc[T]) (T, error) {
+	return l.fetch(ctx, id, load, true)
+}
+
+// Update writes the change numbered version through to the versioned value
+// identified by id, update turns the value of the previous version into it.
+// The value is only replaced while it is still cached and older, so neither a
+// newer value nor an invalidation is overwritten. A value that missed an
+// earlier change cannot be updated and is invalidated instead.
+func (l *Loader[T]) Update(ctx context.Context, id string, version int64, update func(value *T)) error {
+	e, err := cache.Get(ctx, l.cache, l.key, id)
+	if errors.Is(err, cache.ErrMiss) {
+		return nil
+	}
+	if err != nil {
+		return l.Invalidate(ctx, id)
+	}
+
+	ttl := e.ExpiresAt.Sub(l.now())
+	if e.Version >= version || ttl <= 0 {
+		return nil
+	}
+	if e.Version != version-1 {
+		return l.Invalidate(ctx, id)
+	}
+
+	update(&e.Value)
+	e.Version = version
+	if _, err := l.cache.SetNewer(ctx, l.key.For(id), e, version, ttl, true); err != nil {
+		return l.Invalidate(ctx, id)
+	}
+
+	return nil
+}
+
+func (l *Loader[T]) fetch(ctx context.Context, id string, load VersionedLoadFunc[T], versioned bool) (T, error) {
+	if e, ok := l.get(ctx, id); ok {
+		l.hits.Add(1)
+
+		if l.refreshEarly(e) {
+			l.earlyRefreshes.Add(1)
+			l.group.DoChan(id, func() (interface{}, error) {
+				return l.load(context.WithoutCancel(ctx), id, load, versioned)
+			})
+		}
+
+		return e.Value, nil
+	}
+	l.misses.Add(1)
+
+	leader := false
+	ch := l.group.DoChan(id, func() (interface{}, error) {
+		leader = true
+		return l.load(context.WithoutCancel(ctx), id, load, versioned)
+	})
+
+	select {
+	case res := <-ch:
+		if !leader {
+			l.coalesced.Add(1)
+		}
+		if res.Err != nil {
+			var zero T
+			return zero, res.Err
+		}
+		return res.Val.(T), nil
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Invalidate removes the values identified by ids. Loads of the ids already
+// in flight on this replica are not cached and later Fetch calls do not wait
+// for them, as they may have read the values before the change.
+func (l *Loader[T]) Invalidate(ctx context.Context, ids ...string) error {
+	l.mu.Lock()
+	for _, id := range ids {
+		for _, p := range l.loading[id] {
+			p.stale = true
+		}
+		l.group.Forget(id)
+	}
+	l.mu.Unlock()
+
+	return cache.Del(ctx, l.cache, l.key, ids...)
+}
+
+// Stats returns a snapshot of the counters.
+func (l *Loader[T]) Stats() Stats {
+	return Stats{
+		Hits:           l.hits.Load(),
+		Misses:         l.misses.Load(),
+		Coalesced:      l.coalesced.Load(),
+		EarlyRefreshes: l.earlyRefreshes.Load(),
+	}
+}
+
+// get treats expired values as misses.
+func (l *Loader[T]) get(ctx context.Context, id string) (entry[T], bool) {
+	e, err := cache.Get(ctx, l.cache, l.key, id)
+	if err != nil {
+		return entry[T]{}, false
+	}
+	if !l.now().Before(e.ExpiresAt) {
+		return entry[T]{}, false
+	}
+
+	return e, true
+}
+
+// refreshEarly decides with a probability growing towards expiry whether the
+// entry is refreshed now, see "Optimal Probabilistic Cache Stampede Prevention".
+func (l *Loader[T]) refreshEarly(e entry[T]) bool {
+	// 1-rand is in (0, 1], so the logarithm is finite and not positive.
+	gap := -float64(e.Delta) * l.beta * math.Log(1-l.rand())
+	return !l.now().Add(time.Duration(gap)).Before(e.ExpiresAt)
+}
+
+func (l *Loader[T]) load(ctx context.Context, id string, load VersionedLoadFunc[T], versioned bool) (interface{}, error) {
+	p := l.track(id)
+	defer l.untrack(id, p)
+
+	start := l.now()
+	value, version, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := l.now()
+	ttl := time.Duration(float64(l.ttl) * (1 - l.jitter*l.rand()))
+	e := entry[T]{
+		Value:     value,
+		Version:   version,
+		ExpiresAt: now.Add(ttl),
+		Delta:     now.Sub(start),
+	}
+	if l.isStale(p) {
+		return value, nil
+	}
+	if err := l.store(ctx, id, e, ttl, versioned); err != nil {
+		l.log.Error("Failed to cache loaded value",
+			zap.String("key", l.key.For(id)),
+			zap.Error(err),
+		)
+	}
+
+	// Invalidate marks the load stale before deleting the key, so a stale
+	// value written after that deletion is seen here and removed again.
+	if l.isStale(p) {
+		if err := cache.Del(ctx, l.cache, l.key, id); err != nil {
+			l.log.Error("Failed to remove stale loaded value",
+				zap.String("key", l.key.For(id)),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return value, nil
+}
+
+// store writes a loaded entry, versioned entries only over older ones.
+func (l *Loader[T]) store(ctx context.Context, id string, e entry[T], ttl time.Duration, versioned bool) error {
+	if !versioned {
+		return cache.Set(ctx, l.cache, l.key, id, e)
+	}
+
+	_, err := l.cache.SetNewer(ctx, l.key.For(id), e, e.Version, ttl, false)
+	return err
+}
+
+func (l *Loader[T]) track(id string) *pendingLoad {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	p := &pendingLoad{}
+	l.loading[id] = append(l.loading[id], p)
+	return p
+}
+
+func (l *Loader[T]) untrack(id string, p *pendingLoad) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pending := l.loading[id]
+	for i := range pending {
+		if pending[i] == p {
+			pending = append(pending[:i], pending[i+1:]...)
+			break
+		}
+	}
+	if len(pending) == 0 {
+		delete(l.loading, id)
+		return
+	}
+	l.loading[id] = pending
+}
+
+func (l *Loader[T]) isStale(p *pendingLoad) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return p.stale
+}
@@ -0,0 +1,336 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/cache/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var balanceKey = cache.NewKey[int]("balance", 1, time.Minute)
+
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+
+	newLoader := func() (*Loader[int], *time.Time) {
+		now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+		l := NewLoader(memory.NewMemoryCache(10, zap.NewNop()), balanceKey, zap.NewNop(), Options{})
+		l.now = func() time.Time { return now }
+		return l, &now
+	}
+
+	t.Run("Loads on miss and serves from cache", func(t *testing.T) {
+		l, _ := newLoader()
+		loads := 0
+		load := func(context.Context) (int, error) {
+			loads++
+			return 1000, nil
+		}
+
+		for i := 0; i < 3; i++ {
+			value, err := l.Fetch(ctx, "alice", load)
+			require.NoError(t, err)
+			assert.Equal(t, 1000, value)
+		}
+
+		assert.Equal(t, 1, loads)
+		assert.Equal(t, Stats{Hits: 2, Misses: 1}, l.Stats())
+	})
+
+	t.Run("Load errors are returned and not cached", func(t *testing.T) {
+		l, _ := newLoader()
+		errNotFound := errors.New("not found")
+
+		_, err := l.Fetch(ctx, "alice", func(context.Context) (int, error) {
+			return 0, errNotFound
+		})
+		assert.ErrorIs(t, err, errNotFound)
+
+		value, err := l.Fetch(ctx, "alice", func(context.Context) (int, error) {
+			return 1000, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1000, value)
+	})
+
+	t.Run("Concurrent misses share one load", func(t *testing.T) {
+		l, _ := newLoader()
+		release := make(chan struct{})
+		var loads atomic.Int32
+		load := func(context.Context) (int, error) {
+			loads.Add(1)
+			<-release
+			return 1000, nil
+		}
+
+		const callers = 10
+		var wg sync.WaitGroup
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := l.Fetch(ctx, "alice", load)
+				assert.NoError(t, err)
+				assert.Equal(t, 1000, value)
+			}()
+		}
+
+		require.Eventually(t, func() bool {
+			return l.Stats().Misses == callers
+		}, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), loads.Load())
+		assert.Equal(t, uint64(callers-1), l.Stats().Coalesced)
+	})
+
+	t.Run("Canceled caller does not cancel the shared load", func(t *testing.T) {
+		l, _ := newLoader()
+		release := make(chan struct{})
+		loaded := make(chan error, 1)
+
+		canceled, cancel := context.WithCancel(ctx)
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+		_, err := l.Fetch(canceled, "alice", func(ctx context.Context) (int, error) {
+			<-release
+			loaded <- ctx.Err()
+			return 1000, nil
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		close(release)
+		assert.NoError(t, <-loaded)
+	})
+
+	t.Run("Expired values are reloaded", func(t *testing.T) {
+		l, now := newLoader()
+		l.rand = func() float64 { return 0 }
+		loads := 0
+		load := func(context.Context) (int, error) {
+			loads++
+			return loads, nil
+		}
+
+		_, err := l.Fetch(ctx, "alice", load)
+		require.NoError(t, err)
+
+		*now = now.Add(time.Minute)
+		value, err := l.Fetch(ctx, "alice", load)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("TTL is jittered", func(t *testing.T) {
+		l, now := newLoader()
+		// The largest jitter shortens the one minute TTL by 6 seconds.
+		l.rand = func() float64 { return 0.999999 }
+		loads := 0
+		load := func(context.Context) (int, error) {
+			loads++
+			return loads, nil
+		}
+
+		_, err := l.Fetch(ctx, "alice", load)
+		require.NoError(t, err)
+
+		*now = now.Add(55 * time.Second)
+		l.rand = func() float64 { return 0 }
+		value, err := l.Fetch(ctx, "alice", load)
+		require.NoError(t, err)
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("Hits close to expiry refresh early", func(t *testing.T) {
+		l, now := newLoader()
+		l.rand = func() float64 { return 0 }
+		refreshed := make(chan struct{})
+		loads := 0
+		load := func(context.Context) (int, error) {
+			loads++
+			if loads == 1 {
+				// The first load takes 10 seconds.
+				*now = now.Add(10 * time.Second)
+			} else {
+				close(refreshed)
+			}
+			return loads, nil
+		}
+
+		_, err := l.Fetch(ctx, "alice", load)
+		require.NoError(t, err)
+
+		// Far from expiry nothing is refreshed even with an unlucky draw.
+		l.rand = func() float64 { return 0.9 }
+		value, err := l.Fetch(ctx, "alice", load)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+
+		// 5 seconds before expiry a draw with -ln(1-r) >= 0.5 refreshes.
+		*now = now.Add(55 * time.Second)
+		value, err = l.Fetch(ctx, "alice", load)
+		require.NoError(t, err)
+		assert.Equal(t, 1, value)
+
+		select {
+		case <-refreshed:
+		case <-time.After(time.Second):
+			t.Fatal("value was not refreshed")
+		}
+		assert.Equal(t, uint64(1), l.Stats().EarlyRefreshes)
+	})
+
+	t.Run("Values not written by a loader are misses", func(t *testing.T) {
+		l, _ := newLoader()
+		require.NoError(t, cache.Set(ctx, l.cache, balanceKey, "alice", 1000))
+
+		value, err := l.Fetch(ctx, "alice", func(context.Context) (int, error) {
+			return 900, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 900, value)
+	})
+
+	t.Run("Loads in flight during Invalidate are not cached", func(t *testing.T) {
+		l, _ := newLoader()
+		started, release := make(chan struct{}), make(chan struct{})
+
+		done := make(chan int)
+		go func() {
+			value, err := l.Fetch(ctx, "alice", func(context.Context) (int, error) {
+				close(started)
+				<-release
+				return 1000, nil
+			})
+			assert.NoError(t, err)
+			done <- value
+		}()
+		<-started
+
+		// The balance changes while the old one is being loaded.
+		require.NoError(t, l.Invalidate(ctx, "alice"))
+
+		// Later callers do not wait for the stale load.
+		value, err := l.Fetch(ctx, "alice", func(context.Context) (int, error) {
+			return 900, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 900, value)
+
+		close(release)
+		assert.Equal(t, 1000, <-done)
+
+		value, err = l.Fetch(ctx, "alice", func(context.Context) (int, error) {
+			return 800, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 900, value)
+		assert.Empty(t, l.loading)
+	})
+
+	t.Run("Invalidate right before the value is cached removes it", func(t *testing.T) {
+		c := &setHookCache{Cache: memory.NewMemoryCache(10, zap.NewNop())}
+		l := NewLoader(c, balanceKey, zap.NewNop(), Options{})
+
+		// The balance changes after the load checked for staleness but before its value is stored.
+		c.beforeSet = func() {
+			c.beforeSet = nil
+			require.NoError(t, l.Invalidate(ctx, "alice"))
+		}
+
+		value, err := l.Fetch(ctx, "alice", func(context.Context) (int, error) {
+			return 1000, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1000, value)
+
+		value, err = l.Fetch(ctx, "alice", func(context.Context) (int, error) {
+			return 900, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 900, value)
+	})
+
+	t.Run("Update writes the next version through", func(t *testing.T) {
+		l, _ := newLoader()
+		versioned := func(balance int, version int64) VersionedLoadFunc[int] {
+			return func(context.Context) (int, int64, error) {
+				return balance, version, nil
+			}
+		}
+
+		require.NoError(t, l.Update(ctx, "alice", 4, func(balance *int) { *balance -= 100 }))
+		_, err := l.FetchVersioned(ctx, "alice", versioned(1000, 3))
+		require.NoError(t, err)
+
+		require.NoError(t, l.Update(ctx, "alice", 4, func(balance *int) { *balance -= 100 }))
+		// The change was already applied, a second update of it is ignored.
+		require.NoError(t, l.Update(ctx, "alice", 4, func(balance *int) { *balance -= 100 }))
+
+		value, err := l.FetchVersioned(ctx, "alice", versioned(0, 0))
+		require.NoError(t, err)
+		assert.Equal(t, 900, value)
+	})
+
+	t.Run("Update invalidates a value that missed a change", func(t *testing.T) {
+		l, _ := newLoader()
+
+		_, err := l.FetchVersioned(ctx, "alice", func(context.Context) (int, int64, error) {
+			return 1000, 3, nil
+		})
+		require.NoError(t, err)
+		require.NoError(t, l.Update(ctx, "alice", 5, func(balance *int) { *balance -= 100 }))
+
+		value, err := l.FetchVersioned(ctx, "alice", func(context.Context) (int, int64, error) {
+			return 800, 5, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 800, value)
+	})
+
+	t.Run("Slow versioned load does not replace an updated value", func(t *testing.T) {
+		l, _ := newLoader()
+		_, err := l.FetchVersioned(ctx, "alice", func(context.Context) (int, int64, error) {
+			return 1000, 3, nil
+		})
+		require.NoError(t, err)
+		require.NoError(t, l.Update(ctx, "alice", 4, func(balance *int) { *balance -= 100 }))
+
+		// A refresh that read the balance before the change finishes after it.
+		_, err = l.load(ctx, "alice", func(context.Context) (int, int64, error) {
+			return 1000, 3, nil
+		}, true)
+		require.NoError(t, err)
+
+		value, err := l.FetchVersioned(ctx, "alice", func(context.Context) (int, int64, error) {
+			return 0, 0, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 900, value)
+	})
+}
+
+// setHookCache runs beforeSet before every Set.
+type setHookCache struct {
+	cache.Cache
+	beforeSet func()
+}
+
+func (c *setHookCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if c.beforeSet != nil {
+		c.beforeSet()
+	}
+	return c.Cache.Set(ctx, key, value, ttl)
+}
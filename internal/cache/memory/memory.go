@@ -0,0 +1,284 @@
+package memory
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"avito-internship/internal/cache"
+
+	"go.uber.org/zap"
+)
+
+// MemoryCache is an in-process cache.Cache for local runs and tests. It keeps
+// at most maxEntries values and evicts the least recently used one when full.
+type MemoryCache struct {
+	log        *zap.Logger
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru is ordered from the most to the least recently used entry.
+	lru *list.List
+}
+
+type entry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+func NewMemoryCache(maxEntries int, log *zap.Logger) *MemoryCache {
+	return &MemoryCache{
+		log:        log,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func (s *MemoryCache) Get(_ context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return "", cache.ErrMiss
+	}
+
+	e := element.Value.(*entry)
+	if !s.now().Before(e.expiresAt) {
+		s.remove(element)
+		return "", cache.ErrMiss
+	}
+
+	s.lru.MoveToFront(element)
+
+	return e.value, nil
+}
+
+// Set stores the JSON encoding of value, as RedisCache does.
+func (s *MemoryCache) Set(_ context.Context, key string, value interface{}, ttl time.Duration) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		s.log.Error("Failed to marshal value for caching",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt := s.now().Add(ttl)
+	if element, ok := s.entries[key]; ok {
+		e := element.Value.(*entry)
+		e.value, e.expiresAt = string(valueBytes), expiresAt
+		s.lru.MoveToFront(element)
+		return nil
+	}
+
+	s.entries[key] = s.lru.PushFront(&entry{key: key, value: string(valueBytes), expiresAt: expiresAt})
+	for s.maxEntries > 0 && s.lru.Len() > s.maxEntries {
+		s.remove(s.lru.Back())
+	}
+
+	return nil
+}
+
+// SetNewer is Set for versioned values, see cache.Cache.
+func (s *MemoryCache) SetNewer(_ context.Context, key string, value interface{}, version int64, ttl time.Duration, existing bool) (bool, error) {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		s.log.Error("Failed to marshal value for caching",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if ok && !s.now().Before(element.Value.(*entry).expiresAt) {
+		s.remove(element)
+		ok = false
+	}
+	if !ok {
+		if existing {
+			return false, nil
+		}
+		s.entries[key] = s.lru.PushFront(&entry{key: key, value: string(valueBytes), expiresAt: s.now().Add(ttl)})
+		for s.maxEntries > 0 && s.lru.Len() > s.maxEntries {
+			s.remove(s.lru.Back())
+		}
+		return true, nil
+	}
+
+	e := element.Value.(*entry)
+	var stored struct {
+		Version *int64 `json:"version"`
+	}
+	if err := json.Unmarshal([]byte(e.value), &stored); err == nil && stored.Version != nil && *stored.Version >= version {
+		return false, nil
+	}
+
+	e.value, e.expiresAt = string(valueBytes), s.now().Add(ttl)
+	s.lru.MoveToFront(element)
+
+	return true, nil
+}
+
+func (s *MemoryCache) Del(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := s.entries[key]; ok {
+			s.remove(element)
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryCache) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	element, ok := s.entries[key]
+	if ok && !now.Before(element.Value.(*entry).expiresAt) {
+		s.remove(element)
+		ok = false
+	}
+
+	var value int64
+	if ok {
+		if err := json.Unmarshal([]byte(element.Value.(*entry).value), &value); err != nil {
+			return 0, fmt.Errorf("memory: value of %s is not an integer: %w", key, err)
+		}
+	}
+	value++
+
+	encoded := strconv.FormatInt(value, 10)
+	if ok {
+		e := element.Value.(*entry)
+		e.value, e.expiresAt = encoded, now.Add(ttl)
+		s.lru.MoveToFront(element)
+		return value, nil
+	}
+
+	s.entries[key] = s.lru.PushFront(&entry{key: key, value: encoded, expiresAt: now.Add(ttl)})
+	for s.maxEntries > 0 && s.lru.Len() > s.maxEntries {
+		s.remove(s.lru.Back())
+	}
+
+	return value, nil
+}
+
+func (s *MemoryCache) Decr(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return 0, nil
+	}
+
+	e := element.Value.(*entry)
+	if !s.now().Before(e.expiresAt) {
+		s.remove(element)
+		return 0, nil
+	}
+
+	var value int64
+	if err := json.Unmarshal([]byte(e.value), &value); err != nil {
+		return 0, fmt.Errorf("memory: value of %s is not an integer: %w", key, err)
+	}
+	if value <= 0 {
+		return 0, nil
+	}
+	value--
+
+	e.value = strconv.FormatInt(value, 10)
+	s.lru.MoveToFront(element)
+
+	return value, nil
+}
+
+// Len returns the number of stored entries, expired ones not yet evicted included.
+func (s *MemoryCache) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lru.Len()
+}
+
+func (s *MemoryCache) Inspect(_ context.Context, key string) (cache.Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return cache.Entry{}, cache.ErrMiss
+	}
+
+	e := element.Value.(*entry)
+	ttl := e.expiresAt.Sub(s.now())
+	if ttl <= 0 {
+		s.remove(element)
+		return cache.Entry{}, cache.ErrMiss
+	}
+
+	return cache.Entry{Key: key, Value: e.value, TTL: ttl}, nil
+}
+
+// Evict matches keys with path.Match, in which * does not match a slash, unlike in redis.
+func (s *MemoryCache) Evict(_ context.Context, pattern string) (int, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	evicted := 0
+	for key, element := range s.entries {
+		if ok, _ := path.Match(pattern, key); ok {
+			s.remove(element)
+			evicted++
+		}
+	}
+
+	return evicted, nil
+}
+
+// Flush removes all entries.
+func (s *MemoryCache) Flush(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = make(map[string]*list.Element)
+	s.lru.Init()
+
+	return nil
+}
+
+func (s *MemoryCache) Shutdown() error {
+	return s.Flush(context.Background())
+}
+
+func (s *MemoryCache) remove(element *list.Element) {
+	s.lru.Remove(element)
+	delete(s.entries, element.Value.(*entry).key)
+}
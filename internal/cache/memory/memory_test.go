@@ -0,0 +1,239 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"avito-internship/internal/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const ttl = 30 * time.Minute
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+
+	newCache := func(maxEntries int) (*MemoryCache, *time.Time) {
+		now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+		c := NewMemoryCache(maxEntries, zap.NewNop())
+		c.now = func() time.Time { return now }
+		return c, &now
+	}
+
+	t.Run("Values are JSON encoded", func(t *testing.T) {
+		c, _ := newCache(10)
+
+		require.NoError(t, c.Set(ctx, "user_info:alice", map[string]int{"coins": 1000}, ttl))
+		value, err := c.Get(ctx, "user_info:alice")
+		require.NoError(t, err)
+		assert.Equal(t, `{"coins":1000}`, value)
+
+		assert.Error(t, c.Set(ctx, "broken", make(chan int), ttl))
+	})
+
+	t.Run("Missing key", func(t *testing.T) {
+		c, _ := newCache(10)
+
+		_, err := c.Get(ctx, "missing")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+	})
+
+	t.Run("Entries expire after the TTL", func(t *testing.T) {
+		c, now := newCache(10)
+
+		require.NoError(t, c.Set(ctx, "key", 1, ttl))
+		*now = now.Add(ttl - time.Second)
+		_, err := c.Get(ctx, "key")
+		assert.NoError(t, err)
+
+		*now = now.Add(time.Second)
+		_, err = c.Get(ctx, "key")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("Each entry has its own TTL", func(t *testing.T) {
+		c, now := newCache(10)
+
+		require.NoError(t, c.Set(ctx, "short", 1, time.Minute))
+		require.NoError(t, c.Set(ctx, "long", 2, ttl))
+		*now = now.Add(time.Minute)
+
+		_, err := c.Get(ctx, "short")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+		_, err = c.Get(ctx, "long")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Incr counts from zero and resets the TTL", func(t *testing.T) {
+		c, now := newCache(10)
+
+		for want := int64(1); want <= 3; want++ {
+			value, err := c.Incr(ctx, "counter", time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, want, value)
+			*now = now.Add(time.Minute - time.Second)
+		}
+
+		*now = now.Add(time.Second)
+		value, err := c.Incr(ctx, "counter", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), value)
+
+		require.NoError(t, c.Set(ctx, "text", "alice", ttl))
+		_, err = c.Incr(ctx, "text", ttl)
+		assert.Error(t, err)
+	})
+
+	t.Run("Decr keeps the TTL and stops at zero", func(t *testing.T) {
+		c, now := newCache(10)
+
+		value, err := c.Decr(ctx, "counter")
+		require.NoError(t, err)
+		assert.Zero(t, value)
+		_, err = c.Get(ctx, "counter")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+
+		_, err = c.Incr(ctx, "counter", time.Minute)
+		require.NoError(t, err)
+		for _, want := range []int64{0, 0} {
+			value, err := c.Decr(ctx, "counter")
+			require.NoError(t, err)
+			assert.Equal(t, want, value)
+		}
+
+		*now = now.Add(time.Minute)
+		_, err = c.Get(ctx, "counter")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+	})
+
+	t.Run("SetNewer only replaces older versions", func(t *testing.T) {
+		c, now := newCache(10)
+		type versioned struct {
+			Version int64 `json:"version"`
+		}
+
+		stored, err := c.SetNewer(ctx, "key", versioned{Version: 2}, 2, ttl, true)
+		require.NoError(t, err)
+		assert.False(t, stored)
+
+		for _, tc := range []struct {
+			version int64
+			want    bool
+		}{{2, true}, {1, false}, {2, false}, {3, true}} {
+			stored, err := c.SetNewer(ctx, "key", versioned{Version: tc.version}, tc.version, ttl, false)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, stored, "version %d", tc.version)
+		}
+
+		value, err := c.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"version":3}`, value)
+
+		*now = now.Add(ttl)
+		stored, err = c.SetNewer(ctx, "key", versioned{Version: 4}, 4, ttl, true)
+		require.NoError(t, err)
+		assert.False(t, stored)
+	})
+
+	t.Run("Set resets the TTL", func(t *testing.T) {
+		c, now := newCache(10)
+
+		require.NoError(t, c.Set(ctx, "key", 1, ttl))
+		*now = now.Add(ttl - time.Second)
+		require.NoError(t, c.Set(ctx, "key", 2, ttl))
+		*now = now.Add(time.Minute)
+
+		value, err := c.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, "2", value)
+	})
+
+	t.Run("Least recently used entry is evicted", func(t *testing.T) {
+		c, _ := newCache(2)
+
+		require.NoError(t, c.Set(ctx, "a", 1, ttl))
+		require.NoError(t, c.Set(ctx, "b", 2, ttl))
+		_, err := c.Get(ctx, "a")
+		require.NoError(t, err)
+		require.NoError(t, c.Set(ctx, "c", 3, ttl))
+
+		_, err = c.Get(ctx, "b")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+		_, err = c.Get(ctx, "a")
+		assert.NoError(t, err)
+		_, err = c.Get(ctx, "c")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("Del removes keys", func(t *testing.T) {
+		c, _ := newCache(10)
+
+		require.NoError(t, c.Set(ctx, "a", 1, ttl))
+		require.NoError(t, c.Set(ctx, "b", 2, ttl))
+		require.NoError(t, c.Del(ctx, "a", "b", "missing"))
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("Concurrent access", func(t *testing.T) {
+		c := NewMemoryCache(100, zap.NewNop())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 1000; j++ {
+					key := string(rune('a' + (i+j)%26))
+					_ = c.Set(ctx, key, j, ttl)
+					_, _ = c.Get(ctx, key)
+					_ = c.Del(ctx, key)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		assert.LessOrEqual(t, c.Len(), 100)
+	})
+
+	t.Run("Inspect returns the remaining TTL", func(t *testing.T) {
+		c, now := newCache(10)
+
+		require.NoError(t, c.Set(ctx, "key", map[string]int{"coins": 1000}, ttl))
+		*now = now.Add(time.Minute)
+
+		entry, err := c.Inspect(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, cache.Entry{Key: "key", Value: `{"coins":1000}`, TTL: ttl - time.Minute}, entry)
+
+		*now = now.Add(ttl)
+		_, err = c.Inspect(ctx, "key")
+		assert.ErrorIs(t, err, cache.ErrMiss)
+	})
+
+	t.Run("Evict removes the matching keys", func(t *testing.T) {
+		c, _ := newCache(10)
+
+		require.NoError(t, c.Set(ctx, "user_info:v1:alice", 1, ttl))
+		require.NoError(t, c.Set(ctx, "user_info:v1:bob", 2, ttl))
+		require.NoError(t, c.Set(ctx, "session:v1:alice", 3, ttl))
+
+		evicted, err := c.Evict(ctx, "user_info:*")
+		require.NoError(t, err)
+		assert.Equal(t, 2, evicted)
+		_, err = c.Get(ctx, "session:v1:alice")
+		assert.NoError(t, err)
+
+		_, err = c.Evict(ctx, "[")
+		assert.Error(t, err)
+
+		require.NoError(t, c.Flush(ctx))
+		assert.Equal(t, 0, c.Len())
+	})
+}
@@ -7,6 +7,7 @@ package cache
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,6 +35,21 @@ func (m *MockCache) EXPECT() *MockCacheMockRecorder {
 	return m.recorder
 }
 
+// Decr mocks base method.
+func (m *MockCache) Decr(ctx context.Context, key string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decr", ctx, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decr indicates an expected call of Decr.
+func (mr *MockCacheMockRecorder) Decr(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decr", reflect.TypeOf((*MockCache)(nil).Decr), ctx, key)
+}
+
 // Del mocks base method.
 func (m *MockCache) Del(ctx context.Context, keys ...string) error {
 	m.ctrl.T.Helper()
@@ -53,6 +69,35 @@ func (mr *MockCacheMockRecorder) Del(ctx interface{}, keys ...interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockCache)(nil).Del), varargs...)
 }
 
+// Evict mocks base method.
+func (m *MockCache) Evict(ctx context.Context, pattern string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Evict", ctx, pattern)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Evict indicates an expected call of Evict.
+func (mr *MockCacheMockRecorder) Evict(ctx, pattern interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Evict", reflect.TypeOf((*MockCache)(nil).Evict), ctx, pattern)
+}
+
+// Flush mocks base method.
+func (m *MockCache) Flush(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockCacheMockRecorder) Flush(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockCache)(nil).Flush), ctx)
+}
+
 // Get mocks base method.
 func (m *MockCache) Get(ctx context.Context, key string) (string, error) {
 	m.ctrl.T.Helper()
@@ -68,18 +113,63 @@ func (mr *MockCacheMockRecorder) Get(ctx, key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCache)(nil).Get), ctx, key)
 }
 
+// Incr mocks base method.
+func (m *MockCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Incr", ctx, key, ttl)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Incr indicates an expected call of Incr.
+func (mr *MockCacheMockRecorder) Incr(ctx, key, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockCache)(nil).Incr), ctx, key, ttl)
+}
+
+// Inspect mocks base method.
+func (m *MockCache) Inspect(ctx context.Context, key string) (Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Inspect", ctx, key)
+	ret0, _ := ret[0].(Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Inspect indicates an expected call of Inspect.
+func (mr *MockCacheMockRecorder) Inspect(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inspect", reflect.TypeOf((*MockCache)(nil).Inspect), ctx, key)
+}
+
 // Set mocks base method.
-func (m *MockCache) Set(ctx context.Context, key string, value interface{}) error {
+func (m *MockCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Set", ctx, key, value)
+	ret := m.ctrl.Call(m, "Set", ctx, key, value, ttl)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Set indicates an expected call of Set.
-func (mr *MockCacheMockRecorder) Set(ctx, key, value interface{}) *gomock.Call {
+func (mr *MockCacheMockRecorder) Set(ctx, key, value, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCache)(nil).Set), ctx, key, value, ttl)
+}
+
+// SetNewer mocks base method.
+func (m *MockCache) SetNewer(ctx context.Context, key string, value interface{}, version int64, ttl time.Duration, existing bool) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNewer", ctx, key, value, version, ttl, existing)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetNewer indicates an expected call of SetNewer.
+func (mr *MockCacheMockRecorder) SetNewer(ctx, key, value, version, ttl, existing interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockCache)(nil).Set), ctx, key, value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNewer", reflect.TypeOf((*MockCache)(nil).SetNewer), ctx, key, value, version, ttl, existing)
 }
 
 // Shutdown mocks base method.
@@ -0,0 +1,87 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// InvalidationChannel is the pub/sub channel cache invalidations are broadcast on.
+const InvalidationChannel = "cache:invalidate"
+
+// listenRetryDelay is the pause before resubscribing after a pub/sub error.
+const listenRetryDelay = time.Second
+
+// InvalidationBus broadcasts cache invalidations between replicas over Redis pub/sub.
+type InvalidationBus struct {
+	client redis.UniversalClient
+	log    *zap.Logger
+	// origin identifies the replica, so it skips its own messages.
+	origin string
+}
+
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+func NewInvalidationBus(client redis.UniversalClient, log *zap.Logger) *InvalidationBus {
+	return &InvalidationBus{
+		client: client,
+		log:    log,
+		origin: uuid.NewString(),
+	}
+}
+
+func (b *InvalidationBus) Publish(ctx context.Context, keys []string) error {
+	payload, err := json.Marshal(invalidation{Origin: b.origin, Keys: keys})
+	if err != nil {
+		return err
+	}
+
+	return b.client.Publish(ctx, InvalidationChannel, payload).Err()
+}
+
+// Listen treats every (re)subscription and receive error as a possible gap in
+// the messages and asks for a full invalidation.
+func (b *InvalidationBus) Listen(ctx context.Context, invalidate func(keys []string)) {
+	pubsub := b.client.Subscribe(ctx, InvalidationChannel)
+	defer pubsub.Close()
+
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			b.log.Warn("Cache invalidation subscription failed", zap.Error(err))
+			invalidate(nil)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(listenRetryDelay):
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			invalidate(nil)
+		case *redis.Message:
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				b.log.Warn("Malformed cache invalidation", zap.Error(err))
+				invalidate(nil)
+				continue
+			}
+			if inv.Origin != b.origin {
+				invalidate(inv.Keys)
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Topologies the client can connect to.
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+// Options describe the redis deployment to connect to.
+// Zero pool and timeout values keep the go-redis defaults.
+type Options struct {
+	Mode string
+	// DSN is the URL of a standalone server, credentials and the database are taken from it.
+	DSN string
+	// Addrs are the sentinels in sentinel mode and the seed nodes in cluster mode.
+	Addrs []string
+	// MasterName is the master monitored by the sentinels.
+	MasterName string
+	// Username and Password authenticate at the sentinel master or the cluster nodes.
+	Username         string
+	Password         string
+	SentinelPassword string
+	// DB is not supported by redis cluster and must be 0 there.
+	DB int
+
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolTimeout  time.Duration
+
+	TLS TLSOptions
+}
+
+// TLSOptions enable TLS to redis. A rediss:// DSN enables it as well.
+type TLSOptions struct {
+	Enabled bool
+	// CAFile verifies the server against a private CA instead of the system roots.
+	CAFile string
+	// CertFile and KeyFile hold the client certificate for mutual TLS.
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// NewClient creates a client for the topology selected in opts.
+// It does not connect, use Ping to make sure redis is reachable.
+func NewClient(opts Options) (redis.UniversalClient, error) {
+	tlsConfig, err := newTLSConfig(opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.Mode {
+	case ModeStandalone, "":
+		if opts.DSN == "" {
+			return nil, errors.New("redis: dsn is required in standalone mode")
+		}
+		clientOpts, err := redis.ParseURL(opts.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("redis: parse dsn: %w", err)
+		}
+		if tlsConfig != nil {
+			clientOpts.TLSConfig = tlsConfig
+		}
+		applyPoolOptions(opts, &clientOpts.PoolSize, &clientOpts.MinIdleConns,
+			&clientOpts.DialTimeout, &clientOpts.ReadTimeout, &clientOpts.WriteTimeout, &clientOpts.PoolTimeout)
+
+		return redis.NewClient(clientOpts), nil
+	case ModeSentinel:
+		if len(opts.Addrs) == 0 {
+			return nil, errors.New("redis: sentinel addresses are required in sentinel mode")
+		}
+		if opts.MasterName == "" {
+			return nil, errors.New("redis: master name is required in sentinel mode")
+		}
+		failoverOpts := &redis.FailoverOptions{
+			MasterName:       opts.MasterName,
+			SentinelAddrs:    opts.Addrs,
+			SentinelPassword: opts.SentinelPassword,
+			Username:         opts.Username,
+			Password:         opts.Password,
+			DB:               opts.DB,
+			TLSConfig:        tlsConfig,
+		}
+		applyPoolOptions(opts, &failoverOpts.PoolSize, &failoverOpts.MinIdleConns,
+			&failoverOpts.DialTimeout, &failoverOpts.ReadTimeout, &failoverOpts.WriteTimeout, &failoverOpts.PoolTimeout)
+
+		return redis.NewFailoverClient(failoverOpts), nil
+	case ModeCluster:
+		if len(opts.Addrs) == 0 {
+			return nil, errors.New("redis: node addresses are required in cluster mode")
+		}
+		if opts.DB != 0 {
+			return nil, errors.New("redis: cluster mode supports only database 0")
+		}
+		clusterOpts := &redis.ClusterOptions{
+			Addrs:     opts.Addrs,
+			Username:  opts.Username,
+			Password:  opts.Password,
+			TLSConfig: tlsConfig,
+		}
+		applyPoolOptions(opts, &clusterOpts.PoolSize, &clusterOpts.MinIdleConns,
+			&clusterOpts.DialTimeout, &clusterOpts.ReadTimeout, &clusterOpts.WriteTimeout, &clusterOpts.PoolTimeout)
+
+		return redis.NewClusterClient(clusterOpts), nil
+	default:
+		return nil, fmt.Errorf("redis: unknown mode %q", opts.Mode)
+	}
+}
+
+// applyPoolOptions overrides the pool settings of a client with the non-zero ones in opts.
+func applyPoolOptions(opts Options, poolSize, minIdleConns *int, dialTimeout, readTimeout, writeTimeout, poolTimeout *time.Duration) {
+	if opts.PoolSize > 0 {
+		*poolSize = opts.PoolSize
+	}
+	if opts.MinIdleConns > 0 {
+		*minIdleConns = opts.MinIdleConns
+	}
+	if opts.DialTimeout > 0 {
+		*dialTimeout = opts.DialTimeout
+	}
+	if opts.ReadTimeout > 0 {
+		*readTimeout = opts.ReadTimeout
+	}
+	if opts.WriteTimeout > 0 {
+		*writeTimeout = opts.WriteTimeout
+	}
+	if opts.PoolTimeout > 0 {
+		*poolTimeout = opts.PoolTimeout
+	}
+}
+
+// newTLSConfig returns nil when TLS is disabled.
+func newTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	if !opts.Enabled {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis: read ca file: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("redis: no certificates in ca file %s", opts.CAFile)
+		}
+		config.RootCAs = roots
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis: load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// Ping checks that redis is reachable, retrying up to attempts times with
+// doubling backoff, so a missing redis fails the startup instead of the first requests.
+func Ping(ctx context.Context, client redis.UniversalClient, attempts int, backoff time.Duration, log *zap.Logger) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = client.Ping(ctx).Err(); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		log.Warn("Redis is not reachable, retrying",
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("redis: ping: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	return fmt.Errorf("redis: ping failed after %d attempts: %w", attempts, err)
+}
@@ -3,6 +3,10 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
 
 	"avito-internship/internal/cache"
 
@@ -10,30 +14,75 @@ import (
 	"go.uber.org/zap"
 )
 
-type RedisCache struct {
-	Client *redis.Client
-	Log    *zap.Logger
-}
+// scanBatch is the number of keys SCAN is asked for at a time when evicting.
+const scanBatch = 500
 
-func NewRedisCache(url string, log *zap.Logger) *RedisCache {
-	opts, err := redis.ParseURL(url)
-	if err != nil {
-		panic(err)
-	}
+// incrScript increments a counter and refreshes its expiry in one step, so a
+// counter is never left without a TTL.
+var incrScript = redis.NewScript(`
+local value = redis.call('INCR', KEYS[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[1])
+return value
+`)
 
-	client := redis.NewClient(opts)
+// decrScript decrements a counter unless it is missing or already zero, so a
+// late decrement never creates a counter without a TTL.
+var decrScript = redis.NewScript(`
+local value = tonumber(redis.call('GET', KEYS[1]))
+if not value or value <= 0 then
+	return 0
+end
+return redis.call('DECR', KEYS[1])
+`)
+
+// setNewerScript compares the version of the stored value with the new one
+// and writes in the same step, so a concurrent older write never wins.
+var setNewerScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current then
+	local ok, stored = pcall(cjson.decode, current)
+	if ok and type(stored) == 'table' and tonumber(stored.version) and tonumber(stored.version) >= tonumber(ARGV[2]) then
+		return 0
+	end
+elseif ARGV[4] == '1' then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[3])
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// RedisCache stores the keys under "namespace:", so Flush leaves other data
+// in the same redis, like the rate limits, alone.
+type RedisCache struct {
+	Client    redis.UniversalClient
+	Log       *zap.Logger
+	namespace string
+}
 
+// NewRedisCache wraps a client created with NewClient, so it may talk to a
+// single node, a sentinel-managed master or a cluster.
+func NewRedisCache(client redis.UniversalClient, namespace string, log *zap.Logger) *RedisCache {
 	return &RedisCache{
-		Client: client,
-		Log:    log,
+		Client:    client,
+		Log:       log,
+		namespace: namespace,
 	}
 }
 
+// Get wraps redis.Nil in cache.ErrMiss, so callers need not know the backend.
 func (s *RedisCache) Get(ctx context.Context, key string) (string, error) {
-	return s.Client.Get(ctx, key).Result()
+	value, err := s.Client.Get(ctx, s.key(key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", fmt.Errorf("%w: %w", cache.ErrMiss, err)
+	}
+	return value, err
 }
 
-func (s *RedisCache) Set(ctx context.Context, key string, value interface{}) error {
+func (s *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
 	valueBytes, err := json.Marshal(value)
 	if err != nil {
 		s.Log.Error("Failed to marshal value for caching",
@@ -43,11 +92,139 @@ func (s *RedisCache) Set(ctx context.Context, key string, value interface{}) err
 		return err
 	}
 
-	return s.Client.Set(ctx, key, valueBytes, cache.Ttl).Err()
+	return s.Client.Set(ctx, s.key(key), valueBytes, ttl).Err()
+}
+
+func (s *RedisCache) SetNewer(ctx context.Context, key string, value interface{}, version int64, ttl time.Duration, existing bool) (bool, error) {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		s.Log.Error("Failed to marshal value for caching",
+			zap.String("key", key),
+			zap.Error(err),
+		)
+		return false, err
+	}
+
+	existingArg := 0
+	if existing {
+		existingArg = 1
+	}
+
+	stored, err := setNewerScript.Run(ctx, s.Client, []string{s.key(key)},
+		valueBytes, version, ttl.Milliseconds(), existingArg).Int64()
+
+	return stored == 1, err
 }
 
+// Del deletes the keys one by one in a pipeline, so keys of different cluster
+// slots never meet in one command.
 func (s *RedisCache) Del(ctx context.Context, keys ...string) error {
-	return s.Client.Del(ctx, keys...).Err()
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := s.Client.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, s.key(key))
+	}
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+func (s *RedisCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, s.Client, []string{s.key(key)}, ttl.Milliseconds()).Int64()
+}
+
+func (s *RedisCache) Decr(ctx context.Context, key string) (int64, error) {
+	return decrScript.Run(ctx, s.Client, []string{s.key(key)}).Int64()
+}
+
+func (s *RedisCache) Inspect(ctx context.Context, key string) (cache.Entry, error) {
+	pipe := s.Client.Pipeline()
+	get := pipe.Get(ctx, s.key(key))
+	pttl := pipe.PTTL(ctx, s.key(key))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return cache.Entry{}, err
+	}
+
+	value, err := get.Result()
+	if errors.Is(err, redis.Nil) {
+		return cache.Entry{}, fmt.Errorf("%w: %w", cache.ErrMiss, err)
+	}
+	if err != nil {
+		return cache.Entry{}, err
+	}
+
+	// PTTL is negative for keys without expiry.
+	ttl := max(pttl.Val(), 0)
+
+	return cache.Entry{Key: key, Value: value, TTL: ttl}, nil
+}
+
+// Evict scans for the matching keys, on every master of a cluster, and
+// deletes them as they are found. Keys deleted before a failure stay deleted.
+func (s *RedisCache) Evict(ctx context.Context, pattern string) (int, error) {
+	var evicted atomic.Int64
+	evict := func(ctx context.Context, client *redis.Client) error {
+		n, err := evictNode(ctx, client, s.key(pattern))
+		evicted.Add(int64(n))
+		return err
+	}
+
+	var err error
+	switch client := s.Client.(type) {
+	case *redis.ClusterClient:
+		err = client.ForEachMaster(ctx, evict)
+	case *redis.Client:
+		err = evict(ctx, client)
+	default:
+		err = fmt.Errorf("redis: evict is not supported by %T", s.Client)
+	}
+
+	return int(evicted.Load()), err
+}
+
+// Flush evicts every key of the namespace.
+func (s *RedisCache) Flush(ctx context.Context) error {
+	_, err := s.Evict(ctx, "*")
+	return err
+}
+
+func (s *RedisCache) key(key string) string {
+	return s.namespace + ":" + key
+}
+
+// evictNode deletes the keys of a single node matching match. Keys are
+// unlinked one by one, so keys of different cluster slots never meet in one command.
+func evictNode(ctx context.Context, client *redis.Client, match string) (int, error) {
+	evicted := 0
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, match, scanBatch).Result()
+		if err != nil {
+			return evicted, err
+		}
+
+		if len(keys) > 0 {
+			pipe := client.Pipeline()
+			unlinks := make([]*redis.IntCmd, 0, len(keys))
+			for _, key := range keys {
+				unlinks = append(unlinks, pipe.Unlink(ctx, key))
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				return evicted, err
+			}
+			for _, unlink := range unlinks {
+				evicted += int(unlink.Val())
+			}
+		}
+
+		if next == 0 {
+			return evicted, nil
+		}
+		cursor = next
+	}
 }
 
 func (s *RedisCache) Shutdown() error {
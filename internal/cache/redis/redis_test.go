@@ -0,0 +1,148 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeClusterNode is a redis node serving every slot of a cluster. Like a
+// cluster it rejects DEL of several keys with CROSSSLOT, as the keys of the
+// tests hash to different slots.
+type fakeClusterNode struct {
+	addr string
+
+	mu   sync.Mutex
+	dels [][]string
+}
+
+func newFakeClusterNode(t *testing.T) *fakeClusterNode {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	node := &fakeClusterNode{addr: listener.Addr().String()}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go node.serve(conn)
+		}
+	}()
+
+	return node
+}
+
+func (n *fakeClusterNode) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		var reply string
+		switch strings.ToLower(args[0]) {
+		case "hello":
+			// Makes the client fall back to RESP2.
+			reply = "-ERR unknown command 'HELLO'\r\n"
+		case "ping":
+			reply = "+PONG\r\n"
+		case "del":
+			n.mu.Lock()
+			n.dels = append(n.dels, args[1:])
+			n.mu.Unlock()
+
+			if len(args) > 2 {
+				reply = "-CROSSSLOT Keys in request don't hash to the same slot\r\n"
+			} else {
+				reply = ":1\r\n"
+			}
+		default:
+			reply = "+OK\r\n"
+		}
+
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (n *fakeClusterNode) deletes() [][]string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.dels
+}
+
+// readCommand reads a command sent as a RESP array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	count, err := readLength(r, '*')
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		size, err := readLength(r, '$')
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+
+	return args, nil
+}
+
+func readLength(r *bufio.Reader, prefix byte) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	if len(line) < 3 || line[0] != prefix {
+		return 0, fmt.Errorf("unexpected line %q", line)
+	}
+
+	return strconv.Atoi(strings.TrimSpace(line[1:]))
+}
+
+func TestRedisCache_Del_Cluster(t *testing.T) {
+	node := newFakeClusterNode(t)
+	client := redis.NewClusterClient(&redis.ClusterOptions{
+		ClusterSlots: func(context.Context) ([]redis.ClusterSlot, error) {
+			return []redis.ClusterSlot{{Start: 0, End: 16383, Nodes: []redis.ClusterNode{{Addr: node.addr}}}}, nil
+		},
+	})
+	defer client.Close()
+
+	c := NewRedisCache(client, "cache", zap.NewNop())
+
+	require.NoError(t, c.Del(context.Background(), "session:v1:alice", "user_info:v3:alice", "login_failures:user:v1:alice"))
+	assert.Equal(t, [][]string{
+		{"cache:session:v1:alice"},
+		{"cache:user_info:v3:alice"},
+		{"cache:login_failures:user:v1:alice"},
+	}, node.deletes())
+
+	require.NoError(t, c.Del(context.Background()))
+	assert.Len(t, node.deletes(), 3)
+}
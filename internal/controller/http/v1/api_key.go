@@ -0,0 +1,253 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type apiKeyRoutes struct {
+	log           *zap.Logger
+	apiKeyService service.APIKey
+}
+
+func newAPIKeyRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, apiKeyService service.APIKey) {
+	r := apiKeyRoutes{
+		log:           log,
+		apiKeyService: apiKeyService,
+	}
+
+	(*g).Post("/api-keys", func(c *fiber.Ctx) error {
+		return r.createAPIKey(c, ctx)
+	})
+
+	(*g).Get("/api-keys", func(c *fiber.Ctx) error {
+		return r.listAPIKeys(c, ctx)
+	})
+
+	(*g).Delete("/api-keys/:id", func(c *fiber.Ctx) error {
+		return r.revokeAPIKey(c, ctx)
+	})
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=64"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreateAPIKeyResponse is the only response that contains the plain key.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+func (r apiKeyRoutes) createAPIKey(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.apiKeyRoutes.createAPIKey"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/api-keys"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		r.log.Error("failed to decode request body",
+			zap.String("op", op),
+			zap.String("route", "api/api-keys"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+		r.log.Error("invalid request",
+			zap.String("op", op),
+			zap.String("route", "api/api-keys"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validation.ValidataionError(validateErr),
+		})
+	}
+
+	output, err := r.apiKeyService.Create(ctx, service.CreateAPIKeyInput{
+		Username:  username,
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		for _, invalidErr := range []error{servicerrs.ErrInvalidScope, servicerrs.ErrInvalidAPIKeyExpiry} {
+			if errors.Is(err, invalidErr) {
+				r.log.Warn("invalid api key",
+					zap.String("op", op),
+					zap.String("route", "api/api-keys"),
+					zap.String("username", username),
+					zap.Error(err),
+				)
+
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"errors": invalidErr.Error(),
+				})
+			}
+		}
+
+		r.log.Error("failed to create api key",
+			zap.String("op", op),
+			zap.String("route", "api/api-keys"),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(CreateAPIKeyResponse{
+		APIKey: newAPIKeyResponse(output.APIKey),
+		Key:    output.Key,
+	})
+}
+
+func (r apiKeyRoutes) listAPIKeys(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.apiKeyRoutes.listAPIKeys"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/api-keys"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	keys, err := r.apiKeyService.List(ctx, username)
+	if err != nil {
+		r.log.Error("failed to list api keys",
+			zap.String("op", op),
+			zap.String("route", "api/api-keys"),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	response := []APIKey{}
+	for _, key := range keys {
+		response = append(response, newAPIKeyResponse(key))
+	}
+
+	return c.JSON(response)
+}
+
+func (r apiKeyRoutes) revokeAPIKey(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.apiKeyRoutes.revokeAPIKey"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/api-keys"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		r.log.Warn("invalid api key id",
+			zap.String("op", op),
+			zap.String("route", "api/api-keys"),
+			zap.String("id", c.Params("id")),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid api key id",
+		})
+	}
+
+	err = r.apiKeyService.Revoke(ctx, service.RevokeAPIKeyInput{
+		Username: username,
+		ID:       id,
+	})
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrAPIKeyNotFound) {
+			r.log.Warn("api key not found",
+				zap.String("op", op),
+				zap.String("route", "api/api-keys"),
+				zap.Int("id", id),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "api key not found",
+			})
+		}
+
+		r.log.Error("failed to revoke api key",
+			zap.String("op", op),
+			zap.String("route", "api/api-keys"),
+			zap.Int("id", id),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func newAPIKeyResponse(key entity.APIKey) APIKey {
+	return APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
@@ -0,0 +1,174 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_createAPIKey(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyService := service.NewMockAPIKey(ctrl)
+	createdAt := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	input := service.CreateAPIKeyInput{
+		Username: "user",
+		Name:     "HR bot",
+		Scopes:   []string{entity.ScopeCoinsSend},
+	}
+
+	tests := []struct {
+		name            string
+		reqBody         map[string]interface{}
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name:    "Key created",
+			reqBody: map[string]interface{}{"name": "HR bot", "scopes": []string{entity.ScopeCoinsSend}},
+			mockServiceFunc: func() {
+				mockAPIKeyService.EXPECT().Create(ctx, input).Return(service.CreateAPIKeyOutput{
+					Key: "ak_0123abcd_secret",
+					APIKey: entity.APIKey{
+						ID:        1,
+						Name:      "HR bot",
+						Prefix:    "0123abcd",
+						Scopes:    []string{entity.ScopeCoinsSend},
+						CreatedAt: createdAt,
+					},
+				}, nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"id":1,"name":"HR bot","prefix":"0123abcd","scopes":["coins:send"],"createdAt":"2025-02-01T12:00:00Z","key":"ak_0123abcd_secret"}`,
+		},
+		{
+			name:            "Missing scopes",
+			reqBody:         map[string]interface{}{"name": "HR bot"},
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusBadRequest,
+		},
+		{
+			name:    "Unknown scope",
+			reqBody: map[string]interface{}{"name": "HR bot", "scopes": []string{entity.ScopeCoinsSend}},
+			mockServiceFunc: func() {
+				mockAPIKeyService.EXPECT().Create(ctx, input).Return(service.CreateAPIKeyOutput{}, servicerrs.ErrInvalidScope)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"errors":"invalid scope"}`,
+		},
+		{
+			name:    "Internal server error",
+			reqBody: map[string]interface{}{"name": "HR bot", "scopes": []string{entity.ScopeCoinsSend}},
+			mockServiceFunc: func() {
+				mockAPIKeyService.EXPECT().Create(ctx, input).Return(service.CreateAPIKeyOutput{}, errors.New("internal error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := apiKeyRoutes{
+				log:           logger,
+				apiKeyService: mockAPIKeyService,
+			}
+			app.Post("/api-keys", func(c *fiber.Ctx) error {
+				c.Locals("username", "user")
+				return r.createAPIKey(c, ctx)
+			})
+
+			tt.mockServiceFunc()
+
+			reqBody, _ := json.Marshal(tt.reqBody)
+			req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+
+			var bodyBytes bytes.Buffer
+			_, _ = bodyBytes.ReadFrom(resp.Body)
+			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
+		})
+	}
+}
+
+func Test_revokeAPIKey(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAPIKeyService := service.NewMockAPIKey(ctrl)
+
+	tests := []struct {
+		name            string
+		id              string
+		mockServiceFunc func()
+		expectedCode    int
+	}{
+		{
+			name: "Key revoked",
+			id:   "1",
+			mockServiceFunc: func() {
+				mockAPIKeyService.EXPECT().Revoke(ctx, service.RevokeAPIKeyInput{Username: "user", ID: 1}).Return(nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Key not found",
+			id:   "2",
+			mockServiceFunc: func() {
+				mockAPIKeyService.EXPECT().Revoke(ctx, service.RevokeAPIKeyInput{Username: "user", ID: 2}).Return(servicerrs.ErrAPIKeyNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:            "Invalid id",
+			id:              "abc",
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := apiKeyRoutes{
+				log:           logger,
+				apiKeyService: mockAPIKeyService,
+			}
+			app.Delete("/api-keys/:id", func(c *fiber.Ctx) error {
+				c.Locals("username", "user")
+				return r.revokeAPIKey(c, ctx)
+			})
+
+			tt.mockServiceFunc()
+
+			req := httptest.NewRequest(http.MethodDelete, "/api-keys/"+tt.id, nil)
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+		})
+	}
+}
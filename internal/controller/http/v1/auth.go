@@ -3,7 +3,11 @@ package v1
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
+	"avito-internship/internal/model"
 	"avito-internship/internal/service"
 	"avito-internship/internal/service/servicerrs"
 	"avito-internship/pkg/validation"
@@ -14,19 +18,25 @@ import (
 )
 
 type AuthRoutes struct {
-	log         *zap.Logger
-	authService service.Auth
+	log           *zap.Logger
+	authService   service.Auth
+	loginAttempts service.LoginAttempts
 }
 
-func newAuthRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, authService service.Auth) {
+func newAuthRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, authService service.Auth, loginAttempts service.LoginAttempts) {
 	r := AuthRoutes{
-		log:         log,
-		authService: authService,
+		log:           log,
+		authService:   authService,
+		loginAttempts: loginAttempts,
 	}
 
 	(*g).Post("/auth", func(c *fiber.Ctx) error {
 		return r.authorize(c, ctx)
 	})
+
+	(*g).Post("/register", func(c *fiber.Ctx) error {
+		return r.register(c, ctx)
+	})
 }
 
 type AuthRequest struct {
@@ -38,6 +48,18 @@ type AuthResponse struct {
 	Token string `json:"token"`
 }
 
+// AuthorizeResponse carries a challenge token instead of an access token when
+// the user has 2FA enabled. It is exchanged at /api/auth/2fa.
+type AuthorizeResponse struct {
+	Token          string `json:"token,omitempty"`
+	ChallengeToken string `json:"challengeToken,omitempty"`
+}
+
+var invalidUsernameMessage = fmt.Sprintf(
+	"username must be %d to %d characters long, start with a letter and contain only latin letters, digits, '_', '.' or '-'",
+	model.UsernameMinLength, model.UsernameMaxLength,
+)
+
 func (r *AuthRoutes) authorize(c *fiber.Ctx, ctx context.Context) error {
 	const op = "v1.authRoutes.authorize"
 
@@ -70,8 +92,23 @@ func (r *AuthRoutes) authorize(c *fiber.Ctx, ctx context.Context) error {
 		})
 	}
 
-	token, err := r.authService.Authorization(ctx, req.Username, req.Password)
+	if retryAfter := r.loginAttempts.Attempt(ctx, req.Username, c.IP()); retryAfter > 0 {
+		r.log.Warn("too many login attempts",
+			zap.String("op", op),
+			zap.String("route", "api/auth"),
+			zap.String("username", req.Username),
+			zap.String("ip", c.IP()),
+		)
+
+		return tooManyAttempts(c, retryAfter)
+	}
+
+	output, err := r.authService.Authorization(withClient(ctx, c), req.Username, req.Password)
 	if err != nil {
+		if errors.Is(err, servicerrs.ErrInvalidCredentials) || errors.Is(err, servicerrs.ErrUserNotFound) {
+			r.loginAttempts.RegisterFailure(ctx, req.Username, c.IP())
+		}
+
 		if errors.Is(err, servicerrs.ErrInvalidCredentials) {
 			r.log.Warn("invalid credentials",
 				zap.String("op", op),
@@ -84,6 +121,39 @@ func (r *AuthRoutes) authorize(c *fiber.Ctx, ctx context.Context) error {
 				"errors": "invalid credentials",
 			})
 		}
+		if errors.Is(err, servicerrs.ErrUserNotFound) {
+			r.log.Warn("user not found",
+				zap.String("op", op),
+				zap.String("route", "api/auth"),
+				zap.String("username", req.Username),
+			)
+
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"errors": "user not found, register via /api/register",
+			})
+		}
+		if errors.Is(err, servicerrs.ErrInvalidUsername) {
+			r.log.Warn("invalid username",
+				zap.String("op", op),
+				zap.String("route", "api/auth"),
+				zap.String("username", req.Username),
+			)
+
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": invalidUsernameMessage,
+			})
+		}
+		if message, ok := passwordPolicyMessage(err); ok {
+			r.log.Warn("password rejected by policy",
+				zap.String("op", op),
+				zap.String("route", "api/auth"),
+				zap.String("username", req.Username),
+			)
+
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": message,
+			})
+		}
 
 		r.log.Error("failed to authorize user",
 			zap.String("op", op),
@@ -97,9 +167,122 @@ func (r *AuthRoutes) authorize(c *fiber.Ctx, ctx context.Context) error {
 		})
 	}
 
+	// With 2FA the failures are kept until the code is accepted too, so a
+	// correct password does not reset the throttling of wrong codes.
+	if output.TwoFactorRequired {
+		r.loginAttempts.RegisterSuccess(ctx, "", c.IP())
+		return c.JSON(AuthorizeResponse{ChallengeToken: output.Token})
+	}
+
+	r.loginAttempts.RegisterSuccess(ctx, req.Username, c.IP())
+
+	return c.JSON(AuthorizeResponse{Token: output.Token})
+}
+
+// passwordPolicyMessage describes why a new password was rejected.
+func passwordPolicyMessage(err error) (string, bool) {
+	switch {
+	case errors.Is(err, servicerrs.ErrPasswordTooShort):
+		return "password is too short", true
+	case errors.Is(err, servicerrs.ErrPasswordTooLong):
+		return "password is too long", true
+	case errors.Is(err, servicerrs.ErrPasswordBreached):
+		return "password is too common, choose another one", true
+	}
+
+	return "", false
+}
+
+// tooManyAttempts responds with 429 and a Retry-After header rounded up to whole seconds.
+func tooManyAttempts(c *fiber.Ctx, retryAfter time.Duration) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(retryAfter)))
+
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"errors": "too many login attempts, try again later",
+	})
+}
+
+func (r *AuthRoutes) register(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.authRoutes.register"
+
+	var req AuthRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		r.log.Error("failed to decode request body",
+			zap.String("op", op),
+			zap.String("route", "api/register"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+		r.log.Error("invalid request",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validation.ValidataionError(validateErr),
+		})
+	}
+
+	token, err := r.authService.Register(withClient(ctx, c), req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrInvalidUsername) {
+			r.log.Warn("invalid username",
+				zap.String("op", op),
+				zap.String("route", "api/register"),
+				zap.String("username", req.Username),
+			)
+
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": invalidUsernameMessage,
+			})
+		}
+		if message, ok := passwordPolicyMessage(err); ok {
+			r.log.Warn("password rejected by policy",
+				zap.String("op", op),
+				zap.String("route", "api/register"),
+				zap.String("username", req.Username),
+			)
+
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": message,
+			})
+		}
+		if errors.Is(err, servicerrs.ErrUserAlreadyExists) {
+			r.log.Warn("user already exists",
+				zap.String("op", op),
+				zap.String("route", "api/register"),
+				zap.String("username", req.Username),
+			)
+
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"errors": "user already exists",
+			})
+		}
+
+		r.log.Error("failed to register user",
+			zap.String("op", op),
+			zap.String("route", "api/register"),
+			zap.String("username", req.Username),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
 	response := AuthResponse{
 		Token: token,
 	}
 
-	return c.JSON(response)
+	return c.Status(fiber.StatusCreated).JSON(response)
 }
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"avito-internship/internal/service"
 	"avito-internship/internal/service/servicerrs"
@@ -25,37 +26,78 @@ func Test_Authorize(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockAuthService := service.NewMockAuth(ctrl)
+	mockLoginAttempts := service.NewMockLoginAttempts(ctrl)
 
 	tests := []struct {
-		name         string
-		requestBody  map[string]string
-		mockAuthFunc func()
-		expectedCode int
-		expectedBody string
+		name               string
+		requestBody        map[string]string
+		mockAuthFunc       func()
+		expectedCode       int
+		expectedBody       string
+		expectedRetryAfter string
 	}{
 		{
 			name:        "Successful authorization",
 			requestBody: map[string]string{"username": "user", "password": "pass"},
 			mockAuthFunc: func() {
-				mockAuthService.EXPECT().Authorization(ctx, "user", "pass").Return("valid-token", nil)
+				mockLoginAttempts.EXPECT().Attempt(ctx, "user", gomock.Any()).Return(time.Duration(0))
+				mockAuthService.EXPECT().Authorization(gomock.Any(), "user", "pass").Return(service.AuthorizationOutput{Token: "valid-token"}, nil)
+				mockLoginAttempts.EXPECT().RegisterSuccess(ctx, "user", gomock.Any())
 			},
 			expectedCode: http.StatusOK,
 			expectedBody: `{"token":"valid-token"}`,
 		},
+		{
+			name:        "Second factor required",
+			requestBody: map[string]string{"username": "user", "password": "pass"},
+			mockAuthFunc: func() {
+				mockLoginAttempts.EXPECT().Attempt(ctx, "user", gomock.Any()).Return(time.Duration(0))
+				mockAuthService.EXPECT().
+					Authorization(gomock.Any(), "user", "pass").
+					Return(service.AuthorizationOutput{Token: "challenge-token", TwoFactorRequired: true}, nil)
+				mockLoginAttempts.EXPECT().RegisterSuccess(ctx, "", gomock.Any())
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"challengeToken":"challenge-token"}`,
+		},
 		{
 			name:        "Invalid credentials",
 			requestBody: map[string]string{"username": "user", "password": "wrong"},
 			mockAuthFunc: func() {
-				mockAuthService.EXPECT().Authorization(ctx, "user", "wrong").Return("", servicerrs.ErrInvalidCredentials)
+				mockLoginAttempts.EXPECT().Attempt(ctx, "user", gomock.Any()).Return(time.Duration(0))
+				mockAuthService.EXPECT().Authorization(gomock.Any(), "user", "wrong").Return(service.AuthorizationOutput{}, servicerrs.ErrInvalidCredentials)
+				mockLoginAttempts.EXPECT().RegisterFailure(ctx, "user", gomock.Any()).Return(time.Second)
 			},
 			expectedCode: http.StatusBadRequest,
 			expectedBody: `{"errors":"invalid credentials"}`,
 		},
+		{
+			name:        "User not found",
+			requestBody: map[string]string{"username": "user", "password": "pass"},
+			mockAuthFunc: func() {
+				mockLoginAttempts.EXPECT().Attempt(ctx, "user", gomock.Any()).Return(time.Duration(0))
+				mockAuthService.EXPECT().Authorization(gomock.Any(), "user", "pass").Return(service.AuthorizationOutput{}, servicerrs.ErrUserNotFound)
+				mockLoginAttempts.EXPECT().RegisterFailure(ctx, "user", gomock.Any()).Return(time.Duration(0))
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"errors":"user not found, register via /api/register"}`,
+		},
+		{
+			name:        "Too many attempts",
+			requestBody: map[string]string{"username": "user", "password": "pass"},
+			mockAuthFunc: func() {
+				mockLoginAttempts.EXPECT().Attempt(ctx, "user", gomock.Any()).Return(1500 * time.Millisecond)
+			},
+			expectedCode:       http.StatusTooManyRequests,
+			expectedBody:       `{"errors":"too many login attempts, try again later"}`,
+			expectedRetryAfter: "2",
+		},
 		{
 			name:        "Internal server error",
 			requestBody: map[string]string{"username": "user", "password": "pass"},
 			mockAuthFunc: func() {
-				mockAuthService.EXPECT().Authorization(ctx, "user", "pass").Return("", errors.New("unexpected error"))
+				mockLoginAttempts.EXPECT().Attempt(ctx, "user", gomock.Any()).Return(time.Duration(0))
+				mockAuthService.EXPECT().Authorization(gomock.Any(), "user", "pass").Return(service.AuthorizationOutput{}, errors.New("unexpected error"))
 			},
 			expectedCode: http.StatusInternalServerError,
 			expectedBody: `{"errors":"internal error"}`,
@@ -86,7 +128,7 @@ func Test_Authorize(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			app := fiber.New()
-			r := &AuthRoutes{log: logger, authService: mockAuthService}
+			r := &AuthRoutes{log: logger, authService: mockAuthService, loginAttempts: mockLoginAttempts}
 			app.Post("/auth", func(c *fiber.Ctx) error { return r.authorize(c, ctx) })
 
 			tt.mockAuthFunc()
@@ -98,6 +140,89 @@ func Test_Authorize(t *testing.T) {
 
 			assert.Equal(t, tt.expectedCode, resp.StatusCode)
 
+			var bodyBytes bytes.Buffer
+			_, _ = bodyBytes.ReadFrom(resp.Body)
+			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
+			assert.Equal(t, tt.expectedRetryAfter, resp.Header.Get(fiber.HeaderRetryAfter))
+		})
+	}
+}
+
+func Test_Register(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthService := service.NewMockAuth(ctrl)
+
+	tests := []struct {
+		name         string
+		requestBody  map[string]string
+		mockAuthFunc func()
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:        "Successful registration",
+			requestBody: map[string]string{"username": "user", "password": "pass"},
+			mockAuthFunc: func() {
+				mockAuthService.EXPECT().Register(gomock.Any(), "user", "pass").Return("valid-token", nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"token":"valid-token"}`,
+		},
+		{
+			name:        "Invalid username",
+			requestBody: map[string]string{"username": "1", "password": "pass"},
+			mockAuthFunc: func() {
+				mockAuthService.EXPECT().Register(gomock.Any(), "1", "pass").Return("", servicerrs.ErrInvalidUsername)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `username must be 3 to 32 characters long`,
+		},
+		{
+			name:        "User already exists",
+			requestBody: map[string]string{"username": "user", "password": "pass"},
+			mockAuthFunc: func() {
+				mockAuthService.EXPECT().Register(gomock.Any(), "user", "pass").Return("", servicerrs.ErrUserAlreadyExists)
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"errors":"user already exists"}`,
+		},
+		{
+			name:        "Internal server error",
+			requestBody: map[string]string{"username": "user", "password": "pass"},
+			mockAuthFunc: func() {
+				mockAuthService.EXPECT().Register(gomock.Any(), "user", "pass").Return("", errors.New("unexpected error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+		{
+			name:         "Missing password field",
+			requestBody:  map[string]string{"username": "user"},
+			mockAuthFunc: func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"errors":"Password is a required"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := &AuthRoutes{log: logger, authService: mockAuthService}
+			app.Post("/register", func(c *fiber.Ctx) error { return r.register(c, ctx) })
+
+			tt.mockAuthFunc()
+
+			reqBody, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+
 			var bodyBytes bytes.Buffer
 			_, _ = bodyBytes.ReadFrom(resp.Body)
 			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
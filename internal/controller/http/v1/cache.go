@@ -0,0 +1,182 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"avito-internship/internal/cache/loader"
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type cacheRoutes struct {
+	log        *zap.Logger
+	cacheAdmin service.CacheAdmin
+}
+
+func newCacheRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, cacheAdmin service.CacheAdmin) {
+	r := cacheRoutes{
+		log:        log,
+		cacheAdmin: cacheAdmin,
+	}
+
+	(*g).Get("/cache/stats", func(c *fiber.Ctx) error {
+		return r.stats(c)
+	})
+
+	(*g).Get("/cache/entries", func(c *fiber.Ctx) error {
+		return r.inspect(c, ctx)
+	})
+
+	(*g).Delete("/cache/entries", func(c *fiber.Ctx) error {
+		return r.evict(c, ctx)
+	})
+
+	(*g).Delete("/cache", func(c *fiber.Ctx) error {
+		return r.flush(c, ctx)
+	})
+
+	(*g).Post("/cache/warm", func(c *fiber.Ctx) error {
+		return r.warm(c, ctx)
+	})
+}
+
+type CacheStatsResponse struct {
+	UserInfo loader.Stats `json:"userInfo"`
+	Catalog  loader.Stats `json:"catalog"`
+}
+
+type CacheEntry struct {
+	Key string `json:"key"`
+	// Value is the cached JSON, or a string if the entry holds something else.
+	Value json.RawMessage `json:"value"`
+	// TTL is the remaining lifetime in seconds, zero when the entry does not expire.
+	TTL int `json:"ttl"`
+}
+
+type EvictCacheResponse struct {
+	Evicted int `json:"evicted"`
+}
+
+type WarmCacheResponse struct {
+	Users   int  `json:"users"`
+	Failed  int  `json:"failed"`
+	Catalog bool `json:"catalog"`
+}
+
+func (r cacheRoutes) stats(c *fiber.Ctx) error {
+	stats := r.cacheAdmin.CacheStats()
+
+	return c.JSON(CacheStatsResponse{
+		UserInfo: stats.UserInfo,
+		Catalog:  stats.Catalog,
+	})
+}
+
+// inspect returns the entry of the key given in the key query parameter.
+func (r cacheRoutes) inspect(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.cacheRoutes.inspect"
+
+	key := c.Query("key")
+	if key == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "key is required",
+		})
+	}
+
+	entry, err := r.cacheAdmin.InspectCacheEntry(ctx, key)
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrCacheEntryNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "cache entry not found",
+			})
+		}
+
+		return r.cacheError(c, op, err)
+	}
+
+	value := json.RawMessage(entry.Value)
+	if !json.Valid(value) {
+		value, _ = json.Marshal(entry.Value)
+	}
+
+	return c.JSON(CacheEntry{
+		Key:   entry.Key,
+		Value: value,
+		TTL:   ceilSeconds(entry.TTL),
+	})
+}
+
+// evict removes the entries matching the glob in the pattern query parameter.
+func (r cacheRoutes) evict(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.cacheRoutes.evict"
+
+	pattern := c.Query("pattern")
+	if pattern == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "pattern is required",
+		})
+	}
+
+	evicted, err := r.cacheAdmin.EvictCacheEntries(ctx, pattern)
+	if err != nil {
+		return r.cacheError(c, op, err)
+	}
+
+	return c.JSON(EvictCacheResponse{Evicted: evicted})
+}
+
+func (r cacheRoutes) flush(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.cacheRoutes.flush"
+
+	if err := r.cacheAdmin.FlushCache(ctx); err != nil {
+		return r.cacheError(c, op, err)
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// warm preloads the cache, the users query parameter overrides the number of users.
+func (r cacheRoutes) warm(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.cacheRoutes.warm"
+
+	users := c.QueryInt("users")
+	if users < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "users must not be negative",
+		})
+	}
+
+	output, err := r.cacheAdmin.WarmCache(ctx, service.WarmCacheInput{Users: users})
+	if err != nil {
+		return r.cacheError(c, op, err)
+	}
+
+	return c.JSON(WarmCacheResponse{
+		Users:   output.Users,
+		Failed:  output.Failed,
+		Catalog: output.Catalog,
+	})
+}
+
+func (r cacheRoutes) cacheError(c *fiber.Ctx, op string, err error) error {
+	if errors.Is(err, servicerrs.ErrCacheUnavailable) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"errors": "cache is unavailable, try again later",
+		})
+	}
+
+	r.log.Error("cache operation failed",
+		zap.String("op", op),
+		zap.String("route", c.Path()),
+		zap.Error(err),
+	)
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"errors": "internal error",
+	})
+}
@@ -0,0 +1,168 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/cache/loader"
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCacheRoutes(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCacheAdmin := service.NewMockCacheAdmin(ctrl)
+
+	tests := []struct {
+		name            string
+		method          string
+		target          string
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name:   "Stats",
+			method: http.MethodGet,
+			target: "/cache/stats",
+			mockServiceFunc: func() {
+				mockCacheAdmin.EXPECT().CacheStats().Return(service.CacheStats{UserInfo: loader.Stats{Hits: 2, Misses: 1}})
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"userInfo":{"hits":2,"misses":1,"coalesced":0,"earlyRefreshes":0}`,
+		},
+		{
+			name:   "Inspect entry",
+			method: http.MethodGet,
+			target: "/cache/entries?key=user_info:v1:user1",
+			mockServiceFunc: func() {
+				mockCacheAdmin.EXPECT().InspectCacheEntry(ctx, "user_info:v1:user1").
+					Return(cache.Entry{Key: "user_info:v1:user1", Value: `{"coins":1000}`, TTL: 90500 * time.Millisecond}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"key":"user_info:v1:user1","value":{"coins":1000},"ttl":91}`,
+		},
+		{
+			name:   "Inspect entry that is not JSON",
+			method: http.MethodGet,
+			target: "/cache/entries?key=raw",
+			mockServiceFunc: func() {
+				mockCacheAdmin.EXPECT().InspectCacheEntry(ctx, "raw").
+					Return(cache.Entry{Key: "raw", Value: "plain text"}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"key":"raw","value":"plain text","ttl":0}`,
+		},
+		{
+			name:   "Inspect missing entry",
+			method: http.MethodGet,
+			target: "/cache/entries?key=user_info:v1:user2",
+			mockServiceFunc: func() {
+				mockCacheAdmin.EXPECT().InspectCacheEntry(ctx, "user_info:v1:user2").
+					Return(cache.Entry{}, servicerrs.ErrCacheEntryNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"errors":"cache entry not found"}`,
+		},
+		{
+			name:            "Inspect without key",
+			method:          http.MethodGet,
+			target:          "/cache/entries",
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusBadRequest,
+			expectedBody:    `{"errors":"key is required"}`,
+		},
+		{
+			name:   "Evict by pattern",
+			method: http.MethodDelete,
+			target: "/cache/entries?pattern=user_info:*",
+			mockServiceFunc: func() {
+				mockCacheAdmin.EXPECT().EvictCacheEntries(ctx, "user_info:*").Return(4, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"evicted":4}`,
+		},
+		{
+			name:            "Evict without pattern",
+			method:          http.MethodDelete,
+			target:          "/cache/entries",
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusBadRequest,
+			expectedBody:    `{"errors":"pattern is required"}`,
+		},
+		{
+			name:   "Flush",
+			method: http.MethodDelete,
+			target: "/cache",
+			mockServiceFunc: func() {
+				mockCacheAdmin.EXPECT().FlushCache(ctx).Return(nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:   "Flush while the cache is unavailable",
+			method: http.MethodDelete,
+			target: "/cache",
+			mockServiceFunc: func() {
+				mockCacheAdmin.EXPECT().FlushCache(ctx).Return(servicerrs.ErrCacheUnavailable)
+			},
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: `{"errors":"cache is unavailable, try again later"}`,
+		},
+		{
+			name:   "Warm",
+			method: http.MethodPost,
+			target: "/cache/warm?users=50",
+			mockServiceFunc: func() {
+				mockCacheAdmin.EXPECT().WarmCache(ctx, service.WarmCacheInput{Users: 50}).
+					Return(service.WarmCacheOutput{Users: 49, Failed: 1, Catalog: true}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"users":49,"failed":1,"catalog":true}`,
+		},
+		{
+			name:   "Warm error",
+			method: http.MethodPost,
+			target: "/cache/warm",
+			mockServiceFunc: func() {
+				mockCacheAdmin.EXPECT().WarmCache(ctx, service.WarmCacheInput{}).
+					Return(service.WarmCacheOutput{}, errors.New("db error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			g := app.Group("")
+			newCacheRoutes(ctx, zap.NewNop(), &g, mockCacheAdmin)
+
+			tt.mockServiceFunc()
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+
+			var bodyBytes bytes.Buffer
+			_, _ = bodyBytes.ReadFrom(resp.Body)
+			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
+		})
+	}
+}
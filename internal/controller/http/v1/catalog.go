@@ -0,0 +1,116 @@
+package v1
+
+import (
+	"context"
+	"strconv"
+
+	"avito-internship/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type catalogRoutes struct {
+	log            *zap.Logger
+	productService service.Product
+}
+
+func newCatalogRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, productService service.Product) {
+	r := catalogRoutes{
+		log:            log,
+		productService: productService,
+	}
+
+	(*g).Get("/products", func(c *fiber.Ctx) error {
+		return r.searchProducts(c, ctx)
+	})
+}
+
+type Product struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Price       int               `json:"price"`
+	Category    string            `json:"category,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	Stock       *int              `json:"stock,omitempty"`
+	Available   bool              `json:"available"`
+	Variants    []ProductVariant  `json:"variants,omitempty"`
+}
+
+type ProductVariant struct {
+	Name       string            `json:"name"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Stock      *int              `json:"stock,omitempty"`
+}
+
+func (r catalogRoutes) searchProducts(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.catalogRoutes.searchProducts"
+
+	input := service.SearchProductsInput{
+		Query:     c.Query("q"),
+		Category:  c.Query("category"),
+		Available: c.QueryBool("available"),
+	}
+
+	for param, dst := range map[string]**int{
+		"minPrice": &input.MinPrice,
+		"maxPrice": &input.MaxPrice,
+	} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+
+		price, err := strconv.Atoi(raw)
+		if err != nil || price < 0 {
+			r.log.Error("invalid request",
+				zap.String("op", op),
+				zap.String("route", "api/products"),
+				zap.String(param, raw),
+			)
+
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": param + " must be a non-negative integer",
+			})
+		}
+		*dst = &price
+	}
+
+	products, err := r.productService.SearchProducts(ctx, input)
+	if err != nil {
+		r.log.Error("failed to search products",
+			zap.String("op", op),
+			zap.String("route", "api/products"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	response := []Product{}
+	for _, product := range products {
+		variants := []ProductVariant{}
+		for _, variant := range product.Variants {
+			variants = append(variants, ProductVariant{
+				Name:       variant.Name,
+				Attributes: variant.Attributes,
+				Stock:      variant.Stock,
+			})
+		}
+
+		response = append(response, Product{
+			Name:        product.Name,
+			Description: product.Description,
+			Price:       product.Price,
+			Category:    product.Category,
+			Attributes:  product.Attributes,
+			Stock:       product.Stock,
+			Available:   product.Available,
+			Variants:    variants,
+		})
+	}
+
+	return c.JSON(response)
+}
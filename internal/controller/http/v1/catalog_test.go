@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_searchProducts(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductService := service.NewMockProduct(ctrl)
+
+	minPrice := 100
+	stock := 3
+
+	tests := []struct {
+		name            string
+		query           string
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name:  "Successful search",
+			query: "?q=hoody&category=clothes&minPrice=100&available=true",
+			mockServiceFunc: func() {
+				mockProductService.EXPECT().
+					SearchProducts(ctx, service.SearchProductsInput{
+						Query:     "hoody",
+						Category:  "clothes",
+						MinPrice:  &minPrice,
+						Available: true,
+					}).
+					Return([]entity.Product{{
+						Name:      "hoody",
+						Price:     300,
+						Category:  "clothes",
+						Available: true,
+						Variants: []entity.ProductVariant{
+							{Name: "M", Attributes: map[string]string{"size": "M"}, Stock: &stock},
+						},
+					}}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"name":"hoody","price":300,"category":"clothes","available":true,"variants":[{"name":"M","attributes":{"size":"M"},"stock":3}]}]`,
+		},
+		{
+			name:            "Invalid price",
+			query:           "?maxPrice=cheap",
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusBadRequest,
+			expectedBody:    `{"errors":"maxPrice must be a non-negative integer"}`,
+		},
+		{
+			name:  "Internal server error",
+			query: "",
+			mockServiceFunc: func() {
+				mockProductService.EXPECT().
+					SearchProducts(ctx, service.SearchProductsInput{}).
+					Return(nil, errors.New("internal error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := catalogRoutes{
+				log:            logger,
+				productService: mockProductService,
+			}
+			app.Get("/products", func(c *fiber.Ctx) error {
+				return r.searchProducts(c, ctx)
+			})
+
+			tt.mockServiceFunc()
+
+			req := httptest.NewRequest(http.MethodGet, "/products"+tt.query, nil)
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+
+			var bodyBytes bytes.Buffer
+			_, _ = bodyBytes.ReadFrom(resp.Body)
+			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type impersonationRoutes struct {
+	log                  *zap.Logger
+	impersonationService service.Impersonation
+}
+
+func newImpersonationRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, impersonationService service.Impersonation) {
+	r := impersonationRoutes{
+		log:                  log,
+		impersonationService: impersonationService,
+	}
+
+	(*g).Post("/impersonate", func(c *fiber.Ctx) error {
+		return r.impersonate(c, ctx)
+	})
+}
+
+type ImpersonateRequest struct {
+	Username string `json:"username" validate:"required"`
+	// Reason is kept in the audit log, e.g. the support ticket.
+	Reason     string `json:"reason" validate:"required,max=256"`
+	AllowWrite bool   `json:"allowWrite"`
+}
+
+type ImpersonateResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (r impersonationRoutes) impersonate(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.impersonationRoutes.impersonate"
+
+	actor, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/admin/impersonate"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	var req ImpersonateRequest
+	if err := c.BodyParser(&req); err != nil {
+		r.log.Error("failed to decode request body",
+			zap.String("op", op),
+			zap.String("route", "api/admin/impersonate"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+		r.log.Error("invalid request",
+			zap.String("op", op),
+			zap.String("route", "api/admin/impersonate"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validation.ValidataionError(validateErr),
+		})
+	}
+
+	output, err := r.impersonationService.Start(ctx, service.StartImpersonationInput{
+		Actor:      actor,
+		Subject:    req.Username,
+		Reason:     req.Reason,
+		AllowWrite: req.AllowWrite,
+	})
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrUserNotFound) {
+			r.log.Warn("user not found",
+				zap.String("op", op),
+				zap.String("route", "api/admin/impersonate"),
+				zap.String("username", req.Username),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "user not found",
+			})
+		}
+
+		if errors.Is(err, servicerrs.ErrImpersonateSelf) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": servicerrs.ErrImpersonateSelf.Error(),
+			})
+		}
+
+		r.log.Error("failed to start impersonation",
+			zap.String("op", op),
+			zap.String("route", "api/admin/impersonate"),
+			zap.String("username", req.Username),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(ImpersonateResponse{
+		Token:     output.Token,
+		ExpiresAt: output.ExpiresAt,
+	})
+}
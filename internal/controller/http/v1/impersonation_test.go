@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_impersonate(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockImpersonationService := service.NewMockImpersonation(ctrl)
+	expiresAt := time.Date(2025, 2, 1, 12, 15, 0, 0, time.UTC)
+	input := service.StartImpersonationInput{
+		Actor:   "admin",
+		Subject: "user",
+		Reason:  "SUP-42",
+	}
+
+	tests := []struct {
+		name            string
+		reqBody         map[string]interface{}
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name:    "Read-only token issued",
+			reqBody: map[string]interface{}{"username": "user", "reason": "SUP-42"},
+			mockServiceFunc: func() {
+				mockImpersonationService.EXPECT().Start(ctx, input).
+					Return(service.ImpersonationOutput{Token: "token", ExpiresAt: expiresAt}, nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"token":"token","expiresAt":"2025-02-01T12:15:00Z"}`,
+		},
+		{
+			name:    "Write access requested",
+			reqBody: map[string]interface{}{"username": "user", "reason": "SUP-42", "allowWrite": true},
+			mockServiceFunc: func() {
+				writeInput := input
+				writeInput.AllowWrite = true
+				mockImpersonationService.EXPECT().Start(ctx, writeInput).
+					Return(service.ImpersonationOutput{Token: "token", ExpiresAt: expiresAt}, nil)
+			},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"token":"token"`,
+		},
+		{
+			name:            "Missing reason",
+			reqBody:         map[string]interface{}{"username": "user"},
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusBadRequest,
+		},
+		{
+			name:    "User not found",
+			reqBody: map[string]interface{}{"username": "user", "reason": "SUP-42"},
+			mockServiceFunc: func() {
+				mockImpersonationService.EXPECT().Start(ctx, input).Return(service.ImpersonationOutput{}, servicerrs.ErrUserNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"errors":"user not found"}`,
+		},
+		{
+			name:    "Internal error",
+			reqBody: map[string]interface{}{"username": "user", "reason": "SUP-42"},
+			mockServiceFunc: func() {
+				mockImpersonationService.EXPECT().Start(ctx, input).Return(service.ImpersonationOutput{}, errors.New("internal error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := impersonationRoutes{
+				log:                  logger,
+				impersonationService: mockImpersonationService,
+			}
+			app.Post("/impersonate", func(c *fiber.Ctx) error {
+				c.Locals("username", "admin")
+				return r.impersonate(c, ctx)
+			})
+
+			tt.mockServiceFunc()
+
+			reqBody, _ := json.Marshal(tt.reqBody)
+			req := httptest.NewRequest(http.MethodPost, "/impersonate", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+
+			var bodyBytes bytes.Buffer
+			_, _ = bodyBytes.ReadFrom(resp.Body)
+			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
+		})
+	}
+}
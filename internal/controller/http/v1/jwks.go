@@ -0,0 +1,29 @@
+package v1
+
+import (
+	"avito-internship/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type jwksRoutes struct {
+	log         *zap.Logger
+	authService service.Auth
+}
+
+func newJWKSRoutes(log *zap.Logger, g *fiber.Router, authService service.Auth) {
+	r := jwksRoutes{
+		log:         log,
+		authService: authService,
+	}
+
+	(*g).Get("/.well-known/jwks.json", r.getJWKS)
+}
+
+func (r jwksRoutes) getJWKS(c *fiber.Ctx) error {
+	// Verification keys only change on restart, so clients may cache them for a while.
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return c.JSON(r.authService.JWKS())
+}
@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"context"
+
+	"avito-internship/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type lockoutRoutes struct {
+	log           *zap.Logger
+	loginAttempts service.LoginAttempts
+}
+
+func newLockoutRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, loginAttempts service.LoginAttempts) {
+	r := lockoutRoutes{
+		log:           log,
+		loginAttempts: loginAttempts,
+	}
+
+	(*g).Delete("/users/:username/lockout", func(c *fiber.Ctx) error {
+		return r.unlock(c, ctx, service.UnlockInput{Username: c.Params("username")})
+	})
+
+	(*g).Delete("/ips/:ip/lockout", func(c *fiber.Ctx) error {
+		return r.unlock(c, ctx, service.UnlockInput{IP: c.Params("ip")})
+	})
+}
+
+func (r lockoutRoutes) unlock(c *fiber.Ctx, ctx context.Context, input service.UnlockInput) error {
+	const op = "v1.lockoutRoutes.unlock"
+
+	if err := r.loginAttempts.Unlock(ctx, input); err != nil {
+		r.log.Error("failed to unlock login",
+			zap.String("op", op),
+			zap.String("route", c.Path()),
+			zap.String("username", input.Username),
+			zap.String("ip", input.IP),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
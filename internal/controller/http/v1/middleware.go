@@ -1,41 +1,298 @@
 package v1
 
 import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"avito-internship/internal/entity"
 	"avito-internship/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
 )
 
+// apiKeyScopedRoutes lists the routes that accept API keys and the scope each of them requires.
+// Every other protected route, API key management included, needs a user token.
+var apiKeyScopedRoutes = []struct {
+	method string
+	path   string
+	scope  string
+}{
+	{fiber.MethodGet, "/api/info", entity.ScopeInfoRead},
+	{fiber.MethodPost, "/api/sendCoin", entity.ScopeCoinsSend},
+}
+
+// impersonationForbiddenPaths are never available with an impersonation token:
+// admin routes, and managing credentials and sessions of the user.
+var impersonationForbiddenPaths = []string{
+	"/api/admin",
+	"/api/password",
+	"/api/2fa",
+	"/api/api-keys",
+	"/api/sessions",
+}
+
+// impersonationWritePaths are GET routes that change data, so read-only
+// impersonation tokens cannot use them.
+var impersonationWritePaths = []string{
+	"/api/buy",
+}
+
+// bearerRealm is the protection space announced in WWW-Authenticate challenges.
+const bearerRealm = "api"
+
+// b64token is the token syntax of RFC 6750, section 2.1.
+var b64token = regexp.MustCompile(`^[A-Za-z0-9\-._~+/]+=*$`)
+
 type AuthMiddleware struct {
-	authService service.Auth
-	log         *zap.Logger
+	authService          service.Auth
+	apiKeyService        service.APIKey
+	impersonationService service.Impersonation
+	log                  *zap.Logger
 }
 
-func NewAuthMiddleware(log *zap.Logger, authService service.Auth) *AuthMiddleware {
+func NewAuthMiddleware(log *zap.Logger, authService service.Auth, apiKeyService service.APIKey, impersonationService service.Impersonation) *AuthMiddleware {
 	return &AuthMiddleware{
-		authService: authService,
-		log:         log,
+		authService:          authService,
+		apiKeyService:        apiKeyService,
+		impersonationService: impersonationService,
+		log:                  log,
 	}
 }
 
+// Auth accepts a user JWT or an API key, passed either in the X-API-Key header
+// or as a bearer token. The username is stored in the context; for impersonation
+// tokens the acting admin is stored as "actor".
 func (m *AuthMiddleware) Auth() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		const op = "middleware.AuthMiddleware"
 
-		token := c.Get("Authorization")
-		if token == "" {
+		if key := apiKeyFromRequest(c); key != "" {
+			return m.authAPIKey(c, key)
+		}
+
+		header := c.Get(fiber.HeaderAuthorization)
+		if header == "" {
 			m.log.Warn("Missing authorization token", zap.String("op", op))
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+			return bearerChallenge(c, fiber.StatusUnauthorized, "", "")
+		}
+
+		token, ok := bearerToken(header)
+		if !ok {
+			m.log.Warn("Malformed authorization header", zap.String("op", op))
+			return bearerChallenge(c, fiber.StatusBadRequest, "invalid_request", "The Authorization header must use the Bearer scheme")
 		}
 
-		username, err := m.authService.ValidateToken(token)
+		accessToken, err := m.authService.ValidateToken(c.UserContext(), token)
 		if err != nil {
 			m.log.Warn("Invalid token", zap.String("op", op), zap.Error(err))
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+			return bearerChallenge(c, fiber.StatusUnauthorized, "invalid_token", "The access token is invalid or expired")
 		}
 
-		c.Locals("username", username)
+		if accessToken.Actor != "" {
+			return m.authImpersonation(c, accessToken)
+		}
+
+		c.Locals("username", accessToken.Username)
+		c.Locals("sessionID", accessToken.SessionID)
+
+		return c.Next()
+	}
+}
+
+// authImpersonation audits the request and lets it through if the token allows it.
+// A request that cannot be audited is rejected.
+func (m *AuthMiddleware) authImpersonation(c *fiber.Ctx, accessToken service.AccessToken) error {
+	const op = "middleware.AuthMiddleware.authImpersonation"
+
+	allowed := impersonationAllowed(c.Method(), c.Path(), accessToken.ReadOnly)
+	event := entity.ImpersonationEvent{
+		Actor:   accessToken.Actor,
+		Subject: accessToken.Username,
+		Action:  entity.ImpersonationRequest,
+		Method:  c.Method(),
+		Path:    c.Path(),
+	}
+	if !allowed {
+		event.Action = entity.ImpersonationDenied
+	}
+
+	if err := m.impersonationService.Audit(c.UserContext(), event); err != nil {
+		m.log.Error("Failed to audit impersonated request", zap.String("op", op), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal error"})
+	}
+
+	if !allowed {
+		m.log.Warn("Impersonated request denied",
+			zap.String("op", op),
+			zap.String("actor", accessToken.Actor),
+			zap.String("username", accessToken.Username),
+			zap.String("method", c.Method()),
+			zap.String("path", c.Path()),
+		)
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	c.Locals("username", accessToken.Username)
+	c.Locals("actor", accessToken.Actor)
+
+	return c.Next()
+}
+
+func (m *AuthMiddleware) authAPIKey(c *fiber.Ctx, plain string) error {
+	const op = "middleware.AuthMiddleware.authAPIKey"
+
+	key, err := m.apiKeyService.Authenticate(c.UserContext(), plain)
+	if err != nil {
+		m.log.Warn("Invalid API key", zap.String("op", op), zap.Error(err))
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid API key"})
+	}
+
+	scope, ok := apiKeyRouteScope(c.Method(), c.Path())
+	if !ok || !slices.Contains(key.Scopes, scope) {
+		m.log.Warn("API key scope denied",
+			zap.String("op", op),
+			zap.String("prefix", key.Prefix),
+			zap.String("method", c.Method()),
+			zap.String("path", c.Path()),
+		)
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+	}
+
+	c.Locals("username", key.Username)
+
+	return c.Next()
+}
+
+func apiKeyFromRequest(c *fiber.Ctx) string {
+	if key := c.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	token, ok := bearerToken(c.Get(fiber.HeaderAuthorization))
+	if ok && service.IsAPIKey(token) {
+		return token
+	}
+
+	return ""
+}
+
+// bearerToken extracts the token from an Authorization header of the form
+// "Bearer <token>" as defined by RFC 6750. The scheme is case-insensitive.
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimLeft(token, " ")
+	if !b64token.MatchString(token) {
+		return "", false
+	}
+
+	return token, true
+}
+
+// bearerChallenge rejects the request with a WWW-Authenticate challenge.
+// The error code is omitted when the request carried no credentials at all.
+func bearerChallenge(c *fiber.Ctx, status int, code, description string) error {
+	challenge := fmt.Sprintf(`Bearer realm=%q`, bearerRealm)
+	if code != "" {
+		challenge += fmt.Sprintf(`, error=%q, error_description=%q`, code, description)
+	}
+	c.Set(fiber.HeaderWWWAuthenticate, challenge)
+
+	message := "Unauthorized"
+	switch code {
+	case "invalid_request":
+		message = "Invalid authorization header"
+	case "invalid_token":
+		message = "Invalid token"
+	}
+
+	return c.Status(status).JSON(fiber.Map{"error": message})
+}
+
+// apiKeyRouteScope matches paths the way the router does: case-insensitively
+// and ignoring a trailing slash.
+func apiKeyRouteScope(method, path string) (string, bool) {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	for _, route := range apiKeyScopedRoutes {
+		if route.method == method && strings.EqualFold(route.path, path) {
+			return route.scope, true
+		}
+	}
+
+	return "", false
+}
+
+// impersonationAllowed matches paths the way apiKeyRouteScope does. Read-only
+// tokens are limited to safe methods.
+func impersonationAllowed(method, path string, readOnly bool) bool {
+	path = strings.ToLower(path)
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	hasPrefix := func(prefixes []string) bool {
+		return slices.ContainsFunc(prefixes, func(prefix string) bool {
+			return path == prefix || strings.HasPrefix(path, prefix+"/")
+		})
+	}
+
+	if hasPrefix(impersonationForbiddenPaths) {
+		return false
+	}
+	if !readOnly {
+		return true
+	}
+
+	return (method == fiber.MethodGet || method == fiber.MethodHead) && !hasPrefix(impersonationWritePaths)
+}
+
+type AdminMiddleware struct {
+	admins map[string]struct{}
+	log    *zap.Logger
+}
+
+func NewAdminMiddleware(log *zap.Logger, admins []string) *AdminMiddleware {
+	set := make(map[string]struct{}, len(admins))
+	for _, admin := range admins {
+		// An empty ADMINS may be parsed as a single empty name.
+		if admin == "" {
+			continue
+		}
+		set[admin] = struct{}{}
+	}
+
+	return &AdminMiddleware{
+		admins: set,
+		log:    log,
+	}
+}
+
+// Admin must be chained after AuthMiddleware.Auth, since it relies on the username stored in the context.
+func (m *AdminMiddleware) Admin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		const op = "middleware.AdminMiddleware"
+
+		username, ok := c.Locals("username").(string)
+		if !ok {
+			m.log.Warn("Missing username in context", zap.String("op", op))
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		}
+
+		if _, ok := m.admins[username]; !ok {
+			m.log.Warn("Access to admin route denied",
+				zap.String("op", op),
+				zap.String("username", username),
+			)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+		}
 
 		return c.Next()
 	}
@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"context"
+	"errors"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type oidcRoutes struct {
+	log         *zap.Logger
+	oidcService service.OIDC
+}
+
+func newOIDCRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, oidcService service.OIDC) {
+	r := oidcRoutes{
+		log:         log,
+		oidcService: oidcService,
+	}
+
+	(*g).Get("/oidc/login", func(c *fiber.Ctx) error {
+		return r.login(c, ctx)
+	})
+
+	(*g).Get("/oidc/callback", func(c *fiber.Ctx) error {
+		return r.callback(c, ctx)
+	})
+}
+
+func (r oidcRoutes) login(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.oidcRoutes.login"
+
+	authURL, err := r.oidcService.LoginURL(ctx)
+	if err != nil {
+		r.log.Error("failed to start oidc login",
+			zap.String("op", op),
+			zap.String("route", "api/oidc/login"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+func (r oidcRoutes) callback(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.oidcRoutes.callback"
+
+	// The provider redirects back with an error when the user cancels the login.
+	if providerErr := c.Query("error"); providerErr != "" {
+		r.log.Warn("identity provider returned an error",
+			zap.String("op", op),
+			zap.String("route", "api/oidc/callback"),
+			zap.String("error", providerErr),
+			zap.String("description", c.Query("error_description")),
+		)
+
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"errors": servicerrs.ErrOIDCLoginFailed.Error(),
+		})
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		r.log.Warn("missing code or state",
+			zap.String("op", op),
+			zap.String("route", "api/oidc/callback"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "code and state are required",
+		})
+	}
+
+	token, err := r.oidcService.Callback(withClient(ctx, c), service.OIDCCallbackInput{
+		Code:  code,
+		State: state,
+	})
+	if err != nil {
+		for _, e := range []struct {
+			err    error
+			status int
+		}{
+			{servicerrs.ErrInvalidOIDCState, fiber.StatusBadRequest},
+			{servicerrs.ErrOIDCLoginFailed, fiber.StatusUnauthorized},
+			{servicerrs.ErrOIDCEmailNotAllowed, fiber.StatusForbidden},
+			{servicerrs.ErrInvalidUsername, fiber.StatusForbidden},
+			{servicerrs.ErrIdentityConflict, fiber.StatusConflict},
+			{servicerrs.ErrOIDCUsernameTaken, fiber.StatusConflict},
+		} {
+			if errors.Is(err, e.err) {
+				r.log.Warn("oidc login rejected",
+					zap.String("op", op),
+					zap.String("route", "api/oidc/callback"),
+					zap.Error(err),
+				)
+
+				return c.Status(e.status).JSON(fiber.Map{
+					"errors": e.err.Error(),
+				})
+			}
+		}
+
+		r.log.Error("failed to complete oidc login",
+			zap.String("op", op),
+			zap.String("route", "api/oidc/callback"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.JSON(AuthorizeResponse{Token: token})
+}
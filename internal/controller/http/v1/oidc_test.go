@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_oidcLogin(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOIDCService := service.NewMockOIDC(ctrl)
+
+	app := fiber.New()
+	r := oidcRoutes{log: zap.NewNop(), oidcService: mockOIDCService}
+	app.Get("/oidc/login", func(c *fiber.Ctx) error { return r.login(c, ctx) })
+
+	mockOIDCService.EXPECT().LoginURL(ctx).Return("https://idp.example/authorize?state=s", nil)
+
+	resp, _ := app.Test(httptest.NewRequest(http.MethodGet, "/oidc/login", nil))
+
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, "https://idp.example/authorize?state=s", resp.Header.Get("Location"))
+}
+
+func Test_oidcCallback(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOIDCService := service.NewMockOIDC(ctrl)
+	input := service.OIDCCallbackInput{Code: "code", State: "state"}
+
+	tests := []struct {
+		name            string
+		query           string
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name:  "Access token issued",
+			query: "?code=code&state=state",
+			mockServiceFunc: func() {
+				mockOIDCService.EXPECT().Callback(gomock.Any(), input).Return("valid-token", nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"token":"valid-token"}`,
+		},
+		{
+			name:            "Login cancelled at the provider",
+			query:           "?error=access_denied&state=state",
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusUnauthorized,
+			expectedBody:    `{"errors":"identity provider login failed"}`,
+		},
+		{
+			name:            "Missing code",
+			query:           "?state=state",
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusBadRequest,
+			expectedBody:    `{"errors":"code and state are required"}`,
+		},
+		{
+			name:  "Expired state",
+			query: "?code=code&state=state",
+			mockServiceFunc: func() {
+				mockOIDCService.EXPECT().Callback(gomock.Any(), input).Return("", servicerrs.ErrInvalidOIDCState)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"errors":"invalid or expired login state"}`,
+		},
+		{
+			name:  "Email not allowed",
+			query: "?code=code&state=state",
+			mockServiceFunc: func() {
+				mockOIDCService.EXPECT().Callback(gomock.Any(), input).Return("", servicerrs.ErrOIDCEmailNotAllowed)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:  "Identity conflict",
+			query: "?code=code&state=state",
+			mockServiceFunc: func() {
+				mockOIDCService.EXPECT().Callback(gomock.Any(), input).Return("", servicerrs.ErrIdentityConflict)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:  "Username taken by a local account",
+			query: "?code=code&state=state",
+			mockServiceFunc: func() {
+				mockOIDCService.EXPECT().Callback(gomock.Any(), input).Return("", servicerrs.ErrOIDCUsernameTaken)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:  "Internal server error",
+			query: "?code=code&state=state",
+			mockServiceFunc: func() {
+				mockOIDCService.EXPECT().Callback(gomock.Any(), input).Return("", errors.New("internal error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := oidcRoutes{
+				log:         zap.NewNop(),
+				oidcService: mockOIDCService,
+			}
+			app.Get("/oidc/callback", func(c *fiber.Ctx) error { return r.callback(c, ctx) })
+
+			tt.mockServiceFunc()
+
+			resp, _ := app.Test(httptest.NewRequest(http.MethodGet, "/oidc/callback"+tt.query, nil))
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+
+			var bodyBytes bytes.Buffer
+			_, _ = bodyBytes.ReadFrom(resp.Body)
+			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
+		})
+	}
+}
@@ -13,6 +13,14 @@ import (
 	"go.uber.org/zap"
 )
 
+// promoCodeErrors are returned to the client as is when a promo code cannot be applied.
+var promoCodeErrors = []error{
+	servicerrs.ErrPromoCodeNotFound,
+	servicerrs.ErrPromoCodeInactive,
+	servicerrs.ErrPromoCodeNotApplicable,
+	servicerrs.ErrPromoCodeUsageLimit,
+}
+
 type operationRoutes struct {
 	log              *zap.Logger
 	operationService service.Operation
@@ -172,9 +180,16 @@ func (r operationRoutes) buyProduct(c *fiber.Ctx, ctx context.Context) error {
 	}
 	r.log.Info("request body decoded")
 
+	variant := c.Query("variant")
+	promoCode := c.Query("promo")
+	location := c.Query("location")
+
 	err := r.operationService.PurchaseProduct(ctx, service.PurchaseProductInput{
-		Username: username,
-		Product:  item,
+		Username:  username,
+		Product:   item,
+		Variant:   variant,
+		PromoCode: promoCode,
+		Location:  location,
 	})
 	if err != nil {
 		if errors.Is(err, servicerrs.ErrInsufficientFunds) {
@@ -197,6 +212,55 @@ func (r operationRoutes) buyProduct(c *fiber.Ctx, ctx context.Context) error {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"errors": "product not found",
 			})
+		} else if errors.Is(err, servicerrs.ErrVariantNotFound) || errors.Is(err, servicerrs.ErrVariantRequired) {
+			r.log.Warn("invalid product variant",
+				zap.String("op", op),
+				zap.String("route", "api/buy"),
+				zap.String("item", item),
+				zap.String("variant", variant),
+				zap.Error(err),
+			)
+
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": "product variant is required or does not exist",
+			})
+		} else if errors.Is(err, servicerrs.ErrOutOfStock) {
+			r.log.Warn("product out of stock",
+				zap.String("op", op),
+				zap.String("route", "api/buy"),
+				zap.String("item", item),
+			)
+
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"errors": "product out of stock",
+			})
+		} else if errors.Is(err, servicerrs.ErrPurchaseLimitExceeded) {
+			r.log.Warn("purchase limit exceeded",
+				zap.String("op", op),
+				zap.String("route", "api/buy"),
+				zap.String("customer", username),
+				zap.String("item", item),
+			)
+
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"errors": "purchase limit exceeded",
+			})
+		}
+
+		for _, promoErr := range promoCodeErrors {
+			if errors.Is(err, promoErr) {
+				r.log.Warn("promo code rejected",
+					zap.String("op", op),
+					zap.String("route", "api/buy"),
+					zap.String("customer", username),
+					zap.String("promo_code", promoCode),
+					zap.Error(err),
+				)
+
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"errors": promoErr.Error(),
+				})
+			}
 		}
 
 		r.log.Error("failed to buy product",
@@ -194,6 +194,49 @@ func Test_buyProduct(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 			expectedBody: `{"errors":"product not found"}`,
 		},
+		{
+			name: "Out of stock",
+			item: "product1",
+			mockServiceFunc: func() {
+				mockOperationService.EXPECT().
+					PurchaseProduct(ctx, service.PurchaseProductInput{
+						Username: "user",
+						Product:  "product1",
+					}).
+					Return(servicerrs.ErrOutOfStock)
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"errors":"product out of stock"}`,
+		},
+		{
+			name: "Purchase limit exceeded",
+			item: "product1",
+			mockServiceFunc: func() {
+				mockOperationService.EXPECT().
+					PurchaseProduct(ctx, service.PurchaseProductInput{
+						Username: "user",
+						Product:  "product1",
+					}).
+					Return(servicerrs.ErrPurchaseLimitExceeded)
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"errors":"purchase limit exceeded"}`,
+		},
+		{
+			name: "Promo code not applicable",
+			item: "product1?promo=HOODY20",
+			mockServiceFunc: func() {
+				mockOperationService.EXPECT().
+					PurchaseProduct(ctx, service.PurchaseProductInput{
+						Username:  "user",
+						Product:   "product1",
+						PromoCode: "HOODY20",
+					}).
+					Return(servicerrs.ErrPromoCodeNotApplicable)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"errors":"promo code is not applicable to the product"}`,
+		},
 		{
 			name: "Internal server error",
 			item: "product1",
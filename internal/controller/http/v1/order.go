@@ -0,0 +1,230 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type orderRoutes struct {
+	log          *zap.Logger
+	orderService service.Order
+}
+
+func newOrderRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, orderService service.Order) {
+	r := orderRoutes{
+		log:          log,
+		orderService: orderService,
+	}
+
+	(*g).Get("/orders", func(c *fiber.Ctx) error {
+		return r.getUserOrders(c, ctx)
+	})
+}
+
+func newAdminOrderRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, orderService service.Order) {
+	r := orderRoutes{
+		log:          log,
+		orderService: orderService,
+	}
+
+	(*g).Get("/orders", func(c *fiber.Ctx) error {
+		return r.getOrders(c, ctx)
+	})
+
+	(*g).Put("/orders/:id/status", func(c *fiber.Ctx) error {
+		return r.advanceOrder(c, ctx)
+	})
+}
+
+type OrderStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=packed delivered cancelled"`
+}
+
+type Order struct {
+	ID        int       `json:"id"`
+	User      string    `json:"user,omitempty"`
+	Product   string    `json:"product"`
+	Variant   string    `json:"variant,omitempty"`
+	Amount    int       `json:"amount"`
+	Status    string    `json:"status"`
+	Location  string    `json:"location,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (r orderRoutes) getUserOrders(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.orderRoutes.getUserOrders"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/orders"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	orders, err := r.orderService.ListUserOrders(ctx, username)
+	if err != nil {
+		r.log.Error("failed to list user orders",
+			zap.String("op", op),
+			zap.String("route", "api/orders"),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	response := []Order{}
+	for _, order := range orders {
+		// The user is the caller, so there is no need to repeat it.
+		order.Username = ""
+		response = append(response, newOrder(order))
+	}
+
+	return c.JSON(response)
+}
+
+func (r orderRoutes) getOrders(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.orderRoutes.getOrders"
+
+	status := c.Query("status")
+
+	orders, err := r.orderService.ListOrders(ctx, status)
+	if err != nil {
+		r.log.Error("failed to list orders",
+			zap.String("op", op),
+			zap.String("route", "api/admin/orders"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	response := []Order{}
+	for _, order := range orders {
+		response = append(response, newOrder(order))
+	}
+
+	return c.JSON(response)
+}
+
+func (r orderRoutes) advanceOrder(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.orderRoutes.advanceOrder"
+
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		r.log.Error("invalid order id",
+			zap.String("op", op),
+			zap.String("route", "api/admin/orders/status"),
+			zap.String("id", c.Params("id")),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid order id",
+		})
+	}
+
+	r.log.Info("attempting to decode request body")
+	var req OrderStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		r.log.Error("failed to decode request body",
+			zap.String("op", op),
+			zap.String("route", "api/admin/orders/status"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+	r.log.Info("request body decoded")
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+		r.log.Error("invalid request",
+			zap.String("op", op),
+			zap.String("route", "api/admin/orders/status"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validation.ValidataionError(validateErr),
+		})
+	}
+
+	order, err := r.orderService.AdvanceOrder(ctx, service.AdvanceOrderInput{
+		ID:     id,
+		Status: req.Status,
+	})
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrOrderNotFound) {
+			r.log.Warn("order not found",
+				zap.String("op", op),
+				zap.String("route", "api/admin/orders/status"),
+				zap.Int("id", id),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "order not found",
+			})
+		} else if errors.Is(err, servicerrs.ErrInvalidOrderTransition) {
+			r.log.Warn("invalid order status transition",
+				zap.String("op", op),
+				zap.String("route", "api/admin/orders/status"),
+				zap.Int("id", id),
+				zap.String("status", req.Status),
+			)
+
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"errors": "invalid order status transition",
+			})
+		}
+
+		r.log.Error("failed to advance order",
+			zap.String("op", op),
+			zap.String("route", "api/admin/orders/status"),
+			zap.Int("id", id),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.JSON(newOrder(order))
+}
+
+func newOrder(order entity.Order) Order {
+	return Order{
+		ID:        order.ID,
+		User:      order.Username,
+		Product:   order.Product,
+		Variant:   order.Variant,
+		Amount:    order.Amount,
+		Status:    order.Status,
+		Location:  order.Location,
+		CreatedAt: order.CreatedAt,
+		UpdatedAt: order.UpdatedAt,
+	}
+}
@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_advanceOrder(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderService := service.NewMockOrder(ctrl)
+
+	createdAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		id              string
+		requestBody     map[string]interface{}
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name:        "Order packed",
+			id:          "1",
+			requestBody: map[string]interface{}{"status": "packed"},
+			mockServiceFunc: func() {
+				mockOrderService.EXPECT().
+					AdvanceOrder(ctx, service.AdvanceOrderInput{ID: 1, Status: "packed"}).
+					Return(entity.Order{
+						ID:        1,
+						Username:  "user",
+						Product:   "hoody",
+						Variant:   "M",
+						Amount:    300,
+						Status:    "packed",
+						Location:  "3rd floor",
+						CreatedAt: createdAt,
+						UpdatedAt: createdAt,
+					}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"id":1,"user":"user","product":"hoody","variant":"M","amount":300,"status":"packed","location":"3rd floor"`,
+		},
+		{
+			name:        "Order not found",
+			id:          "42",
+			requestBody: map[string]interface{}{"status": "packed"},
+			mockServiceFunc: func() {
+				mockOrderService.EXPECT().
+					AdvanceOrder(ctx, service.AdvanceOrderInput{ID: 42, Status: "packed"}).
+					Return(entity.Order{}, servicerrs.ErrOrderNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"errors":"order not found"}`,
+		},
+		{
+			name:        "Invalid transition",
+			id:          "1",
+			requestBody: map[string]interface{}{"status": "delivered"},
+			mockServiceFunc: func() {
+				mockOrderService.EXPECT().
+					AdvanceOrder(ctx, service.AdvanceOrderInput{ID: 1, Status: "delivered"}).
+					Return(entity.Order{}, servicerrs.ErrInvalidOrderTransition)
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"errors":"invalid order status transition"}`,
+		},
+		{
+			name:        "Internal server error",
+			id:          "1",
+			requestBody: map[string]interface{}{"status": "cancelled"},
+			mockServiceFunc: func() {
+				mockOrderService.EXPECT().
+					AdvanceOrder(ctx, service.AdvanceOrderInput{ID: 1, Status: "cancelled"}).
+					Return(entity.Order{}, errors.New("internal error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+		{
+			name:            "Invalid order id",
+			id:              "abc",
+			requestBody:     map[string]interface{}{"status": "packed"},
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusBadRequest,
+			expectedBody:    `{"errors":"invalid order id"}`,
+		},
+		{
+			name:            "Invalid status",
+			id:              "1",
+			requestBody:     map[string]interface{}{"status": "placed"},
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := orderRoutes{
+				log:          logger,
+				orderService: mockOrderService,
+			}
+			app.Put("/orders/:id/status", func(c *fiber.Ctx) error {
+				return r.advanceOrder(c, ctx)
+			})
+
+			tt.mockServiceFunc()
+
+			reqBody, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest(http.MethodPut, "/orders/"+tt.id+"/status", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+
+			var bodyBytes bytes.Buffer
+			_, _ = bodyBytes.ReadFrom(resp.Body)
+			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
+		})
+	}
+}
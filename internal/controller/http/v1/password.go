@@ -0,0 +1,124 @@
+package v1
+
+import (
+	"context"
+	"errors"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type passwordRoutes struct {
+	log         *zap.Logger
+	authService service.Auth
+}
+
+func newPasswordRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, authService service.Auth) {
+	r := passwordRoutes{
+		log:         log,
+		authService: authService,
+	}
+
+	(*g).Post("/password", func(c *fiber.Ctx) error {
+		return r.changePassword(c, ctx)
+	})
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required"`
+}
+
+func (r passwordRoutes) changePassword(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.passwordRoutes.changePassword"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/password"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	var req ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		r.log.Error("failed to decode request body",
+			zap.String("op", op),
+			zap.String("route", "api/password"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+		r.log.Error("invalid request",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validation.ValidataionError(validateErr),
+		})
+	}
+
+	token, err := r.authService.ChangePassword(withClient(ctx, c), service.ChangePasswordInput{
+		Username:        username,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	})
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrInvalidCredentials) {
+			r.log.Warn("invalid current password",
+				zap.String("op", op),
+				zap.String("route", "api/password"),
+				zap.String("username", username),
+			)
+
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": "invalid current password",
+			})
+		}
+		if message, ok := passwordPolicyMessage(err); ok {
+			r.log.Warn("password rejected by policy",
+				zap.String("op", op),
+				zap.String("route", "api/password"),
+				zap.String("username", username),
+			)
+
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": message,
+			})
+		}
+
+		r.log.Error("failed to change password",
+			zap.String("op", op),
+			zap.String("route", "api/password"),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	response := AuthResponse{
+		Token: token,
+	}
+
+	return c.JSON(response)
+}
@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_changePassword(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthService := service.NewMockAuth(ctrl)
+
+	input := service.ChangePasswordInput{
+		Username:        "user",
+		CurrentPassword: "current-password",
+		NewPassword:     "new-password",
+	}
+
+	tests := []struct {
+		name            string
+		requestBody     map[string]string
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name:        "Password changed",
+			requestBody: map[string]string{"currentPassword": "current-password", "newPassword": "new-password"},
+			mockServiceFunc: func() {
+				mockAuthService.EXPECT().ChangePassword(gomock.Any(), input).Return("new-token", nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"token":"new-token"}`,
+		},
+		{
+			name:        "Invalid current password",
+			requestBody: map[string]string{"currentPassword": "current-password", "newPassword": "new-password"},
+			mockServiceFunc: func() {
+				mockAuthService.EXPECT().ChangePassword(gomock.Any(), input).Return("", servicerrs.ErrInvalidCredentials)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"errors":"invalid current password"}`,
+		},
+		{
+			name:        "Password rejected by policy",
+			requestBody: map[string]string{"currentPassword": "current-password", "newPassword": "new-password"},
+			mockServiceFunc: func() {
+				mockAuthService.EXPECT().ChangePassword(gomock.Any(), input).Return("", servicerrs.ErrPasswordBreached)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"errors":"password is too common, choose another one"}`,
+		},
+		{
+			name:        "Internal server error",
+			requestBody: map[string]string{"currentPassword": "current-password", "newPassword": "new-password"},
+			mockServiceFunc: func() {
+				mockAuthService.EXPECT().ChangePassword(gomock.Any(), input).Return("", errors.New("internal error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+		{
+			name:            "Missing new password",
+			requestBody:     map[string]string{"currentPassword": "current-password"},
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusBadRequest,
+			expectedBody:    `{"errors":"NewPassword is a required"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := passwordRoutes{
+				log:         logger,
+				authService: mockAuthService,
+			}
+			app.Post("/password", func(c *fiber.Ctx) error {
+				c.Locals("username", "user")
+				return r.changePassword(c, ctx)
+			})
+
+			tt.mockServiceFunc()
+
+			reqBody, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/password", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+
+			var bodyBytes bytes.Buffer
+			_, _ = bodyBytes.ReadFrom(resp.Body)
+			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
+		})
+	}
+}
@@ -0,0 +1,455 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type productRoutes struct {
+	log            *zap.Logger
+	productService service.Product
+}
+
+func newProductRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, productService service.Product) {
+	r := productRoutes{
+		log:            log,
+		productService: productService,
+	}
+
+	(*g).Post("/products/:item/restock", func(c *fiber.Ctx) error {
+		return r.restock(c, ctx)
+	})
+
+	(*g).Put("/products/:item/limit", func(c *fiber.Ctx) error {
+		return r.setPurchaseLimit(c, ctx)
+	})
+
+	(*g).Put("/products/:item/price", func(c *fiber.Ctx) error {
+		return r.setPrice(c, ctx)
+	})
+
+	(*g).Get("/products/:item/stock-history", func(c *fiber.Ctx) error {
+		return r.getStockHistory(c, ctx)
+	})
+
+	(*g).Post("/products/:item/variants", func(c *fiber.Ctx) error {
+		return r.addVariant(c, ctx)
+	})
+}
+
+type RestockRequest struct {
+	// Variant must be set for products sold in variants.
+	Variant string `json:"variant"`
+	// Quantity may be negative to write off damaged or lost items.
+	Quantity int    `json:"quantity" validate:"required"`
+	Reason   string `json:"reason"`
+}
+
+type RestockResponse struct {
+	Stock int `json:"stock"`
+}
+
+type PurchaseLimitRequest struct {
+	// Limit of zero removes the cap.
+	Limit int `json:"limit" validate:"gte=0"`
+}
+
+type PriceRequest struct {
+	Price int `json:"price" validate:"required,gt=0"`
+}
+
+type VariantRequest struct {
+	Name       string            `json:"name" validate:"required"`
+	Attributes map[string]string `json:"attributes"`
+	// Stock is unlimited when omitted.
+	Stock *int `json:"stock" validate:"omitempty,gte=0"`
+}
+
+type StockChange struct {
+	Variant   string    `json:"variant,omitempty"`
+	Delta     int       `json:"delta"`
+	Stock     int       `json:"stock"`
+	Reason    string    `json:"reason,omitempty"`
+	ChangedBy string    `json:"changedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (r productRoutes) restock(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.productRoutes.restock"
+
+	admin, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/restock"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	item := c.Params("item")
+
+	r.log.Info("attempting to decode request body")
+	var req RestockRequest
+	if err := c.BodyParser(&req); err != nil {
+		r.log.Error("failed to decode request body",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/restock"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+	r.log.Info("request body decoded")
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+		r.log.Error("invalid request",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/restock"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validation.ValidataionError(validateErr),
+		})
+	}
+
+	stock, err := r.productService.RestockProduct(ctx, service.RestockProductInput{
+		Product:  item,
+		Variant:  req.Variant,
+		Quantity: req.Quantity,
+		Reason:   req.Reason,
+		Admin:    admin,
+	})
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrProductNotFound) {
+			r.log.Error("product not found",
+				zap.String("op", op),
+				zap.String("route", "api/admin/products/restock"),
+				zap.String("item", item),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "product not found",
+			})
+		} else if errors.Is(err, servicerrs.ErrVariantNotFound) {
+			r.log.Error("product variant not found",
+				zap.String("op", op),
+				zap.String("route", "api/admin/products/restock"),
+				zap.String("item", item),
+				zap.String("variant", req.Variant),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "product variant not found",
+			})
+		} else if errors.Is(err, servicerrs.ErrVariantRequired) {
+			r.log.Error("product variant is required",
+				zap.String("op", op),
+				zap.String("route", "api/admin/products/restock"),
+				zap.String("item", item),
+			)
+
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": "product variant is required",
+			})
+		} else if errors.Is(err, servicerrs.ErrNegativeStock) {
+			r.log.Error("stock cannot be negative",
+				zap.String("op", op),
+				zap.String("route", "api/admin/products/restock"),
+				zap.String("item", item),
+			)
+
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": "stock cannot be negative",
+			})
+		}
+
+		r.log.Error("failed to restock product",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/restock"),
+			zap.String("item", item),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.JSON(RestockResponse{
+		Stock: stock,
+	})
+}
+
+func (r productRoutes) setPurchaseLimit(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.productRoutes.setPurchaseLimit"
+
+	item := c.Params("item")
+
+	r.log.Info("attempting to decode request body")
+	var req PurchaseLimitRequest
+	if err := c.BodyParser(&req); err != nil {
+		r.log.Error("failed to decode request body",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/limit"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+	r.log.Info("request body decoded")
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+		r.log.Error("invalid request",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/limit"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validation.ValidataionError(validateErr),
+		})
+	}
+
+	err := r.productService.SetPurchaseLimit(ctx, service.SetPurchaseLimitInput{
+		Product: item,
+		Limit:   req.Limit,
+	})
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrProductNotFound) {
+			r.log.Error("product not found",
+				zap.String("op", op),
+				zap.String("route", "api/admin/products/limit"),
+				zap.String("item", item),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "product not found",
+			})
+		}
+
+		r.log.Error("failed to set purchase limit",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/limit"),
+			zap.String("item", item),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (r productRoutes) setPrice(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.productRoutes.setPrice"
+
+	item := c.Params("item")
+
+	r.log.Info("attempting to decode request body")
+	var req PriceRequest
+	if err := c.BodyParser(&req); err != nil {
+		r.log.Error("failed to decode request body",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/price"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+	r.log.Info("request body decoded")
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+		r.log.Error("invalid request",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/price"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validation.ValidataionError(validateErr),
+		})
+	}
+
+	err := r.productService.SetPrice(ctx, service.SetPriceInput{
+		Product: item,
+		Price:   req.Price,
+	})
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrProductNotFound) {
+			r.log.Error("product not found",
+				zap.String("op", op),
+				zap.String("route", "api/admin/products/price"),
+				zap.String("item", item),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "product not found",
+			})
+		}
+
+		r.log.Error("failed to set product price",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/price"),
+			zap.String("item", item),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (r productRoutes) getStockHistory(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.productRoutes.getStockHistory"
+
+	item := c.Params("item")
+
+	history, err := r.productService.RetrieveStockHistory(ctx, item)
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrProductNotFound) {
+			r.log.Error("product not found",
+				zap.String("op", op),
+				zap.String("route", "api/admin/products/stock-history"),
+				zap.String("item", item),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "product not found",
+			})
+		}
+
+		r.log.Error("failed to get stock history",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/stock-history"),
+			zap.String("item", item),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	response := []StockChange{}
+	for _, change := range history {
+		response = append(response, StockChange{
+			Variant:   change.Variant,
+			Delta:     change.Delta,
+			Stock:     change.Stock,
+			Reason:    change.Reason,
+			ChangedBy: change.ChangedBy,
+			CreatedAt: change.CreatedAt,
+		})
+	}
+
+	return c.JSON(response)
+}
+
+func (r productRoutes) addVariant(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.productRoutes.addVariant"
+
+	item := c.Params("item")
+
+	r.log.Info("attempting to decode request body")
+	var req VariantRequest
+	if err := c.BodyParser(&req); err != nil {
+		r.log.Error("failed to decode request body",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/variants"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+	r.log.Info("request body decoded")
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+		r.log.Error("invalid request",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/variants"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validation.ValidataionError(validateErr),
+		})
+	}
+
+	err := r.productService.AddProductVariant(ctx, service.AddProductVariantInput{
+		Product:    item,
+		Variant:    req.Name,
+		Attributes: req.Attributes,
+		Stock:      req.Stock,
+	})
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrProductNotFound) {
+			r.log.Error("product not found",
+				zap.String("op", op),
+				zap.String("route", "api/admin/products/variants"),
+				zap.String("item", item),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "product not found",
+			})
+		} else if errors.Is(err, servicerrs.ErrVariantAlreadyExists) {
+			r.log.Warn("product variant already exists",
+				zap.String("op", op),
+				zap.String("route", "api/admin/products/variants"),
+				zap.String("item", item),
+				zap.String("variant", req.Name),
+			)
+
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"errors": "product variant already exists",
+			})
+		}
+
+		r.log.Error("failed to add product variant",
+			zap.String("op", op),
+			zap.String("route", "api/admin/products/variants"),
+			zap.String("item", item),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusCreated)
+}
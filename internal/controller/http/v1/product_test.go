@@ -0,0 +1,202 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_restock(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductService := service.NewMockProduct(ctrl)
+
+	tests := []struct {
+		name            string
+		requestBody     map[string]interface{}
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name:        "Successful restock",
+			requestBody: map[string]interface{}{"quantity": 10, "reason": "delivery"},
+			mockServiceFunc: func() {
+				mockProductService.EXPECT().
+					RestockProduct(ctx, service.RestockProductInput{
+						Product:  "powerbank",
+						Quantity: 10,
+						Reason:   "delivery",
+						Admin:    "admin",
+					}).
+					Return(10, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"stock":10}`,
+		},
+		{
+			name:        "Product not found",
+			requestBody: map[string]interface{}{"quantity": 10},
+			mockServiceFunc: func() {
+				mockProductService.EXPECT().
+					RestockProduct(ctx, service.RestockProductInput{
+						Product:  "powerbank",
+						Quantity: 10,
+						Admin:    "admin",
+					}).
+					Return(0, servicerrs.ErrProductNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"errors":"product not found"}`,
+		},
+		{
+			name:        "Variant required",
+			requestBody: map[string]interface{}{"quantity": 10},
+			mockServiceFunc: func() {
+				mockProductService.EXPECT().
+					RestockProduct(ctx, service.RestockProductInput{
+						Product:  "powerbank",
+						Quantity: 10,
+						Admin:    "admin",
+					}).
+					Return(0, servicerrs.ErrVariantRequired)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"errors":"product variant is required"}`,
+		},
+		{
+			name:        "Variant not found",
+			requestBody: map[string]interface{}{"variant": "XXL", "quantity": 10},
+			mockServiceFunc: func() {
+				mockProductService.EXPECT().
+					RestockProduct(ctx, service.RestockProductInput{
+						Product:  "powerbank",
+						Variant:  "XXL",
+						Quantity: 10,
+						Admin:    "admin",
+					}).
+					Return(0, servicerrs.ErrVariantNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"errors":"product variant not found"}`,
+		},
+		{
+			name:        "Negative stock",
+			requestBody: map[string]interface{}{"quantity": -10},
+			mockServiceFunc: func() {
+				mockProductService.EXPECT().
+					RestockProduct(ctx, service.RestockProductInput{
+						Product:  "powerbank",
+						Quantity: -10,
+						Admin:    "admin",
+					}).
+					Return(0, servicerrs.ErrNegativeStock)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"errors":"stock cannot be negative"}`,
+		},
+		{
+			name:        "Internal server error",
+			requestBody: map[string]interface{}{"quantity": 10},
+			mockServiceFunc: func() {
+				mockProductService.EXPECT().
+					RestockProduct(ctx, service.RestockProductInput{
+						Product:  "powerbank",
+						Quantity: 10,
+						Admin:    "admin",
+					}).
+					Return(0, errors.New("internal error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+		{
+			name:            "Invalid request (missing quantity)",
+			requestBody:     map[string]interface{}{"reason": "delivery"},
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusBadRequest,
+			expectedBody:    `{"errors":"Quantity is a required"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := productRoutes{
+				log:            logger,
+				productService: mockProductService,
+			}
+			app.Post("/products/:item/restock", func(c *fiber.Ctx) error {
+				c.Locals("username", "admin")
+				return r.restock(c, ctx)
+			})
+
+			tt.mockServiceFunc()
+
+			reqBody, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/products/powerbank/restock", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+
+			var bodyBytes bytes.Buffer
+			_, _ = bodyBytes.ReadFrom(resp.Body)
+			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	logger := zap.NewNop()
+	middleware := NewAdminMiddleware(logger, []string{"admin"})
+
+	tests := []struct {
+		name         string
+		username     string
+		expectedCode int
+	}{
+		{
+			name:         "Admin allowed",
+			username:     "admin",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Regular user forbidden",
+			username:     "user",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/admin", func(c *fiber.Ctx) error {
+				c.Locals("username", tt.username)
+				return c.Next()
+			}, middleware.Admin(), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type promoRoutes struct {
+	log          *zap.Logger
+	promoService service.Promo
+}
+
+func newPromoRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, promoService service.Promo) {
+	r := promoRoutes{
+		log:          log,
+		promoService: promoService,
+	}
+
+	(*g).Post("/promo-codes", func(c *fiber.Ctx) error {
+		return r.createPromoCode(c, ctx)
+	})
+
+	(*g).Get("/promo-codes", func(c *fiber.Ctx) error {
+		return r.listPromoCodes(c, ctx)
+	})
+
+	(*g).Delete("/promo-codes/:code", func(c *fiber.Ctx) error {
+		return r.deactivatePromoCode(c, ctx)
+	})
+}
+
+type PromoCodeRequest struct {
+	Code           string     `json:"code" validate:"required"`
+	DiscountType   string     `json:"discountType" validate:"required,oneof=percent fixed"`
+	DiscountValue  int        `json:"discountValue" validate:"required,gt=0"`
+	Product        string     `json:"product"`
+	Category       string     `json:"category"`
+	ValidFrom      time.Time  `json:"validFrom"`
+	ValidUntil     *time.Time `json:"validUntil"`
+	MaxUses        *int       `json:"maxUses" validate:"omitempty,gt=0"`
+	MaxUsesPerUser *int       `json:"maxUsesPerUser" validate:"omitempty,gt=0"`
+}
+
+type PromoCode struct {
+	Code           string     `json:"code"`
+	DiscountType   string     `json:"discountType"`
+	DiscountValue  int        `json:"discountValue"`
+	Product        string     `json:"product,omitempty"`
+	Category       string     `json:"category,omitempty"`
+	ValidFrom      time.Time  `json:"validFrom"`
+	ValidUntil     *time.Time `json:"validUntil,omitempty"`
+	MaxUses        *int       `json:"maxUses,omitempty"`
+	MaxUsesPerUser *int       `json:"maxUsesPerUser,omitempty"`
+	Uses           int        `json:"uses"`
+}
+
+func (r promoRoutes) createPromoCode(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.promoRoutes.createPromoCode"
+
+	r.log.Info("attempting to decode request body")
+	var req PromoCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		r.log.Error("failed to decode request body",
+			zap.String("op", op),
+			zap.String("route", "api/admin/promo-codes"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+	r.log.Info("request body decoded")
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+		r.log.Error("invalid request",
+			zap.String("op", op),
+			zap.String("route", "api/admin/promo-codes"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validation.ValidataionError(validateErr),
+		})
+	}
+
+	err := r.promoService.CreatePromoCode(ctx, service.CreatePromoCodeInput{
+		Code:           req.Code,
+		DiscountType:   req.DiscountType,
+		DiscountValue:  req.DiscountValue,
+		Product:        req.Product,
+		Category:       req.Category,
+		ValidFrom:      req.ValidFrom,
+		ValidUntil:     req.ValidUntil,
+		MaxUses:        req.MaxUses,
+		MaxUsesPerUser: req.MaxUsesPerUser,
+	})
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrPromoCodeAlreadyExists) {
+			r.log.Warn("promo code already exists",
+				zap.String("op", op),
+				zap.String("route", "api/admin/promo-codes"),
+				zap.String("code", req.Code),
+			)
+
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"errors": "promo code already exists",
+			})
+		}
+
+		for _, invalidErr := range []error{servicerrs.ErrInvalidDiscount, servicerrs.ErrProductNotFound, servicerrs.ErrCategoryNotFound} {
+			if errors.Is(err, invalidErr) {
+				r.log.Warn("invalid promo code",
+					zap.String("op", op),
+					zap.String("route", "api/admin/promo-codes"),
+					zap.String("code", req.Code),
+					zap.Error(err),
+				)
+
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"errors": invalidErr.Error(),
+				})
+			}
+		}
+
+		r.log.Error("failed to create promo code",
+			zap.String("op", op),
+			zap.String("route", "api/admin/promo-codes"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+func (r promoRoutes) listPromoCodes(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.promoRoutes.listPromoCodes"
+
+	promos, err := r.promoService.ListPromoCodes(ctx)
+	if err != nil {
+		r.log.Error("failed to list promo codes",
+			zap.String("op", op),
+			zap.String("route", "api/admin/promo-codes"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	response := []PromoCode{}
+	for _, promo := range promos {
+		response = append(response, PromoCode{
+			Code:           promo.Code,
+			DiscountType:   promo.DiscountType,
+			DiscountValue:  promo.DiscountValue,
+			Product:        promo.Product,
+			Category:       promo.Category,
+			ValidFrom:      promo.ValidFrom,
+			ValidUntil:     promo.ValidUntil,
+			MaxUses:        promo.MaxUses,
+			MaxUsesPerUser: promo.MaxUsesPerUser,
+			Uses:           promo.Uses,
+		})
+	}
+
+	return c.JSON(response)
+}
+
+func (r promoRoutes) deactivatePromoCode(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.promoRoutes.deactivatePromoCode"
+
+	code := c.Params("code")
+
+	err := r.promoService.DeactivatePromoCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrPromoCodeNotFound) {
+			r.log.Warn("promo code not found",
+				zap.String("op", op),
+				zap.String("route", "api/admin/promo-codes"),
+				zap.String("code", code),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "promo code not found",
+			})
+		}
+
+		r.log.Error("failed to deactivate promo code",
+			zap.String("op", op),
+			zap.String("route", "api/admin/promo-codes"),
+			zap.String("code", code),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
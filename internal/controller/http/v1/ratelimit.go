@@ -0,0 +1,165 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"avito-internship/internal/model"
+	"avito-internship/internal/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Rate limit response headers.
+const (
+	headerRateLimitLimit     = "X-RateLimit-Limit"
+	headerRateLimitRemaining = "X-RateLimit-Remaining"
+	headerRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// RateLimits are the limits of the route groups InitRouter rate limits.
+type RateLimits struct {
+	// Auth covers /api/auth, /api/auth/2fa and /api/register, its user is the
+	// username in the request body. The 2FA codes of a user are limited by the
+	// login lockout instead, as their requests carry a challenge token.
+	Auth     RateLimit
+	SendCoin RateLimit
+	Buy      RateLimit
+}
+
+// RateLimit limits a route group per user and per IP, a zero Limit disables either.
+type RateLimit struct {
+	PerUser ratelimit.Limit
+	PerIP   ratelimit.Limit
+}
+
+type rateLimitBucket struct {
+	key   string
+	limit ratelimit.Limit
+}
+
+type RateLimitMiddleware struct {
+	limiter ratelimit.Limiter
+	log     *zap.Logger
+}
+
+func NewRateLimitMiddleware(log *zap.Logger, limiter ratelimit.Limiter) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		limiter: limiter,
+		log:     log,
+	}
+}
+
+// Limit rate limits the requests of group. It is registered on the routes of
+// the group ahead of their handlers. On protected routes it must be chained
+// after AuthMiddleware.Auth, since the user is taken from the context.
+// Requests pass when the limiter fails, so an outage does not take the routes down.
+func (m *RateLimitMiddleware) Limit(group string, limit RateLimit) fiber.Handler {
+	return m.limit(group, limit, func(c *fiber.Ctx) string {
+		username, _ := c.Locals("username").(string)
+		return username
+	})
+}
+
+// LimitPublic is Limit for routes without a logged in user, which are limited
+// per user by the username in the request body. Requests without one are only
+// limited per IP, the handler rejects them anyway.
+func (m *RateLimitMiddleware) LimitPublic(group string, limit RateLimit) fiber.Handler {
+	return m.limit(group, limit, bodyUsername)
+}
+
+func (m *RateLimitMiddleware) limit(group string, limit RateLimit, user func(c *fiber.Ctx) string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		const op = "middleware.RateLimitMiddleware"
+
+		var buckets []rateLimitBucket
+		if username := user(c); username != "" && limit.PerUser.Enabled() {
+			buckets = append(buckets, rateLimitBucket{group + ":user:" + username, limit.PerUser})
+		}
+		if limit.PerIP.Enabled() {
+			buckets = append(buckets, rateLimitBucket{group + ":ip:" + c.IP(), limit.PerIP})
+		}
+
+		// The reported bucket is the one that rejected the request for the
+		// longest, or the one closest to rejecting it.
+		var (
+			reported ratelimit.Result
+			found    bool
+		)
+		for _, bucket := range buckets {
+			result, err := m.limiter.Allow(c.UserContext(), bucket.key, bucket.limit)
+			if err != nil {
+				m.log.Error("Failed to check rate limit",
+					zap.String("op", op),
+					zap.String("key", bucket.key),
+					zap.Error(err),
+				)
+				continue
+			}
+
+			if !found || binds(result, reported) {
+				reported = result
+				found = true
+			}
+		}
+		if !found {
+			return c.Next()
+		}
+
+		c.Set(headerRateLimitLimit, strconv.Itoa(reported.Limit))
+		c.Set(headerRateLimitRemaining, strconv.Itoa(reported.Remaining))
+		c.Set(headerRateLimitReset, strconv.Itoa(ceilSeconds(reported.Reset)))
+
+		if !reported.Allowed {
+			m.log.Warn("Rate limit exceeded",
+				zap.String("op", op),
+				zap.String("group", group),
+				zap.String("ip", c.IP()),
+			)
+
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(reported.RetryAfter)))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"errors": "too many requests, try again later",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// bodyUsername returns the username of the request body, leaving the body for
+// the handler to parse again. Usernames longer than new accounts may have are
+// hashed, so clients cannot make the limiter store large keys.
+func bodyUsername(c *fiber.Ctx) string {
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return ""
+	}
+
+	if len(req.Username) > model.UsernameMaxLength {
+		sum := sha256.Sum256([]byte(req.Username))
+		return hex.EncodeToString(sum[:])
+	}
+	return req.Username
+}
+
+// binds reports whether result limits the client more than current does.
+func binds(result, current ratelimit.Result) bool {
+	if result.Allowed != current.Allowed {
+		return !result.Allowed
+	}
+	if !result.Allowed {
+		return result.RetryAfter > current.RetryAfter
+	}
+	return result.Remaining < current.Remaining
+}
+
+// ceilSeconds rounds d up to whole seconds, as the headers carry seconds.
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"avito-internship/internal/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	middleware := NewRateLimitMiddleware(zap.NewNop(), ratelimit.NewMemoryLimiter())
+	limits := RateLimits{
+		Auth: RateLimit{
+			PerIP: ratelimit.Limit{Requests: 1, Window: time.Minute},
+		},
+		SendCoin: RateLimit{
+			PerUser: ratelimit.Limit{Requests: 2, Window: time.Minute},
+			PerIP:   ratelimit.Limit{Requests: 10, Window: time.Minute},
+		},
+	}
+	publicLimit := RateLimit{
+		PerUser: ratelimit.Limit{Requests: 1, Window: time.Minute},
+		PerIP:   ratelimit.Limit{Requests: 10, Window: time.Minute},
+	}
+
+	app := fiber.New()
+	app.Post("/auth", middleware.Limit("auth", limits.Auth))
+	app.Post("/auth", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Post("/authors", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	app.Post("/register", middleware.LimitPublic("register", publicLimit))
+	app.Post("/register", func(c *fiber.Ctx) error {
+		var req AuthRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.SendStatus(fiber.StatusBadRequest)
+		}
+		return c.SendString(req.Username)
+	})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("username", c.Get("X-User"))
+		return c.Next()
+	})
+	app.Post("/sendCoin", middleware.Limit("sendCoin", limits.SendCoin))
+	app.Post("/sendCoin", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	send := func(path, user string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("X-User", user)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("Limited per IP", func(t *testing.T) {
+		resp := send("/auth", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "1", resp.Header.Get("X-RateLimit-Limit"))
+		assert.Equal(t, "0", resp.Header.Get("X-RateLimit-Remaining"))
+		assert.Equal(t, "60", resp.Header.Get("X-RateLimit-Reset"))
+
+		resp = send("/auth", "")
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "60", resp.Header.Get(fiber.HeaderRetryAfter))
+
+		// Only the routes of the group are limited.
+		resp = send("/authors", "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("X-RateLimit-Limit"))
+	})
+
+	t.Run("Public routes are limited per username in the body", func(t *testing.T) {
+		register := func(body string) *http.Response {
+			req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			return resp
+		}
+
+		resp := register(`{"username": "alice", "password": "secret"}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "1", resp.Header.Get("X-RateLimit-Limit"))
+
+		resp = register(`{"username": "alice", "password": "other"}`)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+		resp = register(`{"username": "bob", "password": "secret"}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "bob", string(body), "the handler still reads the body")
+
+		// Requests without a username only count against the IP.
+		resp = register(`{"password": "secret"}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "10", resp.Header.Get("X-RateLimit-Limit"))
+
+		long := strings.Repeat("a", 100)
+		resp = register(`{"username": "` + long + `"}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = register(`{"username": "` + long + `"}`)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	})
+
+	t.Run("Limited per user", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			resp := send("/sendCoin", "user1")
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			// The user bucket is closer to the limit than the IP one.
+			assert.Equal(t, "2", resp.Header.Get("X-RateLimit-Limit"))
+		}
+
+		resp := send("/sendCoin", "user1")
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "30", resp.Header.Get(fiber.HeaderRetryAfter))
+
+		resp = send("/sendCoin", "user2")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "1", resp.Header.Get("X-RateLimit-Remaining"))
+	})
+}
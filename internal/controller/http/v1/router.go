@@ -9,16 +9,48 @@ import (
 	"go.uber.org/zap"
 )
 
-func InitRouter(ctx context.Context, log *zap.Logger, app *fiber.App, services *service.Services, middleware fiber.Handler) {
+func InitRouter(ctx context.Context, log *zap.Logger, app *fiber.App, services *service.Services, middleware fiber.Handler, adminMiddleware fiber.Handler, rateLimit *RateLimitMiddleware, limits RateLimits) {
+	// Public keys for token verification by other services
+	root := app.Group("")
+	newJWKSRoutes(log, &root, services.Auth)
+
 	v1 := app.Group("api")
 
 	// Public routes
-	newAuthRoutes(ctx, log, &v1, services.Auth)
+	authLimit := rateLimit.LimitPublic("auth", limits.Auth)
+	v1.Post("/auth", authLimit)
+	v1.Post("/auth/2fa", authLimit)
+	v1.Post("/register", authLimit)
+	newAuthRoutes(ctx, log, &v1, services.Auth, services.LoginAttempts)
+	newTwoFactorLoginRoutes(ctx, log, &v1, services.TwoFactor, services.LoginAttempts)
+	if services.OIDC != nil {
+		newOIDCRoutes(ctx, log, &v1, services.OIDC)
+	}
 
 	// Protected with auth middleware
 	protected := v1.Group("")
 	protected.Use(middleware)
+	protected.Post("/sendCoin", rateLimit.Limit("sendCoin", limits.SendCoin))
+	protected.Get("/buy/:item", rateLimit.Limit("buy", limits.Buy))
 
 	newUserRoutes(ctx, log, &protected, services.User)
+	newPasswordRoutes(ctx, log, &protected, services.Auth)
+	newSessionRoutes(ctx, log, &protected, services.Session)
+	newTwoFactorRoutes(ctx, log, &protected, services.TwoFactor)
+	newAPIKeyRoutes(ctx, log, &protected, services.APIKey)
 	newOperationRoutes(ctx, log, &protected, services.Operation)
+	newCatalogRoutes(ctx, log, &protected, services.Product)
+	newOrderRoutes(ctx, log, &protected, services.Order)
+	newWishlistRoutes(ctx, log, &protected, services.Wishlist)
+
+	// Protected with auth and admin middlewares
+	admin := protected.Group("/admin")
+	admin.Use(adminMiddleware)
+
+	newProductRoutes(ctx, log, &admin, services.Product)
+	newPromoRoutes(ctx, log, &admin, services.Promo)
+	newAdminOrderRoutes(ctx, log, &admin, services.Order)
+	newLockoutRoutes(ctx, log, &admin, services.LoginAttempts)
+	newImpersonationRoutes(ctx, log, &admin, services.Impersonation)
+	newCacheRoutes(ctx, log, &admin, services.CacheAdmin)
 }
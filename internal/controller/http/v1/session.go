@@ -0,0 +1,202 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// revokeOtherSessions is the session id that revokes every session except the current one.
+const revokeOtherSessions = "others"
+
+type sessionRoutes struct {
+	log            *zap.Logger
+	sessionService service.Session
+}
+
+func newSessionRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, sessionService service.Session) {
+	r := sessionRoutes{
+		log:            log,
+		sessionService: sessionService,
+	}
+
+	(*g).Get("/sessions", func(c *fiber.Ctx) error {
+		return r.listSessions(c, ctx)
+	})
+
+	(*g).Delete("/sessions/:id", func(c *fiber.Ctx) error {
+		return r.revokeSession(c, ctx)
+	})
+}
+
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	// Current marks the session of the token the request is made with.
+	Current bool `json:"current"`
+}
+
+type RevokeSessionsResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+func (r sessionRoutes) listSessions(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.sessionRoutes.listSessions"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/sessions"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+	currentID, _ := c.Locals("sessionID").(string)
+
+	sessions, err := r.sessionService.List(ctx, username)
+	if err != nil {
+		r.log.Error("failed to list sessions",
+			zap.String("op", op),
+			zap.String("route", "api/sessions"),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	response := []Session{}
+	for _, session := range sessions {
+		response = append(response, newSessionResponse(session, currentID))
+	}
+
+	return c.JSON(response)
+}
+
+// revokeSession revokes the session with the given id, or every other session
+// of the user when the id is "others".
+func (r sessionRoutes) revokeSession(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.sessionRoutes.revokeSession"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/sessions"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	id := c.Params("id")
+	if id == revokeOtherSessions {
+		currentID, _ := c.Locals("sessionID").(string)
+		if currentID == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": "current session is unknown",
+			})
+		}
+
+		revoked, err := r.sessionService.RevokeOthers(ctx, service.RevokeSessionInput{
+			Username: username,
+			ID:       currentID,
+		})
+		if err != nil {
+			r.log.Error("failed to revoke sessions",
+				zap.String("op", op),
+				zap.String("route", "api/sessions"),
+				zap.String("username", username),
+				zap.Error(err),
+			)
+
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"errors": "internal error",
+			})
+		}
+
+		return c.JSON(RevokeSessionsResponse{Revoked: revoked})
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		r.log.Warn("invalid session id",
+			zap.String("op", op),
+			zap.String("route", "api/sessions"),
+			zap.String("id", id),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid session id",
+		})
+	}
+
+	err := r.sessionService.Revoke(ctx, service.RevokeSessionInput{
+		Username: username,
+		ID:       id,
+	})
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrSessionNotFound) {
+			r.log.Warn("session not found",
+				zap.String("op", op),
+				zap.String("route", "api/sessions"),
+				zap.String("id", id),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "session not found",
+			})
+		}
+
+		r.log.Error("failed to revoke session",
+			zap.String("op", op),
+			zap.String("route", "api/sessions"),
+			zap.String("id", id),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// withClient attaches the requesting device to ctx, so sessions started by the
+// handler record it.
+func withClient(ctx context.Context, c *fiber.Ctx) context.Context {
+	return service.WithClient(ctx, service.Client{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IP:        c.IP(),
+	})
+}
+
+func newSessionResponse(session entity.Session, currentID string) Session {
+	return Session{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		ExpiresAt:  session.ExpiresAt,
+		Current:    session.ID == currentID,
+	}
+}
@@ -0,0 +1,180 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+const (
+	testSessionID      = "7f9c2e1a-4b3d-4c8e-9a6f-0d1e2f3a4b5c"
+	testOtherSessionID = "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+)
+
+func Test_listSessions(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionService := service.NewMockSession(ctrl)
+	at := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name: "Sessions listed",
+			mockServiceFunc: func() {
+				mockSessionService.EXPECT().List(ctx, "user").Return([]entity.Session{
+					{ID: testSessionID, UserAgent: "curl/8.5.0", IP: "10.0.0.1", CreatedAt: at, LastSeenAt: at, ExpiresAt: at},
+					{ID: testOtherSessionID, CreatedAt: at, LastSeenAt: at, ExpiresAt: at},
+				}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"id":"` + testSessionID + `","userAgent":"curl/8.5.0","ip":"10.0.0.1","createdAt":"2025-02-01T12:00:00Z","lastSeenAt":"2025-02-01T12:00:00Z","expiresAt":"2025-02-01T12:00:00Z","current":true},` +
+				`{"id":"` + testOtherSessionID + `","userAgent":"","ip":"","createdAt":"2025-02-01T12:00:00Z","lastSeenAt":"2025-02-01T12:00:00Z","expiresAt":"2025-02-01T12:00:00Z","current":false}]`,
+		},
+		{
+			name: "No sessions",
+			mockServiceFunc: func() {
+				mockSessionService.EXPECT().List(ctx, "user").Return(nil, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `[]`,
+		},
+		{
+			name: "Internal error",
+			mockServiceFunc: func() {
+				mockSessionService.EXPECT().List(ctx, "user").Return(nil, errors.New("internal error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := sessionRoutes{
+				log:            logger,
+				sessionService: mockSessionService,
+			}
+			app.Get("/sessions", func(c *fiber.Ctx) error {
+				c.Locals("username", "user")
+				c.Locals("sessionID", testSessionID)
+				return r.listSessions(c, ctx)
+			})
+
+			tt.mockServiceFunc()
+
+			req := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.JSONEq(t, tt.expectedBody, string(body))
+		})
+	}
+}
+
+func Test_revokeSession(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSessionService := service.NewMockSession(ctrl)
+
+	tests := []struct {
+		name            string
+		id              string
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name: "Session revoked",
+			id:   testOtherSessionID,
+			mockServiceFunc: func() {
+				mockSessionService.EXPECT().Revoke(ctx, service.RevokeSessionInput{Username: "user", ID: testOtherSessionID}).Return(nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `OK`,
+		},
+		{
+			name: "Session not found",
+			id:   testOtherSessionID,
+			mockServiceFunc: func() {
+				mockSessionService.EXPECT().Revoke(ctx, gomock.Any()).Return(servicerrs.ErrSessionNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"errors":"session not found"}`,
+		},
+		{
+			name: "Other sessions revoked",
+			id:   "others",
+			mockServiceFunc: func() {
+				mockSessionService.EXPECT().RevokeOthers(ctx, service.RevokeSessionInput{Username: "user", ID: testSessionID}).Return(2, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"revoked":2}`,
+		},
+		{
+			name:            "Invalid id",
+			id:              "abc",
+			mockServiceFunc: func() {},
+			expectedCode:    http.StatusBadRequest,
+			expectedBody:    `{"errors":"invalid session id"}`,
+		},
+		{
+			name: "Internal error",
+			id:   testOtherSessionID,
+			mockServiceFunc: func() {
+				mockSessionService.EXPECT().Revoke(ctx, gomock.Any()).Return(errors.New("internal error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := sessionRoutes{
+				log:            logger,
+				sessionService: mockSessionService,
+			}
+			app.Delete("/sessions/:id", func(c *fiber.Ctx) error {
+				c.Locals("username", "user")
+				c.Locals("sessionID", testSessionID)
+				return r.revokeSession(c, ctx)
+			})
+
+			tt.mockServiceFunc()
+
+			req := httptest.NewRequest(http.MethodDelete, "/sessions/"+tt.id, nil)
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.expectedBody, string(body))
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package v1
+
+import (
+	"context"
+	"errors"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type twoFactorRoutes struct {
+	log              *zap.Logger
+	twoFactorService service.TwoFactor
+	loginAttempts    service.LoginAttempts
+}
+
+// newTwoFactorLoginRoutes registers the public second step of the login.
+func newTwoFactorLoginRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, twoFactorService service.TwoFactor, loginAttempts service.LoginAttempts) {
+	r := twoFactorRoutes{
+		log:              log,
+		twoFactorService: twoFactorService,
+		loginAttempts:    loginAttempts,
+	}
+
+	(*g).Post("/auth/2fa", func(c *fiber.Ctx) error {
+		return r.completeLogin(c, ctx)
+	})
+}
+
+// newTwoFactorRoutes registers the enrollment routes for authenticated users.
+func newTwoFactorRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, twoFactorService service.TwoFactor) {
+	r := twoFactorRoutes{
+		log:              log,
+		twoFactorService: twoFactorService,
+	}
+
+	(*g).Post("/2fa/enroll", func(c *fiber.Ctx) error {
+		return r.enroll(c, ctx)
+	})
+
+	(*g).Post("/2fa/confirm", func(c *fiber.Ctx) error {
+		return r.confirm(c, ctx)
+	})
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type TwoFactorConfirmRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorConfirmResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	// Code is a TOTP code or one of the recovery codes.
+	Code string `json:"code" validate:"required"`
+}
+
+func (r twoFactorRoutes) enroll(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.twoFactorRoutes.enroll"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/2fa/enroll"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	enrollment, err := r.twoFactorService.Enroll(ctx, username)
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrTwoFactorAlreadyEnabled) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"errors": "two-factor authentication is already enabled",
+			})
+		}
+
+		r.log.Error("failed to enroll in 2FA",
+			zap.String("op", op),
+			zap.String("route", "api/2fa/enroll"),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.JSON(TwoFactorEnrollResponse{
+		Secret: enrollment.Secret,
+		URI:    enrollment.URI,
+	})
+}
+
+func (r twoFactorRoutes) confirm(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.twoFactorRoutes.confirm"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/2fa/confirm"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	var req TwoFactorConfirmRequest
+	if err := c.BodyParser(&req); err != nil {
+		r.log.Error("failed to decode request body",
+			zap.String("op", op),
+			zap.String("route", "api/2fa/confirm"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+		r.log.Error("invalid request",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validation.ValidataionError(validateErr),
+		})
+	}
+
+	codes, err := r.twoFactorService.Confirm(ctx, service.TwoFactorCodeInput{
+		Username: username,
+		Code:     req.Code,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, servicerrs.ErrTwoFactorAlreadyEnabled):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"errors": "two-factor authentication is already enabled",
+			})
+		case errors.Is(err, servicerrs.ErrTwoFactorNotEnrolled):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": "two-factor authentication is not enrolled, call /api/2fa/enroll first",
+			})
+		case errors.Is(err, servicerrs.ErrInvalidTwoFactorCode):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": "invalid code",
+			})
+		}
+
+		r.log.Error("failed to confirm 2FA",
+			zap.String("op", op),
+			zap.String("route", "api/2fa/confirm"),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.JSON(TwoFactorConfirmResponse{
+		RecoveryCodes: codes,
+	})
+}
+
+func (r twoFactorRoutes) completeLogin(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.twoFactorRoutes.completeLogin"
+
+	var req TwoFactorLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		r.log.Error("failed to decode request body",
+			zap.String("op", op),
+			zap.String("route", "api/auth/2fa"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		errors.As(err, &validateErr)
+		r.log.Error("invalid request",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": validation.ValidataionError(validateErr),
+		})
+	}
+
+	// Codes are throttled per username as well as per IP, so new challenge
+	// tokens do not give an attacker knowing the password more guesses.
+	username, err := r.twoFactorService.VerifyChallenge(ctx, req.ChallengeToken)
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrInvalidToken) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"errors": "invalid or expired challenge token",
+			})
+		}
+
+		r.log.Error("failed to verify challenge token",
+			zap.String("op", op),
+			zap.String("route", "api/auth/2fa"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	if retryAfter := r.loginAttempts.Attempt(ctx, username, c.IP()); retryAfter > 0 {
+		return tooManyAttempts(c, retryAfter)
+	}
+
+	token, err := r.twoFactorService.CompleteLogin(withClient(ctx, c), service.CompleteLoginInput{
+		ChallengeToken: req.ChallengeToken,
+		Code:           req.Code,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, servicerrs.ErrInvalidToken):
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"errors": "invalid or expired challenge token",
+			})
+		case errors.Is(err, servicerrs.ErrInvalidTwoFactorCode):
+			r.loginAttempts.RegisterFailure(ctx, username, c.IP())
+
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"errors": "invalid code",
+			})
+		}
+
+		r.log.Error("failed to complete 2FA login",
+			zap.String("op", op),
+			zap.String("route", "api/auth/2fa"),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	r.loginAttempts.RegisterSuccess(ctx, username, c.IP())
+
+	return c.JSON(AuthResponse{
+		Token: token,
+	})
+}
@@ -0,0 +1,180 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_confirmTwoFactor(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTwoFactorService := service.NewMockTwoFactor(ctrl)
+	input := service.TwoFactorCodeInput{Username: "user", Code: "123456"}
+
+	tests := []struct {
+		name            string
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name: "2FA enabled",
+			mockServiceFunc: func() {
+				mockTwoFactorService.EXPECT().Confirm(ctx, input).Return([]string{"abcde-fghij"}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"recoveryCodes":["abcde-fghij"]}`,
+		},
+		{
+			name: "Invalid code",
+			mockServiceFunc: func() {
+				mockTwoFactorService.EXPECT().Confirm(ctx, input).Return(nil, servicerrs.ErrInvalidTwoFactorCode)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"errors":"invalid code"}`,
+		},
+		{
+			name: "Already enabled",
+			mockServiceFunc: func() {
+				mockTwoFactorService.EXPECT().Confirm(ctx, input).Return(nil, servicerrs.ErrTwoFactorAlreadyEnabled)
+			},
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"errors":"two-factor authentication is already enabled"}`,
+		},
+		{
+			name: "Internal server error",
+			mockServiceFunc: func() {
+				mockTwoFactorService.EXPECT().Confirm(ctx, input).Return(nil, errors.New("internal error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := twoFactorRoutes{
+				log:              logger,
+				twoFactorService: mockTwoFactorService,
+			}
+			app.Post("/2fa/confirm", func(c *fiber.Ctx) error {
+				c.Locals("username", "user")
+				return r.confirm(c, ctx)
+			})
+
+			tt.mockServiceFunc()
+
+			reqBody, _ := json.Marshal(map[string]string{"code": "123456"})
+			req := httptest.NewRequest(http.MethodPost, "/2fa/confirm", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+
+			var bodyBytes bytes.Buffer
+			_, _ = bodyBytes.ReadFrom(resp.Body)
+			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
+		})
+	}
+}
+
+func Test_completeTwoFactorLogin(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTwoFactorService := service.NewMockTwoFactor(ctrl)
+	mockLoginAttempts := service.NewMockLoginAttempts(ctrl)
+	input := service.CompleteLoginInput{ChallengeToken: "challenge-token", Code: "123456"}
+
+	tests := []struct {
+		name            string
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name: "Access token issued",
+			mockServiceFunc: func() {
+				mockTwoFactorService.EXPECT().VerifyChallenge(ctx, "challenge-token").Return("user1", nil)
+				mockLoginAttempts.EXPECT().Attempt(ctx, "user1", gomock.Any()).Return(time.Duration(0))
+				mockTwoFactorService.EXPECT().CompleteLogin(gomock.Any(), input).Return("valid-token", nil)
+				mockLoginAttempts.EXPECT().RegisterSuccess(ctx, "user1", gomock.Any())
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"token":"valid-token"}`,
+		},
+		{
+			name: "Invalid code",
+			mockServiceFunc: func() {
+				mockTwoFactorService.EXPECT().VerifyChallenge(ctx, "challenge-token").Return("user1", nil)
+				mockLoginAttempts.EXPECT().Attempt(ctx, "user1", gomock.Any()).Return(time.Duration(0))
+				mockTwoFactorService.EXPECT().CompleteLogin(gomock.Any(), input).Return("", servicerrs.ErrInvalidTwoFactorCode)
+				mockLoginAttempts.EXPECT().RegisterFailure(ctx, "user1", gomock.Any()).Return(time.Duration(0))
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"errors":"invalid code"}`,
+		},
+		{
+			name: "Expired challenge",
+			mockServiceFunc: func() {
+				mockTwoFactorService.EXPECT().VerifyChallenge(ctx, "challenge-token").Return("", servicerrs.ErrInvalidToken)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: `{"errors":"invalid or expired challenge token"}`,
+		},
+		{
+			name: "Too many attempts",
+			mockServiceFunc: func() {
+				mockTwoFactorService.EXPECT().VerifyChallenge(ctx, "challenge-token").Return("user1", nil)
+				mockLoginAttempts.EXPECT().Attempt(ctx, "user1", gomock.Any()).Return(time.Minute)
+			},
+			expectedCode: http.StatusTooManyRequests,
+			expectedBody: `{"errors":"too many login attempts, try again later"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := twoFactorRoutes{
+				log:              logger,
+				twoFactorService: mockTwoFactorService,
+				loginAttempts:    mockLoginAttempts,
+			}
+			app.Post("/auth/2fa", func(c *fiber.Ctx) error { return r.completeLogin(c, ctx) })
+
+			tt.mockServiceFunc()
+
+			reqBody, _ := json.Marshal(map[string]string{"challengeToken": "challenge-token", "code": "123456"})
+			req := httptest.NewRequest(http.MethodPost, "/auth/2fa", bytes.NewReader(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+
+			var bodyBytes bytes.Buffer
+			_, _ = bodyBytes.ReadFrom(resp.Body)
+			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
+		})
+	}
+}
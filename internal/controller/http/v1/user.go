@@ -3,6 +3,7 @@ package v1
 import (
 	"context"
 	"errors"
+	"time"
 
 	"avito-internship/internal/service"
 	"avito-internship/internal/service/servicerrs"
@@ -44,15 +45,25 @@ type CoinHistory struct {
 }
 
 type CoinTransferIn struct {
-	User   string `json:"fromUser,omitempty"`
-	Amount int    `json:"amount,omitempty"`
+	User           string     `json:"fromUser,omitempty"`
+	Amount         int        `json:"amount,omitempty"`
+	Count          int        `json:"count,omitempty"`
+	LastTransferAt *time.Time `json:"lastTransferAt,omitempty"`
 }
 
 type CoinTransferOut struct {
-	User   string `json:"toUser,omitempty"`
-	Amount int    `json:"amount,omitempty"`
+	User           string     `json:"toUser,omitempty"`
+	Amount         int        `json:"amount,omitempty"`
+	Count          int        `json:"count,omitempty"`
+	LastTransferAt *time.Time `json:"lastTransferAt,omitempty"`
 }
 
+// Values of the history query parameter of /api/info.
+const (
+	historyAggregated = "aggregated"
+	historyRaw        = "raw"
+)
+
 func (r *UserRoutes) getInfo(c *fiber.Ctx, ctx context.Context) error {
 	const op = "v1.userRoutes.getInfo"
 
@@ -71,10 +82,18 @@ func (r *UserRoutes) getInfo(c *fiber.Ctx, ctx context.Context) error {
 
 	r.log.Info("username successfully extracted")
 
+	history := c.Query("history", historyAggregated)
+	if history != historyAggregated && history != historyRaw {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "history must be aggregated or raw",
+		})
+	}
+
 	info, err := r.userService.RetrieveUserInfo(
 		ctx,
 		service.RetrieveUserInfoInput{
-			Username: username,
+			Username:   username,
+			RawHistory: history == historyRaw,
 		})
 	if err != nil {
 		if errors.Is(err, servicerrs.ErrUserNotFound) {
@@ -113,8 +132,10 @@ func (r *UserRoutes) getInfo(c *fiber.Ctx, ctx context.Context) error {
 	var transfersIn []CoinTransferIn
 	for _, item := range info.TransferIn {
 		transferIn := CoinTransferIn{
-			User:   item.Username,
-			Amount: item.Amount,
+			User:           item.Username,
+			Amount:         item.Amount,
+			Count:          item.Count,
+			LastTransferAt: item.LastTransferAt,
 		}
 		transfersIn = append(transfersIn, transferIn)
 	}
@@ -122,8 +143,10 @@ func (r *UserRoutes) getInfo(c *fiber.Ctx, ctx context.Context) error {
 	var transfersOut []CoinTransferOut
 	for _, item := range info.TransferOut {
 		transferOut := CoinTransferOut{
-			User:   item.Username,
-			Amount: item.Amount,
+			User:           item.Username,
+			Amount:         item.Amount,
+			Count:          item.Count,
+			LastTransferAt: item.LastTransferAt,
 		}
 		transfersOut = append(transfersOut, transferOut)
 	}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"avito-internship/internal/entity"
 	"avito-internship/internal/service"
@@ -25,10 +26,12 @@ func TestGetInfo(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockUserService := service.NewMockUser(ctrl)
+	lastTransferAt := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
 
 	tests := []struct {
 		name         string
 		username     string
+		query        string
 		mockUserFunc func()
 		expectedCode int
 		expectedBody string
@@ -40,12 +43,36 @@ func TestGetInfo(t *testing.T) {
 				mockUserService.EXPECT().RetrieveUserInfo(ctx, service.RetrieveUserInfoInput{Username: "user1"}).Return(service.RetrieveUserInfoOutput{
 					Balance:     100,
 					Inventory:   []entity.Inventory{{Product: "item1", Quantity: 2}},
-					TransferIn:  []entity.Transfer{{Username: "user2", Amount: 50}},
-					TransferOut: []entity.Transfer{{Username: "user3", Amount: 30}},
+					TransferIn:  []entity.Transfer{{Username: "user2", Amount: 50, Count: 2, LastTransferAt: &lastTransferAt}},
+					TransferOut: []entity.Transfer{{Username: "user3", Amount: 30, Count: 1, LastTransferAt: &lastTransferAt}},
 				}, nil)
 			},
 			expectedCode: http.StatusOK,
-			expectedBody: `{"coins":100`,
+			expectedBody: `"coinHistory":{"received":[{"fromUser":"user2","amount":50,"count":2,"lastTransferAt":"2025-02-01T12:00:00Z"}],"sent":[{"toUser":"user3","amount":30,"count":1,"lastTransferAt":"2025-02-01T12:00:00Z"}]}`,
+		},
+		{
+			name:     "Raw history",
+			username: "user1",
+			query:    "?history=raw",
+			mockUserFunc: func() {
+				mockUserService.EXPECT().RetrieveUserInfo(ctx, service.RetrieveUserInfoInput{Username: "user1", RawHistory: true}).Return(service.RetrieveUserInfoOutput{
+					Balance: 100,
+					TransferIn: []entity.Transfer{
+						{Username: "user2", Amount: 20, Count: 1, LastTransferAt: &lastTransferAt},
+						{Username: "user2", Amount: 30, Count: 1, LastTransferAt: &lastTransferAt},
+					},
+				}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `"received":[{"fromUser":"user2","amount":20,"count":1,"lastTransferAt":"2025-02-01T12:00:00Z"},{"fromUser":"user2","amount":30,"count":1,"lastTransferAt":"2025-02-01T12:00:00Z"}]`,
+		},
+		{
+			name:         "Unknown history",
+			username:     "user1",
+			query:        "?history=daily",
+			mockUserFunc: func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"errors":"history must be aggregated or raw"}`,
 		},
 		{
 			name:     "User not found",
@@ -78,7 +105,7 @@ func TestGetInfo(t *testing.T) {
 
 			tt.mockUserFunc()
 
-			req := httptest.NewRequest(http.MethodGet, "/info", nil)
+			req := httptest.NewRequest(http.MethodGet, "/info"+tt.query, nil)
 			resp, _ := app.Test(req)
 
 			assert.Equal(t, tt.expectedCode, resp.StatusCode)
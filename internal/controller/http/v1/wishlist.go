@@ -0,0 +1,278 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type wishlistRoutes struct {
+	log             *zap.Logger
+	wishlistService service.Wishlist
+}
+
+func newWishlistRoutes(ctx context.Context, log *zap.Logger, g *fiber.Router, wishlistService service.Wishlist) {
+	r := wishlistRoutes{
+		log:             log,
+		wishlistService: wishlistService,
+	}
+
+	(*g).Get("/wishlist", func(c *fiber.Ctx) error {
+		return r.getWishlist(c, ctx)
+	})
+
+	(*g).Post("/wishlist/:item", func(c *fiber.Ctx) error {
+		return r.addToWishlist(c, ctx)
+	})
+
+	(*g).Delete("/wishlist/:item", func(c *fiber.Ctx) error {
+		return r.removeFromWishlist(c, ctx)
+	})
+
+	(*g).Get("/notifications", func(c *fiber.Ctx) error {
+		return r.getNotifications(c, ctx)
+	})
+
+	(*g).Post("/notifications/read", func(c *fiber.Ctx) error {
+		return r.markNotificationsRead(c, ctx)
+	})
+}
+
+type WishlistItem struct {
+	Product   string    `json:"product"`
+	Price     int       `json:"price"`
+	Available bool      `json:"available"`
+	AddedAt   time.Time `json:"addedAt"`
+}
+
+type Notification struct {
+	ID        int       `json:"id"`
+	Product   string    `json:"product"`
+	Type      string    `json:"type"`
+	Price     int       `json:"price"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (r wishlistRoutes) getWishlist(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.wishlistRoutes.getWishlist"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/wishlist"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	wishlist, err := r.wishlistService.ListWishlist(ctx, username)
+	if err != nil {
+		r.log.Error("failed to list wishlist",
+			zap.String("op", op),
+			zap.String("route", "api/wishlist"),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	response := []WishlistItem{}
+	for _, item := range wishlist {
+		response = append(response, WishlistItem{
+			Product:   item.Product,
+			Price:     item.Price,
+			Available: item.Available,
+			AddedAt:   item.AddedAt,
+		})
+	}
+
+	return c.JSON(response)
+}
+
+func (r wishlistRoutes) addToWishlist(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.wishlistRoutes.addToWishlist"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/wishlist"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	item := c.Params("item")
+
+	err := r.wishlistService.AddToWishlist(ctx, service.WishlistInput{
+		Username: username,
+		Product:  item,
+	})
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrProductNotFound) {
+			r.log.Warn("product not found",
+				zap.String("op", op),
+				zap.String("route", "api/wishlist"),
+				zap.String("item", item),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "product not found",
+			})
+		}
+
+		r.log.Error("failed to add product to wishlist",
+			zap.String("op", op),
+			zap.String("route", "api/wishlist"),
+			zap.String("username", username),
+			zap.String("item", item),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (r wishlistRoutes) removeFromWishlist(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.wishlistRoutes.removeFromWishlist"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/wishlist"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	item := c.Params("item")
+
+	err := r.wishlistService.RemoveFromWishlist(ctx, service.WishlistInput{
+		Username: username,
+		Product:  item,
+	})
+	if err != nil {
+		if errors.Is(err, servicerrs.ErrWishlistItemNotFound) {
+			r.log.Warn("product is not in the wishlist",
+				zap.String("op", op),
+				zap.String("route", "api/wishlist"),
+				zap.String("item", item),
+			)
+
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"errors": "product is not in the wishlist",
+			})
+		}
+
+		r.log.Error("failed to remove product from wishlist",
+			zap.String("op", op),
+			zap.String("route", "api/wishlist"),
+			zap.String("username", username),
+			zap.String("item", item),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (r wishlistRoutes) getNotifications(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.wishlistRoutes.getNotifications"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/notifications"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	notifications, err := r.wishlistService.ListNotifications(ctx, username)
+	if err != nil {
+		r.log.Error("failed to list notifications",
+			zap.String("op", op),
+			zap.String("route", "api/notifications"),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	response := []Notification{}
+	for _, notification := range notifications {
+		response = append(response, Notification{
+			ID:        notification.ID,
+			Product:   notification.Product,
+			Type:      notification.Type,
+			Price:     notification.Price,
+			Read:      notification.Read,
+			CreatedAt: notification.CreatedAt,
+		})
+	}
+
+	return c.JSON(response)
+}
+
+func (r wishlistRoutes) markNotificationsRead(c *fiber.Ctx, ctx context.Context) error {
+	const op = "v1.wishlistRoutes.markNotificationsRead"
+
+	username, ok := c.Locals("username").(string)
+	if !ok {
+		r.log.Error("failed to extract username from context",
+			zap.String("op", op),
+			zap.String("route", "api/notifications/read"),
+		)
+
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"errors": "invalid request body",
+		})
+	}
+
+	if err := r.wishlistService.MarkNotificationsRead(ctx, username); err != nil {
+		r.log.Error("failed to mark notifications as read",
+			zap.String("op", op),
+			zap.String("route", "api/notifications/read"),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"errors": "internal error",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"avito-internship/internal/service"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func Test_addToWishlist(t *testing.T) {
+	logger := zap.NewNop()
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWishlistService := service.NewMockWishlist(ctrl)
+
+	tests := []struct {
+		name            string
+		item            string
+		mockServiceFunc func()
+		expectedCode    int
+		expectedBody    string
+	}{
+		{
+			name: "Product added",
+			item: "hoody",
+			mockServiceFunc: func() {
+				mockWishlistService.EXPECT().
+					AddToWishlist(ctx, service.WishlistInput{Username: "user", Product: "hoody"}).
+					Return(nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Product not found",
+			item: "unknown",
+			mockServiceFunc: func() {
+				mockWishlistService.EXPECT().
+					AddToWishlist(ctx, service.WishlistInput{Username: "user", Product: "unknown"}).
+					Return(servicerrs.ErrProductNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"errors":"product not found"}`,
+		},
+		{
+			name: "Internal server error",
+			item: "hoody",
+			mockServiceFunc: func() {
+				mockWishlistService.EXPECT().
+					AddToWishlist(ctx, service.WishlistInput{Username: "user", Product: "hoody"}).
+					Return(errors.New("internal error"))
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `{"errors":"internal error"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			r := wishlistRoutes{
+				log:             logger,
+				wishlistService: mockWishlistService,
+			}
+			app.Post("/wishlist/:item", func(c *fiber.Ctx) error {
+				c.Locals("username", "user")
+				return r.addToWishlist(c, ctx)
+			})
+
+			tt.mockServiceFunc()
+
+			req := httptest.NewRequest(http.MethodPost, "/wishlist/"+tt.item, nil)
+			resp, _ := app.Test(req)
+
+			assert.Equal(t, tt.expectedCode, resp.StatusCode)
+
+			var bodyBytes bytes.Buffer
+			_, _ = bodyBytes.ReadFrom(resp.Body)
+			assert.Contains(t, bodyBytes.String(), tt.expectedBody)
+		})
+	}
+}
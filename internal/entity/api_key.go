@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// Scopes that can be granted to an API key.
+const (
+	ScopeInfoRead  = "info:read"
+	ScopeCoinsSend = "coins:send"
+)
+
+var APIKeyScopes = []string{ScopeInfoRead, ScopeCoinsSend}
+
+// APIKey is a long-lived credential acting on behalf of its owner within its scopes.
+// Only the prefix and the hash of the key are stored.
+type APIKey struct {
+	ID         int
+	Username   string
+	Name       string
+	Prefix     string
+	Hash       []byte
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
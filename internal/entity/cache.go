@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+// CacheInvalidation is a cache key deletion waiting to be replayed.
+type CacheInvalidation struct {
+	ID        int64
+	Key       string
+	CreatedAt time.Time
+}
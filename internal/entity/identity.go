@@ -0,0 +1,9 @@
+package entity
+
+// Identity links a user to an account at an external identity provider.
+type Identity struct {
+	Username string
+	Issuer   string
+	Subject  string
+	Email    string
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// Actions recorded in the impersonation audit log.
+const (
+	ImpersonationStarted = "started"
+	ImpersonationRequest = "request"
+	ImpersonationDenied  = "denied"
+)
+
+// ImpersonationEvent is an entry of the impersonation audit log.
+type ImpersonationEvent struct {
+	Actor   string
+	Subject string
+	Action  string
+	// Method and Path are set for requests made with an impersonation token.
+	Method    string
+	Path      string
+	Reason    string
+	CreatedAt time.Time
+}
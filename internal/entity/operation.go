@@ -1,7 +0,0 @@
-package entity
-
-type Operation struct {
-	User         string
-	Counterparty string
-	Amount       int
-}
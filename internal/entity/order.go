@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+type Order struct {
+	ID        int
+	Username  string
+	Product   string
+	Variant   string
+	Amount    int
+	Status    string
+	Location  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
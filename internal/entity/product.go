@@ -0,0 +1,26 @@
+package entity
+
+type Product struct {
+	Name        string
+	Description string
+	Price       int
+	Category    string
+	Attributes  map[string]string
+	Stock       *int
+	Available   bool
+	Variants    []ProductVariant
+}
+
+type ProductVariant struct {
+	Name       string
+	Attributes map[string]string
+	Stock      *int
+}
+
+type ProductFilter struct {
+	Query     string
+	Category  string
+	MinPrice  *int
+	MaxPrice  *int
+	Available bool
+}
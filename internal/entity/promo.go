@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+type PromoCode struct {
+	Code           string
+	DiscountType   string
+	DiscountValue  int
+	Product        string
+	Category       string
+	ValidFrom      time.Time
+	ValidUntil     *time.Time
+	MaxUses        *int
+	MaxUsesPerUser *int
+	Uses           int
+}
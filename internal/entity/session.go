@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// Session is a login of a user on a device. Every access token belongs to one.
+type Session struct {
+	ID         string
+	Username   string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	RevokedAt  *time.Time
+}
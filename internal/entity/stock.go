@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+type StockChange struct {
+	Variant   string
+	Delta     int
+	Stock     int
+	Reason    string
+	ChangedBy string
+	CreatedAt time.Time
+}
@@ -1,6 +1,14 @@
 package entity
 
+import "time"
+
+// Transfer is either the total of the transfers between a user and a
+// counterparty, or a single transfer, whose Count is 1.
 type Transfer struct {
 	Username string
 	Amount   int
+	Count    int
+	// LastTransferAt is nil for totals counted before the time was recorded,
+	// until they are rebuilt.
+	LastTransferAt *time.Time
 }
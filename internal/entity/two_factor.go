@@ -0,0 +1,7 @@
+package entity
+
+type TwoFactor struct {
+	Secret   string `db:"totp_secret"`
+	Enabled  bool   `db:"totp_enabled"`
+	LastStep *int64 `db:"totp_last_step"`
+}
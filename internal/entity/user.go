@@ -1,6 +1,10 @@
 package entity
 
+import "time"
+
 type User struct {
-	Username string `db:"username"`
-	Password []byte `db:"password"`
+	Username          string     `db:"username"`
+	Password          []byte     `db:"password"`
+	PasswordChangedAt *time.Time `db:"password_changed_at"`
+	TwoFactorEnabled  bool       `db:"totp_enabled"`
 }
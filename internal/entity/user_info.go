@@ -0,0 +1,20 @@
+package entity
+
+// UserInfo holds the balance, the inventory and the coins transferred by a
+// user, summed up per counterparty.
+type UserInfo struct {
+	Balance   int
+	Inventory []Inventory
+	Received  []Transfer
+	Sent      []Transfer
+	// Version counts the changes of the balance, the inventory and the
+	// transfers, so cached copies can tell which of them is newer.
+	Version int64
+}
+
+// BalanceChange is the balance of a user after an operation and the version
+// of their info the operation produced.
+type BalanceChange struct {
+	Balance int
+	Version int64
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+type WishlistItem struct {
+	Product   string
+	Price     int
+	Available bool
+	AddedAt   time.Time
+}
+
+type Notification struct {
+	ID        int
+	Product   string
+	Type      string
+	Price     int
+	Read      bool
+	CreatedAt time.Time
+}
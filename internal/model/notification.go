@@ -0,0 +1,7 @@
+package model
+
+const (
+	NotificationTypeBackInStock = "back_in_stock"
+	NotificationTypePriceDrop   = "price_drop"
+	NotificationTypeAffordable  = "affordable"
+)
@@ -9,6 +9,13 @@ import (
 const (
 	OperationTypeTransfer = "transfer"
 	OperationTypePurchase = "purchase"
+	OperationTypeRefund   = "refund"
+)
+
+// Directions of the transfer totals of a user.
+const (
+	TransferDirectionSent     = "sent"
+	TransferDirectionReceived = "received"
 )
 
 type Operation struct {
@@ -18,5 +25,8 @@ type Operation struct {
 	Type           string    `db:"type"`
 	CounterpartyID int       `db:"counterparty_id"`
 	ProductID      int       `db:"product_id"`
+	VariantID      *int      `db:"variant_id"`
+	ListPrice      *int      `db:"list_price"`
+	PromoCodeID    *int      `db:"promo_code_id"`
 	CreatedAt      time.Time `db:"created_at"`
 }
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OrderStatusPlaced    = "placed"
+	OrderStatusPacked    = "packed"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+)
+
+// orderTransitions lists the statuses an order can move to from each status.
+// Delivered and cancelled orders are final.
+var orderTransitions = map[string][]string{
+	OrderStatusPlaced: {OrderStatusPacked, OrderStatusCancelled},
+	OrderStatusPacked: {OrderStatusDelivered, OrderStatusCancelled},
+}
+
+type Order struct {
+	ID          int       `db:"id"`
+	OperationID uuid.UUID `db:"operation_id"`
+	UserID      int       `db:"user_id"`
+	ProductID   int       `db:"product_id"`
+	VariantID   *int      `db:"variant_id"`
+	Status      string    `db:"status"`
+	Location    string    `db:"location"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// CanTransition reports whether the order is allowed to move to the given status.
+func (o Order) CanTransition(status string) bool {
+	for _, next := range orderTransitions[o.Status] {
+		if next == status {
+			return true
+		}
+	}
+
+	return false
+}
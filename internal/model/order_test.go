@@ -0,0 +1,78 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrder_CanTransition(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected bool
+	}{
+		{
+			name:     "Placed to packed",
+			from:     OrderStatusPlaced,
+			to:       OrderStatusPacked,
+			expected: true,
+		},
+		{
+			name:     "Placed to cancelled",
+			from:     OrderStatusPlaced,
+			to:       OrderStatusCancelled,
+			expected: true,
+		},
+		{
+			name:     "Placed cannot skip packing",
+			from:     OrderStatusPlaced,
+			to:       OrderStatusDelivered,
+			expected: false,
+		},
+		{
+			name:     "Packed to delivered",
+			from:     OrderStatusPacked,
+			to:       OrderStatusDelivered,
+			expected: true,
+		},
+		{
+			name:     "Packed to cancelled",
+			from:     OrderStatusPacked,
+			to:       OrderStatusCancelled,
+			expected: true,
+		},
+		{
+			name:     "Delivered is final",
+			from:     OrderStatusDelivered,
+			to:       OrderStatusCancelled,
+			expected: false,
+		},
+		{
+			name:     "Cancelled is final",
+			from:     OrderStatusCancelled,
+			to:       OrderStatusPlaced,
+			expected: false,
+		},
+		{
+			name:     "Same status",
+			from:     OrderStatusPacked,
+			to:       OrderStatusPacked,
+			expected: false,
+		},
+		{
+			name:     "Unknown status",
+			from:     OrderStatusPlaced,
+			to:       "lost",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := Order{Status: tt.from}
+			assert.Equal(t, tt.expected, order.CanTransition(tt.to))
+		})
+	}
+}
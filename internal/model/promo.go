@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+const (
+	DiscountTypePercent = "percent"
+	DiscountTypeFixed   = "fixed"
+)
+
+type PromoCode struct {
+	ID             int        `db:"id"`
+	Code           string     `db:"code"`
+	DiscountType   string     `db:"discount_type"`
+	DiscountValue  int        `db:"discount_value"`
+	ProductID      *int       `db:"product_id"`
+	CategoryID     *int       `db:"category_id"`
+	ValidFrom      time.Time  `db:"valid_from"`
+	ValidUntil     *time.Time `db:"valid_until"`
+	MaxUses        *int       `db:"max_uses"`
+	MaxUsesPerUser *int       `db:"max_uses_per_user"`
+	Uses           int        `db:"uses"`
+}
+
+// DiscountedPrice applies the promo code discount to the list price.
+// The result is never negative, so a fixed discount larger than the price makes the item free.
+func (p PromoCode) DiscountedPrice(price int) int {
+	var discounted int
+	switch p.DiscountType {
+	case DiscountTypePercent:
+		discounted = price - price*p.DiscountValue/100
+	case DiscountTypeFixed:
+		discounted = price - p.DiscountValue
+	default:
+		discounted = price
+	}
+
+	if discounted < 0 {
+		return 0
+	}
+
+	return discounted
+}
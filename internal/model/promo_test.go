@@ -0,0 +1,53 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromoCode_DiscountedPrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		promo    PromoCode
+		price    int
+		expected int
+	}{
+		{
+			name:     "Percent discount",
+			promo:    PromoCode{DiscountType: DiscountTypePercent, DiscountValue: 20},
+			price:    300,
+			expected: 240,
+		},
+		{
+			name:     "Percent discount rounds in favour of the shop",
+			promo:    PromoCode{DiscountType: DiscountTypePercent, DiscountValue: 15},
+			price:    10,
+			expected: 9,
+		},
+		{
+			name:     "Fixed discount",
+			promo:    PromoCode{DiscountType: DiscountTypeFixed, DiscountValue: 50},
+			price:    200,
+			expected: 150,
+		},
+		{
+			name:     "Fixed discount larger than price",
+			promo:    PromoCode{DiscountType: DiscountTypeFixed, DiscountValue: 50},
+			price:    20,
+			expected: 0,
+		},
+		{
+			name:     "Unknown discount type",
+			promo:    PromoCode{DiscountType: "bogus", DiscountValue: 50},
+			price:    200,
+			expected: 200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.promo.DiscountedPrice(tt.price))
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package model
+
+import "regexp"
+
+const (
+	UsernameMinLength = 3
+	UsernameMaxLength = 32
+)
+
+// usernamePattern allows latin letters, digits, '_', '.' and '-', starting with a letter.
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_.-]*$`)
+
+// ValidUsername reports whether the username can be used for a new account.
+func ValidUsername(username string) bool {
+	if len(username) < UsernameMinLength || len(username) > UsernameMaxLength {
+		return false
+	}
+
+	return usernamePattern.MatchString(username)
+}
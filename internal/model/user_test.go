@@ -0,0 +1,33 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		want     bool
+	}{
+		{"user1", true},
+		{"john.doe-42_x", true},
+		{"abc", true},
+		{strings.Repeat("a", UsernameMaxLength), true},
+		{"ab", false},
+		{strings.Repeat("a", UsernameMaxLength+1), false},
+		{"1user", false},
+		{"_user", false},
+		{"user name", false},
+		{"юзер", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			assert.Equal(t, tt.want, ValidUsername(tt.username))
+		})
+	}
+}
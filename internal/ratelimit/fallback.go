@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// FallbackLimiter limits with primary and switches to fallback for cooldown
+// whenever primary fails, so an unavailable redis neither rejects requests nor
+// delays each of them by its timeouts. Limits are not shared between the
+// instances while the fallback is used.
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	cooldown time.Duration
+	log      *zap.Logger
+	// downUntil is the unix time in nanoseconds until which primary is skipped.
+	downUntil atomic.Int64
+	now       func() time.Time
+}
+
+func NewFallbackLimiter(primary, fallback Limiter, cooldown time.Duration, log *zap.Logger) *FallbackLimiter {
+	return &FallbackLimiter{
+		primary:  primary,
+		fallback: fallback,
+		cooldown: cooldown,
+		log:      log,
+		now:      time.Now,
+	}
+}
+
+func (l *FallbackLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := l.now()
+	if now.UnixNano() < l.downUntil.Load() {
+		return l.fallback.Allow(ctx, key, limit)
+	}
+
+	result, err := l.primary.Allow(ctx, key, limit)
+	if err == nil {
+		return result, nil
+	}
+	if ctx.Err() != nil {
+		return Result{}, err
+	}
+
+	l.log.Warn("Rate limiter failed, falling back to in-memory limits",
+		zap.Duration("cooldown", l.cooldown),
+		zap.Error(err),
+	)
+	l.downUntil.Store(now.Add(l.cooldown).UnixNano())
+
+	return l.fallback.Allow(ctx, key, limit)
+}
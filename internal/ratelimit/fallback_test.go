@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// failingLimiter fails every call while down is set.
+type failingLimiter struct {
+	down  bool
+	calls int
+}
+
+func (l *failingLimiter) Allow(_ context.Context, _ string, limit Limit) (Result, error) {
+	l.calls++
+	if l.down {
+		return Result{}, errors.New("connection refused")
+	}
+	return Result{Allowed: true, Limit: limit.Requests, Remaining: limit.Requests - 1}, nil
+}
+
+func TestFallbackLimiter(t *testing.T) {
+	ctx := context.Background()
+	primary := &failingLimiter{}
+	fallback, clock := newTestMemoryLimiter()
+	limiter := NewFallbackLimiter(primary, fallback, 10*time.Second, zap.NewNop())
+	limiter.now = clock.Now
+	limit := Limit{Requests: 1, Window: time.Minute}
+
+	result, err := limiter.Allow(ctx, "user1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, primary.calls)
+
+	// A failure switches to the fallback, which is used until the cooldown ends.
+	primary.down = true
+	result, err = limiter.Allow(ctx, "user1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	result, err = limiter.Allow(ctx, "user1", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 2, primary.calls)
+
+	primary.down = false
+	clock.now = clock.now.Add(10 * time.Second)
+	result, err = limiter.Allow(ctx, "user1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 3, primary.calls)
+}
@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is advanced by the tests instead of sleeping.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestMemoryLimiter() (*MemoryLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := NewMemoryLimiter()
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+	limiter, clock := newTestMemoryLimiter()
+	limit := Limit{Requests: 3, Window: 3 * time.Second}
+
+	for i := 2; i >= 0; i-- {
+		result, err := limiter.Allow(ctx, "user1", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := limiter.Allow(ctx, "user1", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.Reset)
+
+	// Other keys have buckets of their own.
+	result, err = limiter.Allow(ctx, "user2", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// A token is refilled every second.
+	clock.now = clock.now.Add(time.Second)
+	result, err = limiter.Allow(ctx, "user1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// The bucket does not fill beyond its size.
+	clock.now = clock.now.Add(time.Hour)
+	result, err = limiter.Allow(ctx, "user1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+	assert.Equal(t, time.Second, result.Reset)
+}
+
+func TestMemoryLimiter_Sweep(t *testing.T) {
+	ctx := context.Background()
+	limiter, clock := newTestMemoryLimiter()
+	limit := Limit{Requests: 10, Window: time.Minute}
+
+	_, err := limiter.Allow(ctx, "idle", limit)
+	require.NoError(t, err)
+
+	clock.now = clock.now.Add(sweepInterval)
+	_, err = limiter.Allow(ctx, "busy", limit)
+	require.NoError(t, err)
+
+	assert.NotContains(t, limiter.buckets, "idle")
+	assert.Contains(t, limiter.buckets, "busy")
+}
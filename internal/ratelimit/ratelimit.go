@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit allows Requests per Window. Limits are token buckets: up to Requests
+// may be made at once, after that the allowance refills evenly over Window.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Enabled reports whether requests are limited at all.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Window > 0
+}
+
+// Result describes the bucket of a key after a request.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until the next request is allowed, zero when this one was.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+type Limiter interface {
+	// Allow takes a request from the bucket of key.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// take refills a bucket that held tokens elapsed ago and takes a request from it.
+// It returns the tokens left. The redis script implements the same algorithm.
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	size := float64(limit.Requests)
+	// rate is the number of tokens refilled per nanosecond.
+	rate := size / float64(limit.Window)
+
+	tokens = math.Min(size, tokens+float64(max(elapsed, 0))*rate)
+
+	result := Result{Limit: limit.Requests}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - tokens) / rate))
+	}
+	result.Remaining = int(tokens)
+	result.Reset = time.Duration(math.Ceil((size - tokens) / rate))
+
+	return tokens, result
+}
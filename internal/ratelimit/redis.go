@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucket is take run atomically in redis. KEYS[1] is a hash of the tokens
+// left and the time they were counted at, in microseconds of the redis clock,
+// so instances with skewed clocks share the buckets correctly. ARGV[1] is the
+// bucket size and ARGV[2] the window in microseconds.
+// It returns whether the request is allowed, the requests remaining, and the
+// microseconds until the next request is allowed and until the bucket is full.
+var tokenBucket = redis.NewScript(`
+local size = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(state[1]) or size
+local at = tonumber(state[2]) or now
+
+local rate = size / window
+tokens = math.min(size, tokens + math.max(0, now - at) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+local reset = math.ceil((size - tokens) / rate)
+
+redis.call('HSET', KEYS[1], 'tokens', string.format('%.6f', tokens), 'at', string.format('%.0f', now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(reset / 1000))
+
+return {allowed, math.floor(tokens), retry, reset}
+`)
+
+// RedisLimiter keeps the buckets in redis, so the limits hold across instances.
+type RedisLimiter struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisLimiter stores the bucket of key under prefix+key.
+func NewRedisLimiter(client redis.UniversalClient, prefix string) *RedisLimiter {
+	return &RedisLimiter{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	values, err := tokenBucket.Run(ctx, l.client, []string{l.prefix + key},
+		limit.Requests, limit.Window.Microseconds()).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: run token bucket script: %w", err)
+	}
+	if len(values) != 4 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected token bucket script reply %v", values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit.Requests,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		Reset:      time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
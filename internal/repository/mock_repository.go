@@ -8,6 +8,7 @@ import (
 	entity "avito-internship/internal/entity"
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -50,14 +51,12 @@ func (mr *MockUserMockRecorder) AddUser(ctx, username, password interface{}) *go
 }
 
 // GetInfo mocks base method.
-func (m *MockUser) GetInfo(ctx context.Context, username string) (int, []entity.Operation, []entity.Inventory, error) {
+func (m *MockUser) GetInfo(ctx context.Context, username string) (entity.UserInfo, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetInfo", ctx, username)
-	ret0, _ := ret[0].(int)
-	ret1, _ := ret[1].([]entity.Operation)
-	ret2, _ := ret[2].([]entity.Inventory)
-	ret3, _ := ret[3].(error)
-	return ret0, ret1, ret2, ret3
+	ret0, _ := ret[0].(entity.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // GetInfo indicates an expected call of GetInfo.
@@ -66,6 +65,37 @@ func (mr *MockUserMockRecorder) GetInfo(ctx, username interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInfo", reflect.TypeOf((*MockUser)(nil).GetInfo), ctx, username)
 }
 
+// GetMostActiveUsers mocks base method.
+func (m *MockUser) GetMostActiveUsers(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMostActiveUsers", ctx, since, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMostActiveUsers indicates an expected call of GetMostActiveUsers.
+func (mr *MockUserMockRecorder) GetMostActiveUsers(ctx, since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMostActiveUsers", reflect.TypeOf((*MockUser)(nil).GetMostActiveUsers), ctx, since, limit)
+}
+
+// GetTransferHistory mocks base method.
+func (m *MockUser) GetTransferHistory(ctx context.Context, username string) ([]entity.Transfer, []entity.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferHistory", ctx, username)
+	ret0, _ := ret[0].([]entity.Transfer)
+	ret1, _ := ret[1].([]entity.Transfer)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetTransferHistory indicates an expected call of GetTransferHistory.
+func (mr *MockUserMockRecorder) GetTransferHistory(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferHistory", reflect.TypeOf((*MockUser)(nil).GetTransferHistory), ctx, username)
+}
+
 // GetUserCredentials mocks base method.
 func (m *MockUser) GetUserCredentials(ctx context.Context, username string) (entity.User, error) {
 	m.ctrl.T.Helper()
@@ -81,6 +111,34 @@ func (mr *MockUserMockRecorder) GetUserCredentials(ctx, username interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserCredentials", reflect.TypeOf((*MockUser)(nil).GetUserCredentials), ctx, username)
 }
 
+// RehashPassword mocks base method.
+func (m *MockUser) RehashPassword(ctx context.Context, username string, password []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RehashPassword", ctx, username, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RehashPassword indicates an expected call of RehashPassword.
+func (mr *MockUserMockRecorder) RehashPassword(ctx, username, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RehashPassword", reflect.TypeOf((*MockUser)(nil).RehashPassword), ctx, username, password)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUser) UpdatePassword(ctx context.Context, username string, password []byte, changedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, username, password, changedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserMockRecorder) UpdatePassword(ctx, username, password, changedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUser)(nil).UpdatePassword), ctx, username, password, changedAt)
+}
+
 // MockOperation is a mock of Operation interface.
 type MockOperation struct {
 	ctrl     *gomock.Controller
@@ -104,26 +162,44 @@ func (m *MockOperation) EXPECT() *MockOperationMockRecorder {
 	return m.recorder
 }
 
+// RebuildTransferTotals mocks base method.
+func (m *MockOperation) RebuildTransferTotals(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebuildTransferTotals", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RebuildTransferTotals indicates an expected call of RebuildTransferTotals.
+func (mr *MockOperationMockRecorder) RebuildTransferTotals(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebuildTransferTotals", reflect.TypeOf((*MockOperation)(nil).RebuildTransferTotals), ctx)
+}
+
 // SavePurchase mocks base method.
-func (m *MockOperation) SavePurchase(ctx context.Context, username, product string) error {
+func (m *MockOperation) SavePurchase(ctx context.Context, username, product, variant, promoCode, location string) (entity.BalanceChange, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SavePurchase", ctx, username, product)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "SavePurchase", ctx, username, product, variant, promoCode, location)
+	ret0, _ := ret[0].(entity.BalanceChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // SavePurchase indicates an expected call of SavePurchase.
-func (mr *MockOperationMockRecorder) SavePurchase(ctx, username, product interface{}) *gomock.Call {
+func (mr *MockOperationMockRecorder) SavePurchase(ctx, username, product, variant, promoCode, location interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePurchase", reflect.TypeOf((*MockOperation)(nil).SavePurchase), ctx, username, product)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePurchase", reflect.TypeOf((*MockOperation)(nil).SavePurchase), ctx, username, product, variant, promoCode, location)
 }
 
 // SaveTransfer mocks base method.
-func (m *MockOperation) SaveTransfer(ctx context.Context, sender, recipient string, amount int) error {
+func (m *MockOperation) SaveTransfer(ctx context.Context, sender, recipient string, amount int) (entity.BalanceChange, entity.BalanceChange, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SaveTransfer", ctx, sender, recipient, amount)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(entity.BalanceChange)
+	ret1, _ := ret[1].(entity.BalanceChange)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // SaveTransfer indicates an expected call of SaveTransfer.
@@ -131,3 +207,797 @@ func (mr *MockOperationMockRecorder) SaveTransfer(ctx, sender, recipient, amount
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTransfer", reflect.TypeOf((*MockOperation)(nil).SaveTransfer), ctx, sender, recipient, amount)
 }
+
+// MockProduct is a mock of Product interface.
+type MockProduct struct {
+	ctrl     *gomock.Controller
+	recorder *MockProductMockRecorder
+}
+
+// MockProductMockRecorder is the mock recorder for MockProduct.
+type MockProductMockRecorder struct {
+	mock *MockProduct
+}
+
+// NewMockProduct creates a new mock instance.
+func NewMockProduct(ctrl *gomock.Controller) *MockProduct {
+	mock := &MockProduct{ctrl: ctrl}
+	mock.recorder = &MockProductMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProduct) EXPECT() *MockProductMockRecorder {
+	return m.recorder
+}
+
+// AddVariant mocks base method.
+func (m *MockProduct) AddVariant(ctx context.Context, product string, variant entity.ProductVariant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddVariant", ctx, product, variant)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddVariant indicates an expected call of AddVariant.
+func (mr *MockProductMockRecorder) AddVariant(ctx, product, variant interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddVariant", reflect.TypeOf((*MockProduct)(nil).AddVariant), ctx, product, variant)
+}
+
+// GetStockHistory mocks base method.
+func (m *MockProduct) GetStockHistory(ctx context.Context, product string) ([]entity.StockChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStockHistory", ctx, product)
+	ret0, _ := ret[0].([]entity.StockChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStockHistory indicates an expected call of GetStockHistory.
+func (mr *MockProductMockRecorder) GetStockHistory(ctx, product interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStockHistory", reflect.TypeOf((*MockProduct)(nil).GetStockHistory), ctx, product)
+}
+
+// Restock mocks base method.
+func (m *MockProduct) Restock(ctx context.Context, product, variant string, delta int, reason, changedBy string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restock", ctx, product, variant, delta, reason, changedBy)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restock indicates an expected call of Restock.
+func (mr *MockProductMockRecorder) Restock(ctx, product, variant, delta, reason, changedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restock", reflect.TypeOf((*MockProduct)(nil).Restock), ctx, product, variant, delta, reason, changedBy)
+}
+
+// SearchProducts mocks base method.
+func (m *MockProduct) SearchProducts(ctx context.Context, filter entity.ProductFilter) ([]entity.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchProducts", ctx, filter)
+	ret0, _ := ret[0].([]entity.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchProducts indicates an expected call of SearchProducts.
+func (mr *MockProductMockRecorder) SearchProducts(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchProducts", reflect.TypeOf((*MockProduct)(nil).SearchProducts), ctx, filter)
+}
+
+// SetPrice mocks base method.
+func (m *MockProduct) SetPrice(ctx context.Context, product string, price int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPrice", ctx, product, price)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPrice indicates an expected call of SetPrice.
+func (mr *MockProductMockRecorder) SetPrice(ctx, product, price interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPrice", reflect.TypeOf((*MockProduct)(nil).SetPrice), ctx, product, price)
+}
+
+// SetPurchaseLimit mocks base method.
+func (m *MockProduct) SetPurchaseLimit(ctx context.Context, product string, limit *int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPurchaseLimit", ctx, product, limit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPurchaseLimit indicates an expected call of SetPurchaseLimit.
+func (mr *MockProductMockRecorder) SetPurchaseLimit(ctx, product, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPurchaseLimit", reflect.TypeOf((*MockProduct)(nil).SetPurchaseLimit), ctx, product, limit)
+}
+
+// MockPromo is a mock of Promo interface.
+type MockPromo struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromoMockRecorder
+}
+
+// MockPromoMockRecorder is the mock recorder for MockPromo.
+type MockPromoMockRecorder struct {
+	mock *MockPromo
+}
+
+// NewMockPromo creates a new mock instance.
+func NewMockPromo(ctrl *gomock.Controller) *MockPromo {
+	mock := &MockPromo{ctrl: ctrl}
+	mock.recorder = &MockPromoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromo) EXPECT() *MockPromoMockRecorder {
+	return m.recorder
+}
+
+// AddPromoCode mocks base method.
+func (m *MockPromo) AddPromoCode(ctx context.Context, promo entity.PromoCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPromoCode", ctx, promo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddPromoCode indicates an expected call of AddPromoCode.
+func (mr *MockPromoMockRecorder) AddPromoCode(ctx, promo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPromoCode", reflect.TypeOf((*MockPromo)(nil).AddPromoCode), ctx, promo)
+}
+
+// DeactivatePromoCode mocks base method.
+func (m *MockPromo) DeactivatePromoCode(ctx context.Context, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivatePromoCode", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeactivatePromoCode indicates an expected call of DeactivatePromoCode.
+func (mr *MockPromoMockRecorder) DeactivatePromoCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivatePromoCode", reflect.TypeOf((*MockPromo)(nil).DeactivatePromoCode), ctx, code)
+}
+
+// GetPromoCodes mocks base method.
+func (m *MockPromo) GetPromoCodes(ctx context.Context) ([]entity.PromoCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPromoCodes", ctx)
+	ret0, _ := ret[0].([]entity.PromoCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPromoCodes indicates an expected call of GetPromoCodes.
+func (mr *MockPromoMockRecorder) GetPromoCodes(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPromoCodes", reflect.TypeOf((*MockPromo)(nil).GetPromoCodes), ctx)
+}
+
+// MockOrder is a mock of Order interface.
+type MockOrder struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderMockRecorder
+}
+
+// MockOrderMockRecorder is the mock recorder for MockOrder.
+type MockOrderMockRecorder struct {
+	mock *MockOrder
+}
+
+// NewMockOrder creates a new mock instance.
+func NewMockOrder(ctrl *gomock.Controller) *MockOrder {
+	mock := &MockOrder{ctrl: ctrl}
+	mock.recorder = &MockOrderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrder) EXPECT() *MockOrderMockRecorder {
+	return m.recorder
+}
+
+// GetOrders mocks base method.
+func (m *MockOrder) GetOrders(ctx context.Context, status string) ([]entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrders", ctx, status)
+	ret0, _ := ret[0].([]entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrders indicates an expected call of GetOrders.
+func (mr *MockOrderMockRecorder) GetOrders(ctx, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockOrder)(nil).GetOrders), ctx, status)
+}
+
+// GetUserOrders mocks base method.
+func (m *MockOrder) GetUserOrders(ctx context.Context, username string) ([]entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrders", ctx, username)
+	ret0, _ := ret[0].([]entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrders indicates an expected call of GetUserOrders.
+func (mr *MockOrderMockRecorder) GetUserOrders(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrders", reflect.TypeOf((*MockOrder)(nil).GetUserOrders), ctx, username)
+}
+
+// UpdateOrderStatus mocks base method.
+func (m *MockOrder) UpdateOrderStatus(ctx context.Context, id int, status string) (entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrderStatus", ctx, id, status)
+	ret0, _ := ret[0].(entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateOrderStatus indicates an expected call of UpdateOrderStatus.
+func (mr *MockOrderMockRecorder) UpdateOrderStatus(ctx, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockOrder)(nil).UpdateOrderStatus), ctx, id, status)
+}
+
+// MockWishlist is a mock of Wishlist interface.
+type MockWishlist struct {
+	ctrl     *gomock.Controller
+	recorder *MockWishlistMockRecorder
+}
+
+// MockWishlistMockRecorder is the mock recorder for MockWishlist.
+type MockWishlistMockRecorder struct {
+	mock *MockWishlist
+}
+
+// NewMockWishlist creates a new mock instance.
+func NewMockWishlist(ctrl *gomock.Controller) *MockWishlist {
+	mock := &MockWishlist{ctrl: ctrl}
+	mock.recorder = &MockWishlistMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWishlist) EXPECT() *MockWishlistMockRecorder {
+	return m.recorder
+}
+
+// AddToWishlist mocks base method.
+func (m *MockWishlist) AddToWishlist(ctx context.Context, username, product string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToWishlist", ctx, username, product)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddToWishlist indicates an expected call of AddToWishlist.
+func (mr *MockWishlistMockRecorder) AddToWishlist(ctx, username, product interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToWishlist", reflect.TypeOf((*MockWishlist)(nil).AddToWishlist), ctx, username, product)
+}
+
+// GetNotifications mocks base method.
+func (m *MockWishlist) GetNotifications(ctx context.Context, username string) ([]entity.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotifications", ctx, username)
+	ret0, _ := ret[0].([]entity.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotifications indicates an expected call of GetNotifications.
+func (mr *MockWishlistMockRecorder) GetNotifications(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotifications", reflect.TypeOf((*MockWishlist)(nil).GetNotifications), ctx, username)
+}
+
+// GetWishlist mocks base method.
+func (m *MockWishlist) GetWishlist(ctx context.Context, username string) ([]entity.WishlistItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWishlist", ctx, username)
+	ret0, _ := ret[0].([]entity.WishlistItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWishlist indicates an expected call of GetWishlist.
+func (mr *MockWishlistMockRecorder) GetWishlist(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWishlist", reflect.TypeOf((*MockWishlist)(nil).GetWishlist), ctx, username)
+}
+
+// MarkNotificationsRead mocks base method.
+func (m *MockWishlist) MarkNotificationsRead(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationsRead", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotificationsRead indicates an expected call of MarkNotificationsRead.
+func (mr *MockWishlistMockRecorder) MarkNotificationsRead(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationsRead", reflect.TypeOf((*MockWishlist)(nil).MarkNotificationsRead), ctx, username)
+}
+
+// RemoveFromWishlist mocks base method.
+func (m *MockWishlist) RemoveFromWishlist(ctx context.Context, username, product string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFromWishlist", ctx, username, product)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveFromWishlist indicates an expected call of RemoveFromWishlist.
+func (mr *MockWishlistMockRecorder) RemoveFromWishlist(ctx, username, product interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFromWishlist", reflect.TypeOf((*MockWishlist)(nil).RemoveFromWishlist), ctx, username, product)
+}
+
+// MockTwoFactor is a mock of TwoFactor interface.
+type MockTwoFactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTwoFactorMockRecorder
+}
+
+// MockTwoFactorMockRecorder is the mock recorder for MockTwoFactor.
+type MockTwoFactorMockRecorder struct {
+	mock *MockTwoFactor
+}
+
+// NewMockTwoFactor creates a new mock instance.
+func NewMockTwoFactor(ctrl *gomock.Controller) *MockTwoFactor {
+	mock := &MockTwoFactor{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTwoFactor) EXPECT() *MockTwoFactorMockRecorder {
+	return m.recorder
+}
+
+// EnableTwoFactor mocks base method.
+func (m *MockTwoFactor) EnableTwoFactor(ctx context.Context, username string, step int64, recoveryCodes [][]byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTwoFactor", ctx, username, step, recoveryCodes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTwoFactor indicates an expected call of EnableTwoFactor.
+func (mr *MockTwoFactorMockRecorder) EnableTwoFactor(ctx, username, step, recoveryCodes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTwoFactor", reflect.TypeOf((*MockTwoFactor)(nil).EnableTwoFactor), ctx, username, step, recoveryCodes)
+}
+
+// GetTwoFactor mocks base method.
+func (m *MockTwoFactor) GetTwoFactor(ctx context.Context, username string) (entity.TwoFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTwoFactor", ctx, username)
+	ret0, _ := ret[0].(entity.TwoFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTwoFactor indicates an expected call of GetTwoFactor.
+func (mr *MockTwoFactorMockRecorder) GetTwoFactor(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTwoFactor", reflect.TypeOf((*MockTwoFactor)(nil).GetTwoFactor), ctx, username)
+}
+
+// SetTOTPSecret mocks base method.
+func (m *MockTwoFactor) SetTOTPSecret(ctx context.Context, username, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTOTPSecret", ctx, username, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTOTPSecret indicates an expected call of SetTOTPSecret.
+func (mr *MockTwoFactorMockRecorder) SetTOTPSecret(ctx, username, secret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTOTPSecret", reflect.TypeOf((*MockTwoFactor)(nil).SetTOTPSecret), ctx, username, secret)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockTwoFactor) UseRecoveryCode(ctx context.Context, username string, codeHash []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, username, codeHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockTwoFactorMockRecorder) UseRecoveryCode(ctx, username, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockTwoFactor)(nil).UseRecoveryCode), ctx, username, codeHash)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockTwoFactor) UseTOTPStep(ctx context.Context, username string, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, username, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockTwoFactorMockRecorder) UseTOTPStep(ctx, username, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockTwoFactor)(nil).UseTOTPStep), ctx, username, step)
+}
+
+// MockAPIKey is a mock of APIKey interface.
+type MockAPIKey struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyMockRecorder
+}
+
+// MockAPIKeyMockRecorder is the mock recorder for MockAPIKey.
+type MockAPIKeyMockRecorder struct {
+	mock *MockAPIKey
+}
+
+// NewMockAPIKey creates a new mock instance.
+func NewMockAPIKey(ctrl *gomock.Controller) *MockAPIKey {
+	mock := &MockAPIKey{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKey) EXPECT() *MockAPIKeyMockRecorder {
+	return m.recorder
+}
+
+// AddAPIKey mocks base method.
+func (m *MockAPIKey) AddAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAPIKey", ctx, key)
+	ret0, _ := ret[0].(entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAPIKey indicates an expected call of AddAPIKey.
+func (mr *MockAPIKeyMockRecorder) AddAPIKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAPIKey", reflect.TypeOf((*MockAPIKey)(nil).AddAPIKey), ctx, key)
+}
+
+// GetAPIKeyByPrefix mocks base method.
+func (m *MockAPIKey) GetAPIKeyByPrefix(ctx context.Context, prefix string) (entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByPrefix", ctx, prefix)
+	ret0, _ := ret[0].(entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByPrefix indicates an expected call of GetAPIKeyByPrefix.
+func (mr *MockAPIKeyMockRecorder) GetAPIKeyByPrefix(ctx, prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByPrefix", reflect.TypeOf((*MockAPIKey)(nil).GetAPIKeyByPrefix), ctx, prefix)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockAPIKey) GetAPIKeys(ctx context.Context, username string) ([]entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", ctx, username)
+	ret0, _ := ret[0].([]entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockAPIKeyMockRecorder) GetAPIKeys(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockAPIKey)(nil).GetAPIKeys), ctx, username)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKey) RevokeAPIKey(ctx context.Context, username string, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, username, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeyMockRecorder) RevokeAPIKey(ctx, username, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKey)(nil).RevokeAPIKey), ctx, username, id)
+}
+
+// TouchAPIKey mocks base method.
+func (m *MockAPIKey) TouchAPIKey(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockAPIKeyMockRecorder) TouchAPIKey(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockAPIKey)(nil).TouchAPIKey), ctx, id)
+}
+
+// MockIdentity is a mock of Identity interface.
+type MockIdentity struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityMockRecorder
+}
+
+// MockIdentityMockRecorder is the mock recorder for MockIdentity.
+type MockIdentityMockRecorder struct {
+	mock *MockIdentity
+}
+
+// NewMockIdentity creates a new mock instance.
+func NewMockIdentity(ctrl *gomock.Controller) *MockIdentity {
+	mock := &MockIdentity{ctrl: ctrl}
+	mock.recorder = &MockIdentityMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentity) EXPECT() *MockIdentityMockRecorder {
+	return m.recorder
+}
+
+// CreateIdentityUser mocks base method.
+func (m *MockIdentity) CreateIdentityUser(ctx context.Context, identity entity.Identity, password []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdentityUser", ctx, identity, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIdentityUser indicates an expected call of CreateIdentityUser.
+func (mr *MockIdentityMockRecorder) CreateIdentityUser(ctx, identity, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdentityUser", reflect.TypeOf((*MockIdentity)(nil).CreateIdentityUser), ctx, identity, password)
+}
+
+// GetIdentityUsername mocks base method.
+func (m *MockIdentity) GetIdentityUsername(ctx context.Context, issuer, subject string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdentityUsername", ctx, issuer, subject)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdentityUsername indicates an expected call of GetIdentityUsername.
+func (mr *MockIdentityMockRecorder) GetIdentityUsername(ctx, issuer, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdentityUsername", reflect.TypeOf((*MockIdentity)(nil).GetIdentityUsername), ctx, issuer, subject)
+}
+
+// MockSession is a mock of Session interface.
+type MockSession struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionMockRecorder
+}
+
+// MockSessionMockRecorder is the mock recorder for MockSession.
+type MockSessionMockRecorder struct {
+	mock *MockSession
+}
+
+// NewMockSession creates a new mock instance.
+func NewMockSession(ctrl *gomock.Controller) *MockSession {
+	mock := &MockSession{ctrl: ctrl}
+	mock.recorder = &MockSessionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSession) EXPECT() *MockSessionMockRecorder {
+	return m.recorder
+}
+
+// AddSession mocks base method.
+func (m *MockSession) AddSession(ctx context.Context, session entity.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSession", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddSession indicates an expected call of AddSession.
+func (mr *MockSessionMockRecorder) AddSession(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSession", reflect.TypeOf((*MockSession)(nil).AddSession), ctx, session)
+}
+
+// GetSession mocks base method.
+func (m *MockSession) GetSession(ctx context.Context, id string) (entity.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", ctx, id)
+	ret0, _ := ret[0].(entity.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockSessionMockRecorder) GetSession(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockSession)(nil).GetSession), ctx, id)
+}
+
+// GetSessions mocks base method.
+func (m *MockSession) GetSessions(ctx context.Context, username string) ([]entity.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessions", ctx, username)
+	ret0, _ := ret[0].([]entity.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSessions indicates an expected call of GetSessions.
+func (mr *MockSessionMockRecorder) GetSessions(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessions", reflect.TypeOf((*MockSession)(nil).GetSessions), ctx, username)
+}
+
+// RevokeSession mocks base method.
+func (m *MockSession) RevokeSession(ctx context.Context, username, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, username, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockSessionMockRecorder) RevokeSession(ctx, username, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockSession)(nil).RevokeSession), ctx, username, id)
+}
+
+// RevokeSessions mocks base method.
+func (m *MockSession) RevokeSessions(ctx context.Context, username, exceptID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSessions", ctx, username, exceptID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeSessions indicates an expected call of RevokeSessions.
+func (mr *MockSessionMockRecorder) RevokeSessions(ctx, username, exceptID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSessions", reflect.TypeOf((*MockSession)(nil).RevokeSessions), ctx, username, exceptID)
+}
+
+// TouchSession mocks base method.
+func (m *MockSession) TouchSession(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchSession indicates an expected call of TouchSession.
+func (mr *MockSessionMockRecorder) TouchSession(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockSession)(nil).TouchSession), ctx, id)
+}
+
+// MockImpersonation is a mock of Impersonation interface.
+type MockImpersonation struct {
+	ctrl     *gomock.Controller
+	recorder *MockImpersonationMockRecorder
+}
+
+// MockImpersonationMockRecorder is the mock recorder for MockImpersonation.
+type MockImpersonationMockRecorder struct {
+	mock *MockImpersonation
+}
+
+// NewMockImpersonation creates a new mock instance.
+func NewMockImpersonation(ctrl *gomock.Controller) *MockImpersonation {
+	mock := &MockImpersonation{ctrl: ctrl}
+	mock.recorder = &MockImpersonationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImpersonation) EXPECT() *MockImpersonationMockRecorder {
+	return m.recorder
+}
+
+// AddImpersonationEvent mocks base method.
+func (m *MockImpersonation) AddImpersonationEvent(ctx context.Context, event entity.ImpersonationEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddImpersonationEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddImpersonationEvent indicates an expected call of AddImpersonationEvent.
+func (mr *MockImpersonationMockRecorder) AddImpersonationEvent(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddImpersonationEvent", reflect.TypeOf((*MockImpersonation)(nil).AddImpersonationEvent), ctx, event)
+}
+
+// MockCacheInvalidation is a mock of CacheInvalidation interface.
+type MockCacheInvalidation struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheInvalidationMockRecorder
+}
+
+// MockCacheInvalidationMockRecorder is the mock recorder for MockCacheInvalidation.
+type MockCacheInvalidationMockRecorder struct {
+	mock *MockCacheInvalidation
+}
+
+// NewMockCacheInvalidation creates a new mock instance.
+func NewMockCacheInvalidation(ctrl *gomock.Controller) *MockCacheInvalidation {
+	mock := &MockCacheInvalidation{ctrl: ctrl}
+	mock.recorder = &MockCacheInvalidationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheInvalidation) EXPECT() *MockCacheInvalidationMockRecorder {
+	return m.recorder
+}
+
+// AddCacheInvalidations mocks base method.
+func (m *MockCacheInvalidation) AddCacheInvalidations(ctx context.Context, keys []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCacheInvalidations", ctx, keys)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCacheInvalidations indicates an expected call of AddCacheInvalidations.
+func (mr *MockCacheInvalidationMockRecorder) AddCacheInvalidations(ctx, keys interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCacheInvalidations", reflect.TypeOf((*MockCacheInvalidation)(nil).AddCacheInvalidations), ctx, keys)
+}
+
+// DeleteCacheInvalidations mocks base method.
+func (m *MockCacheInvalidation) DeleteCacheInvalidations(ctx context.Context, ids []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCacheInvalidations", ctx, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCacheInvalidations indicates an expected call of DeleteCacheInvalidations.
+func (mr *MockCacheInvalidationMockRecorder) DeleteCacheInvalidations(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCacheInvalidations", reflect.TypeOf((*MockCacheInvalidation)(nil).DeleteCacheInvalidations), ctx, ids)
+}
+
+// GetCacheInvalidations mocks base method.
+func (m *MockCacheInvalidation) GetCacheInvalidations(ctx context.Context, limit int) ([]entity.CacheInvalidation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCacheInvalidations", ctx, limit)
+	ret0, _ := ret[0].([]entity.CacheInvalidation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCacheInvalidations indicates an expected call of GetCacheInvalidations.
+func (mr *MockCacheInvalidationMockRecorder) GetCacheInvalidations(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCacheInvalidations", reflect.TypeOf((*MockCacheInvalidation)(nil).GetCacheInvalidations), ctx, limit)
+}
@@ -0,0 +1,170 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type APIKeyRepository struct {
+	*postgres.Postgres
+}
+
+func NewAPIKeyRepository(pg *postgres.Postgres) *APIKeyRepository {
+	return &APIKeyRepository{pg}
+}
+
+func (r *APIKeyRepository) AddAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error) {
+	const op = "repository.APIKeyRepository.AddAPIKey"
+
+	query := `
+        INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+        SELECT id, @name, @prefix, @key_hash, @scopes, @expires_at FROM users WHERE username = @username
+        RETURNING id, created_at
+    `
+	args := pgx.NamedArgs{
+		"username":   key.Username,
+		"name":       key.Name,
+		"prefix":     key.Prefix,
+		"key_hash":   key.Hash,
+		"scopes":     key.Scopes,
+		"expires_at": key.ExpiresAt,
+	}
+
+	if err := r.Pool.QueryRow(ctx, query, args).Scan(&key.ID, &key.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.APIKey{}, fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
+		}
+		return entity.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// GetAPIKeys returns the keys of the user that have not been revoked, expired ones included.
+func (r *APIKeyRepository) GetAPIKeys(ctx context.Context, username string) ([]entity.APIKey, error) {
+	const op = "repository.APIKeyRepository.GetAPIKeys"
+
+	query := `
+		SELECT k.id, k.name, k.prefix, k.scopes, k.expires_at, k.last_used_at, k.created_at
+		FROM api_keys k
+		JOIN users u ON k.user_id = u.id
+		WHERE u.username = @username AND k.revoked_at IS NULL
+		ORDER BY k.created_at DESC, k.id DESC`
+	args := pgx.NamedArgs{
+		"username": username,
+	}
+
+	rows, err := r.Pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	keys := []entity.APIKey{}
+	for rows.Next() {
+		key := entity.APIKey{Username: username}
+		err := rows.Scan(
+			&key.ID,
+			&key.Name,
+			&key.Prefix,
+			&key.Scopes,
+			&key.ExpiresAt,
+			&key.LastUsedAt,
+			&key.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", op, rows.Err())
+	}
+
+	return keys, nil
+}
+
+// GetAPIKeyByPrefix looks up a key that has not been revoked. Expiry is checked by the caller.
+func (r *APIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (entity.APIKey, error) {
+	const op = "repository.APIKeyRepository.GetAPIKeyByPrefix"
+
+	query := `
+		SELECT k.id, u.username, k.name, k.prefix, k.key_hash, k.scopes, k.expires_at, k.last_used_at, k.created_at
+		FROM api_keys k
+		JOIN users u ON k.user_id = u.id
+		WHERE k.prefix = @prefix AND k.revoked_at IS NULL`
+	args := pgx.NamedArgs{
+		"prefix": prefix,
+	}
+
+	var key entity.APIKey
+	err := r.Pool.QueryRow(ctx, query, args).Scan(
+		&key.ID,
+		&key.Username,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		&key.Scopes,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.APIKey{}, fmt.Errorf("%s: %w", op, repoerrs.ErrAPIKeyNotFound)
+		}
+		return entity.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, username string, id int) error {
+	const op = "repository.APIKeyRepository.RevokeAPIKey"
+
+	query := `
+        UPDATE api_keys SET revoked_at = NOW()
+        WHERE id = @id AND user_id = (SELECT id FROM users WHERE username = @username) AND revoked_at IS NULL
+    `
+	args := pgx.NamedArgs{
+		"username": username,
+		"id":       id,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrAPIKeyNotFound)
+	}
+
+	return nil
+}
+
+// TouchAPIKey updates the last use time of the key at most once a minute,
+// so a busy integration does not write to the table on every request.
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id int) error {
+	const op = "repository.APIKeyRepository.TouchAPIKey"
+
+	query := `
+        UPDATE api_keys SET last_used_at = NOW()
+        WHERE id = @id AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+    `
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+
+	if _, err := r.Pool.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
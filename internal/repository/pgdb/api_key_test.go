@@ -0,0 +1,120 @@
+package pgdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyRepository_AddAPIKey(t *testing.T) {
+	key := entity.APIKey{
+		Username: "hr-bot",
+		Name:     "HR bot",
+		Prefix:   "0123abcd",
+		Hash:     []byte("hash"),
+		Scopes:   []string{entity.ScopeInfoRead},
+	}
+	args := pgx.NamedArgs{
+		"username":   key.Username,
+		"name":       key.Name,
+		"prefix":     key.Prefix,
+		"key_hash":   key.Hash,
+		"scopes":     key.Scopes,
+		"expires_at": key.ExpiresAt,
+	}
+	createdAt := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m pgxmock.PgxPoolIface)
+		wantID       int
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("INSERT INTO api_keys").
+					WithArgs(args).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt))
+			},
+			wantID: 1,
+		},
+		{
+			name: "User Not Found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("INSERT INTO api_keys").
+					WithArgs(args).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: repoerrs.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			repo := NewAPIKeyRepository(&postgres.Postgres{Pool: poolMock})
+
+			saved, err := repo.AddAPIKey(context.Background(), key)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantID, saved.ID)
+				assert.Equal(t, createdAt, saved.CreatedAt)
+			}
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAPIKeyRepository_RevokeAPIKey(t *testing.T) {
+	testCases := []struct {
+		name         string
+		rowsAffected int64
+		wantErr      error
+	}{
+		{
+			name:         "OK",
+			rowsAffected: 1,
+		},
+		{
+			name:         "Key Not Found",
+			rowsAffected: 0,
+			wantErr:      repoerrs.ErrAPIKeyNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			poolMock.ExpectExec("UPDATE api_keys SET revoked_at = NOW()").
+				WithArgs(pgx.NamedArgs{"username": "hr-bot", "id": 1}).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tc.rowsAffected))
+
+			repo := NewAPIKeyRepository(&postgres.Postgres{Pool: poolMock})
+
+			err := repo.RevokeAPIKey(context.Background(), "hr-bot", 1)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
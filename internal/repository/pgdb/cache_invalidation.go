@@ -0,0 +1,87 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+
+	"avito-internship/internal/entity"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CacheInvalidationRepository struct {
+	*postgres.Postgres
+}
+
+func NewCacheInvalidationRepository(pg *postgres.Postgres) *CacheInvalidationRepository {
+	return &CacheInvalidationRepository{pg}
+}
+
+func (r *CacheInvalidationRepository) AddCacheInvalidations(ctx context.Context, keys []string) error {
+	const op = "repository.CacheInvalidationRepository.AddCacheInvalidations"
+
+	query := `
+		INSERT INTO cache_invalidations (key)
+		SELECT unnest(@keys::text[])`
+	args := pgx.NamedArgs{
+		"keys": keys,
+	}
+
+	if _, err := r.Pool.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetCacheInvalidations returns the oldest pending invalidations first.
+func (r *CacheInvalidationRepository) GetCacheInvalidations(ctx context.Context, limit int) ([]entity.CacheInvalidation, error) {
+	const op = "repository.CacheInvalidationRepository.GetCacheInvalidations"
+
+	query := `
+		SELECT id, key, created_at
+		FROM cache_invalidations
+		ORDER BY id
+		LIMIT @limit`
+	args := pgx.NamedArgs{
+		"limit": limit,
+	}
+
+	rows, err := r.Pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	invalidations := []entity.CacheInvalidation{}
+	for rows.Next() {
+		var invalidation entity.CacheInvalidation
+		if err := rows.Scan(&invalidation.ID, &invalidation.Key, &invalidation.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		invalidations = append(invalidations, invalidation)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", op, rows.Err())
+	}
+
+	return invalidations, nil
+}
+
+func (r *CacheInvalidationRepository) DeleteCacheInvalidations(ctx context.Context, ids []int64) error {
+	const op = "repository.CacheInvalidationRepository.DeleteCacheInvalidations"
+
+	query := `
+		DELETE FROM cache_invalidations
+		WHERE id = ANY(@ids)`
+	args := pgx.NamedArgs{
+		"ids": ids,
+	}
+
+	if _, err := r.Pool.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
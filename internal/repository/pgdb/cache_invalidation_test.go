@@ -0,0 +1,99 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheInvalidationRepository_AddCacheInvalidations(t *testing.T) {
+	keys := []string{"user_info:user1", "user_info:user2"}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m pgxmock.PgxPoolIface)
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO cache_invalidations").
+					WithArgs(pgx.NamedArgs{"keys": keys}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 2))
+			},
+		},
+		{
+			name: "Database Error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO cache_invalidations").
+					WithArgs(pgx.NamedArgs{"keys": keys}).
+					WillReturnError(errors.New("connection refused"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			repo := NewCacheInvalidationRepository(&postgres.Postgres{Pool: poolMock})
+
+			err := repo.AddCacheInvalidations(context.Background(), keys)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCacheInvalidationRepository_GetCacheInvalidations(t *testing.T) {
+	at := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	poolMock, _ := pgxmock.NewPool()
+	defer poolMock.Close()
+	poolMock.ExpectQuery("SELECT (.+) FROM cache_invalidations").
+		WithArgs(pgx.NamedArgs{"limit": 100}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "key", "created_at"}).
+			AddRow(int64(1), "user_info:user1", at).
+			AddRow(int64(2), "user_info:user2", at))
+
+	repo := NewCacheInvalidationRepository(&postgres.Postgres{Pool: poolMock})
+
+	invalidations, err := repo.GetCacheInvalidations(context.Background(), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []entity.CacheInvalidation{
+		{ID: 1, Key: "user_info:user1", CreatedAt: at},
+		{ID: 2, Key: "user_info:user2", CreatedAt: at},
+	}, invalidations)
+	assert.NoError(t, poolMock.ExpectationsWereMet())
+}
+
+func TestCacheInvalidationRepository_DeleteCacheInvalidations(t *testing.T) {
+	ids := []int64{1, 2}
+
+	poolMock, _ := pgxmock.NewPool()
+	defer poolMock.Close()
+	poolMock.ExpectExec("DELETE FROM cache_invalidations").
+		WithArgs(pgx.NamedArgs{"ids": ids}).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+
+	repo := NewCacheInvalidationRepository(&postgres.Postgres{Pool: poolMock})
+
+	assert.NoError(t, repo.DeleteCacheInvalidations(context.Background(), ids))
+	assert.NoError(t, poolMock.ExpectationsWereMet())
+}
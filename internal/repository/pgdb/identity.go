@@ -0,0 +1,96 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type IdentityRepository struct {
+	*postgres.Postgres
+}
+
+func NewIdentityRepository(pg *postgres.Postgres) *IdentityRepository {
+	return &IdentityRepository{pg}
+}
+
+func (r *IdentityRepository) GetIdentityUsername(ctx context.Context, issuer string, subject string) (string, error) {
+	const op = "repository.IdentityRepository.GetIdentityUsername"
+
+	query := `
+		SELECT u.username
+		FROM user_identities i
+		JOIN users u ON i.user_id = u.id
+		WHERE i.issuer = @issuer AND i.subject = @subject`
+	args := pgx.NamedArgs{
+		"issuer":  issuer,
+		"subject": subject,
+	}
+
+	var username string
+	if err := r.Pool.QueryRow(ctx, query, args).Scan(&username); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("%s: %w", op, repoerrs.ErrIdentityNotFound)
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return username, nil
+}
+
+// CreateIdentityUser creates the user with the password hash and links the
+// identity to it. An existing user is never linked, as its owner has not
+// proven they are the holder of the identity.
+func (r *IdentityRepository) CreateIdentityUser(ctx context.Context, identity entity.Identity, password []byte) error {
+	const op = "repository.IdentityRepository.CreateIdentityUser"
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO users(username, password) VALUES(@username, @password) ON CONFLICT (username) DO NOTHING RETURNING id`
+	args := pgx.NamedArgs{
+		"username": identity.Username,
+		"password": password,
+	}
+	var userID int
+	if err := tx.QueryRow(ctx, query, args).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, repoerrs.ErrUserAlreadyExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `
+        INSERT INTO user_identities (user_id, issuer, subject, email)
+        VALUES (@user_id, @issuer, @subject, @email)
+    `
+	args = pgx.NamedArgs{
+		"user_id": userID,
+		"issuer":  identity.Issuer,
+		"subject": identity.Subject,
+		"email":   identity.Email,
+	}
+	if _, err := tx.Exec(ctx, query, args); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, repoerrs.ErrIdentityConflict)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
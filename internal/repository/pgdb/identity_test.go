@@ -0,0 +1,120 @@
+package pgdb
+
+import (
+	"context"
+	"testing"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityRepository_CreateIdentityUser(t *testing.T) {
+	identity := entity.Identity{
+		Username: "alice",
+		Issuer:   "https://idp.example",
+		Subject:  "sub-alice",
+		Email:    "alice@corp.example",
+	}
+	password := []byte("hash")
+	userArgs := pgx.NamedArgs{"username": identity.Username, "password": password}
+	identityArgs := pgx.NamedArgs{
+		"user_id": 1,
+		"issuer":  identity.Issuer,
+		"subject": identity.Subject,
+		"email":   identity.Email,
+	}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m pgxmock.PgxPoolIface)
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectQuery("INSERT INTO users(.+) ON CONFLICT \\(username\\) DO NOTHING RETURNING id").
+					WithArgs(userArgs).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+				m.ExpectExec("INSERT INTO user_identities").
+					WithArgs(identityArgs).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				m.ExpectCommit()
+			},
+		},
+		{
+			name: "Username Taken",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectQuery("INSERT INTO users").
+					WithArgs(userArgs).
+					WillReturnError(pgx.ErrNoRows)
+				m.ExpectRollback()
+			},
+			wantErr: repoerrs.ErrUserAlreadyExists,
+		},
+		{
+			name: "Identity Conflict",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectQuery("INSERT INTO users").
+					WithArgs(userArgs).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+				m.ExpectExec("INSERT INTO user_identities").
+					WithArgs(identityArgs).
+					WillReturnError(&pgconn.PgError{Code: "23505"})
+				m.ExpectRollback()
+			},
+			wantErr: repoerrs.ErrIdentityConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			repo := NewIdentityRepository(&postgres.Postgres{Pool: poolMock})
+
+			err := repo.CreateIdentityUser(context.Background(), identity, password)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestIdentityRepository_GetIdentityUsername(t *testing.T) {
+	poolMock, _ := pgxmock.NewPool()
+	defer poolMock.Close()
+
+	args := pgx.NamedArgs{"issuer": "https://idp.example", "subject": "sub-alice"}
+	poolMock.ExpectQuery("SELECT u.username").
+		WithArgs(args).
+		WillReturnRows(pgxmock.NewRows([]string{"username"}).AddRow("alice"))
+	poolMock.ExpectQuery("SELECT u.username").
+		WithArgs(args).
+		WillReturnError(pgx.ErrNoRows)
+
+	repo := NewIdentityRepository(&postgres.Postgres{Pool: poolMock})
+
+	username, err := repo.GetIdentityUsername(context.Background(), "https://idp.example", "sub-alice")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", username)
+
+	_, err = repo.GetIdentityUsername(context.Background(), "https://idp.example", "sub-alice")
+	assert.ErrorIs(t, err, repoerrs.ErrIdentityNotFound)
+
+	assert.NoError(t, poolMock.ExpectationsWereMet())
+}
@@ -0,0 +1,48 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type ImpersonationRepository struct {
+	*postgres.Postgres
+}
+
+func NewImpersonationRepository(pg *postgres.Postgres) *ImpersonationRepository {
+	return &ImpersonationRepository{pg}
+}
+
+func (r *ImpersonationRepository) AddImpersonationEvent(ctx context.Context, event entity.ImpersonationEvent) error {
+	const op = "repository.ImpersonationRepository.AddImpersonationEvent"
+
+	query := `
+		INSERT INTO impersonation_log (actor_id, subject_id, action, method, path, reason)
+		SELECT a.id, s.id, @action, @method, @path, @reason
+		FROM users a, users s
+		WHERE a.username = @actor AND s.username = @subject`
+	args := pgx.NamedArgs{
+		"actor":   event.Actor,
+		"subject": event.Subject,
+		"action":  event.Action,
+		"method":  event.Method,
+		"path":    event.Path,
+		"reason":  event.Reason,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
+	}
+
+	return nil
+}
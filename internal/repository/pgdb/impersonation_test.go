@@ -0,0 +1,75 @@
+package pgdb
+
+import (
+	"context"
+	"testing"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonationRepository_AddImpersonationEvent(t *testing.T) {
+	event := entity.ImpersonationEvent{
+		Actor:   "admin",
+		Subject: "user1",
+		Action:  entity.ImpersonationRequest,
+		Method:  "GET",
+		Path:    "/api/info",
+	}
+	args := pgx.NamedArgs{
+		"actor":   event.Actor,
+		"subject": event.Subject,
+		"action":  event.Action,
+		"method":  event.Method,
+		"path":    event.Path,
+		"reason":  event.Reason,
+	}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m pgxmock.PgxPoolIface)
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO impersonation_log").
+					WithArgs(args).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+		},
+		{
+			name: "User Not Found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO impersonation_log").
+					WithArgs(args).
+					WillReturnResult(pgxmock.NewResult("INSERT", 0))
+			},
+			wantErr: repoerrs.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			repo := NewImpersonationRepository(&postgres.Postgres{Pool: poolMock})
+
+			err := repo.AddImpersonationEvent(context.Background(), event)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 
+	"avito-internship/internal/entity"
 	"avito-internship/internal/model"
 	"avito-internship/internal/repository/repoerrs"
 	"avito-internship/pkg/postgres"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
@@ -20,18 +22,20 @@ func NewOperationRepository(pg *postgres.Postgres) *OperationRepository {
 	return &OperationRepository{pg}
 }
 
-func (r *OperationRepository) SaveTransfer(ctx context.Context, sender string, recipient string, amount int) error {
+// SaveTransfer returns the new balances and info versions of the sender and
+// the recipient.
+func (r *OperationRepository) SaveTransfer(ctx context.Context, sender string, recipient string, amount int) (entity.BalanceChange, entity.BalanceChange, error) {
 	const op = "repository.OperationRepository.Transfer"
 
 	tx, err := r.Pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
 	}
 	defer tx.Rollback(ctx)
 
 	var senderID int
 	var senderBalance int
-	getSenderQuery := `SELECT id, balance FROM users WHERE username = @username`
+	getSenderQuery := `SELECT id, balance FROM users WHERE username = @username FOR UPDATE`
 	getSenderArgs := pgx.NamedArgs{
 		"username": sender,
 	}
@@ -39,13 +43,13 @@ func (r *OperationRepository) SaveTransfer(ctx context.Context, sender string, r
 	err = tx.QueryRow(ctx, getSenderQuery, getSenderArgs).Scan(&senderID, &senderBalance)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
+			return entity.BalanceChange{}, entity.BalanceChange{}, fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
 		}
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	if senderBalance < amount {
-		return fmt.Errorf("%s: %w", op, repoerrs.ErrInsufficientFunds)
+		return entity.BalanceChange{}, entity.BalanceChange{}, fmt.Errorf("%s: %w", op, repoerrs.ErrInsufficientFunds)
 	}
 
 	var recipientID int
@@ -57,31 +61,45 @@ func (r *OperationRepository) SaveTransfer(ctx context.Context, sender string, r
 	err = tx.QueryRow(ctx, getRecipientIDQuery, getRecipientIDArgs).Scan(&recipientID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
+			return entity.BalanceChange{}, entity.BalanceChange{}, fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
 		}
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	updateSenderQuery := `UPDATE users SET balance = balance - @amount WHERE id = @id`
+	var senderChange entity.BalanceChange
+	updateSenderQuery := `
+        UPDATE users SET balance = balance - @amount, info_version = info_version + 1
+        WHERE id = @id
+        RETURNING balance, info_version
+    `
 	updateSenderArgs := pgx.NamedArgs{
 		"id":     senderID,
 		"amount": amount,
 	}
 
-	_, err = tx.Exec(ctx, updateSenderQuery, updateSenderArgs)
+	err = tx.QueryRow(ctx, updateSenderQuery, updateSenderArgs).Scan(&senderChange.Balance, &senderChange.Version)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	updateRecipientQuery := `UPDATE users SET balance = balance + @amount WHERE id = @id`
+	var recipientChange entity.BalanceChange
+	updateRecipientQuery := `
+        UPDATE users SET balance = balance + @amount, info_version = info_version + 1
+        WHERE id = @id
+        RETURNING balance, info_version
+    `
 	updateRecipientArgs := pgx.NamedArgs{
 		"id":     recipientID,
 		"amount": amount,
 	}
 
-	_, err = tx.Exec(ctx, updateRecipientQuery, updateRecipientArgs)
+	err = tx.QueryRow(ctx, updateRecipientQuery, updateRecipientArgs).Scan(&recipientChange.Balance, &recipientChange.Version)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := notifyAffordable(ctx, tx, recipientID, recipientChange.Balance-amount, recipientChange.Balance); err != nil {
+		return entity.BalanceChange{}, entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	operationQuery := `
@@ -97,29 +115,104 @@ func (r *OperationRepository) SaveTransfer(ctx context.Context, sender string, r
 
 	_, err = tx.Exec(ctx, operationQuery, operationArgs)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	totalsQuery := `
+        INSERT INTO transfer_totals (user_id, counterparty_id, direction, amount, count, last_transfer_at)
+        VALUES (@sender_id, @recipient_id, @sent, @amount, 1, NOW()), (@recipient_id, @sender_id, @received, @amount, 1, NOW())
+        ON CONFLICT (user_id, direction, counterparty_id) DO UPDATE
+        SET amount = transfer_totals.amount + EXCLUDED.amount,
+            count = transfer_totals.count + 1,
+            last_transfer_at = EXCLUDED.last_transfer_at
+    `
+	totalsArgs := pgx.NamedArgs{
+		"sender_id":    senderID,
+		"recipient_id": recipientID,
+		"sent":         model.TransferDirectionSent,
+		"received":     model.TransferDirectionReceived,
+		"amount":       amount,
+	}
+
+	_, err = tx.Exec(ctx, totalsQuery, totalsArgs)
+	if err != nil {
+		return entity.BalanceChange{}, entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil
+	return senderChange, recipientChange, nil
 }
 
-func (r *OperationRepository) SavePurchase(ctx context.Context, username string, product string) error {
+// RebuildTransferTotals recomputes the transfer totals from the operations and
+// returns the number of rows written. Transfers made meanwhile wait for the
+// rebuild to commit, so none of them is counted twice or lost.
+func (r *OperationRepository) RebuildTransferTotals(ctx context.Context) (int64, error) {
+	const op = "repository.OperationRepository.RebuildTransferTotals"
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// EXCLUSIVE still lets GetInfo read the previous totals until the commit.
+	_, err = tx.Exec(ctx, `LOCK TABLE transfer_totals IN EXCLUSIVE MODE`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM transfer_totals`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rebuildQuery := `
+        INSERT INTO transfer_totals (user_id, counterparty_id, direction, amount, count, last_transfer_at)
+        SELECT user_id, counterparty_id, @sent, SUM(amount), COUNT(*), MAX(created_at)
+        FROM operations
+        WHERE type = @type
+        GROUP BY user_id, counterparty_id
+        UNION ALL
+        SELECT counterparty_id, user_id, @received, SUM(amount), COUNT(*), MAX(created_at)
+        FROM operations
+        WHERE type = @type
+        GROUP BY counterparty_id, user_id
+    `
+	rebuildArgs := pgx.NamedArgs{
+		"type":     model.OperationTypeTransfer,
+		"sent":     model.TransferDirectionSent,
+		"received": model.TransferDirectionReceived,
+	}
+
+	tag, err := tx.Exec(ctx, rebuildQuery, rebuildArgs)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// SavePurchase returns the new balance and info version of the customer.
+func (r *OperationRepository) SavePurchase(ctx context.Context, username string, product string, variant string, promoCode string, location string) (entity.BalanceChange, error) {
 	const op = "repository.OperationRepository.Purchase"
 
 	tx, err := r.Pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	defer tx.Rollback(ctx)
 
 	var userID int
 	var userBalance int
-	query := `SELECT id, balance FROM users WHERE username = @username`
+	query := `SELECT id, balance FROM users WHERE username = @username FOR UPDATE`
 	args := pgx.NamedArgs{
 		"username": username,
 	}
@@ -127,39 +220,123 @@ func (r *OperationRepository) SavePurchase(ctx context.Context, username string,
 	err = tx.QueryRow(ctx, query, args).Scan(&userID, &userBalance)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
+			return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
 		}
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	var productID int
 	var productPrice int
-	query = `SELECT id, price FROM products WHERE name = @product`
+	var purchaseLimit *int
+	var categoryID *int
+	var hasVariants bool
+	query = `
+		SELECT
+			p.id,
+			p.price,
+			p.purchase_limit,
+			p.category_id,
+			EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id) AS has_variants
+		FROM products p
+		WHERE p.name = @product`
 	args = pgx.NamedArgs{
 		"product": product,
 	}
 
-	err = tx.QueryRow(ctx, query, args).Scan(&productID, &productPrice)
+	err = tx.QueryRow(ctx, query, args).Scan(&productID, &productPrice, &purchaseLimit, &categoryID, &hasVariants)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("%s: %w", op, repoerrs.ErrProductNotFound)
+			return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, repoerrs.ErrProductNotFound)
+		}
+		return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var variantID *int
+	if variant != "" {
+		variantID = new(int)
+		variantQuery := `SELECT id FROM product_variants WHERE product_id = @product_id AND name = @variant`
+		variantArgs := pgx.NamedArgs{
+			"product_id": productID,
+			"variant":    variant,
+		}
+
+		if err := tx.QueryRow(ctx, variantQuery, variantArgs).Scan(variantID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, repoerrs.ErrVariantNotFound)
+			}
+			return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
+		}
+	} else if hasVariants {
+		return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, repoerrs.ErrVariantRequired)
+	}
+
+	price := productPrice
+	var promo *model.PromoCode
+	if promoCode != "" {
+		promo, err = r.applicablePromoCode(ctx, tx, promoCode, userID, productID, categoryID)
+		if err != nil {
+			return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		price = promo.DiscountedPrice(productPrice)
+	}
+
+	if userBalance < price {
+		return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, repoerrs.ErrInsufficientFunds)
+	}
+
+	if purchaseLimit != nil {
+		var owned int
+		ownedQuery := `SELECT COALESCE(SUM(quantity), 0) FROM inventory WHERE user_id = @user_id AND product_id = @product_id`
+		ownedArgs := pgx.NamedArgs{
+			"user_id":    userID,
+			"product_id": productID,
+		}
+
+		if err := tx.QueryRow(ctx, ownedQuery, ownedArgs).Scan(&owned); err != nil {
+			return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if owned >= *purchaseLimit {
+			return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, repoerrs.ErrPurchaseLimitExceeded)
 		}
-		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if userBalance < productPrice {
-		return fmt.Errorf("%s: %w", op, repoerrs.ErrInsufficientFunds)
+	// NULL stock means the product is not limited, so the decrement leaves it untouched.
+	// Products with variants keep their stock per variant.
+	decrementStockQuery := `UPDATE products SET stock = stock - 1 WHERE id = @id AND (stock IS NULL OR stock > 0)`
+	decrementStockArgs := pgx.NamedArgs{
+		"id": productID,
+	}
+	if variantID != nil {
+		decrementStockQuery = `UPDATE product_variants SET stock = stock - 1 WHERE id = @id AND (stock IS NULL OR stock > 0)`
+		decrementStockArgs = pgx.NamedArgs{
+			"id": *variantID,
+		}
 	}
 
-	updateBalanceQuery := `UPDATE users SET balance = balance - @price WHERE id = @id`
+	tag, err := tx.Exec(ctx, decrementStockQuery, decrementStockArgs)
+	if err != nil {
+		return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, repoerrs.ErrOutOfStock)
+	}
+
+	var change entity.BalanceChange
+	updateBalanceQuery := `
+        UPDATE users SET balance = balance - @price, info_version = info_version + 1
+        WHERE id = @id
+        RETURNING balance, info_version
+    `
 	updateBalanceArgs := pgx.NamedArgs{
 		"id":    userID,
-		"price": productPrice,
+		"price": price,
 	}
 
-	_, err = tx.Exec(ctx, updateBalanceQuery, updateBalanceArgs)
+	err = tx.QueryRow(ctx, updateBalanceQuery, updateBalanceArgs).Scan(&change.Balance, &change.Version)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	upsertInventoryQuery := `
@@ -175,30 +352,161 @@ func (r *OperationRepository) SavePurchase(ctx context.Context, username string,
 
 	_, err = tx.Exec(ctx, upsertInventoryQuery, upsertInventoryArgs)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	var promoCodeID *int
+	if promo != nil {
+		promoCodeID = &promo.ID
+	}
+
+	var operationID uuid.UUID
 	operationQuery := `
-        INSERT INTO operations (user_id, amount, type, product_id)
-        VALUES (@user_id, @amount, @type, @product_id)
+        INSERT INTO operations (user_id, amount, type, product_id, variant_id, list_price, promo_code_id)
+        VALUES (@user_id, @amount, @type, @product_id, @variant_id, @list_price, @promo_code_id)
+        RETURNING id
     `
 	operationArgs := pgx.NamedArgs{
-		"user_id":    userID,
-		"amount":     productPrice,
-		"type":       "purchase",
-		"product_id": productID,
+		"user_id":       userID,
+		"amount":        price,
+		"type":          model.OperationTypePurchase,
+		"product_id":    productID,
+		"variant_id":    variantID,
+		"list_price":    productPrice,
+		"promo_code_id": promoCodeID,
+	}
+
+	err = tx.QueryRow(ctx, operationQuery, operationArgs).Scan(&operationID)
+	if err != nil {
+		return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	fmt.Println(operationArgs)
+	orderQuery := `
+        INSERT INTO orders (operation_id, user_id, product_id, variant_id, status, location)
+        VALUES (@operation_id, @user_id, @product_id, @variant_id, @status, @location)
+    `
+	orderArgs := pgx.NamedArgs{
+		"operation_id": operationID,
+		"user_id":      userID,
+		"product_id":   productID,
+		"variant_id":   variantID,
+		"status":       model.OrderStatusPlaced,
+		"location":     location,
+	}
 
-	_, err = tx.Exec(ctx, operationQuery, operationArgs)
+	_, err = tx.Exec(ctx, orderQuery, orderArgs)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if promo != nil {
+		usePromoQuery := `UPDATE promo_codes SET uses = uses + 1 WHERE id = @id`
+		usePromoArgs := pgx.NamedArgs{
+			"id": promo.ID,
+		}
+
+		_, err = tx.Exec(ctx, usePromoQuery, usePromoArgs)
+		if err != nil {
+			return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		usageQuery := `
+            INSERT INTO promo_code_usages (promo_code_id, user_id, operation_id)
+            VALUES (@promo_code_id, @user_id, @operation_id)
+        `
+		usageArgs := pgx.NamedArgs{
+			"promo_code_id": promo.ID,
+			"user_id":       userID,
+			"operation_id":  operationID,
+		}
+
+		_, err = tx.Exec(ctx, usageQuery, usageArgs)
+		if err != nil {
+			return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return entity.BalanceChange{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return change, nil
+}
+
+// applicablePromoCode locks the promo code row and checks that it can be applied
+// by the user to the product within the current transaction.
+func (r *OperationRepository) applicablePromoCode(ctx context.Context, tx pgx.Tx, code string, userID int, productID int, categoryID *int) (*model.PromoCode, error) {
+	var promo model.PromoCode
+	var active bool
+	query := `
+		SELECT
+			id,
+			discount_type,
+			discount_value,
+			product_id,
+			category_id,
+			max_uses,
+			max_uses_per_user,
+			uses,
+			(valid_from <= NOW() AND (valid_until IS NULL OR valid_until > NOW())) AS active
+		FROM promo_codes
+		WHERE code = @code
+		FOR UPDATE`
+	args := pgx.NamedArgs{
+		"code": code,
+	}
+
+	err := tx.QueryRow(ctx, query, args).Scan(
+		&promo.ID,
+		&promo.DiscountType,
+		&promo.DiscountValue,
+		&promo.ProductID,
+		&promo.CategoryID,
+		&promo.MaxUses,
+		&promo.MaxUsesPerUser,
+		&promo.Uses,
+		&active,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repoerrs.ErrPromoCodeNotFound
+		}
+		return nil, err
+	}
+	promo.Code = code
+
+	if !active {
+		return nil, repoerrs.ErrPromoCodeInactive
+	}
+
+	if promo.ProductID != nil && *promo.ProductID != productID {
+		return nil, repoerrs.ErrPromoCodeNotApplicable
+	}
+
+	if promo.CategoryID != nil && (categoryID == nil || *promo.CategoryID != *categoryID) {
+		return nil, repoerrs.ErrPromoCodeNotApplicable
+	}
+
+	if promo.MaxUses != nil && promo.Uses >= *promo.MaxUses {
+		return nil, repoerrs.ErrPromoCodeUsageLimit
+	}
+
+	if promo.MaxUsesPerUser != nil {
+		var userUses int
+		userUsesQuery := `SELECT COUNT(*) FROM promo_code_usages WHERE promo_code_id = @promo_code_id AND user_id = @user_id`
+		userUsesArgs := pgx.NamedArgs{
+			"promo_code_id": promo.ID,
+			"user_id":       userID,
+		}
+
+		if err := tx.QueryRow(ctx, userUsesQuery, userUsesArgs).Scan(&userUses); err != nil {
+			return nil, err
+		}
+
+		if userUses >= *promo.MaxUsesPerUser {
+			return nil, repoerrs.ErrPromoCodeUsageLimit
+		}
 	}
 
-	return nil
+	return &promo, nil
 }
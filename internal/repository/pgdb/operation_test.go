@@ -5,9 +5,11 @@ import (
 	"errors"
 	"testing"
 
+	"avito-internship/internal/entity"
 	"avito-internship/internal/model"
 	"avito-internship/pkg/postgres"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
@@ -28,6 +30,9 @@ func TestUserRepository_SaveTransfer(t *testing.T) {
 		args         args
 		mockBehavior MockBehavior
 		wantErr      bool
+
+		wantSender    entity.BalanceChange
+		wantRecipient entity.BalanceChange
 	}{
 		{
 			name: "OK",
@@ -42,7 +47,7 @@ func TestUserRepository_SaveTransfer(t *testing.T) {
 
 				rows := pgxmock.NewRows([]string{"id", "balance"}).
 					AddRow(1, 500)
-				m.ExpectQuery("SELECT id, balance FROM users WHERE username = @username").
+				m.ExpectQuery("SELECT id, balance FROM users WHERE username = @username FOR UPDATE").
 					WithArgs(args.sender).
 					WillReturnRows(rows)
 
@@ -52,21 +57,43 @@ func TestUserRepository_SaveTransfer(t *testing.T) {
 					WithArgs(args.recipient).
 					WillReturnRows(recipientRows)
 
-				m.ExpectExec("UPDATE users SET balance = balance - @amount WHERE id = @id").
+				m.ExpectQuery("UPDATE users SET balance = balance - @amount, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
 					WithArgs(args.amount, 1).
-					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(400, int64(7)))
 
-				m.ExpectExec("UPDATE users SET balance = balance \\+ @amount WHERE id = @id").
+				m.ExpectQuery("UPDATE users SET balance = balance \\+ @amount, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
 					WithArgs(args.amount, 2).
-					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(400, int64(3)))
+
+				m.ExpectExec("INSERT INTO notifications (.+) FROM wishlists w").
+					WithArgs(pgx.NamedArgs{
+						"user_id":     2,
+						"type":        model.NotificationTypeAffordable,
+						"old_balance": 300,
+						"new_balance": 400,
+					}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 0))
 
 				m.ExpectExec("INSERT INTO operations \\(user_id, amount, type, counterparty_id\\) VALUES \\(@user_id, @amount, @type, @counterparty_id\\)").
 					WithArgs(1, args.amount, model.OperationTypeTransfer, 2).
 					WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
+				m.ExpectExec("INSERT INTO transfer_totals (.+) ON CONFLICT (.+) SET amount = transfer_totals.amount \\+ EXCLUDED.amount, count = transfer_totals.count \\+ 1").
+					WithArgs(pgx.NamedArgs{
+						"sender_id":    1,
+						"recipient_id": 2,
+						"sent":         model.TransferDirectionSent,
+						"received":     model.TransferDirectionReceived,
+						"amount":       args.amount,
+					}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 2))
+
 				m.ExpectCommit()
 			},
 			wantErr: false,
+
+			wantSender:    entity.BalanceChange{Balance: 400, Version: 7},
+			wantRecipient: entity.BalanceChange{Balance: 400, Version: 3},
 		},
 		{
 			name: "Sender Not Found",
@@ -156,7 +183,7 @@ func TestUserRepository_SaveTransfer(t *testing.T) {
 					WithArgs(args.recipient).
 					WillReturnRows(recipientRows)
 
-				m.ExpectExec("UPDATE users SET balance = balance - @amount WHERE id = @id").
+				m.ExpectQuery("UPDATE users SET balance = balance - @amount, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
 					WithArgs(pgx.NamedArgs{"id": 1, "amount": args.amount}).
 					WillReturnError(errors.New("update sender balance error"))
 
@@ -187,11 +214,11 @@ func TestUserRepository_SaveTransfer(t *testing.T) {
 					WithArgs(args.recipient).
 					WillReturnRows(recipientRows)
 
-				m.ExpectExec("UPDATE users SET balance = balance - @amount WHERE id = @id").
+				m.ExpectQuery("UPDATE users SET balance = balance - @amount, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
 					WithArgs(pgx.NamedArgs{"id": 1, "amount": args.amount}).
-					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(900, int64(5)))
 
-				m.ExpectExec("UPDATE users SET balance = balance + @amount WHERE id = @id").
+				m.ExpectQuery("UPDATE users SET balance = balance \\+ @amount, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
 					WithArgs(pgx.NamedArgs{"id": 2, "amount": args.amount}).
 					WillReturnError(errors.New("update recipient balance error"))
 
@@ -222,13 +249,13 @@ func TestUserRepository_SaveTransfer(t *testing.T) {
 					WithArgs(args.recipient).
 					WillReturnRows(recipientRows)
 
-				m.ExpectExec("UPDATE users SET balance = balance - @amount WHERE id = @id").
+				m.ExpectQuery("UPDATE users SET balance = balance - @amount, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
 					WithArgs(pgx.NamedArgs{"id": 1, "amount": args.amount}).
-					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(900, int64(5)))
 
-				m.ExpectExec("UPDATE users SET balance = balance + @amount WHERE id = @id").
+				m.ExpectQuery("UPDATE users SET balance = balance \\+ @amount, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
 					WithArgs(pgx.NamedArgs{"id": 2, "amount": args.amount}).
-					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(600, int64(3)))
 
 				m.ExpectExec("INSERT INTO operations").
 					WithArgs(pgx.NamedArgs{
@@ -266,13 +293,13 @@ func TestUserRepository_SaveTransfer(t *testing.T) {
 					WithArgs(args.recipient).
 					WillReturnRows(recipientRows)
 
-				m.ExpectExec("UPDATE users SET balance = balance - @amount WHERE id = @id").
+				m.ExpectQuery("UPDATE users SET balance = balance - @amount, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
 					WithArgs(pgx.NamedArgs{"id": 1, "amount": args.amount}).
-					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(900, int64(5)))
 
-				m.ExpectExec("UPDATE users SET balance = balance + @amount WHERE id = @id").
+				m.ExpectQuery("UPDATE users SET balance = balance \\+ @amount, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
 					WithArgs(pgx.NamedArgs{"id": 2, "amount": args.amount}).
-					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(600, int64(3)))
 
 				m.ExpectExec("INSERT INTO operations").
 					WithArgs(pgx.NamedArgs{
@@ -304,13 +331,15 @@ func TestUserRepository_SaveTransfer(t *testing.T) {
 			}
 			operationRepoMock := NewOperationRepository(postgresMock)
 
-			err = operationRepoMock.SaveTransfer(tc.args.ctx, tc.args.sender, tc.args.recipient, tc.args.amount)
+			sender, recipient, err := operationRepoMock.SaveTransfer(tc.args.ctx, tc.args.sender, tc.args.recipient, tc.args.amount)
 
 			if tc.wantErr {
 				assert.Error(t, err)
 				return
 			}
 			assert.NoError(t, err)
+			assert.Equal(t, tc.wantSender, sender)
+			assert.Equal(t, tc.wantRecipient, recipient)
 
 			err = poolMock.ExpectationsWereMet()
 			assert.NoError(t, err)
@@ -320,9 +349,12 @@ func TestUserRepository_SaveTransfer(t *testing.T) {
 
 func TestOperationRepository_SavePurchase(t *testing.T) {
 	type args struct {
-		ctx      context.Context
-		username string
-		product  string
+		ctx       context.Context
+		username  string
+		product   string
+		variant   string
+		promoCode string
+		location  string
 	}
 
 	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
@@ -332,6 +364,7 @@ func TestOperationRepository_SavePurchase(t *testing.T) {
 		args         args
 		mockBehavior MockBehavior
 		wantErr      bool
+		wantChange   entity.BalanceChange
 	}{
 		{
 			name: "OK",
@@ -339,6 +372,7 @@ func TestOperationRepository_SavePurchase(t *testing.T) {
 				ctx:      context.Background(),
 				username: "test_user",
 				product:  "test_product",
+				location: "3rd floor",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
 				m.ExpectBegin()
@@ -349,32 +383,52 @@ func TestOperationRepository_SavePurchase(t *testing.T) {
 					WithArgs(pgx.NamedArgs{"username": args.username}).
 					WillReturnRows(userRows)
 
-				productRows := pgxmock.NewRows([]string{"id", "price"}).
-					AddRow(1, 100)
-				m.ExpectQuery("SELECT id, price FROM products WHERE name = @product").
+				productRows := pgxmock.NewRows([]string{"id", "price", "purchase_limit", "category_id", "has_variants"}).
+					AddRow(1, 100, nil, nil, false)
+				m.ExpectQuery("SELECT (.+) FROM products p WHERE p.name = @product").
 					WithArgs(pgx.NamedArgs{"product": args.product}).
 					WillReturnRows(productRows)
 
-				m.ExpectExec("UPDATE users SET balance = balance - @price WHERE id = @id").
-					WithArgs(pgx.NamedArgs{"id": 1, "price": 100}).
+				m.ExpectExec("UPDATE products SET stock = stock - 1 WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": 1}).
 					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
+				m.ExpectQuery("UPDATE users SET balance = balance - @price, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
+					WithArgs(pgx.NamedArgs{"id": 1, "price": 100}).
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(900, int64(5)))
+
 				m.ExpectExec("INSERT INTO inventory").
 					WithArgs(pgx.NamedArgs{"user_id": 1, "product_id": 1}).
 					WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
-				m.ExpectExec("INSERT INTO operations").
+				operationID := uuid.New()
+				m.ExpectQuery("INSERT INTO operations").
+					WithArgs(pgx.NamedArgs{
+						"user_id":       1,
+						"amount":        100,
+						"type":          model.OperationTypePurchase,
+						"product_id":    1,
+						"variant_id":    (*int)(nil),
+						"list_price":    100,
+						"promo_code_id": (*int)(nil),
+					}).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(operationID))
+
+				m.ExpectExec("INSERT INTO orders").
 					WithArgs(pgx.NamedArgs{
-						"user_id":    1,
-						"amount":     100,
-						"type":       model.OperationTypePurchase,
-						"product_id": 1,
+						"operation_id": operationID,
+						"user_id":      1,
+						"product_id":   1,
+						"variant_id":   (*int)(nil),
+						"status":       model.OrderStatusPlaced,
+						"location":     args.location,
 					}).
 					WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 				m.ExpectCommit()
 			},
-			wantErr: false,
+			wantErr:    false,
+			wantChange: entity.BalanceChange{Balance: 900, Version: 5},
 		},
 		{
 			name: "User Not Found",
@@ -410,7 +464,7 @@ func TestOperationRepository_SavePurchase(t *testing.T) {
 					WithArgs(pgx.NamedArgs{"username": args.username}).
 					WillReturnRows(userRows)
 
-				m.ExpectQuery("SELECT id, price FROM products WHERE name = @product").
+				m.ExpectQuery("SELECT (.+) FROM products p WHERE p.name = @product").
 					WithArgs(pgx.NamedArgs{"product": args.product}).
 					WillReturnError(pgx.ErrNoRows)
 
@@ -434,12 +488,194 @@ func TestOperationRepository_SavePurchase(t *testing.T) {
 					WithArgs(pgx.NamedArgs{"username": args.username}).
 					WillReturnRows(userRows)
 
-				productRows := pgxmock.NewRows([]string{"id", "price"}).
-					AddRow(1, 100)
-				m.ExpectQuery("SELECT id, price FROM products WHERE name = @product").
+				productRows := pgxmock.NewRows([]string{"id", "price", "purchase_limit", "category_id", "has_variants"}).
+					AddRow(1, 100, nil, nil, false)
+				m.ExpectQuery("SELECT (.+) FROM products p WHERE p.name = @product").
+					WithArgs(pgx.NamedArgs{"product": args.product}).
+					WillReturnRows(productRows)
+
+				m.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Out Of Stock",
+			args: args{
+				ctx:      context.Background(),
+				username: "test_user",
+				product:  "test_product",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				userRows := pgxmock.NewRows([]string{"id", "balance"}).
+					AddRow(1, 1000)
+				m.ExpectQuery("SELECT id, balance FROM users WHERE username = @username").
+					WithArgs(pgx.NamedArgs{"username": args.username}).
+					WillReturnRows(userRows)
+
+				productRows := pgxmock.NewRows([]string{"id", "price", "purchase_limit", "category_id", "has_variants"}).
+					AddRow(1, 100, nil, nil, false)
+				m.ExpectQuery("SELECT (.+) FROM products p WHERE p.name = @product").
+					WithArgs(pgx.NamedArgs{"product": args.product}).
+					WillReturnRows(productRows)
+
+				m.ExpectExec("UPDATE products SET stock = stock - 1 WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": 1}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+				m.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Purchase Limit Exceeded",
+			args: args{
+				ctx:      context.Background(),
+				username: "test_user",
+				product:  "test_product",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				userRows := pgxmock.NewRows([]string{"id", "balance"}).
+					AddRow(1, 1000)
+				m.ExpectQuery("SELECT id, balance FROM users WHERE username = @username").
+					WithArgs(pgx.NamedArgs{"username": args.username}).
+					WillReturnRows(userRows)
+
+				limit := 2
+				productRows := pgxmock.NewRows([]string{"id", "price", "purchase_limit", "category_id", "has_variants"}).
+					AddRow(1, 100, &limit, nil, false)
+				m.ExpectQuery("SELECT (.+) FROM products p WHERE p.name = @product").
+					WithArgs(pgx.NamedArgs{"product": args.product}).
+					WillReturnRows(productRows)
+
+				ownedRows := pgxmock.NewRows([]string{"coalesce"}).
+					AddRow(2)
+				m.ExpectQuery("SELECT COALESCE\\(SUM\\(quantity\\), 0\\) FROM inventory").
+					WithArgs(pgx.NamedArgs{"user_id": 1, "product_id": 1}).
+					WillReturnRows(ownedRows)
+
+				m.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "OK With Promo Code",
+			args: args{
+				ctx:       context.Background(),
+				username:  "test_user",
+				product:   "test_product",
+				promoCode: "SALE20",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				userRows := pgxmock.NewRows([]string{"id", "balance"}).
+					AddRow(1, 90)
+				m.ExpectQuery("SELECT id, balance FROM users WHERE username = @username").
+					WithArgs(pgx.NamedArgs{"username": args.username}).
+					WillReturnRows(userRows)
+
+				categoryID := 3
+				productRows := pgxmock.NewRows([]string{"id", "price", "purchase_limit", "category_id", "has_variants"}).
+					AddRow(1, 100, nil, &categoryID, false)
+				m.ExpectQuery("SELECT (.+) FROM products p WHERE p.name = @product").
+					WithArgs(pgx.NamedArgs{"product": args.product}).
+					WillReturnRows(productRows)
+
+				maxUsesPerUser := 1
+				promoRows := pgxmock.NewRows([]string{"id", "discount_type", "discount_value", "product_id", "category_id", "max_uses", "max_uses_per_user", "uses", "active"}).
+					AddRow(7, model.DiscountTypePercent, 20, nil, &categoryID, nil, &maxUsesPerUser, 10, true)
+				m.ExpectQuery("FROM promo_codes").
+					WithArgs(pgx.NamedArgs{"code": args.promoCode}).
+					WillReturnRows(promoRows)
+
+				m.ExpectQuery("SELECT COUNT\\(\\*\\) FROM promo_code_usages").
+					WithArgs(pgx.NamedArgs{"promo_code_id": 7, "user_id": 1}).
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+
+				m.ExpectExec("UPDATE products SET stock = stock - 1 WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": 1}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				m.ExpectQuery("UPDATE users SET balance = balance - @price, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
+					WithArgs(pgx.NamedArgs{"id": 1, "price": 80}).
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(10, int64(5)))
+
+				m.ExpectExec("INSERT INTO inventory").
+					WithArgs(pgx.NamedArgs{"user_id": 1, "product_id": 1}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				promoCodeID := 7
+				operationID := uuid.New()
+				m.ExpectQuery("INSERT INTO operations").
+					WithArgs(pgx.NamedArgs{
+						"user_id":       1,
+						"amount":        80,
+						"type":          model.OperationTypePurchase,
+						"product_id":    1,
+						"variant_id":    (*int)(nil),
+						"list_price":    100,
+						"promo_code_id": &promoCodeID,
+					}).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(operationID))
+
+				m.ExpectExec("INSERT INTO orders").
+					WithArgs(pgx.NamedArgs{
+						"operation_id": operationID,
+						"user_id":      1,
+						"product_id":   1,
+						"variant_id":   (*int)(nil),
+						"status":       model.OrderStatusPlaced,
+						"location":     args.location,
+					}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectExec("UPDATE promo_codes SET uses = uses \\+ 1 WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": 7}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				m.ExpectExec("INSERT INTO promo_code_usages").
+					WithArgs(pgx.NamedArgs{"promo_code_id": 7, "user_id": 1, "operation_id": operationID}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectCommit()
+			},
+			wantErr:    false,
+			wantChange: entity.BalanceChange{Balance: 10, Version: 5},
+		},
+		{
+			name: "Promo Code Not Applicable",
+			args: args{
+				ctx:       context.Background(),
+				username:  "test_user",
+				product:   "test_product",
+				promoCode: "HOODY20",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				userRows := pgxmock.NewRows([]string{"id", "balance"}).
+					AddRow(1, 1000)
+				m.ExpectQuery("SELECT id, balance FROM users WHERE username = @username").
+					WithArgs(pgx.NamedArgs{"username": args.username}).
+					WillReturnRows(userRows)
+
+				productRows := pgxmock.NewRows([]string{"id", "price", "purchase_limit", "category_id", "has_variants"}).
+					AddRow(1, 100, nil, nil, false)
+				m.ExpectQuery("SELECT (.+) FROM products p WHERE p.name = @product").
 					WithArgs(pgx.NamedArgs{"product": args.product}).
 					WillReturnRows(productRows)
 
+				hoodyID := 6
+				promoRows := pgxmock.NewRows([]string{"id", "discount_type", "discount_value", "product_id", "category_id", "max_uses", "max_uses_per_user", "uses", "active"}).
+					AddRow(7, model.DiscountTypePercent, 20, &hoodyID, nil, nil, nil, 0, true)
+				m.ExpectQuery("FROM promo_codes").
+					WithArgs(pgx.NamedArgs{"code": args.promoCode}).
+					WillReturnRows(promoRows)
+
 				m.ExpectRollback()
 			},
 			wantErr: true,
@@ -460,13 +696,17 @@ func TestOperationRepository_SavePurchase(t *testing.T) {
 					WithArgs(pgx.NamedArgs{"username": args.username}).
 					WillReturnRows(userRows)
 
-				productRows := pgxmock.NewRows([]string{"id", "price"}).
-					AddRow(1, 100)
-				m.ExpectQuery("SELECT id, price FROM products WHERE name = @product").
+				productRows := pgxmock.NewRows([]string{"id", "price", "purchase_limit", "category_id", "has_variants"}).
+					AddRow(1, 100, nil, nil, false)
+				m.ExpectQuery("SELECT (.+) FROM products p WHERE p.name = @product").
 					WithArgs(pgx.NamedArgs{"product": args.product}).
 					WillReturnRows(productRows)
 
-				m.ExpectExec("UPDATE users SET balance = balance - @price WHERE id = @id").
+				m.ExpectExec("UPDATE products SET stock = stock - 1 WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": 1}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				m.ExpectQuery("UPDATE users SET balance = balance - @price, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
 					WithArgs(pgx.NamedArgs{"id": 1, "price": 100}).
 					WillReturnError(errors.New("update balance error"))
 
@@ -490,16 +730,20 @@ func TestOperationRepository_SavePurchase(t *testing.T) {
 					WithArgs(pgx.NamedArgs{"username": args.username}).
 					WillReturnRows(userRows)
 
-				productRows := pgxmock.NewRows([]string{"id", "price"}).
-					AddRow(1, 100)
-				m.ExpectQuery("SELECT id, price FROM products WHERE name = @product").
+				productRows := pgxmock.NewRows([]string{"id", "price", "purchase_limit", "category_id", "has_variants"}).
+					AddRow(1, 100, nil, nil, false)
+				m.ExpectQuery("SELECT (.+) FROM products p WHERE p.name = @product").
 					WithArgs(pgx.NamedArgs{"product": args.product}).
 					WillReturnRows(productRows)
 
-				m.ExpectExec("UPDATE users SET balance = balance - @price WHERE id = @id").
-					WithArgs(pgx.NamedArgs{"id": 1, "price": 100}).
+				m.ExpectExec("UPDATE products SET stock = stock - 1 WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": 1}).
 					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
+				m.ExpectQuery("UPDATE users SET balance = balance - @price, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
+					WithArgs(pgx.NamedArgs{"id": 1, "price": 100}).
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(900, int64(5)))
+
 				m.ExpectExec("INSERT INTO inventory").
 					WithArgs(pgx.NamedArgs{"user_id": 1, "product_id": 1}).
 					WillReturnError(errors.New("insert inventory error"))
@@ -524,26 +768,33 @@ func TestOperationRepository_SavePurchase(t *testing.T) {
 					WithArgs(pgx.NamedArgs{"username": args.username}).
 					WillReturnRows(userRows)
 
-				productRows := pgxmock.NewRows([]string{"id", "price"}).
-					AddRow(1, 100)
-				m.ExpectQuery("SELECT id, price FROM products WHERE name = @product").
+				productRows := pgxmock.NewRows([]string{"id", "price", "purchase_limit", "category_id", "has_variants"}).
+					AddRow(1, 100, nil, nil, false)
+				m.ExpectQuery("SELECT (.+) FROM products p WHERE p.name = @product").
 					WithArgs(pgx.NamedArgs{"product": args.product}).
 					WillReturnRows(productRows)
 
-				m.ExpectExec("UPDATE users SET balance = balance - @price WHERE id = @id").
-					WithArgs(pgx.NamedArgs{"id": 1, "price": 100}).
+				m.ExpectExec("UPDATE products SET stock = stock - 1 WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": 1}).
 					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
+				m.ExpectQuery("UPDATE users SET balance = balance - @price, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
+					WithArgs(pgx.NamedArgs{"id": 1, "price": 100}).
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(900, int64(5)))
+
 				m.ExpectExec("INSERT INTO inventory").
 					WithArgs(pgx.NamedArgs{"user_id": 1, "product_id": 1}).
 					WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
-				m.ExpectExec("INSERT INTO operations").
+				m.ExpectQuery("INSERT INTO operations").
 					WithArgs(pgx.NamedArgs{
-						"user_id":    1,
-						"amount":     100,
-						"type":       model.OperationTypePurchase,
-						"product_id": 1,
+						"user_id":       1,
+						"amount":        100,
+						"type":          model.OperationTypePurchase,
+						"product_id":    1,
+						"variant_id":    (*int)(nil),
+						"list_price":    100,
+						"promo_code_id": (*int)(nil),
 					}).
 					WillReturnError(errors.New("insert operation error"))
 
@@ -567,26 +818,45 @@ func TestOperationRepository_SavePurchase(t *testing.T) {
 					WithArgs(pgx.NamedArgs{"username": args.username}).
 					WillReturnRows(userRows)
 
-				productRows := pgxmock.NewRows([]string{"id", "price"}).
-					AddRow(1, 100)
-				m.ExpectQuery("SELECT id, price FROM products WHERE name = @product").
+				productRows := pgxmock.NewRows([]string{"id", "price", "purchase_limit", "category_id", "has_variants"}).
+					AddRow(1, 100, nil, nil, false)
+				m.ExpectQuery("SELECT (.+) FROM products p WHERE p.name = @product").
 					WithArgs(pgx.NamedArgs{"product": args.product}).
 					WillReturnRows(productRows)
 
-				m.ExpectExec("UPDATE users SET balance = balance - @price WHERE id = @id").
-					WithArgs(pgx.NamedArgs{"id": 1, "price": 100}).
+				m.ExpectExec("UPDATE products SET stock = stock - 1 WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": 1}).
 					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
+				m.ExpectQuery("UPDATE users SET balance = balance - @price, info_version = info_version \\+ 1 WHERE id = @id RETURNING balance, info_version").
+					WithArgs(pgx.NamedArgs{"id": 1, "price": 100}).
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(900, int64(5)))
+
 				m.ExpectExec("INSERT INTO inventory").
 					WithArgs(pgx.NamedArgs{"user_id": 1, "product_id": 1}).
 					WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
-				m.ExpectExec("INSERT INTO operations").
+				operationID := uuid.New()
+				m.ExpectQuery("INSERT INTO operations").
+					WithArgs(pgx.NamedArgs{
+						"user_id":       1,
+						"amount":        100,
+						"type":          model.OperationTypePurchase,
+						"product_id":    1,
+						"variant_id":    (*int)(nil),
+						"list_price":    100,
+						"promo_code_id": (*int)(nil),
+					}).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(operationID))
+
+				m.ExpectExec("INSERT INTO orders").
 					WithArgs(pgx.NamedArgs{
-						"user_id":    1,
-						"amount":     100,
-						"type":       model.OperationTypePurchase,
-						"product_id": 1,
+						"operation_id": operationID,
+						"user_id":      1,
+						"product_id":   1,
+						"variant_id":   (*int)(nil),
+						"status":       model.OrderStatusPlaced,
+						"location":     args.location,
 					}).
 					WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
@@ -611,16 +881,87 @@ func TestOperationRepository_SavePurchase(t *testing.T) {
 			}
 			operationRepo := NewOperationRepository(postgresMock)
 
-			err = operationRepo.SavePurchase(tc.args.ctx, tc.args.username, tc.args.product)
+			change, err := operationRepo.SavePurchase(tc.args.ctx, tc.args.username, tc.args.product, tc.args.variant, tc.args.promoCode, tc.args.location)
 
 			if tc.wantErr {
 				assert.Error(t, err)
 				return
 			}
 			assert.NoError(t, err)
+			assert.Equal(t, tc.wantChange, change)
 
 			err = poolMock.ExpectationsWereMet()
 			assert.NoError(t, err)
 		})
 	}
 }
+
+func TestOperationRepository_RebuildTransferTotals(t *testing.T) {
+	rebuildArgs := pgx.NamedArgs{
+		"type":     model.OperationTypeTransfer,
+		"sent":     model.TransferDirectionSent,
+		"received": model.TransferDirectionReceived,
+	}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m pgxmock.PgxPoolIface)
+		want         int64
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectExec("LOCK TABLE transfer_totals IN EXCLUSIVE MODE").
+					WillReturnResult(pgxmock.NewResult("LOCK TABLE", 0))
+				m.ExpectExec("DELETE FROM transfer_totals").
+					WillReturnResult(pgxmock.NewResult("DELETE", 3))
+				m.ExpectExec("INSERT INTO transfer_totals (.+) FROM operations (.+) UNION ALL (.+) FROM operations").
+					WithArgs(rebuildArgs).
+					WillReturnResult(pgxmock.NewResult("INSERT", 4))
+				m.ExpectCommit()
+			},
+			want: 4,
+		},
+		{
+			name: "Insert Error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectExec("LOCK TABLE transfer_totals").
+					WillReturnResult(pgxmock.NewResult("LOCK TABLE", 0))
+				m.ExpectExec("DELETE FROM transfer_totals").
+					WillReturnResult(pgxmock.NewResult("DELETE", 3))
+				m.ExpectExec("INSERT INTO transfer_totals").
+					WithArgs(rebuildArgs).
+					WillReturnError(errors.New("insert error"))
+				m.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("Failed to create mock pool: %v", err)
+			}
+			defer poolMock.Close()
+
+			tc.mockBehavior(poolMock)
+
+			operationRepoMock := NewOperationRepository(&postgres.Postgres{Pool: poolMock})
+
+			rows, err := operationRepoMock.RebuildTransferTotals(context.Background())
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, rows)
+			}
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/model"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ordersQuery selects orders with the names of the user, product and variant
+// and the amount paid for the purchase.
+const ordersQuery = `
+	SELECT
+		o.id,
+		u.username,
+		p.name AS product,
+		COALESCE(v.name, '') AS variant,
+		op.amount,
+		o.status,
+		o.location,
+		o.created_at,
+		o.updated_at
+	FROM orders o
+	JOIN users u ON o.user_id = u.id
+	JOIN products p ON o.product_id = p.id
+	LEFT JOIN product_variants v ON o.variant_id = v.id
+	JOIN operations op ON o.operation_id = op.id`
+
+// stockReasonOrderCancelled is the stock history reason of items put back by a cancelled order.
+const stockReasonOrderCancelled = "order cancelled"
+
+type OrderRepository struct {
+	*postgres.Postgres
+}
+
+func NewOrderRepository(pg *postgres.Postgres) *OrderRepository {
+	return &OrderRepository{pg}
+}
+
+func (r *OrderRepository) GetUserOrders(ctx context.Context, username string) ([]entity.Order, error) {
+	const op = "repository.OrderRepository.GetUserOrders"
+
+	query := ordersQuery + `
+		WHERE u.username = @username
+		ORDER BY o.created_at DESC, o.id DESC`
+	args := pgx.NamedArgs{
+		"username": username,
+	}
+
+	rows, err := r.Pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+func (r *OrderRepository) GetOrders(ctx context.Context, status string) ([]entity.Order, error) {
+	const op = "repository.OrderRepository.GetOrders"
+
+	// Oldest orders first, so the queue is handed out in the order it was placed.
+	query := ordersQuery + `
+		WHERE (@status = '' OR o.status = @status)
+		ORDER BY o.created_at, o.id`
+	args := pgx.NamedArgs{
+		"status": status,
+	}
+
+	rows, err := r.Pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+func (r *OrderRepository) UpdateOrderStatus(ctx context.Context, id int, status string) (entity.Order, error) {
+	const op = "repository.OrderRepository.UpdateOrderStatus"
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return entity.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var order model.Order
+	var amount int
+	var promoCodeID *int
+	query := `
+		SELECT o.operation_id, o.user_id, o.product_id, o.variant_id, o.status, op.amount, op.promo_code_id
+		FROM orders o
+		JOIN operations op ON o.operation_id = op.id
+		WHERE o.id = @id
+		FOR UPDATE OF o`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+
+	err = tx.QueryRow(ctx, query, args).Scan(&order.OperationID, &order.UserID, &order.ProductID, &order.VariantID, &order.Status, &amount, &promoCodeID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Order{}, fmt.Errorf("%s: %w", op, repoerrs.ErrOrderNotFound)
+		}
+		return entity.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !order.CanTransition(status) {
+		return entity.Order{}, fmt.Errorf("%s: %w", op, repoerrs.ErrInvalidOrderTransition)
+	}
+
+	updateQuery := `UPDATE orders SET status = @status, updated_at = NOW() WHERE id = @id`
+	updateArgs := pgx.NamedArgs{
+		"id":     id,
+		"status": status,
+	}
+
+	_, err = tx.Exec(ctx, updateQuery, updateArgs)
+	if err != nil {
+		return entity.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if status == model.OrderStatusCancelled {
+		if err := r.refundOrder(ctx, tx, order, amount, promoCodeID); err != nil {
+			return entity.Order{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	rows, err := tx.Query(ctx, ordersQuery+` WHERE o.id = @id`, args)
+	if err != nil {
+		return entity.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return entity.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(orders) == 0 {
+		return entity.Order{}, fmt.Errorf("%s: %w", op, repoerrs.ErrOrderNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return entity.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders[0], nil
+}
+
+// refundOrder returns the paid amount to the user, puts the item back in stock,
+// removes it from the user's inventory and releases the promo code redemption
+// within the current transaction.
+func (r *OrderRepository) refundOrder(ctx context.Context, tx pgx.Tx, order model.Order, amount int, promoCodeID *int) error {
+	refundQuery := `UPDATE users SET balance = balance + @amount, info_version = info_version + 1 WHERE id = @id`
+	refundArgs := pgx.NamedArgs{
+		"id":     order.UserID,
+		"amount": amount,
+	}
+
+	_, err := tx.Exec(ctx, refundQuery, refundArgs)
+	if err != nil {
+		return err
+	}
+
+	// NULL stock means the product is not limited, so there is nothing to put back.
+	restoreStockQuery := `UPDATE products SET stock = stock + 1 WHERE id = @id AND stock IS NOT NULL RETURNING stock`
+	restoreStockArgs := pgx.NamedArgs{
+		"id": order.ProductID,
+	}
+	if order.VariantID != nil {
+		restoreStockQuery = `UPDATE product_variants SET stock = stock + 1 WHERE id = @id AND stock IS NOT NULL RETURNING stock`
+		restoreStockArgs = pgx.NamedArgs{
+			"id": *order.VariantID,
+		}
+	}
+
+	var stock int
+	err = tx.QueryRow(ctx, restoreStockQuery, restoreStockArgs).Scan(&stock)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return err
+	default:
+		historyQuery := `
+            INSERT INTO stock_changes (product_id, variant_id, delta, stock, reason)
+            VALUES (@product_id, @variant_id, 1, @stock, @reason)
+        `
+		historyArgs := pgx.NamedArgs{
+			"product_id": order.ProductID,
+			"variant_id": order.VariantID,
+			"stock":      stock,
+			"reason":     stockReasonOrderCancelled,
+		}
+
+		_, err = tx.Exec(ctx, historyQuery, historyArgs)
+		if err != nil {
+			return err
+		}
+
+		if stock == 1 {
+			if err := notifyBackInStock(ctx, tx, order.ProductID, order.VariantID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if promoCodeID != nil {
+		releasePromoQuery := `UPDATE promo_codes SET uses = uses - 1 WHERE id = @id AND uses > 0`
+		releasePromoArgs := pgx.NamedArgs{
+			"id": *promoCodeID,
+		}
+
+		_, err = tx.Exec(ctx, releasePromoQuery, releasePromoArgs)
+		if err != nil {
+			return err
+		}
+
+		usageQuery := `DELETE FROM promo_code_usages WHERE operation_id = @operation_id`
+		usageArgs := pgx.NamedArgs{
+			"operation_id": order.OperationID,
+		}
+
+		_, err = tx.Exec(ctx, usageQuery, usageArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	inventoryArgs := pgx.NamedArgs{
+		"user_id":    order.UserID,
+		"product_id": order.ProductID,
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE inventory SET quantity = quantity - 1 WHERE user_id = @user_id AND product_id = @product_id AND quantity > 1`, inventoryArgs)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		_, err = tx.Exec(ctx, `DELETE FROM inventory WHERE user_id = @user_id AND product_id = @product_id`, inventoryArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	operationQuery := `
+        INSERT INTO operations (user_id, amount, type, product_id, variant_id)
+        VALUES (@user_id, @amount, @type, @product_id, @variant_id)
+    `
+	operationArgs := pgx.NamedArgs{
+		"user_id":    order.UserID,
+		"amount":     amount,
+		"type":       model.OperationTypeRefund,
+		"product_id": order.ProductID,
+		"variant_id": order.VariantID,
+	}
+
+	_, err = tx.Exec(ctx, operationQuery, operationArgs)
+	return err
+}
+
+func scanOrders(rows pgx.Rows) ([]entity.Order, error) {
+	orders := []entity.Order{}
+	for rows.Next() {
+		var order entity.Order
+		err := rows.Scan(
+			&order.ID,
+			&order.Username,
+			&order.Product,
+			&order.Variant,
+			&order.Amount,
+			&order.Status,
+			&order.Location,
+			&order.CreatedAt,
+			&order.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	return orders, nil
+}
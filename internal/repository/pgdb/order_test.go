@@ -0,0 +1,316 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/model"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderRepository_UpdateOrderStatus(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		id     int
+		status string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	createdAt := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+	updatedAt := createdAt.Add(time.Hour)
+	operationID := uuid.New()
+	orderColumns := []string{"id", "username", "product", "variant", "amount", "status", "location", "created_at", "updated_at"}
+
+	testCases := []struct {
+		name          string
+		args          args
+		mockBehavior  MockBehavior
+		expectedOrder entity.Order
+		wantErr       error
+	}{
+		{
+			name: "Packed",
+			args: args{
+				ctx:    context.Background(),
+				id:     1,
+				status: model.OrderStatusPacked,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				m.ExpectQuery("SELECT o.operation_id, o.user_id, o.product_id, o.variant_id, o.status, op.amount, op.promo_code_id FROM orders o (.+) FOR UPDATE OF o").
+					WithArgs(pgx.NamedArgs{"id": args.id}).
+					WillReturnRows(pgxmock.NewRows([]string{"operation_id", "user_id", "product_id", "variant_id", "status", "amount", "promo_code_id"}).
+						AddRow(operationID, 2, 3, nil, model.OrderStatusPlaced, 500, nil))
+
+				m.ExpectExec("UPDATE orders SET status = @status, updated_at = NOW\\(\\) WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": args.id, "status": args.status}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				m.ExpectQuery("FROM orders o (.+) WHERE o.id = @id").
+					WithArgs(pgx.NamedArgs{"id": args.id}).
+					WillReturnRows(pgxmock.NewRows(orderColumns).
+						AddRow(1, "user", "powerbank", "", 500, model.OrderStatusPacked, "3rd floor", createdAt, updatedAt))
+
+				m.ExpectCommit()
+			},
+			expectedOrder: entity.Order{
+				ID:        1,
+				Username:  "user",
+				Product:   "powerbank",
+				Amount:    500,
+				Status:    model.OrderStatusPacked,
+				Location:  "3rd floor",
+				CreatedAt: createdAt,
+				UpdatedAt: updatedAt,
+			},
+		},
+		{
+			name: "Cancelled With Refund",
+			args: args{
+				ctx:    context.Background(),
+				id:     1,
+				status: model.OrderStatusCancelled,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				variantID := 4
+				promoCodeID := 5
+				m.ExpectQuery("SELECT o.operation_id, o.user_id, o.product_id, o.variant_id, o.status, op.amount, op.promo_code_id FROM orders o").
+					WithArgs(pgx.NamedArgs{"id": args.id}).
+					WillReturnRows(pgxmock.NewRows([]string{"operation_id", "user_id", "product_id", "variant_id", "status", "amount", "promo_code_id"}).
+						AddRow(operationID, 2, 3, &variantID, model.OrderStatusPacked, 300, &promoCodeID))
+
+				m.ExpectExec("UPDATE orders SET status").
+					WithArgs(pgx.NamedArgs{"id": args.id, "status": args.status}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				m.ExpectExec("UPDATE users SET balance = balance \\+ @amount, info_version = info_version \\+ 1 WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": 2, "amount": 300}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				m.ExpectQuery("UPDATE product_variants SET stock = stock \\+ 1 WHERE id = @id AND stock IS NOT NULL RETURNING stock").
+					WithArgs(pgx.NamedArgs{"id": variantID}).
+					WillReturnRows(pgxmock.NewRows([]string{"stock"}).AddRow(1))
+
+				m.ExpectExec("INSERT INTO stock_changes").
+					WithArgs(pgx.NamedArgs{"product_id": 3, "variant_id": &variantID, "stock": 1, "reason": "order cancelled"}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectQuery("SELECT EXISTS").
+					WithArgs(pgx.NamedArgs{"product_id": 3, "variant_id": variantID}).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+
+				m.ExpectExec("INSERT INTO notifications").
+					WithArgs(pgx.NamedArgs{"product_id": 3, "type": model.NotificationTypeBackInStock}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 2))
+
+				m.ExpectExec("UPDATE promo_codes SET uses = uses - 1 WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": promoCodeID}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				m.ExpectExec("DELETE FROM promo_code_usages WHERE operation_id = @operation_id").
+					WithArgs(pgx.NamedArgs{"operation_id": operationID}).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+				m.ExpectExec("UPDATE inventory SET quantity = quantity - 1").
+					WithArgs(pgx.NamedArgs{"user_id": 2, "product_id": 3}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+				m.ExpectExec("DELETE FROM inventory WHERE user_id = @user_id AND product_id = @product_id").
+					WithArgs(pgx.NamedArgs{"user_id": 2, "product_id": 3}).
+					WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+				m.ExpectExec("INSERT INTO operations").
+					WithArgs(pgx.NamedArgs{
+						"user_id":    2,
+						"amount":     300,
+						"type":       model.OperationTypeRefund,
+						"product_id": 3,
+						"variant_id": &variantID,
+					}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectQuery("FROM orders o (.+) WHERE o.id = @id").
+					WithArgs(pgx.NamedArgs{"id": args.id}).
+					WillReturnRows(pgxmock.NewRows(orderColumns).
+						AddRow(1, "user", "hoody", "M", 300, model.OrderStatusCancelled, "", createdAt, updatedAt))
+
+				m.ExpectCommit()
+			},
+			expectedOrder: entity.Order{
+				ID:        1,
+				Username:  "user",
+				Product:   "hoody",
+				Variant:   "M",
+				Amount:    300,
+				Status:    model.OrderStatusCancelled,
+				CreatedAt: createdAt,
+				UpdatedAt: updatedAt,
+			},
+		},
+		{
+			name: "Cancelled Unlimited Product",
+			args: args{
+				ctx:    context.Background(),
+				id:     2,
+				status: model.OrderStatusCancelled,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				m.ExpectQuery("SELECT o.operation_id, o.user_id, o.product_id, o.variant_id, o.status, op.amount, op.promo_code_id FROM orders o").
+					WithArgs(pgx.NamedArgs{"id": args.id}).
+					WillReturnRows(pgxmock.NewRows([]string{"operation_id", "user_id", "product_id", "variant_id", "status", "amount", "promo_code_id"}).
+						AddRow(operationID, 2, 3, nil, model.OrderStatusPlaced, 500, nil))
+
+				m.ExpectExec("UPDATE orders SET status").
+					WithArgs(pgx.NamedArgs{"id": args.id, "status": args.status}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				m.ExpectExec("UPDATE users SET balance = balance \\+ @amount, info_version = info_version \\+ 1 WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": 2, "amount": 500}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				m.ExpectQuery("UPDATE products SET stock = stock \\+ 1 WHERE id = @id AND stock IS NOT NULL RETURNING stock").
+					WithArgs(pgx.NamedArgs{"id": 3}).
+					WillReturnError(pgx.ErrNoRows)
+
+				m.ExpectExec("UPDATE inventory SET quantity = quantity - 1").
+					WithArgs(pgx.NamedArgs{"user_id": 2, "product_id": 3}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				m.ExpectExec("INSERT INTO operations").
+					WithArgs(pgx.NamedArgs{
+						"user_id":    2,
+						"amount":     500,
+						"type":       model.OperationTypeRefund,
+						"product_id": 3,
+						"variant_id": (*int)(nil),
+					}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectQuery("FROM orders o (.+) WHERE o.id = @id").
+					WithArgs(pgx.NamedArgs{"id": args.id}).
+					WillReturnRows(pgxmock.NewRows(orderColumns).
+						AddRow(2, "user", "powerbank", "", 500, model.OrderStatusCancelled, "", createdAt, updatedAt))
+
+				m.ExpectCommit()
+			},
+			expectedOrder: entity.Order{
+				ID:        2,
+				Username:  "user",
+				Product:   "powerbank",
+				Amount:    500,
+				Status:    model.OrderStatusCancelled,
+				CreatedAt: createdAt,
+				UpdatedAt: updatedAt,
+			},
+		},
+		{
+			name: "Order Not Found",
+			args: args{
+				ctx:    context.Background(),
+				id:     42,
+				status: model.OrderStatusPacked,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				m.ExpectQuery("SELECT o.operation_id, o.user_id, o.product_id, o.variant_id, o.status, op.amount, op.promo_code_id FROM orders o").
+					WithArgs(pgx.NamedArgs{"id": args.id}).
+					WillReturnError(pgx.ErrNoRows)
+
+				m.ExpectRollback()
+			},
+			wantErr: repoerrs.ErrOrderNotFound,
+		},
+		{
+			name: "Invalid Transition",
+			args: args{
+				ctx:    context.Background(),
+				id:     1,
+				status: model.OrderStatusCancelled,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				m.ExpectQuery("SELECT o.operation_id, o.user_id, o.product_id, o.variant_id, o.status, op.amount, op.promo_code_id FROM orders o").
+					WithArgs(pgx.NamedArgs{"id": args.id}).
+					WillReturnRows(pgxmock.NewRows([]string{"operation_id", "user_id", "product_id", "variant_id", "status", "amount", "promo_code_id"}).
+						AddRow(operationID, 2, 3, nil, model.OrderStatusDelivered, 500, nil))
+
+				m.ExpectRollback()
+			},
+			wantErr: repoerrs.ErrInvalidOrderTransition,
+		},
+		{
+			name: "Refund Error",
+			args: args{
+				ctx:    context.Background(),
+				id:     1,
+				status: model.OrderStatusCancelled,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				m.ExpectQuery("SELECT o.operation_id, o.user_id, o.product_id, o.variant_id, o.status, op.amount, op.promo_code_id FROM orders o").
+					WithArgs(pgx.NamedArgs{"id": args.id}).
+					WillReturnRows(pgxmock.NewRows([]string{"operation_id", "user_id", "product_id", "variant_id", "status", "amount", "promo_code_id"}).
+						AddRow(operationID, 2, 3, nil, model.OrderStatusPlaced, 500, nil))
+
+				m.ExpectExec("UPDATE orders SET status").
+					WithArgs(pgx.NamedArgs{"id": args.id, "status": args.status}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+				m.ExpectExec("UPDATE users SET balance = balance \\+ @amount, info_version = info_version \\+ 1 WHERE id = @id").
+					WithArgs(pgx.NamedArgs{"id": 2, "amount": 500}).
+					WillReturnError(errors.New("refund error"))
+
+				m.ExpectRollback()
+			},
+			wantErr: errors.New("refund error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("Failed to create mock pool: %v", err)
+			}
+			defer poolMock.Close()
+
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Pool: poolMock,
+			}
+			orderRepo := NewOrderRepository(postgresMock)
+
+			order, err := orderRepo.UpdateOrderStatus(tc.args.ctx, tc.args.id, tc.args.status)
+
+			if tc.wantErr != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedOrder, order)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,415 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/model"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type ProductRepository struct {
+	*postgres.Postgres
+}
+
+func NewProductRepository(pg *postgres.Postgres) *ProductRepository {
+	return &ProductRepository{pg}
+}
+
+func (r *ProductRepository) Restock(ctx context.Context, product string, variant string, delta int, reason string, changedBy string) (int, error) {
+	const op = "repository.ProductRepository.Restock"
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var productID int
+	var variantID *int
+	var stock int
+	var wasUnlimited bool
+	// Products with variants keep their stock per variant, so the product itself cannot be restocked.
+	// The first restock of unlimited (NULL) stock starts counting from zero, which makes the stock limited.
+	updateStockQuery := `
+		UPDATE products p SET stock = COALESCE(p.stock, 0) + @delta
+		FROM products old
+		WHERE old.id = p.id AND p.name = @product AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)
+		RETURNING p.id, p.stock, old.stock IS NULL`
+	updateStockArgs := pgx.NamedArgs{
+		"product": product,
+		"delta":   delta,
+	}
+	if variant != "" {
+		variantID = new(int)
+		updateStockQuery = `
+			UPDATE product_variants v SET stock = COALESCE(v.stock, 0) + @delta
+			FROM products p, product_variants old
+			WHERE old.id = v.id AND v.product_id = p.id AND p.name = @product AND v.name = @variant
+			RETURNING p.id, v.id, v.stock, old.stock IS NULL`
+		updateStockArgs["variant"] = variant
+	}
+
+	if variantID != nil {
+		err = tx.QueryRow(ctx, updateStockQuery, updateStockArgs).Scan(&productID, variantID, &stock, &wasUnlimited)
+	} else {
+		err = tx.QueryRow(ctx, updateStockQuery, updateStockArgs).Scan(&productID, &stock, &wasUnlimited)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if variantID != nil {
+				return 0, fmt.Errorf("%s: %w", op, repoerrs.ErrVariantNotFound)
+			}
+
+			var exists bool
+			existsQuery := `SELECT EXISTS (SELECT 1 FROM products WHERE name = @product)`
+			existsArgs := pgx.NamedArgs{
+				"product": product,
+			}
+
+			if err := tx.QueryRow(ctx, existsQuery, existsArgs).Scan(&exists); err != nil {
+				return 0, fmt.Errorf("%s: %w", op, err)
+			}
+			if exists {
+				return 0, fmt.Errorf("%s: %w", op, repoerrs.ErrVariantRequired)
+			}
+			return 0, fmt.Errorf("%s: %w", op, repoerrs.ErrProductNotFound)
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23514" {
+			return 0, fmt.Errorf("%s: %w", op, repoerrs.ErrNegativeStock)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	historyQuery := `
+        INSERT INTO stock_changes (product_id, variant_id, delta, stock, reason, changed_by)
+        VALUES (@product_id, @variant_id, @delta, @stock, @reason, (SELECT id FROM users WHERE username = @changed_by))
+    `
+	historyArgs := pgx.NamedArgs{
+		"product_id": productID,
+		"variant_id": variantID,
+		"delta":      delta,
+		"stock":      stock,
+		"reason":     reason,
+		"changed_by": changedBy,
+	}
+
+	_, err = tx.Exec(ctx, historyQuery, historyArgs)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The product is back in stock when it was unavailable before the restock and is available after it.
+	// Unlimited stock was available.
+	if !wasUnlimited && stock-delta <= 0 && stock > 0 {
+		if err := notifyBackInStock(ctx, tx, productID, variantID); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stock, nil
+}
+
+func (r *ProductRepository) AddVariant(ctx context.Context, product string, variant entity.ProductVariant) error {
+	const op = "repository.ProductRepository.AddVariant"
+
+	attributes := variant.Attributes
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+
+	query := `
+        INSERT INTO product_variants (product_id, name, attributes, stock)
+        SELECT id, @name, @attributes, @stock FROM products WHERE name = @product
+    `
+	args := pgx.NamedArgs{
+		"product":    product,
+		"name":       variant.Name,
+		"attributes": attributes,
+		"stock":      variant.Stock,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, repoerrs.ErrVariantAlreadyExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrProductNotFound)
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) SetPurchaseLimit(ctx context.Context, product string, limit *int) error {
+	const op = "repository.ProductRepository.SetPurchaseLimit"
+
+	query := `UPDATE products SET purchase_limit = @limit WHERE name = @product`
+	args := pgx.NamedArgs{
+		"product": product,
+		"limit":   limit,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrProductNotFound)
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) SetPrice(ctx context.Context, product string, price int) error {
+	const op = "repository.ProductRepository.SetPrice"
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var productID int
+	var oldPrice int
+	query := `
+		UPDATE products p SET price = @price
+		FROM (SELECT id, price FROM products WHERE name = @product FOR UPDATE) old
+		WHERE p.id = old.id
+		RETURNING p.id, old.price`
+	args := pgx.NamedArgs{
+		"product": product,
+		"price":   price,
+	}
+
+	err = tx.QueryRow(ctx, query, args).Scan(&productID, &oldPrice)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, repoerrs.ErrProductNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if price < oldPrice {
+		if err := notifyWishlisters(ctx, tx, productID, model.NotificationTypePriceDrop); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) GetStockHistory(ctx context.Context, product string) ([]entity.StockChange, error) {
+	const op = "repository.ProductRepository.GetStockHistory"
+
+	var productID int
+	productQuery := `SELECT id FROM products WHERE name = @product`
+	productArgs := pgx.NamedArgs{
+		"product": product,
+	}
+
+	err := r.Pool.QueryRow(ctx, productQuery, productArgs).Scan(&productID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, repoerrs.ErrProductNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	historyQuery := `
+		SELECT
+			COALESCE(v.name, '') AS variant,
+			sc.delta,
+			sc.stock,
+			sc.reason,
+			COALESCE(u.username, '') AS changed_by,
+			sc.created_at
+		FROM stock_changes sc
+		LEFT JOIN product_variants v ON sc.variant_id = v.id
+		LEFT JOIN users u ON sc.changed_by = u.id
+		WHERE sc.product_id = @product_id
+		ORDER BY sc.created_at DESC, sc.id DESC`
+	historyArgs := pgx.NamedArgs{
+		"product_id": productID,
+	}
+
+	rows, err := r.Pool.Query(ctx, historyQuery, historyArgs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	history := []entity.StockChange{}
+	for rows.Next() {
+		var change entity.StockChange
+		if err := rows.Scan(&change.Variant, &change.Delta, &change.Stock, &change.Reason, &change.ChangedBy, &change.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		history = append(history, change)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", op, rows.Err())
+	}
+
+	return history, nil
+}
+
+func (r *ProductRepository) SearchProducts(ctx context.Context, filter entity.ProductFilter) ([]entity.Product, error) {
+	const op = "repository.ProductRepository.SearchProducts"
+
+	// Products with variants are available while at least one of the variants is in stock.
+	productsQuery := `
+		WITH catalog AS (
+			SELECT
+				p.id,
+				p.name,
+				p.description,
+				p.price,
+				COALESCE(c.name, '') AS category,
+				p.attributes,
+				p.stock,
+				CASE
+					WHEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)
+					THEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id AND (v.stock IS NULL OR v.stock > 0))
+					ELSE p.stock IS NULL OR p.stock > 0
+				END AS available,
+				CASE
+					WHEN @query = '' THEN 0
+					ELSE ts_rank(p.search_vector, websearch_to_tsquery('english', @query))
+				END AS rank
+			FROM products p
+			LEFT JOIN categories c ON p.category_id = c.id
+			WHERE (@query = '' OR p.search_vector @@ websearch_to_tsquery('english', @query))
+			AND (@category = '' OR c.name = @category)
+			AND (@min_price::INT IS NULL OR p.price >= @min_price)
+			AND (@max_price::INT IS NULL OR p.price <= @max_price)
+		)
+		SELECT id, name, description, price, category, attributes, stock, available
+		FROM catalog
+		WHERE NOT @available OR available
+		ORDER BY rank DESC, name`
+	productsArgs := pgx.NamedArgs{
+		"query":     filter.Query,
+		"category":  filter.Category,
+		"min_price": filter.MinPrice,
+		"max_price": filter.MaxPrice,
+		"available": filter.Available,
+	}
+
+	rows, err := r.Pool.Query(ctx, productsQuery, productsArgs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	products := []entity.Product{}
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		var product entity.Product
+		err := rows.Scan(
+			&id,
+			&product.Name,
+			&product.Description,
+			&product.Price,
+			&product.Category,
+			&product.Attributes,
+			&product.Stock,
+			&product.Available,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		products = append(products, product)
+		ids = append(ids, id)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", op, rows.Err())
+	}
+
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	variantsQuery := `
+		SELECT product_id, name, attributes, stock
+		FROM product_variants
+		WHERE product_id = ANY(@ids)
+		ORDER BY product_id, id`
+	variantsArgs := pgx.NamedArgs{
+		"ids": ids,
+	}
+
+	rows, err = r.Pool.Query(ctx, variantsQuery, variantsArgs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	positions := make(map[int]int, len(ids))
+	for i, id := range ids {
+		positions[id] = i
+	}
+
+	for rows.Next() {
+		var productID int
+		var variant entity.ProductVariant
+		if err := rows.Scan(&productID, &variant.Name, &variant.Attributes, &variant.Stock); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		i := positions[productID]
+		products[i].Variants = append(products[i].Variants, variant)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", op, rows.Err())
+	}
+
+	return products, nil
+}
+
+// notifyBackInStock notifies the wishlisters of the product whose stock, or the stock of
+// the given variant, has just become available within the current transaction.
+// A product with variants is available while any of its variants is, so nobody is notified
+// when another variant was already in stock.
+func notifyBackInStock(ctx context.Context, tx pgx.Tx, productID int, variantID *int) error {
+	if variantID != nil {
+		var otherAvailable bool
+		otherAvailableQuery := `
+			SELECT EXISTS (
+				SELECT 1 FROM product_variants
+				WHERE product_id = @product_id AND id <> @variant_id AND (stock IS NULL OR stock > 0)
+			)`
+		otherAvailableArgs := pgx.NamedArgs{
+			"product_id": productID,
+			"variant_id": *variantID,
+		}
+
+		if err := tx.QueryRow(ctx, otherAvailableQuery, otherAvailableArgs).Scan(&otherAvailable); err != nil {
+			return err
+		}
+		if otherAvailable {
+			return nil
+		}
+	}
+
+	return notifyWishlisters(ctx, tx, productID, model.NotificationTypeBackInStock)
+}
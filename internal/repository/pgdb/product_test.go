@@ -0,0 +1,607 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/model"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductRepository_Restock(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		product   string
+		variant   string
+		delta     int
+		reason    string
+		changedBy string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name          string
+		args          args
+		mockBehavior  MockBehavior
+		expectedStock int
+		wantErr       error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:       context.Background(),
+				product:   "powerbank",
+				delta:     10,
+				reason:    "delivery",
+				changedBy: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				rows := pgxmock.NewRows([]string{"id", "stock", "was_unlimited"}).
+					AddRow(5, 12, false)
+				m.ExpectQuery("UPDATE products p SET stock = COALESCE\\(p.stock, 0\\) \\+ @delta FROM products old WHERE old.id = p.id AND p.name = @product AND NOT EXISTS (.+) RETURNING p.id, p.stock, old.stock IS NULL").
+					WithArgs(pgx.NamedArgs{"product": args.product, "delta": args.delta}).
+					WillReturnRows(rows)
+
+				m.ExpectExec("INSERT INTO stock_changes").
+					WithArgs(pgx.NamedArgs{
+						"product_id": 5,
+						"variant_id": (*int)(nil),
+						"delta":      args.delta,
+						"stock":      12,
+						"reason":     args.reason,
+						"changed_by": args.changedBy,
+					}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectCommit()
+			},
+			expectedStock: 12,
+		},
+		{
+			name: "Back In Stock",
+			args: args{
+				ctx:       context.Background(),
+				product:   "powerbank",
+				delta:     10,
+				reason:    "delivery",
+				changedBy: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				rows := pgxmock.NewRows([]string{"id", "stock", "was_unlimited"}).
+					AddRow(5, 10, false)
+				m.ExpectQuery("UPDATE products p SET stock").
+					WithArgs(pgx.NamedArgs{"product": args.product, "delta": args.delta}).
+					WillReturnRows(rows)
+
+				m.ExpectExec("INSERT INTO stock_changes").
+					WithArgs(pgx.NamedArgs{
+						"product_id": 5,
+						"variant_id": (*int)(nil),
+						"delta":      args.delta,
+						"stock":      10,
+						"reason":     args.reason,
+						"changed_by": args.changedBy,
+					}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectExec("INSERT INTO notifications (.+) FROM wishlists w").
+					WithArgs(pgx.NamedArgs{"product_id": 5, "type": model.NotificationTypeBackInStock}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 2))
+
+				m.ExpectCommit()
+			},
+			expectedStock: 10,
+		},
+		{
+			name: "Product Not Found",
+			args: args{
+				ctx:       context.Background(),
+				product:   "unknown",
+				delta:     10,
+				changedBy: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				m.ExpectQuery("UPDATE products p SET stock").
+					WithArgs(pgx.NamedArgs{"product": args.product, "delta": args.delta}).
+					WillReturnError(pgx.ErrNoRows)
+
+				m.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM products WHERE name = @product\\)").
+					WithArgs(pgx.NamedArgs{"product": args.product}).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+
+				m.ExpectRollback()
+			},
+			wantErr: repoerrs.ErrProductNotFound,
+		},
+		{
+			name: "Variant Required",
+			args: args{
+				ctx:       context.Background(),
+				product:   "hoody",
+				delta:     10,
+				changedBy: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				m.ExpectQuery("UPDATE products p SET stock").
+					WithArgs(pgx.NamedArgs{"product": args.product, "delta": args.delta}).
+					WillReturnError(pgx.ErrNoRows)
+
+				m.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM products WHERE name = @product\\)").
+					WithArgs(pgx.NamedArgs{"product": args.product}).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+				m.ExpectRollback()
+			},
+			wantErr: repoerrs.ErrVariantRequired,
+		},
+		{
+			name: "Variant OK",
+			args: args{
+				ctx:       context.Background(),
+				product:   "hoody",
+				variant:   "M",
+				delta:     5,
+				reason:    "delivery",
+				changedBy: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				rows := pgxmock.NewRows([]string{"id", "id", "stock", "was_unlimited"}).
+					AddRow(3, 8, 7, false)
+				m.ExpectQuery("UPDATE product_variants v SET stock = COALESCE\\(v.stock, 0\\) \\+ @delta FROM products p, product_variants old").
+					WithArgs(pgx.NamedArgs{"product": args.product, "variant": args.variant, "delta": args.delta}).
+					WillReturnRows(rows)
+
+				variantID := 8
+				m.ExpectExec("INSERT INTO stock_changes").
+					WithArgs(pgx.NamedArgs{
+						"product_id": 3,
+						"variant_id": &variantID,
+						"delta":      args.delta,
+						"stock":      7,
+						"reason":     args.reason,
+						"changed_by": args.changedBy,
+					}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectCommit()
+			},
+			expectedStock: 7,
+		},
+		{
+			name: "Variant Back In Stock While Others Available",
+			args: args{
+				ctx:       context.Background(),
+				product:   "hoody",
+				variant:   "M",
+				delta:     5,
+				changedBy: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				rows := pgxmock.NewRows([]string{"id", "id", "stock", "was_unlimited"}).
+					AddRow(3, 8, 5, false)
+				m.ExpectQuery("UPDATE product_variants v SET stock").
+					WithArgs(pgx.NamedArgs{"product": args.product, "variant": args.variant, "delta": args.delta}).
+					WillReturnRows(rows)
+
+				variantID := 8
+				m.ExpectExec("INSERT INTO stock_changes").
+					WithArgs(pgx.NamedArgs{
+						"product_id": 3,
+						"variant_id": &variantID,
+						"delta":      args.delta,
+						"stock":      5,
+						"reason":     args.reason,
+						"changed_by": args.changedBy,
+					}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectQuery("SELECT EXISTS \\( SELECT 1 FROM product_variants WHERE product_id = @product_id AND id <> @variant_id").
+					WithArgs(pgx.NamedArgs{"product_id": 3, "variant_id": 8}).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+				m.ExpectCommit()
+			},
+			expectedStock: 5,
+		},
+		{
+			name: "Variant Back In Stock",
+			args: args{
+				ctx:       context.Background(),
+				product:   "hoody",
+				variant:   "M",
+				delta:     5,
+				changedBy: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				rows := pgxmock.NewRows([]string{"id", "id", "stock", "was_unlimited"}).
+					AddRow(3, 8, 5, false)
+				m.ExpectQuery("UPDATE product_variants v SET stock").
+					WithArgs(pgx.NamedArgs{"product": args.product, "variant": args.variant, "delta": args.delta}).
+					WillReturnRows(rows)
+
+				variantID := 8
+				m.ExpectExec("INSERT INTO stock_changes").
+					WithArgs(pgx.NamedArgs{
+						"product_id": 3,
+						"variant_id": &variantID,
+						"delta":      args.delta,
+						"stock":      5,
+						"reason":     args.reason,
+						"changed_by": args.changedBy,
+					}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectQuery("SELECT EXISTS \\( SELECT 1 FROM product_variants").
+					WithArgs(pgx.NamedArgs{"product_id": 3, "variant_id": 8}).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+
+				m.ExpectExec("INSERT INTO notifications (.+) FROM wishlists w").
+					WithArgs(pgx.NamedArgs{"product_id": 3, "type": model.NotificationTypeBackInStock}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectCommit()
+			},
+			expectedStock: 5,
+		},
+		{
+			name: "Variant Not Found",
+			args: args{
+				ctx:       context.Background(),
+				product:   "hoody",
+				variant:   "XXL",
+				delta:     5,
+				changedBy: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				m.ExpectQuery("UPDATE product_variants v SET stock").
+					WithArgs(pgx.NamedArgs{"product": args.product, "variant": args.variant, "delta": args.delta}).
+					WillReturnError(pgx.ErrNoRows)
+
+				m.ExpectRollback()
+			},
+			wantErr: repoerrs.ErrVariantNotFound,
+		},
+		{
+			name: "Negative Stock",
+			args: args{
+				ctx:       context.Background(),
+				product:   "powerbank",
+				delta:     -100,
+				changedBy: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				m.ExpectQuery("UPDATE products p SET stock").
+					WithArgs(pgx.NamedArgs{"product": args.product, "delta": args.delta}).
+					WillReturnError(&pgconn.PgError{Code: "23514"})
+
+				m.ExpectRollback()
+			},
+			wantErr: repoerrs.ErrNegativeStock,
+		},
+		{
+			name: "Unlimited Stock Becomes Limited",
+			args: args{
+				ctx:       context.Background(),
+				product:   "powerbank",
+				delta:     10,
+				reason:    "inventory",
+				changedBy: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				// Unlimited stock counts from zero and was available, so nobody is notified.
+				rows := pgxmock.NewRows([]string{"id", "stock", "was_unlimited"}).
+					AddRow(5, 10, true)
+				m.ExpectQuery("UPDATE products p SET stock").
+					WithArgs(pgx.NamedArgs{"product": args.product, "delta": args.delta}).
+					WillReturnRows(rows)
+
+				m.ExpectExec("INSERT INTO stock_changes").
+					WithArgs(pgx.NamedArgs{
+						"product_id": 5,
+						"variant_id": (*int)(nil),
+						"delta":      args.delta,
+						"stock":      10,
+						"reason":     args.reason,
+						"changed_by": args.changedBy,
+					}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectCommit()
+			},
+			expectedStock: 10,
+		},
+		{
+			name: "Insert History Error",
+			args: args{
+				ctx:       context.Background(),
+				product:   "powerbank",
+				delta:     10,
+				changedBy: "admin",
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				rows := pgxmock.NewRows([]string{"id", "stock", "was_unlimited"}).
+					AddRow(5, 12, false)
+				m.ExpectQuery("UPDATE products p SET stock").
+					WithArgs(pgx.NamedArgs{"product": args.product, "delta": args.delta}).
+					WillReturnRows(rows)
+
+				m.ExpectExec("INSERT INTO stock_changes").
+					WithArgs(pgx.NamedArgs{
+						"product_id": 5,
+						"variant_id": (*int)(nil),
+						"delta":      args.delta,
+						"stock":      12,
+						"reason":     args.reason,
+						"changed_by": args.changedBy,
+					}).
+					WillReturnError(errors.New("insert history error"))
+
+				m.ExpectRollback()
+			},
+			wantErr: errors.New("insert history error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("Failed to create mock pool: %v", err)
+			}
+			defer poolMock.Close()
+
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Pool: poolMock,
+			}
+			productRepo := NewProductRepository(postgresMock)
+
+			stock, err := productRepo.Restock(tc.args.ctx, tc.args.product, tc.args.variant, tc.args.delta, tc.args.reason, tc.args.changedBy)
+
+			if tc.wantErr != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStock, stock)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestProductRepository_SearchProducts(t *testing.T) {
+	type MockBehavior func(m pgxmock.PgxPoolIface, filter entity.ProductFilter)
+
+	stock := 4
+	maxPrice := 500
+
+	testCases := []struct {
+		name             string
+		filter           entity.ProductFilter
+		mockBehavior     MockBehavior
+		expectedProducts []entity.Product
+		wantErr          error
+	}{
+		{
+			name:   "OK",
+			filter: entity.ProductFilter{Query: "hoody", MaxPrice: &maxPrice, Available: true},
+			mockBehavior: func(m pgxmock.PgxPoolIface, filter entity.ProductFilter) {
+				productRows := pgxmock.NewRows([]string{"id", "name", "description", "price", "category", "attributes", "stock", "available"}).
+					AddRow(3, "hoody", "Warm hoody", 300, "clothes", map[string]string{"color": "grey"}, nil, true)
+				m.ExpectQuery("WITH catalog AS (.+) FROM products p (.+) websearch_to_tsquery").
+					WithArgs(pgx.NamedArgs{
+						"query":     filter.Query,
+						"category":  filter.Category,
+						"min_price": filter.MinPrice,
+						"max_price": filter.MaxPrice,
+						"available": filter.Available,
+					}).
+					WillReturnRows(productRows)
+
+				variantRows := pgxmock.NewRows([]string{"product_id", "name", "attributes", "stock"}).
+					AddRow(3, "M", map[string]string{"size": "M"}, &stock)
+				m.ExpectQuery("SELECT product_id, name, attributes, stock FROM product_variants WHERE product_id = ANY\\(@ids\\)").
+					WithArgs(pgx.NamedArgs{"ids": []int{3}}).
+					WillReturnRows(variantRows)
+			},
+			expectedProducts: []entity.Product{{
+				Name:        "hoody",
+				Description: "Warm hoody",
+				Price:       300,
+				Category:    "clothes",
+				Attributes:  map[string]string{"color": "grey"},
+				Available:   true,
+				Variants: []entity.ProductVariant{
+					{Name: "M", Attributes: map[string]string{"size": "M"}, Stock: &stock},
+				},
+			}},
+		},
+		{
+			name:   "Nothing Found",
+			filter: entity.ProductFilter{Category: "books"},
+			mockBehavior: func(m pgxmock.PgxPoolIface, filter entity.ProductFilter) {
+				m.ExpectQuery("WITH catalog AS").
+					WithArgs(pgx.NamedArgs{
+						"query":     filter.Query,
+						"category":  filter.Category,
+						"min_price": filter.MinPrice,
+						"max_price": filter.MaxPrice,
+						"available": filter.Available,
+					}).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "name", "description", "price", "category", "attributes", "stock", "available"}))
+			},
+			expectedProducts: []entity.Product{},
+		},
+		{
+			name:   "Query Error",
+			filter: entity.ProductFilter{},
+			mockBehavior: func(m pgxmock.PgxPoolIface, filter entity.ProductFilter) {
+				m.ExpectQuery("WITH catalog AS").
+					WithArgs(pgx.NamedArgs{
+						"query":     filter.Query,
+						"category":  filter.Category,
+						"min_price": filter.MinPrice,
+						"max_price": filter.MaxPrice,
+						"available": filter.Available,
+					}).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: errors.New("query error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("Failed to create mock pool: %v", err)
+			}
+			defer poolMock.Close()
+
+			tc.mockBehavior(poolMock, tc.filter)
+
+			postgresMock := &postgres.Postgres{
+				Pool: poolMock,
+			}
+			productRepo := NewProductRepository(postgresMock)
+
+			products, err := productRepo.SearchProducts(context.Background(), tc.filter)
+
+			if tc.wantErr != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedProducts, products)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestProductRepository_SetPrice(t *testing.T) {
+	type args struct {
+		product string
+		price   int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "Price Drop",
+			args: args{product: "hoody", price: 250},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				m.ExpectQuery("UPDATE products p SET price = @price (.+) RETURNING p.id, old.price").
+					WithArgs(pgx.NamedArgs{"product": args.product, "price": args.price}).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "price"}).AddRow(3, 300))
+
+				m.ExpectExec("INSERT INTO notifications (.+) FROM wishlists w").
+					WithArgs(pgx.NamedArgs{"product_id": 3, "type": model.NotificationTypePriceDrop}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+				m.ExpectCommit()
+			},
+		},
+		{
+			name: "Price Rise",
+			args: args{product: "hoody", price: 350},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				m.ExpectQuery("UPDATE products p SET price = @price").
+					WithArgs(pgx.NamedArgs{"product": args.product, "price": args.price}).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "price"}).AddRow(3, 300))
+
+				m.ExpectCommit()
+			},
+		},
+		{
+			name: "Product Not Found",
+			args: args{product: "unknown", price: 100},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectBegin()
+
+				m.ExpectQuery("UPDATE products p SET price = @price").
+					WithArgs(pgx.NamedArgs{"product": args.product, "price": args.price}).
+					WillReturnError(pgx.ErrNoRows)
+
+				m.ExpectRollback()
+			},
+			wantErr: repoerrs.ErrProductNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("Failed to create mock pool: %v", err)
+			}
+			defer poolMock.Close()
+
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Pool: poolMock,
+			}
+			productRepo := NewProductRepository(postgresMock)
+
+			err = productRepo.SetPrice(context.Background(), tc.args.product, tc.args.price)
+
+			if tc.wantErr != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type PromoRepository struct {
+	*postgres.Postgres
+}
+
+func NewPromoRepository(pg *postgres.Postgres) *PromoRepository {
+	return &PromoRepository{pg}
+}
+
+func (r *PromoRepository) AddPromoCode(ctx context.Context, promo entity.PromoCode) error {
+	const op = "repository.PromoRepository.AddPromoCode"
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var productID *int
+	if promo.Product != "" {
+		productID = new(int)
+		query := `SELECT id FROM products WHERE name = @product`
+		args := pgx.NamedArgs{
+			"product": promo.Product,
+		}
+
+		if err := tx.QueryRow(ctx, query, args).Scan(productID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%s: %w", op, repoerrs.ErrProductNotFound)
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	var categoryID *int
+	if promo.Category != "" {
+		categoryID = new(int)
+		query := `SELECT id FROM categories WHERE name = @category`
+		args := pgx.NamedArgs{
+			"category": promo.Category,
+		}
+
+		if err := tx.QueryRow(ctx, query, args).Scan(categoryID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%s: %w", op, repoerrs.ErrCategoryNotFound)
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	insertQuery := `
+        INSERT INTO promo_codes (code, discount_type, discount_value, product_id, category_id, valid_from, valid_until, max_uses, max_uses_per_user)
+        VALUES (@code, @discount_type, @discount_value, @product_id, @category_id, @valid_from, @valid_until, @max_uses, @max_uses_per_user)
+    `
+	insertArgs := pgx.NamedArgs{
+		"code":              promo.Code,
+		"discount_type":     promo.DiscountType,
+		"discount_value":    promo.DiscountValue,
+		"product_id":        productID,
+		"category_id":       categoryID,
+		"valid_from":        promo.ValidFrom,
+		"valid_until":       promo.ValidUntil,
+		"max_uses":          promo.MaxUses,
+		"max_uses_per_user": promo.MaxUsesPerUser,
+	}
+
+	_, err = tx.Exec(ctx, insertQuery, insertArgs)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return fmt.Errorf("%s: %w", op, repoerrs.ErrPromoCodeAlreadyExists)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *PromoRepository) GetPromoCodes(ctx context.Context) ([]entity.PromoCode, error) {
+	const op = "repository.PromoRepository.GetPromoCodes"
+
+	query := `
+		SELECT
+			pc.code,
+			pc.discount_type,
+			pc.discount_value,
+			COALESCE(p.name, '') AS product,
+			COALESCE(c.name, '') AS category,
+			pc.valid_from,
+			pc.valid_until,
+			pc.max_uses,
+			pc.max_uses_per_user,
+			pc.uses
+		FROM promo_codes pc
+		LEFT JOIN products p ON pc.product_id = p.id
+		LEFT JOIN categories c ON pc.category_id = c.id
+		ORDER BY pc.created_at DESC, pc.id DESC`
+
+	rows, err := r.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	promos := []entity.PromoCode{}
+	for rows.Next() {
+		var promo entity.PromoCode
+		err := rows.Scan(
+			&promo.Code,
+			&promo.DiscountType,
+			&promo.DiscountValue,
+			&promo.Product,
+			&promo.Category,
+			&promo.ValidFrom,
+			&promo.ValidUntil,
+			&promo.MaxUses,
+			&promo.MaxUsesPerUser,
+			&promo.Uses,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		promos = append(promos, promo)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", op, rows.Err())
+	}
+
+	return promos, nil
+}
+
+func (r *PromoRepository) DeactivatePromoCode(ctx context.Context, code string) error {
+	const op = "repository.PromoRepository.DeactivatePromoCode"
+
+	query := `UPDATE promo_codes SET valid_until = NOW() WHERE code = @code AND (valid_until IS NULL OR valid_until > NOW())`
+	args := pgx.NamedArgs{
+		"code": code,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrPromoCodeNotFound)
+	}
+
+	return nil
+}
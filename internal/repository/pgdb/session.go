@@ -0,0 +1,199 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type SessionRepository struct {
+	*postgres.Postgres
+}
+
+func NewSessionRepository(pg *postgres.Postgres) *SessionRepository {
+	return &SessionRepository{pg}
+}
+
+func (r *SessionRepository) AddSession(ctx context.Context, session entity.Session) error {
+	const op = "repository.SessionRepository.AddSession"
+
+	query := `
+        INSERT INTO sessions (id, user_id, user_agent, ip, expires_at)
+        SELECT @id, id, @user_agent, @ip, @expires_at FROM users WHERE username = @username
+    `
+	args := pgx.NamedArgs{
+		"id":         session.ID,
+		"username":   session.Username,
+		"user_agent": session.UserAgent,
+		"ip":         session.IP,
+		"expires_at": session.ExpiresAt,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// GetSession returns the session, revoked and expired ones included.
+func (r *SessionRepository) GetSession(ctx context.Context, id string) (entity.Session, error) {
+	const op = "repository.SessionRepository.GetSession"
+
+	query := `
+		SELECT s.id::text, u.username, s.user_agent, s.ip, s.created_at, s.last_seen_at, s.expires_at, s.revoked_at
+		FROM sessions s
+		JOIN users u ON s.user_id = u.id
+		WHERE s.id = @id`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+
+	var session entity.Session
+	err := r.Pool.QueryRow(ctx, query, args).Scan(
+		&session.ID,
+		&session.Username,
+		&session.UserAgent,
+		&session.IP,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Session{}, fmt.Errorf("%s: %w", op, repoerrs.ErrSessionNotFound)
+		}
+		return entity.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+// GetSessions returns the sessions of the user that are neither revoked nor expired.
+func (r *SessionRepository) GetSessions(ctx context.Context, username string) ([]entity.Session, error) {
+	const op = "repository.SessionRepository.GetSessions"
+
+	query := `
+		SELECT s.id::text, s.user_agent, s.ip, s.created_at, s.last_seen_at, s.expires_at
+		FROM sessions s
+		JOIN users u ON s.user_id = u.id
+		WHERE u.username = @username AND s.revoked_at IS NULL AND s.expires_at > NOW()
+		ORDER BY s.last_seen_at DESC`
+	args := pgx.NamedArgs{
+		"username": username,
+	}
+
+	rows, err := r.Pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	sessions := []entity.Session{}
+	for rows.Next() {
+		session := entity.Session{Username: username}
+		err := rows.Scan(
+			&session.ID,
+			&session.UserAgent,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastSeenAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		sessions = append(sessions, session)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", op, rows.Err())
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepository) TouchSession(ctx context.Context, id string) error {
+	const op = "repository.SessionRepository.TouchSession"
+
+	query := `UPDATE sessions SET last_seen_at = NOW() WHERE id = @id`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+
+	if _, err := r.Pool.Exec(ctx, query, args); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *SessionRepository) RevokeSession(ctx context.Context, username string, id string) error {
+	const op = "repository.SessionRepository.RevokeSession"
+
+	query := `
+        UPDATE sessions SET revoked_at = NOW()
+        WHERE id = @id AND user_id = (SELECT id FROM users WHERE username = @username) AND revoked_at IS NULL
+    `
+	args := pgx.NamedArgs{
+		"username": username,
+		"id":       id,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrSessionNotFound)
+	}
+
+	return nil
+}
+
+// RevokeSessions revokes the active sessions of the user except exceptID, all of them
+// when it is empty, and returns the IDs of the revoked sessions.
+func (r *SessionRepository) RevokeSessions(ctx context.Context, username string, exceptID string) ([]string, error) {
+	const op = "repository.SessionRepository.RevokeSessions"
+
+	query := `
+        UPDATE sessions SET revoked_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE username = @username)
+        AND revoked_at IS NULL AND expires_at > NOW() AND id::text <> @except_id
+        RETURNING id::text
+    `
+	args := pgx.NamedArgs{
+		"username":  username,
+		"except_id": exceptID,
+	}
+
+	rows, err := r.Pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", op, rows.Err())
+	}
+
+	return ids, nil
+}
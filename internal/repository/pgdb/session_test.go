@@ -0,0 +1,249 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionRepository_AddSession(t *testing.T) {
+	session := entity.Session{
+		ID:        "7f9c2e1a-4b3d-4c8e-9a6f-0d1e2f3a4b5c",
+		Username:  "user1",
+		UserAgent: "curl/8.5.0",
+		IP:        "10.0.0.1",
+		ExpiresAt: time.Date(2025, 2, 1, 13, 0, 0, 0, time.UTC),
+	}
+	args := pgx.NamedArgs{
+		"id":         session.ID,
+		"username":   session.Username,
+		"user_agent": session.UserAgent,
+		"ip":         session.IP,
+		"expires_at": session.ExpiresAt,
+	}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m pgxmock.PgxPoolIface)
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO sessions").
+					WithArgs(args).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			},
+		},
+		{
+			name: "User Not Found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("INSERT INTO sessions").
+					WithArgs(args).
+					WillReturnResult(pgxmock.NewResult("INSERT", 0))
+			},
+			wantErr: repoerrs.ErrUserNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			repo := NewSessionRepository(&postgres.Postgres{Pool: poolMock})
+
+			err := repo.AddSession(context.Background(), session)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSessionRepository_GetSession(t *testing.T) {
+	const id = "7f9c2e1a-4b3d-4c8e-9a6f-0d1e2f3a4b5c"
+	at := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"id", "username", "user_agent", "ip", "created_at", "last_seen_at", "expires_at", "revoked_at"}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m pgxmock.PgxPoolIface)
+		want         entity.Session
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM sessions").
+					WithArgs(pgx.NamedArgs{"id": id}).
+					WillReturnRows(pgxmock.NewRows(columns).AddRow(id, "user1", "curl/8.5.0", "10.0.0.1", at, at, at, &at))
+			},
+			want: entity.Session{
+				ID:         id,
+				Username:   "user1",
+				UserAgent:  "curl/8.5.0",
+				IP:         "10.0.0.1",
+				CreatedAt:  at,
+				LastSeenAt: at,
+				ExpiresAt:  at,
+				RevokedAt:  &at,
+			},
+		},
+		{
+			name: "Session Not Found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT (.+) FROM sessions").
+					WithArgs(pgx.NamedArgs{"id": id}).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			wantErr: repoerrs.ErrSessionNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			repo := NewSessionRepository(&postgres.Postgres{Pool: poolMock})
+
+			session, err := repo.GetSession(context.Background(), id)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, session)
+			}
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSessionRepository_RevokeSession(t *testing.T) {
+	args := pgx.NamedArgs{
+		"username": "user1",
+		"id":       "7f9c2e1a-4b3d-4c8e-9a6f-0d1e2f3a4b5c",
+	}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m pgxmock.PgxPoolIface)
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE sessions SET revoked_at").
+					WithArgs(args).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "Session Not Found",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectExec("UPDATE sessions SET revoked_at").
+					WithArgs(args).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoerrs.ErrSessionNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			repo := NewSessionRepository(&postgres.Postgres{Pool: poolMock})
+
+			err := repo.RevokeSession(context.Background(), "user1", "7f9c2e1a-4b3d-4c8e-9a6f-0d1e2f3a4b5c")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSessionRepository_RevokeSessions(t *testing.T) {
+	args := pgx.NamedArgs{
+		"username":  "user1",
+		"except_id": "current",
+	}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m pgxmock.PgxPoolIface)
+		want         []string
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("UPDATE sessions SET revoked_at").
+					WithArgs(args).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow("session1").AddRow("session2"))
+			},
+			want: []string{"session1", "session2"},
+		},
+		{
+			name: "Nothing To Revoke",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("UPDATE sessions SET revoked_at").
+					WithArgs(args).
+					WillReturnRows(pgxmock.NewRows([]string{"id"}))
+			},
+			want: []string{},
+		},
+		{
+			name: "Query Error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("UPDATE sessions SET revoked_at").
+					WithArgs(args).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			repo := NewSessionRepository(&postgres.Postgres{Pool: poolMock})
+
+			ids, err := repo.RevokeSessions(context.Background(), "user1", "current")
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, ids)
+			}
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type TwoFactorRepository struct {
+	*postgres.Postgres
+}
+
+func NewTwoFactorRepository(pg *postgres.Postgres) *TwoFactorRepository {
+	return &TwoFactorRepository{pg}
+}
+
+func (r *TwoFactorRepository) GetTwoFactor(ctx context.Context, username string) (entity.TwoFactor, error) {
+	const op = "repository.TwoFactorRepository.GetTwoFactor"
+
+	query := `SELECT COALESCE(totp_secret, ''), totp_enabled, totp_last_step FROM users WHERE username = @username`
+	args := pgx.NamedArgs{
+		"username": username,
+	}
+
+	var twoFactor entity.TwoFactor
+	err := r.Pool.QueryRow(ctx, query, args).Scan(&twoFactor.Secret, &twoFactor.Enabled, &twoFactor.LastStep)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.TwoFactor{}, fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
+		}
+		return entity.TwoFactor{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return twoFactor, nil
+}
+
+// SetTOTPSecret stores a secret that is not used for login until EnableTwoFactor.
+// It fails with ErrTwoFactorAlreadyEnabled rather than replace the secret of an enabled account.
+func (r *TwoFactorRepository) SetTOTPSecret(ctx context.Context, username string, secret string) error {
+	const op = "repository.TwoFactorRepository.SetTOTPSecret"
+
+	query := `
+        UPDATE users SET totp_secret = @secret, totp_last_step = NULL
+        WHERE username = @username AND NOT totp_enabled
+    `
+	args := pgx.NamedArgs{
+		"username": username,
+		"secret":   secret,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrTwoFactorAlreadyEnabled)
+	}
+
+	return nil
+}
+
+// EnableTwoFactor turns on 2FA, marks the confirmation step as used and
+// replaces the recovery codes of the user.
+func (r *TwoFactorRepository) EnableTwoFactor(ctx context.Context, username string, step int64, recoveryCodes [][]byte) error {
+	const op = "repository.TwoFactorRepository.EnableTwoFactor"
+
+	tx, err := r.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        UPDATE users SET totp_enabled = TRUE, totp_last_step = @step
+        WHERE username = @username AND totp_secret IS NOT NULL AND NOT totp_enabled
+        RETURNING id
+    `
+	args := pgx.NamedArgs{
+		"username": username,
+		"step":     step,
+	}
+
+	var userID int
+	if err := tx.QueryRow(ctx, query, args).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, repoerrs.ErrTwoFactorAlreadyEnabled)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `DELETE FROM recovery_codes WHERE user_id = @user_id`
+	if _, err := tx.Exec(ctx, query, pgx.NamedArgs{"user_id": userID}); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query = `INSERT INTO recovery_codes (user_id, code_hash) VALUES (@user_id, @code_hash)`
+	for _, code := range recoveryCodes {
+		args := pgx.NamedArgs{
+			"user_id":   userID,
+			"code_hash": code,
+		}
+		if _, err := tx.Exec(ctx, query, args); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep records a successful TOTP login. Each step can be used once,
+// so a code intercepted after use cannot be replayed.
+func (r *TwoFactorRepository) UseTOTPStep(ctx context.Context, username string, step int64) error {
+	const op = "repository.TwoFactorRepository.UseTOTPStep"
+
+	query := `
+        UPDATE users SET totp_last_step = @step
+        WHERE username = @username AND totp_enabled AND (totp_last_step IS NULL OR totp_last_step < @step)
+    `
+	args := pgx.NamedArgs{
+		"username": username,
+		"step":     step,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrTOTPCodeUsed)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code of the user as used.
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, username string, codeHash []byte) error {
+	const op = "repository.TwoFactorRepository.UseRecoveryCode"
+
+	query := `
+        UPDATE recovery_codes SET used_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE username = @username)
+        AND code_hash = @code_hash AND used_at IS NULL
+    `
+	args := pgx.NamedArgs{
+		"username":  username,
+		"code_hash": codeHash,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrRecoveryCodeNotFound)
+	}
+
+	return nil
+}
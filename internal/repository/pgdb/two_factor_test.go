@@ -0,0 +1,111 @@
+package pgdb
+
+import (
+	"context"
+	"testing"
+
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoFactorRepository_EnableTwoFactor(t *testing.T) {
+	codes := [][]byte{[]byte("hash1"), []byte("hash2")}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m pgxmock.PgxPoolIface)
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectQuery("UPDATE users SET totp_enabled = TRUE").
+					WithArgs(int64(100), "test_user").
+					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+				m.ExpectExec("DELETE FROM recovery_codes").
+					WithArgs(1).
+					WillReturnResult(pgxmock.NewResult("DELETE", 0))
+				for _, code := range codes {
+					m.ExpectExec("INSERT INTO recovery_codes").
+						WithArgs(1, code).
+						WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				}
+				m.ExpectCommit()
+			},
+		},
+		{
+			name: "Already Enabled",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectBegin()
+				m.ExpectQuery("UPDATE users SET totp_enabled = TRUE").
+					WithArgs(int64(100), "test_user").
+					WillReturnError(pgx.ErrNoRows)
+				m.ExpectRollback()
+			},
+			wantErr: repoerrs.ErrTwoFactorAlreadyEnabled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			repo := NewTwoFactorRepository(&postgres.Postgres{Pool: poolMock})
+
+			err := repo.EnableTwoFactor(context.Background(), "test_user", 100, codes)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTwoFactorRepository_UseTOTPStep(t *testing.T) {
+	testCases := []struct {
+		name         string
+		rowsAffected int64
+		wantErr      error
+	}{
+		{
+			name:         "OK",
+			rowsAffected: 1,
+		},
+		{
+			name:         "Step Already Used",
+			rowsAffected: 0,
+			wantErr:      repoerrs.ErrTOTPCodeUsed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			poolMock.ExpectExec("UPDATE users SET totp_last_step = @step").
+				WithArgs(int64(100), "test_user").
+				WillReturnResult(pgxmock.NewResult("UPDATE", tc.rowsAffected))
+
+			repo := NewTwoFactorRepository(&postgres.Postgres{Pool: poolMock})
+
+			err := repo.UseTOTPStep(context.Background(), "test_user", 100)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"avito-internship/internal/entity"
+	"avito-internship/internal/model"
 	"avito-internship/internal/repository/repoerrs"
 	"avito-internship/pkg/postgres"
 
@@ -13,8 +15,6 @@ import (
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
-var pgErr *pgconn.PgError
-
 type UserRepository struct {
 	*postgres.Postgres
 }
@@ -34,6 +34,7 @@ func (r *UserRepository) AddUser(ctx context.Context, username string, password
 
 	_, err := r.Pool.Exec(ctx, query, args)
 	if err != nil {
+		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
 			return fmt.Errorf("%s: %w", op, repoerrs.ErrUserAlreadyExists)
 		}
@@ -46,14 +47,14 @@ func (r *UserRepository) AddUser(ctx context.Context, username string, password
 func (r *UserRepository) GetUserCredentials(ctx context.Context, username string) (entity.User, error) {
 	const op = "repository.UserRepository.GetUserCredentials"
 
-	query := `SELECT username, password FROM users WHERE username = @username`
+	query := `SELECT username, password, password_changed_at, totp_enabled FROM users WHERE username = @username`
 	args := pgx.NamedArgs{
 		"username": username,
 	}
 
 	var user entity.User
 
-	err := r.Pool.QueryRow(ctx, query, args).Scan(&user.Username, &user.Password)
+	err := r.Pool.QueryRow(ctx, query, args).Scan(&user.Username, &user.Password, &user.PasswordChangedAt, &user.TwoFactorEnabled)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return entity.User{}, fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
@@ -64,56 +65,115 @@ func (r *UserRepository) GetUserCredentials(ctx context.Context, username string
 	return user, nil
 }
 
-func (r *UserRepository) GetInfo(ctx context.Context, username string) (int, []entity.Operation, []entity.Inventory, error) {
+// UpdatePassword sets a new password hash and records the change time,
+// which revokes the tokens issued before it.
+func (r *UserRepository) UpdatePassword(ctx context.Context, username string, password []byte, changedAt time.Time) error {
+	const op = "repository.UserRepository.UpdatePassword"
+
+	query := `
+        UPDATE users SET password = @password, password_changed_at = @changed_at
+        WHERE username = @username
+    `
+	args := pgx.NamedArgs{
+		"username":   username,
+		"password":   password,
+		"changed_at": changedAt,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// RehashPassword replaces the hash of an unchanged password, e.g. after the bcrypt cost changed.
+func (r *UserRepository) RehashPassword(ctx context.Context, username string, password []byte) error {
+	const op = "repository.UserRepository.RehashPassword"
+
+	query := `UPDATE users SET password = @password WHERE username = @username`
+	args := pgx.NamedArgs{
+		"username": username,
+		"password": password,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// GetInfo reads the transfers of the user from the transfer totals kept up to
+// date by SaveTransfer, so the cost does not grow with the number of operations.
+// Everything is read from one snapshot, so the info matches its version.
+func (r *UserRepository) GetInfo(ctx context.Context, username string) (entity.UserInfo, error) {
 	const op = "repository.UserRepository.GetInfo"
 
-	tx, err := r.Pool.Begin(ctx)
+	tx, err := r.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
 	if err != nil {
-		return 0, nil, nil, fmt.Errorf("%s: %w", op, err)
+		return entity.UserInfo{}, fmt.Errorf("%s: %w", op, err)
 	}
 	defer tx.Rollback(ctx)
 
-	queryBalance := `SELECT balance FROM users WHERE username = @username`
+	queryBalance := `SELECT balance, info_version FROM users WHERE username = @username`
 	argsBalance := pgx.NamedArgs{"username": username}
 
-	var balance int
-	err = tx.QueryRow(ctx, queryBalance, argsBalance).Scan(&balance)
+	info := entity.UserInfo{
+		Inventory: []entity.Inventory{},
+		Received:  []entity.Transfer{},
+		Sent:      []entity.Transfer{},
+	}
+	err = tx.QueryRow(ctx, queryBalance, argsBalance).Scan(&info.Balance, &info.Version)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, nil, nil, fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
+			return entity.UserInfo{}, fmt.Errorf("%s: %w", op, repoerrs.ErrUserNotFound)
 		}
-		return 0, nil, nil, fmt.Errorf("%s: %w", op, err)
+		return entity.UserInfo{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	queryOperations := `
+	queryTransfers := `
 		SELECT 
-			u1.username AS user,
-			u2.username AS counterparty,
-			o.amount
-		FROM operations o
-		LEFT JOIN users u1 ON o.user_id = u1.id
-		LEFT JOIN users u2 ON o.counterparty_id = u2.id
-		WHERE (u1.username = @username OR u2.username = @username)
-		AND o.type = 'transfer'`
-	argsOperations := pgx.NamedArgs{"username": username}
-
-	operations := []entity.Operation{}
-	rows, err := tx.Query(ctx, queryOperations, argsOperations)
+			t.direction,
+			u.username AS counterparty,
+			t.amount,
+			t.count,
+			t.last_transfer_at
+		FROM transfer_totals t
+		JOIN users u ON t.counterparty_id = u.id
+		WHERE t.user_id = (SELECT id FROM users WHERE username = @username)
+		ORDER BY u.username`
+	argsTransfers := pgx.NamedArgs{"username": username}
+
+	rows, err := tx.Query(ctx, queryTransfers, argsTransfers)
 	if err != nil {
-		return 0, nil, nil, fmt.Errorf("%s: %w", op, err)
+		return entity.UserInfo{}, fmt.Errorf("%s: %w", op, err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var operation entity.Operation
-		if err := rows.Scan(&operation.User, &operation.Counterparty, &operation.Amount); err != nil {
-			return 0, nil, nil, fmt.Errorf("%s: %w", op, err)
+		var direction string
+		var transfer entity.Transfer
+		if err := rows.Scan(&direction, &transfer.Username, &transfer.Amount, &transfer.Count, &transfer.LastTransferAt); err != nil {
+			return entity.UserInfo{}, fmt.Errorf("%s: %w", op, err)
 		}
 
-		operations = append(operations, operation)
+		if direction == model.TransferDirectionSent {
+			info.Sent = append(info.Sent, transfer)
+		} else {
+			info.Received = append(info.Received, transfer)
+		}
 	}
 	if rows.Err() != nil {
-		return 0, nil, nil, fmt.Errorf("%s: %w", op, rows.Err())
+		return entity.UserInfo{}, fmt.Errorf("%s: %w", op, rows.Err())
 	}
 
 	queryInventory := `
@@ -125,27 +185,118 @@ func (r *UserRepository) GetInfo(ctx context.Context, username string) (int, []e
 		WHERE i.user_id = (SELECT id FROM users WHERE username = @username)`
 	argsInventory := pgx.NamedArgs{"username": username}
 
-	inventory := []entity.Inventory{}
 	rows, err = tx.Query(ctx, queryInventory, argsInventory)
 	if err != nil {
-		return 0, nil, nil, fmt.Errorf("%s: %w", op, err)
+		return entity.UserInfo{}, fmt.Errorf("%s: %w", op, err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var inv entity.Inventory
 		if err := rows.Scan(&inv.Product, &inv.Quantity); err != nil {
-			return 0, nil, nil, fmt.Errorf("%s: %w", op, err)
+			return entity.UserInfo{}, fmt.Errorf("%s: %w", op, err)
 		}
-		inventory = append(inventory, inv)
+		info.Inventory = append(info.Inventory, inv)
 	}
 	if rows.Err() != nil {
-		return 0, nil, nil, fmt.Errorf("%s: %w", op, rows.Err())
+		return entity.UserInfo{}, fmt.Errorf("%s: %w", op, rows.Err())
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return 0, nil, nil, fmt.Errorf("%s: %w", op, err)
+		return entity.UserInfo{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return info, nil
+}
+
+// GetTransferHistory returns every transfer received and sent by the user, the
+// latest first. Unlike GetInfo it grows with the number of operations.
+func (r *UserRepository) GetTransferHistory(ctx context.Context, username string) ([]entity.Transfer, []entity.Transfer, error) {
+	const op = "repository.UserRepository.GetTransferHistory"
+
+	query := `
+		SELECT 
+			o.user_id = me.id AS sent,
+			c.username AS counterparty,
+			o.amount,
+			o.created_at
+		FROM users me
+		JOIN operations o ON o.user_id = me.id OR o.counterparty_id = me.id
+		JOIN users c ON c.id = CASE WHEN o.user_id = me.id THEN o.counterparty_id ELSE o.user_id END
+		WHERE me.username = @username
+		AND o.type = @type
+		ORDER BY o.created_at DESC`
+	args := pgx.NamedArgs{
+		"username": username,
+		"type":     model.OperationTypeTransfer,
+	}
+
+	rows, err := r.Pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	received := []entity.Transfer{}
+	sent := []entity.Transfer{}
+	for rows.Next() {
+		var isSent bool
+		transfer := entity.Transfer{Count: 1}
+		if err := rows.Scan(&isSent, &transfer.Username, &transfer.Amount, &transfer.LastTransferAt); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if isSent {
+			sent = append(sent, transfer)
+		} else {
+			received = append(received, transfer)
+		}
+	}
+	if rows.Err() != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, rows.Err())
+	}
+
+	return received, sent, nil
+}
+
+// GetMostActiveUsers returns up to limit users with the most operations since
+// the given time, as sender, recipient or buyer, the most active first.
+func (r *UserRepository) GetMostActiveUsers(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	const op = "repository.UserRepository.GetMostActiveUsers"
+
+	query := `
+		SELECT u.username
+		FROM (
+			SELECT user_id FROM operations WHERE created_at >= @since
+			UNION ALL
+			SELECT counterparty_id FROM operations WHERE created_at >= @since AND counterparty_id IS NOT NULL
+		) participants
+		JOIN users u ON u.id = participants.user_id
+		GROUP BY u.username
+		ORDER BY COUNT(*) DESC, u.username
+		LIMIT @limit`
+	args := pgx.NamedArgs{
+		"since": since,
+		"limit": limit,
+	}
+
+	rows, err := r.Pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	usernames := []string{}
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		usernames = append(usernames, username)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", op, rows.Err())
 	}
 
-	return balance, operations, inventory, nil
+	return usernames, nil
 }
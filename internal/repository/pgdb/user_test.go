@@ -3,8 +3,10 @@ package pgdb
 import (
 	"context"
 	"testing"
+	"time"
 
 	"avito-internship/internal/entity"
+	"avito-internship/internal/repository/repoerrs"
 	"avito-internship/pkg/postgres"
 
 	"github.com/jackc/pgx/v5"
@@ -96,6 +98,94 @@ func TestUserRepository_AddUser(t *testing.T) {
 	}
 }
 
+func TestUserRepository_UpdatePassword(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		username  string
+		password  []byte
+		changedAt time.Time
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	changedAt := time.Date(2025, 2, 1, 10, 0, 0, 123456000, time.UTC)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:       context.Background(),
+				username:  "test_user",
+				password:  []byte("new_hash"),
+				changedAt: changedAt,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE users SET password = @password, password_changed_at = @changed_at").
+					WithArgs(pgx.NamedArgs{"username": args.username, "password": args.password, "changed_at": args.changedAt}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "User Not Found",
+			args: args{
+				ctx:       context.Background(),
+				username:  "non_existent_user",
+				password:  []byte("new_hash"),
+				changedAt: changedAt,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE users SET password = @password, password_changed_at = @changed_at").
+					WithArgs(pgx.NamedArgs{"username": args.username, "password": args.password, "changed_at": args.changedAt}).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: repoerrs.ErrUserNotFound,
+		},
+		{
+			name: "Query Execution Error",
+			args: args{
+				ctx:       context.Background(),
+				username:  "test_user",
+				password:  []byte("new_hash"),
+				changedAt: changedAt,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectExec("UPDATE users SET password = @password, password_changed_at = @changed_at").
+					WithArgs(pgx.NamedArgs{"username": args.username, "password": args.password, "changed_at": args.changedAt}).
+					WillReturnError(assert.AnError)
+			},
+			wantErr: assert.AnError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Pool: poolMock,
+			}
+			userRepoMock := NewUserRepository(postgresMock)
+
+			err := userRepoMock.UpdatePassword(tc.args.ctx, tc.args.username, tc.args.password, tc.args.changedAt)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
 func TestUserRepository_GetUserCredentials(t *testing.T) {
 	type args struct {
 		ctx      context.Context
@@ -118,9 +208,9 @@ func TestUserRepository_GetUserCredentials(t *testing.T) {
 				username: "test_user",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				rows := pgxmock.NewRows([]string{"username", "password"}).
-					AddRow("test_user", []byte("hashed_password"))
-				m.ExpectQuery("SELECT username, password FROM users WHERE username = @username").
+				rows := pgxmock.NewRows([]string{"username", "password", "password_changed_at", "totp_enabled"}).
+					AddRow("test_user", []byte("hashed_password"), nil, false)
+				m.ExpectQuery("SELECT username, password, password_changed_at, totp_enabled FROM users WHERE username = @username").
 					WithArgs(args.username).
 					WillReturnRows(rows)
 			},
@@ -137,7 +227,7 @@ func TestUserRepository_GetUserCredentials(t *testing.T) {
 				username: "non_existent_user",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				m.ExpectQuery("SELECT username, password FROM users WHERE username = @username").
+				m.ExpectQuery("SELECT username, password, password_changed_at, totp_enabled FROM users WHERE username = @username").
 					WithArgs(args.username).
 					WillReturnError(pgx.ErrNoRows)
 			},
@@ -181,13 +271,13 @@ func TestUserRepository_GetInfo(t *testing.T) {
 
 	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
 
+	lastTransferAt := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+
 	testCases := []struct {
 		name         string
 		args         args
 		mockBehavior MockBehavior
-		wantBalance  int
-		wantOps      []entity.Operation
-		wantInv      []entity.Inventory
+		want         entity.UserInfo
 		wantErr      bool
 	}{
 		{
@@ -197,15 +287,17 @@ func TestUserRepository_GetInfo(t *testing.T) {
 				username: "test_user",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				m.ExpectBegin()
-				m.ExpectQuery("SELECT balance FROM users").
+				m.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+				m.ExpectQuery("SELECT balance, info_version FROM users").
 					WithArgs(args.username).
-					WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(100))
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(100, int64(12)))
 
-				m.ExpectQuery("SELECT.*FROM operations").
+				m.ExpectQuery("SELECT.*FROM transfer_totals").
 					WithArgs(args.username).
-					WillReturnRows(pgxmock.NewRows([]string{"user", "counterparty", "amount"}).
-						AddRow(args.username, "other_user", 50))
+					WillReturnRows(pgxmock.NewRows([]string{"direction", "counterparty", "amount", "count", "last_transfer_at"}).
+						AddRow("sent", "other_user", 50, 1, &lastTransferAt).
+						AddRow("received", "other_user", 20, 2, &lastTransferAt).
+						AddRow("received", "third_user", 70, 3, (*time.Time)(nil)))
 
 				m.ExpectQuery("SELECT.*FROM inventory").
 					WithArgs(args.username).
@@ -214,12 +306,15 @@ func TestUserRepository_GetInfo(t *testing.T) {
 
 				m.ExpectCommit()
 			},
-			wantBalance: 100,
-			wantOps: []entity.Operation{
-				{User: "test_user", Counterparty: "other_user", Amount: 50},
-			},
-			wantInv: []entity.Inventory{
-				{Product: "item1", Quantity: 10},
+			want: entity.UserInfo{
+				Balance:   100,
+				Version:   12,
+				Inventory: []entity.Inventory{{Product: "item1", Quantity: 10}},
+				Received: []entity.Transfer{
+					{Username: "other_user", Amount: 20, Count: 2, LastTransferAt: &lastTransferAt},
+					{Username: "third_user", Amount: 70, Count: 3},
+				},
+				Sent: []entity.Transfer{{Username: "other_user", Amount: 50, Count: 1, LastTransferAt: &lastTransferAt}},
 			},
 			wantErr: false,
 		},
@@ -230,8 +325,8 @@ func TestUserRepository_GetInfo(t *testing.T) {
 				username: "unknown_user",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				m.ExpectBegin()
-				m.ExpectQuery("SELECT balance FROM users").
+				m.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+				m.ExpectQuery("SELECT balance, info_version FROM users").
 					WithArgs(args.username).
 					WillReturnError(pgx.ErrNoRows)
 				m.ExpectRollback()
@@ -245,22 +340,22 @@ func TestUserRepository_GetInfo(t *testing.T) {
 				username: "test_user",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				m.ExpectBegin().WillReturnError(assert.AnError)
+				m.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}).WillReturnError(assert.AnError)
 			},
 			wantErr: true,
 		},
 		{
-			name: "Operations Query Error",
+			name: "Transfers Query Error",
 			args: args{
 				ctx:      context.Background(),
 				username: "test_user",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				m.ExpectBegin()
-				m.ExpectQuery("SELECT balance FROM users").
+				m.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+				m.ExpectQuery("SELECT balance, info_version FROM users").
 					WithArgs(args.username).
-					WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(100))
-				m.ExpectQuery("SELECT.*FROM operations").
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(100, int64(12)))
+				m.ExpectQuery("SELECT.*FROM transfer_totals").
 					WithArgs(args.username).
 					WillReturnError(assert.AnError)
 				m.ExpectRollback()
@@ -268,28 +363,31 @@ func TestUserRepository_GetInfo(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "User Without Operations and Inventory",
+			name: "User Without Transfers and Inventory",
 			args: args{
 				ctx:      context.Background(),
 				username: "empty_user",
 			},
 			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
-				m.ExpectBegin()
-				m.ExpectQuery("SELECT balance FROM users").
+				m.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+				m.ExpectQuery("SELECT balance, info_version FROM users").
 					WithArgs(args.username).
-					WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(50))
-				m.ExpectQuery("SELECT.*FROM operations").
+					WillReturnRows(pgxmock.NewRows([]string{"balance", "info_version"}).AddRow(50, int64(0)))
+				m.ExpectQuery("SELECT.*FROM transfer_totals").
 					WithArgs(args.username).
-					WillReturnRows(pgxmock.NewRows([]string{"user", "counterparty", "amount", "type"}))
+					WillReturnRows(pgxmock.NewRows([]string{"direction", "counterparty", "amount", "count", "last_transfer_at"}))
 				m.ExpectQuery("SELECT.*FROM inventory").
 					WithArgs(args.username).
 					WillReturnRows(pgxmock.NewRows([]string{"product", "quantity"}))
 				m.ExpectCommit()
 			},
-			wantBalance: 50,
-			wantOps:     []entity.Operation{},
-			wantInv:     []entity.Inventory{},
-			wantErr:     false,
+			want: entity.UserInfo{
+				Balance:   50,
+				Inventory: []entity.Inventory{},
+				Received:  []entity.Transfer{},
+				Sent:      []entity.Transfer{},
+			},
+			wantErr: false,
 		},
 	}
 
@@ -304,16 +402,167 @@ func TestUserRepository_GetInfo(t *testing.T) {
 			}
 			userRepoMock := NewUserRepository(postgresMock)
 
-			balance, operations, inventory, err := userRepoMock.GetInfo(tc.args.ctx, tc.args.username)
+			info, err := userRepoMock.GetInfo(tc.args.ctx, tc.args.username)
 
 			if tc.wantErr {
 				assert.Error(t, err)
 				return
 			}
 			assert.NoError(t, err)
-			assert.Equal(t, tc.wantBalance, balance)
-			assert.Equal(t, tc.wantOps, operations)
-			assert.Equal(t, tc.wantInv, inventory)
+			assert.Equal(t, tc.want, info)
+		})
+	}
+}
+
+func TestUserRepository_GetTransferHistory(t *testing.T) {
+	first := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	args := pgx.NamedArgs{"username": "test_user", "type": "transfer"}
+
+	testCases := []struct {
+		name         string
+		mockBehavior func(m pgxmock.PgxPoolIface)
+		wantReceived []entity.Transfer
+		wantSent     []entity.Transfer
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT.*FROM users me.*JOIN operations.*ORDER BY o.created_at DESC").
+					WithArgs(args).
+					WillReturnRows(pgxmock.NewRows([]string{"sent", "counterparty", "amount", "created_at"}).
+						AddRow(false, "other_user", 30, &second).
+						AddRow(true, "other_user", 50, &first).
+						AddRow(false, "other_user", 20, &first))
+			},
+			wantReceived: []entity.Transfer{
+				{Username: "other_user", Amount: 30, Count: 1, LastTransferAt: &second},
+				{Username: "other_user", Amount: 20, Count: 1, LastTransferAt: &first},
+			},
+			wantSent: []entity.Transfer{
+				{Username: "other_user", Amount: 50, Count: 1, LastTransferAt: &first},
+			},
+		},
+		{
+			name: "Query Error",
+			mockBehavior: func(m pgxmock.PgxPoolIface) {
+				m.ExpectQuery("SELECT.*FROM users me").
+					WithArgs(args).
+					WillReturnError(assert.AnError)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock)
+
+			userRepoMock := NewUserRepository(&postgres.Postgres{Pool: poolMock})
+
+			received, sent, err := userRepoMock.GetTransferHistory(context.Background(), "test_user")
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantReceived, received)
+			assert.Equal(t, tc.wantSent, sent)
+			assert.NoError(t, poolMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUserRepository_GetMostActiveUsers(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		since time.Time
+		limit int
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	since := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		want         []string
+		wantErr      bool
+	}{
+		{
+			name: "OK",
+			args: args{
+				ctx:   context.Background(),
+				since: since,
+				limit: 2,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("SELECT u.username.*FROM operations.*ORDER BY COUNT").
+					WithArgs(pgx.NamedArgs{"since": args.since, "limit": args.limit}).
+					WillReturnRows(pgxmock.NewRows([]string{"username"}).
+						AddRow("user1").
+						AddRow("user2"))
+			},
+			want:    []string{"user1", "user2"},
+			wantErr: false,
+		},
+		{
+			name: "No Operations",
+			args: args{
+				ctx:   context.Background(),
+				since: since,
+				limit: 10,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("SELECT u.username").
+					WithArgs(pgx.NamedArgs{"since": args.since, "limit": args.limit}).
+					WillReturnRows(pgxmock.NewRows([]string{"username"}))
+			},
+			want:    []string{},
+			wantErr: false,
+		},
+		{
+			name: "Query Error",
+			args: args{
+				ctx:   context.Background(),
+				since: since,
+				limit: 10,
+			},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("SELECT u.username").
+					WithArgs(pgx.NamedArgs{"since": args.since, "limit": args.limit}).
+					WillReturnError(assert.AnError)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, _ := pgxmock.NewPool()
+			defer poolMock.Close()
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Pool: poolMock,
+			}
+			userRepoMock := NewUserRepository(postgresMock)
+
+			usernames, err := userRepoMock.GetMostActiveUsers(tc.args.ctx, tc.args.since, tc.args.limit)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, usernames)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
 		})
 	}
 }
@@ -0,0 +1,219 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/model"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type WishlistRepository struct {
+	*postgres.Postgres
+}
+
+func NewWishlistRepository(pg *postgres.Postgres) *WishlistRepository {
+	return &WishlistRepository{pg}
+}
+
+func (r *WishlistRepository) AddToWishlist(ctx context.Context, username string, product string) error {
+	const op = "repository.WishlistRepository.AddToWishlist"
+
+	// Adding a product twice keeps the original entry.
+	query := `
+        WITH target AS (
+            SELECT u.id AS user_id, p.id AS product_id
+            FROM users u, products p
+            WHERE u.username = @username AND p.name = @product
+        ), inserted AS (
+            INSERT INTO wishlists (user_id, product_id)
+            SELECT user_id, product_id FROM target
+            ON CONFLICT (user_id, product_id) DO NOTHING
+        )
+        SELECT EXISTS (SELECT 1 FROM target)
+    `
+	args := pgx.NamedArgs{
+		"username": username,
+		"product":  product,
+	}
+
+	var found bool
+	if err := r.Pool.QueryRow(ctx, query, args).Scan(&found); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !found {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrProductNotFound)
+	}
+
+	return nil
+}
+
+func (r *WishlistRepository) RemoveFromWishlist(ctx context.Context, username string, product string) error {
+	const op = "repository.WishlistRepository.RemoveFromWishlist"
+
+	query := `
+        DELETE FROM wishlists
+        WHERE user_id = (SELECT id FROM users WHERE username = @username)
+        AND product_id = (SELECT id FROM products WHERE name = @product)
+    `
+	args := pgx.NamedArgs{
+		"username": username,
+		"product":  product,
+	}
+
+	tag, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, repoerrs.ErrWishlistItemNotFound)
+	}
+
+	return nil
+}
+
+func (r *WishlistRepository) GetWishlist(ctx context.Context, username string) ([]entity.WishlistItem, error) {
+	const op = "repository.WishlistRepository.GetWishlist"
+
+	query := `
+		SELECT
+			p.name,
+			p.price,
+			CASE
+				WHEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id)
+				THEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id AND (v.stock IS NULL OR v.stock > 0))
+				ELSE p.stock IS NULL OR p.stock > 0
+			END AS available,
+			w.created_at
+		FROM wishlists w
+		JOIN products p ON w.product_id = p.id
+		WHERE w.user_id = (SELECT id FROM users WHERE username = @username)
+		ORDER BY w.created_at DESC, w.id DESC`
+	args := pgx.NamedArgs{
+		"username": username,
+	}
+
+	rows, err := r.Pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	wishlist := []entity.WishlistItem{}
+	for rows.Next() {
+		var item entity.WishlistItem
+		if err := rows.Scan(&item.Product, &item.Price, &item.Available, &item.AddedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		wishlist = append(wishlist, item)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", op, rows.Err())
+	}
+
+	return wishlist, nil
+}
+
+func (r *WishlistRepository) GetNotifications(ctx context.Context, username string) ([]entity.Notification, error) {
+	const op = "repository.WishlistRepository.GetNotifications"
+
+	query := `
+		SELECT n.id, p.name, n.type, n.price, n.read_at IS NOT NULL AS read, n.created_at
+		FROM notifications n
+		JOIN products p ON n.product_id = p.id
+		WHERE n.user_id = (SELECT id FROM users WHERE username = @username)
+		ORDER BY n.created_at DESC, n.id DESC`
+	args := pgx.NamedArgs{
+		"username": username,
+	}
+
+	rows, err := r.Pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	notifications := []entity.Notification{}
+	for rows.Next() {
+		var notification entity.Notification
+		err := rows.Scan(
+			&notification.ID,
+			&notification.Product,
+			&notification.Type,
+			&notification.Price,
+			&notification.Read,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notifications = append(notifications, notification)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", op, rows.Err())
+	}
+
+	return notifications, nil
+}
+
+func (r *WishlistRepository) MarkNotificationsRead(ctx context.Context, username string) error {
+	const op = "repository.WishlistRepository.MarkNotificationsRead"
+
+	query := `
+        UPDATE notifications SET read_at = NOW()
+        WHERE user_id = (SELECT id FROM users WHERE username = @username) AND read_at IS NULL
+    `
+	args := pgx.NamedArgs{
+		"username": username,
+	}
+
+	_, err := r.Pool.Exec(ctx, query, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// notifyWishlisters notifies every user who wishlisted the product within the current transaction.
+func notifyWishlisters(ctx context.Context, tx pgx.Tx, productID int, notificationType string) error {
+	query := `
+        INSERT INTO notifications (user_id, product_id, type, price)
+        SELECT w.user_id, p.id, @type, p.price
+        FROM wishlists w
+        JOIN products p ON w.product_id = p.id
+        WHERE w.product_id = @product_id
+    `
+	args := pgx.NamedArgs{
+		"product_id": productID,
+		"type":       notificationType,
+	}
+
+	_, err := tx.Exec(ctx, query, args)
+	return err
+}
+
+// notifyAffordable notifies the user about wishlisted products that the balance
+// change from oldBalance to newBalance has made affordable.
+func notifyAffordable(ctx context.Context, tx pgx.Tx, userID int, oldBalance int, newBalance int) error {
+	query := `
+        INSERT INTO notifications (user_id, product_id, type, price)
+        SELECT w.user_id, p.id, @type, p.price
+        FROM wishlists w
+        JOIN products p ON w.product_id = p.id
+        WHERE w.user_id = @user_id AND p.price > @old_balance AND p.price <= @new_balance
+    `
+	args := pgx.NamedArgs{
+		"user_id":     userID,
+		"type":        model.NotificationTypeAffordable,
+		"old_balance": oldBalance,
+		"new_balance": newBalance,
+	}
+
+	_, err := tx.Exec(ctx, query, args)
+	return err
+}
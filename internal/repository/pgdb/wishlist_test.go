@@ -0,0 +1,138 @@
+package pgdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWishlistRepository_AddToWishlist(t *testing.T) {
+	type args struct {
+		username string
+		product  string
+	}
+
+	type MockBehavior func(m pgxmock.PgxPoolIface, args args)
+
+	testCases := []struct {
+		name         string
+		args         args
+		mockBehavior MockBehavior
+		wantErr      error
+	}{
+		{
+			name: "OK",
+			args: args{username: "user", product: "hoody"},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("INSERT INTO wishlists (.+) ON CONFLICT \\(user_id, product_id\\) DO NOTHING").
+					WithArgs(pgx.NamedArgs{"username": args.username, "product": args.product}).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+		},
+		{
+			name: "Product Not Found",
+			args: args{username: "user", product: "unknown"},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("INSERT INTO wishlists").
+					WithArgs(pgx.NamedArgs{"username": args.username, "product": args.product}).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			wantErr: repoerrs.ErrProductNotFound,
+		},
+		{
+			name: "Query Error",
+			args: args{username: "user", product: "hoody"},
+			mockBehavior: func(m pgxmock.PgxPoolIface, args args) {
+				m.ExpectQuery("INSERT INTO wishlists").
+					WithArgs(pgx.NamedArgs{"username": args.username, "product": args.product}).
+					WillReturnError(errors.New("query error"))
+			},
+			wantErr: errors.New("query error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("Failed to create mock pool: %v", err)
+			}
+			defer poolMock.Close()
+
+			tc.mockBehavior(poolMock, tc.args)
+
+			postgresMock := &postgres.Postgres{
+				Pool: poolMock,
+			}
+			wishlistRepo := NewWishlistRepository(postgresMock)
+
+			err = wishlistRepo.AddToWishlist(context.Background(), tc.args.username, tc.args.product)
+
+			if tc.wantErr != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWishlistRepository_RemoveFromWishlist(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rows    int64
+		wantErr error
+	}{
+		{
+			name: "OK",
+			rows: 1,
+		},
+		{
+			name:    "Not In Wishlist",
+			rows:    0,
+			wantErr: repoerrs.ErrWishlistItemNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			poolMock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Fatalf("Failed to create mock pool: %v", err)
+			}
+			defer poolMock.Close()
+
+			poolMock.ExpectExec("DELETE FROM wishlists").
+				WithArgs(pgx.NamedArgs{"username": "user", "product": "hoody"}).
+				WillReturnResult(pgxmock.NewResult("DELETE", tc.rows))
+
+			postgresMock := &postgres.Postgres{
+				Pool: poolMock,
+			}
+			wishlistRepo := NewWishlistRepository(postgresMock)
+
+			err = wishlistRepo.RemoveFromWishlist(context.Background(), "user", "hoody")
+
+			if tc.wantErr != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			err = poolMock.ExpectationsWereMet()
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -3,8 +3,30 @@ package repoerrs
 import "errors"
 
 var (
-	ErrUserAlreadyExists = errors.New("user already exists")
-	ErrUserNotFound      = errors.New("user not found")
-	ErrInsufficientFunds = errors.New("insufficient funds")
-	ErrProductNotFound   = errors.New("product not found")
+	ErrUserAlreadyExists       = errors.New("user already exists")
+	ErrUserNotFound            = errors.New("user not found")
+	ErrInsufficientFunds       = errors.New("insufficient funds")
+	ErrProductNotFound         = errors.New("product not found")
+	ErrOutOfStock              = errors.New("product out of stock")
+	ErrPurchaseLimitExceeded   = errors.New("purchase limit exceeded")
+	ErrNegativeStock           = errors.New("stock cannot be negative")
+	ErrCategoryNotFound        = errors.New("category not found")
+	ErrVariantNotFound         = errors.New("product variant not found")
+	ErrVariantRequired         = errors.New("product variant is required")
+	ErrVariantAlreadyExists    = errors.New("product variant already exists")
+	ErrPromoCodeNotFound       = errors.New("promo code not found")
+	ErrPromoCodeAlreadyExists  = errors.New("promo code already exists")
+	ErrPromoCodeInactive       = errors.New("promo code is not active")
+	ErrPromoCodeNotApplicable  = errors.New("promo code is not applicable to the product")
+	ErrPromoCodeUsageLimit     = errors.New("promo code usage limit reached")
+	ErrOrderNotFound           = errors.New("order not found")
+	ErrInvalidOrderTransition  = errors.New("invalid order status transition")
+	ErrWishlistItemNotFound    = errors.New("product is not in the wishlist")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPCodeUsed            = errors.New("totp code has already been used")
+	ErrRecoveryCodeNotFound    = errors.New("recovery code not found")
+	ErrAPIKeyNotFound          = errors.New("api key not found")
+	ErrIdentityNotFound        = errors.New("identity not found")
+	ErrIdentityConflict        = errors.New("user is linked to another identity")
+	ErrSessionNotFound         = errors.New("session not found")
 )
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"avito-internship/internal/entity"
 	"avito-internship/internal/repository/pgdb"
@@ -11,22 +12,117 @@ import (
 type User interface {
 	AddUser(ctx context.Context, username string, password []byte) error
 	GetUserCredentials(ctx context.Context, username string) (entity.User, error)
-	GetInfo(ctx context.Context, username string) (int, []entity.Operation, []entity.Inventory, error)
+	UpdatePassword(ctx context.Context, username string, password []byte, changedAt time.Time) error
+	RehashPassword(ctx context.Context, username string, password []byte) error
+	GetInfo(ctx context.Context, username string) (entity.UserInfo, error)
+	GetTransferHistory(ctx context.Context, username string) (received []entity.Transfer, sent []entity.Transfer, err error)
+	GetMostActiveUsers(ctx context.Context, since time.Time, limit int) ([]string, error)
 }
 
 type Operation interface {
-	SaveTransfer(ctx context.Context, sender string, recipient string, amount int) error
-	SavePurchase(ctx context.Context, username string, product string) error
+	SaveTransfer(ctx context.Context, sender string, recipient string, amount int) (senderChange entity.BalanceChange, recipientChange entity.BalanceChange, err error)
+	SavePurchase(ctx context.Context, username string, product string, variant string, promoCode string, location string) (entity.BalanceChange, error)
+	RebuildTransferTotals(ctx context.Context) (int64, error)
+}
+
+type Product interface {
+	Restock(ctx context.Context, product string, variant string, delta int, reason string, changedBy string) (int, error)
+	AddVariant(ctx context.Context, product string, variant entity.ProductVariant) error
+	SetPurchaseLimit(ctx context.Context, product string, limit *int) error
+	SetPrice(ctx context.Context, product string, price int) error
+	GetStockHistory(ctx context.Context, product string) ([]entity.StockChange, error)
+	SearchProducts(ctx context.Context, filter entity.ProductFilter) ([]entity.Product, error)
+}
+
+type Promo interface {
+	AddPromoCode(ctx context.Context, promo entity.PromoCode) error
+	GetPromoCodes(ctx context.Context) ([]entity.PromoCode, error)
+	DeactivatePromoCode(ctx context.Context, code string) error
+}
+
+type Order interface {
+	GetUserOrders(ctx context.Context, username string) ([]entity.Order, error)
+	GetOrders(ctx context.Context, status string) ([]entity.Order, error)
+	UpdateOrderStatus(ctx context.Context, id int, status string) (entity.Order, error)
+}
+
+type Wishlist interface {
+	AddToWishlist(ctx context.Context, username string, product string) error
+	RemoveFromWishlist(ctx context.Context, username string, product string) error
+	GetWishlist(ctx context.Context, username string) ([]entity.WishlistItem, error)
+	GetNotifications(ctx context.Context, username string) ([]entity.Notification, error)
+	MarkNotificationsRead(ctx context.Context, username string) error
+}
+
+type TwoFactor interface {
+	GetTwoFactor(ctx context.Context, username string) (entity.TwoFactor, error)
+	SetTOTPSecret(ctx context.Context, username string, secret string) error
+	EnableTwoFactor(ctx context.Context, username string, step int64, recoveryCodes [][]byte) error
+	UseTOTPStep(ctx context.Context, username string, step int64) error
+	UseRecoveryCode(ctx context.Context, username string, codeHash []byte) error
+}
+
+type APIKey interface {
+	AddAPIKey(ctx context.Context, key entity.APIKey) (entity.APIKey, error)
+	GetAPIKeys(ctx context.Context, username string) ([]entity.APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, username string, id int) error
+	TouchAPIKey(ctx context.Context, id int) error
+}
+
+type Identity interface {
+	GetIdentityUsername(ctx context.Context, issuer string, subject string) (string, error)
+	CreateIdentityUser(ctx context.Context, identity entity.Identity, password []byte) error
+}
+
+type Session interface {
+	AddSession(ctx context.Context, session entity.Session) error
+	GetSession(ctx context.Context, id string) (entity.Session, error)
+	GetSessions(ctx context.Context, username string) ([]entity.Session, error)
+	TouchSession(ctx context.Context, id string) error
+	RevokeSession(ctx context.Context, username string, id string) error
+	RevokeSessions(ctx context.Context, username string, exceptID string) ([]string, error)
+}
+
+type Impersonation interface {
+	AddImpersonationEvent(ctx context.Context, event entity.ImpersonationEvent) error
+}
+
+type CacheInvalidation interface {
+	AddCacheInvalidations(ctx context.Context, keys []string) error
+	GetCacheInvalidations(ctx context.Context, limit int) ([]entity.CacheInvalidation, error)
+	DeleteCacheInvalidations(ctx context.Context, ids []int64) error
 }
 
 type Repositories struct {
 	User
 	Operation
+	Product
+	Promo
+	Order
+	Wishlist
+	TwoFactor
+	APIKey
+	Identity
+	Session
+	Impersonation
+	CacheInvalidation
 }
 
 func NewRepositories(pg *postgres.Postgres) *Repositories {
 	return &Repositories{
-		User:      pgdb.NewUserRepository(pg),
-		Operation: pgdb.NewOperationRepository(pg),
+		User:          pgdb.NewUserRepository(pg),
+		Operation:     pgdb.NewOperationRepository(pg),
+		Product:       pgdb.NewProductRepository(pg),
+		Promo:         pgdb.NewPromoRepository(pg),
+		Order:         pgdb.NewOrderRepository(pg),
+		Wishlist:      pgdb.NewWishlistRepository(pg),
+		TwoFactor:     pgdb.NewTwoFactorRepository(pg),
+		APIKey:        pgdb.NewAPIKeyRepository(pg),
+		Identity:      pgdb.NewIdentityRepository(pg),
+		Session:       pgdb.NewSessionRepository(pg),
+		Impersonation: pgdb.NewImpersonationRepository(pg),
+
+		CacheInvalidation: pgdb.NewCacheInvalidationRepository(pg),
 	}
 }
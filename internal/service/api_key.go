@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+
+	"go.uber.org/zap"
+)
+
+// API keys look like ak_<prefix>_<secret>. The prefix identifies the key in
+// listings and logs, the whole key is stored as a SHA-256 hash.
+const (
+	apiKeyMarker      = "ak_"
+	apiKeyPrefixBytes = 8
+	apiKeySecretBytes = 32
+)
+
+// apiKeyTouchInterval is how stale the last use time of a key may get before
+// it is saved again, so busy keys do not write on every request.
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyService struct {
+	log  *zap.Logger
+	repo repository.APIKey
+	now  func() time.Time
+}
+
+func NewAPIKeyService(log *zap.Logger, repo repository.APIKey) *APIKeyService {
+	return &APIKeyService{
+		log:  log,
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// IsAPIKey reports whether the credential has the API key format rather than being a JWT.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, apiKeyMarker)
+}
+
+// Create issues a new key. The plain key is returned only once and cannot be recovered later.
+func (s *APIKeyService) Create(ctx context.Context, input CreateAPIKeyInput) (CreateAPIKeyOutput, error) {
+	const op = "service.APIKey.Create"
+	s.log.Info("Attempting to create API key",
+		zap.String("username", input.Username),
+		zap.Strings("scopes", input.Scopes),
+	)
+
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return CreateAPIKeyOutput{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(s.now()) {
+		return CreateAPIKeyOutput{}, fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidAPIKeyExpiry)
+	}
+
+	plain, prefix, err := generateAPIKey()
+	if err != nil {
+		s.log.Error("Failed to generate API key",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+		return CreateAPIKeyOutput{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key, err := s.repo.AddAPIKey(ctx, entity.APIKey{
+		Username:  input.Username,
+		Name:      input.Name,
+		Prefix:    prefix,
+		Hash:      hashAPIKey(plain),
+		Scopes:    scopes,
+		ExpiresAt: input.ExpiresAt,
+	})
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrUserNotFound) {
+			return CreateAPIKeyOutput{}, fmt.Errorf("%s: %w", op, servicerrs.ErrUserNotFound)
+		}
+		s.log.Error("Failed to save API key",
+			zap.String("op", op),
+			zap.String("username", input.Username),
+			zap.Error(err),
+		)
+		return CreateAPIKeyOutput{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("API key created",
+		zap.String("username", input.Username),
+		zap.String("prefix", prefix),
+	)
+
+	return CreateAPIKeyOutput{Key: plain, APIKey: key}, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, username string) ([]entity.APIKey, error) {
+	const op = "service.APIKey.List"
+
+	keys, err := s.repo.GetAPIKeys(ctx, username)
+	if err != nil {
+		s.log.Error("Failed to retrieve API keys",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, input RevokeAPIKeyInput) error {
+	const op = "service.APIKey.Revoke"
+
+	if err := s.repo.RevokeAPIKey(ctx, input.Username, input.ID); err != nil {
+		if errors.Is(err, repoerrs.ErrAPIKeyNotFound) {
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrAPIKeyNotFound)
+		}
+		s.log.Error("Failed to revoke API key",
+			zap.String("op", op),
+			zap.String("username", input.Username),
+			zap.Int("id", input.ID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("API key revoked",
+		zap.String("username", input.Username),
+		zap.Int("id", input.ID),
+	)
+
+	return nil
+}
+
+// Authenticate returns the key matching the plain key if it is neither revoked nor expired.
+func (s *APIKeyService) Authenticate(ctx context.Context, plain string) (entity.APIKey, error) {
+	const op = "service.APIKey.Authenticate"
+
+	prefix, ok := apiKeyPrefix(plain)
+	if !ok {
+		return entity.APIKey{}, fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidAPIKey)
+	}
+
+	key, err := s.repo.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrAPIKeyNotFound) {
+			s.log.Warn("Unknown API key",
+				zap.String("op", op),
+				zap.String("prefix", prefix),
+			)
+			return entity.APIKey{}, fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidAPIKey)
+		}
+		s.log.Error("Failed to retrieve API key",
+			zap.String("op", op),
+			zap.String("prefix", prefix),
+			zap.Error(err),
+		)
+		return entity.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if subtle.ConstantTimeCompare(key.Hash, hashAPIKey(plain)) != 1 {
+		s.log.Warn("API key hash mismatch",
+			zap.String("op", op),
+			zap.String("prefix", prefix),
+		)
+		return entity.APIKey{}, fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidAPIKey)
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(s.now()) {
+		s.log.Warn("Expired API key",
+			zap.String("op", op),
+			zap.String("prefix", prefix),
+		)
+		return entity.APIKey{}, fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidAPIKey)
+	}
+
+	if key.LastUsedAt != nil && s.now().Sub(*key.LastUsedAt) < apiKeyTouchInterval {
+		return key, nil
+	}
+
+	// The request is served even if the last use time cannot be saved.
+	if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+		s.log.Error("Failed to update API key last use time",
+			zap.String("op", op),
+			zap.String("prefix", prefix),
+			zap.Error(err),
+		)
+	}
+
+	return key, nil
+}
+
+// normalizeScopes rejects unknown scopes and returns the rest sorted and deduplicated.
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, servicerrs.ErrInvalidScope
+	}
+
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(entity.APIKeyScopes, scope) {
+			return nil, fmt.Errorf("%w: %s", servicerrs.ErrInvalidScope, scope)
+		}
+		normalized = append(normalized, scope)
+	}
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized), nil
+}
+
+func generateAPIKey() (plain, prefix string, err error) {
+	raw := make([]byte, apiKeyPrefixBytes+apiKeySecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	prefix = hex.EncodeToString(raw[:apiKeyPrefixBytes])
+	plain = apiKeyMarker + prefix + "_" + hex.EncodeToString(raw[apiKeyPrefixBytes:])
+
+	return plain, prefix, nil
+}
+
+func apiKeyPrefix(plain string) (string, bool) {
+	rest, ok := strings.CutPrefix(plain, apiKeyMarker)
+	if !ok {
+		return "", false
+	}
+
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 2*apiKeyPrefixBytes || len(secret) != 2*apiKeySecretBytes {
+		return "", false
+	}
+
+	return prefix, true
+}
+
+func hashAPIKey(plain string) []byte {
+	sum := sha256.Sum256([]byte(plain))
+
+	return sum[:]
+}
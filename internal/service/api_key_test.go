@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAPIKeyService_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockAPIKey(ctrl)
+	service := NewAPIKeyService(zap.NewNop(), mockRepo)
+	now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		input         CreateAPIKeyInput
+		mockRepoSetup func(*repository.MockAPIKey)
+		wantScopes    []string
+		expectedError error
+	}{
+		{
+			name: "Key created",
+			input: CreateAPIKeyInput{
+				Username:  "hr-bot",
+				Name:      "HR bot",
+				Scopes:    []string{entity.ScopeInfoRead, entity.ScopeCoinsSend, entity.ScopeInfoRead},
+				ExpiresAt: &future,
+			},
+			mockRepoSetup: func(m *repository.MockAPIKey) {
+				m.EXPECT().
+					AddAPIKey(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, key entity.APIKey) (entity.APIKey, error) {
+						key.ID = 1
+						return key, nil
+					})
+			},
+			wantScopes: []string{entity.ScopeCoinsSend, entity.ScopeInfoRead},
+		},
+		{
+			name:          "Unknown scope",
+			input:         CreateAPIKeyInput{Username: "hr-bot", Name: "HR bot", Scopes: []string{"admin"}},
+			mockRepoSetup: func(m *repository.MockAPIKey) {},
+			expectedError: servicerrs.ErrInvalidScope,
+		},
+		{
+			name:          "No scopes",
+			input:         CreateAPIKeyInput{Username: "hr-bot", Name: "HR bot"},
+			mockRepoSetup: func(m *repository.MockAPIKey) {},
+			expectedError: servicerrs.ErrInvalidScope,
+		},
+		{
+			name: "Expiry in the past",
+			input: CreateAPIKeyInput{
+				Username:  "hr-bot",
+				Name:      "HR bot",
+				Scopes:    []string{entity.ScopeInfoRead},
+				ExpiresAt: &past,
+			},
+			mockRepoSetup: func(m *repository.MockAPIKey) {},
+			expectedError: servicerrs.ErrInvalidAPIKeyExpiry,
+		},
+		{
+			name:  "Repository error",
+			input: CreateAPIKeyInput{Username: "hr-bot", Name: "HR bot", Scopes: []string{entity.ScopeInfoRead}},
+			mockRepoSetup: func(m *repository.MockAPIKey) {
+				m.EXPECT().
+					AddAPIKey(gomock.Any(), gomock.Any()).
+					Return(entity.APIKey{}, errors.New("repository error"))
+			},
+			expectedError: errors.New("repository error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup(mockRepo)
+
+			output, err := service.Create(context.Background(), tt.input)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, IsAPIKey(output.Key))
+			assert.Equal(t, tt.wantScopes, output.APIKey.Scopes)
+			assert.Equal(t, hashAPIKey(output.Key), output.APIKey.Hash)
+
+			prefix, ok := apiKeyPrefix(output.Key)
+			assert.True(t, ok)
+			assert.Equal(t, output.APIKey.Prefix, prefix)
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockAPIKey(ctrl)
+	service := NewAPIKeyService(zap.NewNop(), mockRepo)
+	now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	plain, prefix, err := generateAPIKey()
+	require.NoError(t, err)
+	other, _, err := generateAPIKey()
+	require.NoError(t, err)
+
+	past := now.Add(-time.Minute)
+	stored := entity.APIKey{
+		ID:       1,
+		Username: "hr-bot",
+		Prefix:   prefix,
+		Hash:     hashAPIKey(plain),
+		Scopes:   []string{entity.ScopeInfoRead},
+	}
+	expired := stored
+	expired.ExpiresAt = &past
+	justUsed := now.Add(-time.Second)
+	recentlyUsed := stored
+	recentlyUsed.LastUsedAt = &justUsed
+	longUnused := now.Add(-time.Hour)
+	staleUse := stored
+	staleUse.LastUsedAt = &longUnused
+
+	tests := []struct {
+		name          string
+		key           string
+		mockRepoSetup func(*repository.MockAPIKey)
+		expectedError error
+	}{
+		{
+			name: "Valid key",
+			key:  plain,
+			mockRepoSetup: func(m *repository.MockAPIKey) {
+				m.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(stored, nil)
+				m.EXPECT().TouchAPIKey(gomock.Any(), 1).Return(nil)
+			},
+		},
+		{
+			name: "Last use time not saved",
+			key:  plain,
+			mockRepoSetup: func(m *repository.MockAPIKey) {
+				m.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(stored, nil)
+				m.EXPECT().TouchAPIKey(gomock.Any(), 1).Return(errors.New("repository error"))
+			},
+		},
+		{
+			name: "Recently used key is not touched",
+			key:  plain,
+			mockRepoSetup: func(m *repository.MockAPIKey) {
+				m.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(recentlyUsed, nil)
+			},
+		},
+		{
+			name: "Long unused key is touched",
+			key:  plain,
+			mockRepoSetup: func(m *repository.MockAPIKey) {
+				m.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(staleUse, nil)
+				m.EXPECT().TouchAPIKey(gomock.Any(), 1).Return(nil)
+			},
+		},
+		{
+			name:          "Malformed key",
+			key:           "ak_short",
+			mockRepoSetup: func(m *repository.MockAPIKey) {},
+			expectedError: servicerrs.ErrInvalidAPIKey,
+		},
+		{
+			name: "Revoked or unknown key",
+			key:  plain,
+			mockRepoSetup: func(m *repository.MockAPIKey) {
+				m.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(entity.APIKey{}, repoerrs.ErrAPIKeyNotFound)
+			},
+			expectedError: servicerrs.ErrInvalidAPIKey,
+		},
+		{
+			name: "Secret mismatch",
+			key:  other[:len(apiKeyMarker)] + prefix + other[len(apiKeyMarker)+len(prefix):],
+			mockRepoSetup: func(m *repository.MockAPIKey) {
+				m.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(stored, nil)
+			},
+			expectedError: servicerrs.ErrInvalidAPIKey,
+		},
+		{
+			name: "Expired key",
+			key:  plain,
+			mockRepoSetup: func(m *repository.MockAPIKey) {
+				m.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(expired, nil)
+			},
+			expectedError: servicerrs.ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup(mockRepo)
+
+			key, err := service.Authenticate(context.Background(), tt.key)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "hr-bot", key.Username)
+		})
+	}
+}
+
+func TestAPIKeyService_Revoke(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockAPIKey(ctrl)
+	service := NewAPIKeyService(zap.NewNop(), mockRepo)
+
+	mockRepo.EXPECT().RevokeAPIKey(gomock.Any(), "hr-bot", 1).Return(nil)
+	assert.NoError(t, service.Revoke(context.Background(), RevokeAPIKeyInput{Username: "hr-bot", ID: 1}))
+
+	mockRepo.EXPECT().RevokeAPIKey(gomock.Any(), "hr-bot", 2).Return(repoerrs.ErrAPIKeyNotFound)
+	err := service.Revoke(context.Background(), RevokeAPIKeyInput{Username: "hr-bot", ID: 2})
+	assert.ErrorIs(t, err, servicerrs.ErrAPIKeyNotFound)
+}
@@ -4,48 +4,100 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
+	"avito-internship/internal/cache"
+	"avito-internship/internal/model"
 	"avito-internship/internal/repository"
 	"avito-internship/internal/repository/repoerrs"
 	"avito-internship/internal/service/servicerrs"
 	"avito-internship/internal/utils/jwt"
+	"avito-internship/internal/utils/password"
 
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const defaultChallengeTTL = 5 * time.Minute
+
 type AuthService struct {
 	log            *zap.Logger
+	cache          cache.Cache
 	userRepository repository.User
-	tokenTTL       time.Duration
-	salt           string
+	sessions       Session
+	keys           *jwt.KeyRing
+	autoRegister   bool
+	passwordPolicy *password.Policy
+	bcryptCost     int
+	challengeTTL   time.Duration
+}
+
+// AuthConfig holds the settings of AuthService.
+type AuthConfig struct {
+	// Sessions issues the access tokens.
+	Sessions     Session
+	Keys         *jwt.KeyRing
+	AutoRegister bool
+	// PasswordPolicy applies to new passwords only. Nil accepts any password bcrypt can hash.
+	PasswordPolicy *password.Policy
+	// BcryptCost of new hashes. Existing hashes are rehashed on login when it changes.
+	BcryptCost int
+	// ChallengeTTL is the lifetime of the challenge token issued to users with 2FA.
+	ChallengeTTL time.Duration
 }
 
-func NewAuthService(log *zap.Logger, repo repository.User, tokenTTL time.Duration, salt string) *AuthService {
+func NewAuthService(log *zap.Logger, cache cache.Cache, repo repository.User, cfg AuthConfig) *AuthService {
+	policy := cfg.PasswordPolicy
+	if policy == nil {
+		policy = password.NewPolicy(0, 0, nil)
+	}
+
+	bcryptCost := cfg.BcryptCost
+	if bcryptCost == 0 {
+		bcryptCost = bcrypt.DefaultCost
+	}
+
+	challengeTTL := cfg.ChallengeTTL
+	if challengeTTL == 0 {
+		challengeTTL = defaultChallengeTTL
+	}
+
 	return &AuthService{
 		log:            log,
+		cache:          cache,
 		userRepository: repo,
-		tokenTTL:       tokenTTL,
-		salt:           salt,
+		sessions:       cfg.Sessions,
+		keys:           cfg.Keys,
+		autoRegister:   cfg.AutoRegister,
+		passwordPolicy: policy,
+		bcryptCost:     bcryptCost,
+		challengeTTL:   challengeTTL,
 	}
 }
 
-func (s *AuthService) Authorization(ctx context.Context, username, password string) (string, error) {
+// Register creates a new account and returns a token for it.
+func (s *AuthService) Register(ctx context.Context, username, password string) (string, error) {
+	const op = "service.Auth.Register"
+	s.log.Info("Attempting to register user", zap.String("username", username))
+
+	return s.createUser(ctx, username, password, op)
+}
+
+func (s *AuthService) Authorization(ctx context.Context, username, password string) (AuthorizationOutput, error) {
 	const op = "service.Auth.Authorization"
 	s.log.Info("Attempting to authorize user", zap.String("username", username))
 
 	user, err := s.userRepository.GetUserCredentials(ctx, username)
 	if err != nil {
 		if errors.Is(err, repoerrs.ErrUserNotFound) {
-			return s.handleUserNotFound(ctx, username, password, op)
+			token, err := s.handleUserNotFound(ctx, username, password, op)
+			return AuthorizationOutput{Token: token}, err
 		}
 		s.log.Error("Failed to retrieve user credentials",
 			zap.String("op", op),
 			zap.Error(err),
 		)
-		return "", fmt.Errorf("%s: %w", op, err)
+		return AuthorizationOutput{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	if err := bcrypt.CompareHashAndPassword(user.Password, []byte(password)); err != nil {
@@ -54,16 +106,197 @@ func (s *AuthService) Authorization(ctx context.Context, username, password stri
 			zap.String("username", username),
 			zap.Error(err),
 		)
+		return AuthorizationOutput{}, fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidCredentials)
+	}
+
+	if cost, err := bcrypt.Cost(user.Password); err == nil && cost != s.bcryptCost {
+		s.rehashPassword(ctx, username, password, op)
+	}
+
+	if user.TwoFactorEnabled {
+		return s.generateChallenge(username, op)
+	}
+
+	token, err := s.generateToken(ctx, username, op)
+	return AuthorizationOutput{Token: token}, err
+}
+
+// generateChallenge issues the token TwoFactor.CompleteLogin exchanges for an access token.
+func (s *AuthService) generateChallenge(username, op string) (AuthorizationOutput, error) {
+	token, err := s.keys.NewChallengeToken(username, s.challengeTTL)
+	if err != nil {
+		s.log.Error("Failed to generate challenge token",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return AuthorizationOutput{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("Password accepted, second factor required",
+		zap.String("username", username),
+	)
+
+	return AuthorizationOutput{Token: token, TwoFactorRequired: true}, nil
+}
+
+// rehashPassword upgrades the stored hash to the configured bcrypt cost.
+// Failures are logged only, since the login itself has succeeded.
+func (s *AuthService) rehashPassword(ctx context.Context, username, password, op string) {
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), s.bcryptCost)
+	if err != nil {
+		s.log.Error("Failed to rehash password",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if err := s.userRepository.RehashPassword(ctx, username, passwordHash); err != nil {
+		s.log.Error("Failed to save rehashed password",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.log.Info("Password rehashed", zap.String("username", username), zap.Int("cost", s.bcryptCost))
+}
+
+// ChangePassword replaces the password of the user, revokes the tokens issued
+// before the change and returns a new token.
+func (s *AuthService) ChangePassword(ctx context.Context, input ChangePasswordInput) (string, error) {
+	const op = "service.Auth.ChangePassword"
+	s.log.Info("Attempting to change password", zap.String("username", input.Username))
+
+	user, err := s.userRepository.GetUserCredentials(ctx, input.Username)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrUserNotFound) {
+			s.log.Warn("User not found",
+				zap.String("op", op),
+				zap.String("username", input.Username),
+			)
+			return "", fmt.Errorf("%s: %w", op, servicerrs.ErrUserNotFound)
+		}
+		s.log.Error("Failed to retrieve user credentials",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(user.Password, []byte(input.CurrentPassword)); err != nil {
+		s.log.Warn("Invalid current password",
+			zap.String("op", op),
+			zap.String("username", input.Username),
+		)
 		return "", fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidCredentials)
 	}
 
-	return s.generateToken(username, op)
+	if err := s.checkPassword(input.NewPassword, op); err != nil {
+		return "", err
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), s.bcryptCost)
+	if err != nil {
+		s.log.Error("Failed to generate password hash",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Postgres keeps microseconds, so the cached time matches the stored one.
+	changedAt := time.Now().Truncate(time.Microsecond)
+	if err := s.userRepository.UpdatePassword(ctx, input.Username, passwordHash, changedAt); err != nil {
+		s.log.Error("Failed to update password",
+			zap.String("op", op),
+			zap.String("username", input.Username),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := cache.Del(ctx, s.cache, passwordChangedAtKey, input.Username); err != nil {
+		s.log.Error("Failed to invalidate password change time",
+			zap.String("op", op),
+			zap.String("username", input.Username),
+			zap.Error(err),
+		)
+	}
+
+	// Tokens issued before the change are rejected anyway, revoking the sessions
+	// removes them from the session list.
+	if err := s.sessions.RevokeAll(ctx, input.Username); err != nil {
+		s.log.Error("Failed to revoke sessions",
+			zap.String("op", op),
+			zap.String("username", input.Username),
+			zap.Error(err),
+		)
+	}
+
+	s.log.Info("Password changed", zap.String("username", input.Username))
+
+	// Tokens carry the issue time in milliseconds, so the new one must be issued
+	// in a later millisecond than the change to outlive it.
+	time.Sleep(time.Until(changedAt.Truncate(time.Millisecond).Add(time.Millisecond)))
+
+	return s.generateToken(ctx, input.Username, op)
+}
+
+func (s *AuthService) checkPassword(plain, op string) error {
+	err := s.passwordPolicy.Validate(plain)
+	if err == nil {
+		return nil
+	}
+
+	s.log.Warn("Password rejected by policy",
+		zap.String("op", op),
+		zap.Error(err),
+	)
+
+	switch {
+	case errors.Is(err, password.ErrTooShort):
+		return fmt.Errorf("%s: %w", op, servicerrs.ErrPasswordTooShort)
+	case errors.Is(err, password.ErrTooLong):
+		return fmt.Errorf("%s: %w", op, servicerrs.ErrPasswordTooLong)
+	case errors.Is(err, password.ErrBreached):
+		return fmt.Errorf("%s: %w", op, servicerrs.ErrPasswordBreached)
+	}
+
+	return fmt.Errorf("%s: %w", op, err)
 }
 
 func (s *AuthService) handleUserNotFound(ctx context.Context, username, password, op string) (string, error) {
+	if !s.autoRegister {
+		s.log.Warn("User not found and auto-registration is disabled",
+			zap.String("op", op),
+			zap.String("username", username),
+		)
+		return "", fmt.Errorf("%s: %w", op, servicerrs.ErrUserNotFound)
+	}
+
 	s.log.Info("User not found, creating a new one", zap.String("username", username))
 
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return s.createUser(ctx, username, password, op)
+}
+
+func (s *AuthService) createUser(ctx context.Context, username, password, op string) (string, error) {
+	if !model.ValidUsername(username) {
+		s.log.Warn("Invalid username",
+			zap.String("op", op),
+			zap.String("username", username),
+		)
+		return "", fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidUsername)
+	}
+
+	if err := s.checkPassword(password, op); err != nil {
+		return "", err
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), s.bcryptCost)
 	if err != nil {
 		s.log.Error("Failed to generate password hash",
 			zap.String("op", op),
@@ -73,6 +306,13 @@ func (s *AuthService) handleUserNotFound(ctx context.Context, username, password
 	}
 
 	if err := s.userRepository.AddUser(ctx, username, passwordHash); err != nil {
+		if errors.Is(err, repoerrs.ErrUserAlreadyExists) {
+			s.log.Warn("User already exists",
+				zap.String("op", op),
+				zap.String("username", username),
+			)
+			return "", fmt.Errorf("%s: %w", op, servicerrs.ErrUserAlreadyExists)
+		}
 		s.log.Error("Failed to create user",
 			zap.String("op", op),
 			zap.Error(err),
@@ -80,11 +320,11 @@ func (s *AuthService) handleUserNotFound(ctx context.Context, username, password
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	return s.generateToken(username, op)
+	return s.generateToken(ctx, username, op)
 }
 
-func (s *AuthService) generateToken(username, op string) (string, error) {
-	token, err := jwt.NewToken(username, s.salt, s.tokenTTL)
+func (s *AuthService) generateToken(ctx context.Context, username, op string) (string, error) {
+	token, err := s.sessions.Start(ctx, username)
 	if err != nil {
 		s.log.Error("Failed to generate token",
 			zap.String("op", op),
@@ -101,25 +341,103 @@ func (s *AuthService) generateToken(username, op string) (string, error) {
 	return token, nil
 }
 
-func (s *AuthService) ValidateToken(token string) (string, error) {
+// ValidateToken validates a raw access token, the Bearer scheme is stripped by the caller.
+func (s *AuthService) ValidateToken(ctx context.Context, token string) (AccessToken, error) {
 	const op = "service.Auth.ValidateToken"
 
-	if len(token) > 7 && strings.HasPrefix(token, "Bearer ") {
-		token = token[7:]
+	claims, err := s.keys.ParseToken(token)
+	if err != nil {
+		s.log.Warn("Invalid token",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+		return AccessToken{}, fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidToken)
+	}
+
+	// Access tokens belong to a session, impersonation tokens name the admin acting with them.
+	valid := claims.Purpose == "" && claims.ID != "" ||
+		claims.Purpose == jwt.PurposeImpersonation && claims.Actor != ""
+	if !valid {
+		s.log.Warn("Token is not an access token",
+			zap.String("op", op),
+			zap.String("username", claims.Username),
+			zap.String("purpose", claims.Purpose),
+		)
+		return AccessToken{}, fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidToken)
 	}
 
-	username, err := jwt.ParseToken(token, s.salt)
+	changedAt, err := s.passwordChangedAt(ctx, claims.Username)
 	if err != nil {
-		s.log.Warn("Invalid token",
+		if errors.Is(err, repoerrs.ErrUserNotFound) {
+			s.log.Warn("Token of unknown user",
+				zap.String("op", op),
+				zap.String("username", claims.Username),
+			)
+			return AccessToken{}, fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidToken)
+		}
+		s.log.Error("Failed to retrieve password change time",
 			zap.String("op", op),
+			zap.String("username", claims.Username),
 			zap.Error(err),
 		)
-		return "", fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidToken)
+		return AccessToken{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if claims.IssuedAt.Before(changedAt) {
+		s.log.Warn("Token revoked by password change",
+			zap.String("op", op),
+			zap.String("username", claims.Username),
+		)
+		return AccessToken{}, fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidToken)
+	}
+
+	if claims.Actor != "" {
+		s.log.Info("Impersonation token validated",
+			zap.String("username", claims.Username),
+			zap.String("actor", claims.Actor),
+		)
+		return AccessToken{Username: claims.Username, Actor: claims.Actor, ReadOnly: claims.ReadOnly}, nil
+	}
+
+	if err := s.sessions.Check(ctx, claims.Username, claims.ID); err != nil {
+		return AccessToken{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	s.log.Info("Token validated successfully",
-		zap.String("username", username),
+		zap.String("username", claims.Username),
 	)
 
-	return username, nil
+	return AccessToken{Username: claims.Username, SessionID: claims.ID}, nil
+}
+
+// passwordChangedAt returns when the user last changed the password, or the
+// epoch if never. The value is cached to keep the database off the request path.
+func (s *AuthService) passwordChangedAt(ctx context.Context, username string) (time.Time, error) {
+	if unixMicro, err := cache.Get(ctx, s.cache, passwordChangedAtKey, username); err == nil {
+		return time.UnixMicro(unixMicro), nil
+	}
+
+	user, err := s.userRepository.GetUserCredentials(ctx, username)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	changedAt := time.Unix(0, 0)
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+
+	if err := cache.Set(ctx, s.cache, passwordChangedAtKey, username, changedAt.UnixMicro()); err != nil {
+		s.log.Error("Failed to cache password change time",
+			zap.String("username", username),
+			zap.Error(err),
+		)
+	}
+
+	return changedAt, nil
+}
+
+// JWKS returns the public keys tokens can be verified with.
+func (s *AuthService) JWKS() jwt.JWKS {
+	return s.keys.JWKS()
 }
@@ -6,10 +6,13 @@ import (
 	"testing"
 	"time"
 
+	"avito-internship/internal/cache"
 	"avito-internship/internal/entity"
 	"avito-internship/internal/repository"
 	"avito-internship/internal/repository/repoerrs"
 	"avito-internship/internal/service/servicerrs"
+	"avito-internship/internal/utils/jwt"
+	"avito-internship/internal/utils/password"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -23,18 +26,24 @@ func TestAuthService_Authorization(t *testing.T) {
 
 	mockRepo := repository.NewMockUser(ctrl)
 	logger := zap.NewNop()
-	tokenTTL := time.Hour
-	salt := "test-salt"
+	keys, err := jwt.GenerateKeyRing()
+	assert.NoError(t, err)
 
-	service := NewAuthService(logger, mockRepo, tokenTTL, salt)
+	service := NewAuthService(logger, cache.NewMockCache(ctrl), mockRepo, AuthConfig{
+		Sessions:       newTestSessions(ctrl, keys),
+		Keys:           keys,
+		AutoRegister:   true,
+		PasswordPolicy: password.NewPolicy(8, 0, []string{"qwerty123"}),
+	})
 
 	tests := []struct {
-		name          string
-		username      string
-		password      string
-		mockRepoSetup func()
-		expectedToken string
-		expectedError error
+		name              string
+		username          string
+		password          string
+		mockRepoSetup     func()
+		expectedToken     string
+		twoFactorRequired bool
+		expectedError     error
 	}{
 		{
 			name:     "Successful authorization",
@@ -49,6 +58,18 @@ func TestAuthService_Authorization(t *testing.T) {
 			expectedToken: "valid-token",
 			expectedError: nil,
 		},
+		{
+			name:     "2FA enabled, challenge issued",
+			username: "user1",
+			password: "password123",
+			mockRepoSetup: func() {
+				hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+				mockRepo.EXPECT().
+					GetUserCredentials(gomock.Any(), "user1").
+					Return(entity.User{Password: hashedPassword, TwoFactorEnabled: true}, nil)
+			},
+			twoFactorRequired: true,
+		},
 		{
 			name:     "User not found, successful registration",
 			username: "user1",
@@ -64,6 +85,51 @@ func TestAuthService_Authorization(t *testing.T) {
 			expectedToken: "valid-token",
 			expectedError: nil,
 		},
+		{
+			name:     "User not found, invalid username",
+			username: "1 user",
+			password: "password123",
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().
+					GetUserCredentials(gomock.Any(), "1 user").
+					Return(entity.User{}, repoerrs.ErrUserNotFound)
+			},
+			expectedToken: "",
+			expectedError: servicerrs.ErrInvalidUsername,
+		},
+		{
+			name:     "User not found, breached password",
+			username: "user1",
+			password: "qwerty123",
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().
+					GetUserCredentials(gomock.Any(), "user1").
+					Return(entity.User{}, repoerrs.ErrUserNotFound)
+			},
+			expectedToken: "",
+			expectedError: servicerrs.ErrPasswordBreached,
+		},
+		{
+			name:     "Outdated bcrypt cost, password rehashed",
+			username: "user1",
+			password: "password123",
+			mockRepoSetup: func() {
+				hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+				mockRepo.EXPECT().
+					GetUserCredentials(gomock.Any(), "user1").
+					Return(entity.User{Password: hashedPassword}, nil)
+				mockRepo.EXPECT().
+					RehashPassword(gomock.Any(), "user1", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, hash []byte) error {
+						cost, err := bcrypt.Cost(hash)
+						assert.NoError(t, err)
+						assert.Equal(t, bcrypt.DefaultCost, cost)
+						return nil
+					})
+			},
+			expectedToken: "valid-token",
+			expectedError: nil,
+		},
 		{
 			name:     "Invalid credentials",
 			username: "user1",
@@ -95,14 +161,360 @@ func TestAuthService_Authorization(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockRepoSetup()
 
-			token, err := service.Authorization(context.Background(), tt.username, tt.password)
+			output, err := service.Authorization(context.Background(), tt.username, tt.password)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, output.Token)
+				assert.Equal(t, tt.twoFactorRequired, output.TwoFactorRequired)
+
+				claims, err := keys.ParseToken(output.Token)
+				assert.NoError(t, err)
+				if tt.twoFactorRequired {
+					assert.Equal(t, jwt.PurposeTwoFactor, claims.Purpose)
+				} else {
+					assert.Empty(t, claims.Purpose)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthService_ValidateToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockUser(ctrl)
+	logger := zap.NewNop()
+
+	keys, err := jwt.GenerateKeyRing()
+	assert.NoError(t, err)
+	otherKeys, err := jwt.GenerateKeyRing()
+	assert.NoError(t, err)
+
+	mockCache, _ := newMemoryCache(ctrl)
+	mockSessions := NewMockSession(ctrl)
+	service := NewAuthService(logger, mockCache, mockRepo, AuthConfig{Sessions: mockSessions, Keys: keys})
+
+	challengeToken, err := keys.NewChallengeToken("user1", time.Minute)
+	assert.NoError(t, err)
+
+	token, err := keys.NewToken("user1", "session1", time.Hour)
+	assert.NoError(t, err)
+	foreignToken, err := otherKeys.NewToken("user1", "session1", time.Hour)
+	assert.NoError(t, err)
+	revokedToken, err := keys.NewToken("user2", "session2", time.Hour)
+	assert.NoError(t, err)
+	unknownUserToken, err := keys.NewToken("user3", "session3", time.Hour)
+	assert.NoError(t, err)
+	noSessionToken, err := keys.NewToken("user1", "", time.Hour)
+	assert.NoError(t, err)
+	revokedSessionToken, err := keys.NewToken("user1", "session4", time.Hour)
+	assert.NoError(t, err)
+
+	impersonationToken, err := keys.NewImpersonationToken("user1", "admin", true, time.Minute)
+	assert.NoError(t, err)
+
+	mockSessions.EXPECT().Check(gomock.Any(), "user1", "session1").Return(nil)
+	mockSessions.EXPECT().Check(gomock.Any(), "user1", "session4").Return(servicerrs.ErrInvalidToken)
+
+	// The password change time is loaded once and then served from the cache.
+	mockRepo.EXPECT().
+		GetUserCredentials(gomock.Any(), "user1").
+		Return(entity.User{Username: "user1"}, nil)
+	// The password is changed right after the token is issued, usually within the same second.
+	changedAt := time.Now()
+	mockRepo.EXPECT().
+		GetUserCredentials(gomock.Any(), "user2").
+		Return(entity.User{Username: "user2", PasswordChangedAt: &changedAt}, nil)
+	mockRepo.EXPECT().
+		GetUserCredentials(gomock.Any(), "user3").
+		Return(entity.User{}, repoerrs.ErrUserNotFound)
+
+	tests := []struct {
+		name          string
+		token         string
+		expected      AccessToken
+		expectedError error
+	}{
+		{
+			name:     "Valid token",
+			token:    token,
+			expected: AccessToken{Username: "user1", SessionID: "session1"},
+		},
+		{
+			name:     "Impersonation token is not bound to a session",
+			token:    impersonationToken,
+			expected: AccessToken{Username: "user1", Actor: "admin", ReadOnly: true},
+		},
+		{
+			name:          "Token with authorization scheme",
+			token:         "Bearer " + token,
+			expectedError: servicerrs.ErrInvalidToken,
+		},
+		{
+			name:          "Token signed by unknown key",
+			token:         foreignToken,
+			expectedError: servicerrs.ErrInvalidToken,
+		},
+		{
+			name:          "Challenge token is not an access token",
+			token:         challengeToken,
+			expectedError: servicerrs.ErrInvalidToken,
+		},
+		{
+			name:          "Token issued before password change",
+			token:         revokedToken,
+			expectedError: servicerrs.ErrInvalidToken,
+		},
+		{
+			name:          "Token of deleted user",
+			token:         unknownUserToken,
+			expectedError: servicerrs.ErrInvalidToken,
+		},
+		{
+			name:          "Token without session",
+			token:         noSessionToken,
+			expectedError: servicerrs.ErrInvalidToken,
+		},
+		{
+			name:          "Token of revoked session",
+			token:         revokedSessionToken,
+			expectedError: servicerrs.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessToken, err := service.ValidateToken(context.Background(), tt.token)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, accessToken)
+			}
+		})
+	}
+}
+
+func TestAuthService_AuthorizationWithoutAutoRegister(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockUser(ctrl)
+	keys, err := jwt.GenerateKeyRing()
+	assert.NoError(t, err)
+
+	service := NewAuthService(zap.NewNop(), cache.NewMockCache(ctrl), mockRepo, AuthConfig{Sessions: newTestSessions(ctrl, keys), Keys: keys})
+
+	mockRepo.EXPECT().
+		GetUserCredentials(gomock.Any(), "user1").
+		Return(entity.User{}, repoerrs.ErrUserNotFound)
+
+	output, err := service.Authorization(context.Background(), "user1", "password123")
+
+	assert.ErrorIs(t, err, servicerrs.ErrUserNotFound)
+	assert.Empty(t, output.Token)
+}
+
+func TestAuthService_Register(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockUser(ctrl)
+	keys, err := jwt.GenerateKeyRing()
+	assert.NoError(t, err)
+
+	// Registration works regardless of the auto-registration switch.
+	service := NewAuthService(zap.NewNop(), cache.NewMockCache(ctrl), mockRepo, AuthConfig{
+		Sessions:       newTestSessions(ctrl, keys),
+		Keys:           keys,
+		PasswordPolicy: password.NewPolicy(8, 0, nil),
+		BcryptCost:     bcrypt.MinCost,
+	})
+
+	tests := []struct {
+		name          string
+		username      string
+		password      string
+		mockRepoSetup func()
+		expectedError error
+	}{
+		{
+			name:     "Successful registration",
+			username: "user1",
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().
+					AddUser(gomock.Any(), "user1", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, password []byte) error {
+						assert.NoError(t, bcrypt.CompareHashAndPassword(password, []byte("password123")))
+						return nil
+					})
+			},
+		},
+		{
+			name:          "Invalid username",
+			username:      "us",
+			mockRepoSetup: func() {},
+			expectedError: servicerrs.ErrInvalidUsername,
+		},
+		{
+			name:          "Password too short",
+			username:      "user1",
+			password:      "short",
+			mockRepoSetup: func() {},
+			expectedError: servicerrs.ErrPasswordTooShort,
+		},
+		{
+			name:     "User already exists",
+			username: "user1",
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().
+					AddUser(gomock.Any(), "user1", gomock.Any()).
+					Return(repoerrs.ErrUserAlreadyExists)
+			},
+			expectedError: servicerrs.ErrUserAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup()
+
+			pass := tt.password
+			if pass == "" {
+				pass = "password123"
+			}
+
+			token, err := service.Register(context.Background(), tt.username, pass)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Empty(t, token)
+			} else {
+				assert.NoError(t, err)
+				claims, err := keys.ParseToken(token)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.username, claims.Username)
+			}
+		})
+	}
+}
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockUser(ctrl)
+	mockCache := cache.NewMockCache(ctrl)
+	mockSessions := NewMockSession(ctrl)
+	keys, err := jwt.GenerateKeyRing()
+	assert.NoError(t, err)
+
+	service := NewAuthService(zap.NewNop(), mockCache, mockRepo, AuthConfig{
+		Sessions:       mockSessions,
+		Keys:           keys,
+		PasswordPolicy: password.NewPolicy(8, 0, []string{"password1"}),
+		BcryptCost:     bcrypt.MinCost,
+	})
+
+	currentHash, _ := bcrypt.GenerateFromPassword([]byte("current-password"), bcrypt.MinCost)
+
+	tests := []struct {
+		name          string
+		input         ChangePasswordInput
+		mockSetup     func()
+		expectedError error
+	}{
+		{
+			name: "Successful change",
+			input: ChangePasswordInput{
+				Username:        "user1",
+				CurrentPassword: "current-password",
+				NewPassword:     "new-password",
+			},
+			mockSetup: func() {
+				mockRepo.EXPECT().
+					GetUserCredentials(gomock.Any(), "user1").
+					Return(entity.User{Username: "user1", Password: currentHash}, nil)
+				mockRepo.EXPECT().
+					UpdatePassword(gomock.Any(), "user1", gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, hash []byte, _ time.Time) error {
+						assert.NoError(t, bcrypt.CompareHashAndPassword(hash, []byte("new-password")))
+						return nil
+					})
+				mockCache.EXPECT().Del(gomock.Any(), "password_changed_at:v2:user1").Return(nil)
+				gomock.InOrder(
+					mockSessions.EXPECT().RevokeAll(gomock.Any(), "user1").Return(nil),
+					mockSessions.EXPECT().Start(gomock.Any(), "user1").Return("new-token", nil),
+				)
+			},
+		},
+		{
+			name: "Wrong current password",
+			input: ChangePasswordInput{
+				Username:        "user1",
+				CurrentPassword: "wrong-password",
+				NewPassword:     "new-password",
+			},
+			mockSetup: func() {
+				mockRepo.EXPECT().
+					GetUserCredentials(gomock.Any(), "user1").
+					Return(entity.User{Username: "user1", Password: currentHash}, nil)
+			},
+			expectedError: servicerrs.ErrInvalidCredentials,
+		},
+		{
+			name: "Breached new password",
+			input: ChangePasswordInput{
+				Username:        "user1",
+				CurrentPassword: "current-password",
+				NewPassword:     "password1",
+			},
+			mockSetup: func() {
+				mockRepo.EXPECT().
+					GetUserCredentials(gomock.Any(), "user1").
+					Return(entity.User{Username: "user1", Password: currentHash}, nil)
+			},
+			expectedError: servicerrs.ErrPasswordBreached,
+		},
+		{
+			name: "Repository error",
+			input: ChangePasswordInput{
+				Username:        "user1",
+				CurrentPassword: "current-password",
+				NewPassword:     "new-password",
+			},
+			mockSetup: func() {
+				mockRepo.EXPECT().
+					GetUserCredentials(gomock.Any(), "user1").
+					Return(entity.User{Username: "user1", Password: currentHash}, nil)
+				mockRepo.EXPECT().
+					UpdatePassword(gomock.Any(), "user1", gomock.Any(), gomock.Any()).
+					Return(errors.New("repository error"))
+			},
+			expectedError: errors.New("repository error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			token, err := service.ChangePassword(context.Background(), tt.input)
 
 			if tt.expectedError != nil {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedError.Error())
+				assert.Empty(t, token)
 			} else {
 				assert.NoError(t, err)
-				assert.NotEmpty(t, token)
+				assert.Equal(t, "new-token", token)
 			}
 		})
 	}
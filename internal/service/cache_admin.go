@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/cache/breaker"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/service/servicerrs"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// CacheWarmUpConfig selects the users whose info WarmCache preloads.
+type CacheWarmUpConfig struct {
+	// Users is the number of the most active users preloaded by default.
+	Users int
+	// Period is how far back the activity of users is counted.
+	Period time.Duration
+	// Concurrency bounds the user info loads running at once.
+	Concurrency int
+}
+
+type CacheAdminService struct {
+	log      *zap.Logger
+	cache    cache.Cache
+	repo     repository.User
+	users    User
+	products Product
+	warmUp   CacheWarmUpConfig
+	now      func() time.Time
+}
+
+func NewCacheAdminService(log *zap.Logger, cache cache.Cache, repo repository.User, users User, products Product, warmUp CacheWarmUpConfig) *CacheAdminService {
+	return &CacheAdminService{
+		log:      log,
+		cache:    cache,
+		repo:     repo,
+		users:    users,
+		products: products,
+		warmUp:   warmUp,
+		now:      time.Now,
+	}
+}
+
+// WarmCache preloads the catalog and the info of the most active users, so
+// after a deploy or a redis flush their first requests do not all reach
+// postgres at once. Values are loaded through the services, which cache them
+// as on any read; values cached already are left as they are. A user whose
+// info fails to load is counted and skipped.
+func (s *CacheAdminService) WarmCache(ctx context.Context, input WarmCacheInput) (WarmCacheOutput, error) {
+	const op = "service.CacheAdminService.WarmCache"
+
+	users := input.Users
+	if users <= 0 {
+		users = s.warmUp.Users
+	}
+
+	s.log.Info("attempting to warm up cache", zap.Int("users", users))
+
+	var output WarmCacheOutput
+	if _, err := s.products.SearchProducts(ctx, SearchProductsInput{}); err != nil {
+		s.log.Warn("failed to warm up catalog",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+	} else {
+		output.Catalog = true
+	}
+
+	usernames, err := s.repo.GetMostActiveUsers(ctx, s.now().Add(-s.warmUp.Period), users)
+	if err != nil {
+		s.log.Error("failed to get most active users",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return output, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		g      errgroup.Group
+		failed atomic.Int64
+	)
+	g.SetLimit(max(s.warmUp.Concurrency, 1))
+	for _, username := range usernames {
+		g.Go(func() error {
+			if _, err := s.users.RetrieveUserInfo(ctx, RetrieveUserInfoInput{Username: username}); err != nil {
+				failed.Add(1)
+				s.log.Warn("failed to warm up user info",
+					zap.String("op", op),
+					zap.String("username", username),
+					zap.Error(err),
+				)
+			}
+			return nil
+		})
+	}
+	// The goroutines never fail, errors are counted instead.
+	_ = g.Wait()
+
+	output.Failed = int(failed.Load())
+	output.Users = len(usernames) - output.Failed
+
+	s.log.Info("cache successfully warmed up",
+		zap.Int("users", output.Users),
+		zap.Int("failed", output.Failed),
+		zap.Bool("catalog", output.Catalog),
+	)
+
+	return output, nil
+}
+
+func (s *CacheAdminService) InspectCacheEntry(ctx context.Context, key string) (cache.Entry, error) {
+	const op = "service.CacheAdminService.InspectCacheEntry"
+
+	entry, err := s.cache.Inspect(ctx, key)
+	if err != nil {
+		if errors.Is(err, cache.ErrMiss) {
+			return cache.Entry{}, fmt.Errorf("%s: %w", op, servicerrs.ErrCacheEntryNotFound)
+		}
+
+		s.log.Error("failed to inspect cache entry",
+			zap.String("op", op),
+			zap.String("key", key),
+			zap.Error(err),
+		)
+
+		return cache.Entry{}, fmt.Errorf("%s: %w", op, cacheError(err))
+	}
+
+	return entry, nil
+}
+
+func (s *CacheAdminService) EvictCacheEntries(ctx context.Context, pattern string) (int, error) {
+	const op = "service.CacheAdminService.EvictCacheEntries"
+
+	s.log.Info("attempting to evict cache entries", zap.String("pattern", pattern))
+
+	evicted, err := s.cache.Evict(ctx, pattern)
+	if err != nil {
+		s.log.Error("failed to evict cache entries",
+			zap.String("op", op),
+			zap.String("pattern", pattern),
+			zap.Int("evicted", evicted),
+			zap.Error(err),
+		)
+
+		return evicted, fmt.Errorf("%s: %w", op, cacheError(err))
+	}
+
+	s.log.Info("cache entries successfully evicted", zap.Int("evicted", evicted))
+
+	return evicted, nil
+}
+
+func (s *CacheAdminService) FlushCache(ctx context.Context) error {
+	const op = "service.CacheAdminService.FlushCache"
+
+	s.log.Info("attempting to flush cache")
+
+	if err := s.cache.Flush(ctx); err != nil {
+		s.log.Error("failed to flush cache",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return fmt.Errorf("%s: %w", op, cacheError(err))
+	}
+
+	s.log.Info("cache successfully flushed")
+
+	return nil
+}
+
+func (s *CacheAdminService) CacheStats() CacheStats {
+	return CacheStats{
+		UserInfo: s.users.InfoCacheStats(),
+		Catalog:  s.products.CatalogCacheStats(),
+	}
+}
+
+// cacheError reports a bypassed cache as unavailable, so admins retry later.
+func cacheError(err error) error {
+	if errors.Is(err, breaker.ErrOpen) {
+		return servicerrs.ErrCacheUnavailable
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/cache/breaker"
+	"avito-internship/internal/cache/loader"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCacheAdminService_WarmCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	warmUp := CacheWarmUpConfig{Users: 100, Period: 24 * time.Hour, Concurrency: 2}
+
+	tests := []struct {
+		name           string
+		input          WarmCacheInput
+		setup          func(repo *repository.MockUser, users *MockUser, products *MockProduct)
+		expectedOutput WarmCacheOutput
+		expectedError  bool
+	}{
+		{
+			name:  "Configured number of users",
+			input: WarmCacheInput{},
+			setup: func(repo *repository.MockUser, users *MockUser, products *MockProduct) {
+				products.EXPECT().SearchProducts(gomock.Any(), SearchProductsInput{}).Return(nil, nil)
+				repo.EXPECT().GetMostActiveUsers(gomock.Any(), now.Add(-24*time.Hour), 100).
+					Return([]string{"user1", "user2", "user3"}, nil)
+				users.EXPECT().RetrieveUserInfo(gomock.Any(), RetrieveUserInfoInput{Username: "user1"}).Return(RetrieveUserInfoOutput{}, nil)
+				users.EXPECT().RetrieveUserInfo(gomock.Any(), RetrieveUserInfoInput{Username: "user2"}).Return(RetrieveUserInfoOutput{}, errors.New("db error"))
+				users.EXPECT().RetrieveUserInfo(gomock.Any(), RetrieveUserInfoInput{Username: "user3"}).Return(RetrieveUserInfoOutput{}, nil)
+			},
+			expectedOutput: WarmCacheOutput{Users: 2, Failed: 1, Catalog: true},
+		},
+		{
+			name:  "Requested number of users",
+			input: WarmCacheInput{Users: 1},
+			setup: func(repo *repository.MockUser, users *MockUser, products *MockProduct) {
+				products.EXPECT().SearchProducts(gomock.Any(), SearchProductsInput{}).Return(nil, errors.New("db error"))
+				repo.EXPECT().GetMostActiveUsers(gomock.Any(), now.Add(-24*time.Hour), 1).Return([]string{"user1"}, nil)
+				users.EXPECT().RetrieveUserInfo(gomock.Any(), RetrieveUserInfoInput{Username: "user1"}).Return(RetrieveUserInfoOutput{}, nil)
+			},
+			expectedOutput: WarmCacheOutput{Users: 1, Catalog: false},
+		},
+		{
+			name:  "Most active users error",
+			input: WarmCacheInput{},
+			setup: func(repo *repository.MockUser, users *MockUser, products *MockProduct) {
+				products.EXPECT().SearchProducts(gomock.Any(), SearchProductsInput{}).Return(nil, nil)
+				repo.EXPECT().GetMostActiveUsers(gomock.Any(), gomock.Any(), 100).Return(nil, errors.New("db error"))
+			},
+			expectedOutput: WarmCacheOutput{Catalog: true},
+			expectedError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := repository.NewMockUser(ctrl)
+			mockUsers := NewMockUser(ctrl)
+			mockProducts := NewMockProduct(ctrl)
+			tt.setup(mockRepo, mockUsers, mockProducts)
+
+			service := NewCacheAdminService(zap.NewNop(), cache.NewMockCache(ctrl), mockRepo, mockUsers, mockProducts, warmUp)
+			service.now = func() time.Time { return now }
+
+			output, err := service.WarmCache(ctx, tt.input)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedOutput, output)
+		})
+	}
+}
+
+func TestCacheAdminService_Entries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockCache := cache.NewMockCache(ctrl)
+	service := NewCacheAdminService(zap.NewNop(), mockCache, repository.NewMockUser(ctrl), NewMockUser(ctrl), NewMockProduct(ctrl), CacheWarmUpConfig{})
+
+	entry := cache.Entry{Key: "user_info:v1:user1", Value: `{"value":{}}`, TTL: time.Minute}
+	mockCache.EXPECT().Inspect(gomock.Any(), "user_info:v1:user1").Return(entry, nil)
+	got, err := service.InspectCacheEntry(ctx, "user_info:v1:user1")
+	require.NoError(t, err)
+	assert.Equal(t, entry, got)
+
+	mockCache.EXPECT().Inspect(gomock.Any(), "user_info:v1:user2").Return(cache.Entry{}, cache.ErrMiss)
+	_, err = service.InspectCacheEntry(ctx, "user_info:v1:user2")
+	assert.ErrorIs(t, err, servicerrs.ErrCacheEntryNotFound)
+
+	mockCache.EXPECT().Evict(gomock.Any(), "user_info:*").Return(3, nil)
+	evicted, err := service.EvictCacheEntries(ctx, "user_info:*")
+	require.NoError(t, err)
+	assert.Equal(t, 3, evicted)
+
+	mockCache.EXPECT().Flush(gomock.Any()).Return(breaker.ErrOpen)
+	err = service.FlushCache(ctx)
+	assert.ErrorIs(t, err, servicerrs.ErrCacheUnavailable)
+}
+
+func TestCacheAdminService_CacheStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsers := NewMockUser(ctrl)
+	mockProducts := NewMockProduct(ctrl)
+	service := NewCacheAdminService(zap.NewNop(), cache.NewMockCache(ctrl), repository.NewMockUser(ctrl), mockUsers, mockProducts, CacheWarmUpConfig{})
+
+	mockUsers.EXPECT().InfoCacheStats().Return(loader.Stats{Hits: 3, Misses: 1})
+	mockProducts.EXPECT().CatalogCacheStats().Return(loader.Stats{Hits: 10})
+
+	assert.Equal(t, CacheStats{
+		UserInfo: loader.Stats{Hits: 3, Misses: 1},
+		Catalog:  loader.Stats{Hits: 10},
+	}, service.CacheStats())
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/entity"
+)
+
+// catalogID identifies the only entry of catalogKey.
+const catalogID = "all"
+
+// Cache keys of all services. Bump the version of a key when the type it
+// holds changes, so values written by the previous release are not decoded
+// into the new type.
+var (
+	// userInfoKey is updated by operations and dropped after order
+	// cancellations. It is kept short, as a load on another replica that read
+	// the info before a cancellation can still cache it after the drop.
+	// Version 4 stores the info version the operations are checked against.
+	userInfoKey = cache.NewKey[RetrieveUserInfoOutput]("user_info", 4, 5*time.Minute)
+
+	sessionStateKey = cache.NewKey[sessionState]("session", 1, 30*time.Minute)
+
+	// The login failure counters count every attempt until it succeeds and expire
+	// LoginAttemptsLimits.LockoutDuration after the last one, the login blocks
+	// when they end, so the TTL of these keys is not used.
+	userLoginFailuresKey = cache.NewKey[int64]("login_failures:user", 1, 0)
+	ipLoginFailuresKey   = cache.NewKey[int64]("login_failures:ip", 1, 0)
+	userLoginBlockKey    = cache.NewKey[time.Time]("login_block:user", 1, 0)
+	ipLoginBlockKey      = cache.NewKey[time.Time]("login_block:ip", 1, 0)
+
+	// challengeFailuresKey counts the wrong codes entered with a challenge
+	// token, by its ID, and expires with the token.
+	challengeFailuresKey = cache.NewKey[int64]("2fa_challenge_failures", 1, 0)
+
+	// oidcLoginKey bounds the time the user has to log in at the provider.
+	oidcLoginKey = cache.NewKey[oidcLogin]("oidc_state", 1, 10*time.Minute)
+
+	// catalogKey holds the unfiltered catalog under catalogID. It is dropped
+	// when admins change products, stock sold or refunded shows up on expiry.
+	catalogKey = cache.NewKey[[]entity.Product]("catalog", 1, time.Minute)
+
+	// passwordChangedAtKey holds a Unix time in microseconds.
+	passwordChangedAtKey = cache.NewKey[int64]("password_changed_at", 2, 30*time.Minute)
+)
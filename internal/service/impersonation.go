@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/internal/utils/jwt"
+
+	"go.uber.org/zap"
+)
+
+// ImpersonationService lets admins act on behalf of users to debug their
+// complaints. Every token issued and every request made with it is audited.
+type ImpersonationService struct {
+	log      *zap.Logger
+	repo     repository.Impersonation
+	keys     *jwt.KeyRing
+	tokenTTL time.Duration
+	now      func() time.Time
+}
+
+func NewImpersonationService(log *zap.Logger, repo repository.Impersonation, keys *jwt.KeyRing, tokenTTL time.Duration) *ImpersonationService {
+	return &ImpersonationService{
+		log:      log,
+		repo:     repo,
+		keys:     keys,
+		tokenTTL: tokenTTL,
+		now:      time.Now,
+	}
+}
+
+// Start issues an impersonation token for the subject. The token is read-only
+// unless input.AllowWrite is set.
+func (s *ImpersonationService) Start(ctx context.Context, input StartImpersonationInput) (ImpersonationOutput, error) {
+	const op = "service.Impersonation.Start"
+
+	if input.Actor == input.Subject {
+		return ImpersonationOutput{}, fmt.Errorf("%s: %w", op, servicerrs.ErrImpersonateSelf)
+	}
+
+	// The token is issued only once the audit entry is written.
+	err := s.repo.AddImpersonationEvent(ctx, entity.ImpersonationEvent{
+		Actor:   input.Actor,
+		Subject: input.Subject,
+		Action:  entity.ImpersonationStarted,
+		Reason:  input.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrUserNotFound) {
+			return ImpersonationOutput{}, fmt.Errorf("%s: %w", op, servicerrs.ErrUserNotFound)
+		}
+		s.log.Error("Failed to audit impersonation",
+			zap.String("op", op),
+			zap.String("actor", input.Actor),
+			zap.String("subject", input.Subject),
+			zap.Error(err),
+		)
+		return ImpersonationOutput{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	expiresAt := s.now().Add(s.tokenTTL)
+	token, err := s.keys.NewImpersonationToken(input.Subject, input.Actor, !input.AllowWrite, s.tokenTTL)
+	if err != nil {
+		s.log.Error("Failed to generate impersonation token",
+			zap.String("op", op),
+			zap.String("actor", input.Actor),
+			zap.String("subject", input.Subject),
+			zap.Error(err),
+		)
+		return ImpersonationOutput{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Warn("Impersonation started",
+		zap.String("actor", input.Actor),
+		zap.String("subject", input.Subject),
+		zap.Bool("allow_write", input.AllowWrite),
+		zap.String("reason", input.Reason),
+	)
+
+	return ImpersonationOutput{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// Audit records a request made with an impersonation token. The request must
+// be rejected when it fails, so nothing is done on behalf of a user unaudited.
+func (s *ImpersonationService) Audit(ctx context.Context, event entity.ImpersonationEvent) error {
+	const op = "service.Impersonation.Audit"
+
+	if err := s.repo.AddImpersonationEvent(ctx, event); err != nil {
+		s.log.Error("Failed to audit impersonated request",
+			zap.String("op", op),
+			zap.String("actor", event.Actor),
+			zap.String("subject", event.Subject),
+			zap.String("method", event.Method),
+			zap.String("path", event.Path),
+			zap.Error(err),
+		)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("Impersonated request",
+		zap.String("actor", event.Actor),
+		zap.String("subject", event.Subject),
+		zap.String("action", event.Action),
+		zap.String("method", event.Method),
+		zap.String("path", event.Path),
+	)
+
+	return nil
+}
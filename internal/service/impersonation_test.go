@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/internal/utils/jwt"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestImpersonationService_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	keys, err := jwt.GenerateKeyRing()
+	require.NoError(t, err)
+
+	mockRepo := repository.NewMockImpersonation(ctrl)
+	service := NewImpersonationService(zap.NewNop(), mockRepo, keys, 15*time.Minute)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	started := entity.ImpersonationEvent{
+		Actor:   "admin",
+		Subject: "user1",
+		Action:  entity.ImpersonationStarted,
+		Reason:  "SUP-42",
+	}
+
+	tests := []struct {
+		name          string
+		input         StartImpersonationInput
+		mockRepoSetup func()
+		wantReadOnly  bool
+		expectedError error
+	}{
+		{
+			name:  "Read-only token",
+			input: StartImpersonationInput{Actor: "admin", Subject: "user1", Reason: "SUP-42"},
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().AddImpersonationEvent(gomock.Any(), started).Return(nil)
+			},
+			wantReadOnly: true,
+		},
+		{
+			name:  "Write token",
+			input: StartImpersonationInput{Actor: "admin", Subject: "user1", Reason: "SUP-42", AllowWrite: true},
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().AddImpersonationEvent(gomock.Any(), started).Return(nil)
+			},
+		},
+		{
+			name:          "Actor impersonates themselves",
+			input:         StartImpersonationInput{Actor: "admin", Subject: "admin", Reason: "SUP-42"},
+			mockRepoSetup: func() {},
+			expectedError: servicerrs.ErrImpersonateSelf,
+		},
+		{
+			name:  "Unknown user",
+			input: StartImpersonationInput{Actor: "admin", Subject: "user1", Reason: "SUP-42"},
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().AddImpersonationEvent(gomock.Any(), started).Return(repoerrs.ErrUserNotFound)
+			},
+			expectedError: servicerrs.ErrUserNotFound,
+		},
+		{
+			name:  "Audit log unavailable",
+			input: StartImpersonationInput{Actor: "admin", Subject: "user1", Reason: "SUP-42"},
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().AddImpersonationEvent(gomock.Any(), started).Return(errors.New("repository error"))
+			},
+			expectedError: errors.New("repository error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup()
+
+			output, err := service.Start(context.Background(), tt.input)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+				assert.Empty(t, output.Token)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, now.Add(15*time.Minute), output.ExpiresAt)
+
+			claims, err := keys.ParseToken(output.Token)
+			require.NoError(t, err)
+			assert.Equal(t, "user1", claims.Username)
+			assert.Equal(t, "admin", claims.Actor)
+			assert.Equal(t, jwt.PurposeImpersonation, claims.Purpose)
+			assert.Equal(t, tt.wantReadOnly, claims.ReadOnly)
+		})
+	}
+}
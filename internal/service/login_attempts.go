@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/cache/memory"
+
+	"go.uber.org/zap"
+)
+
+// loginAttemptsFallbackEntries bounds the counters a replica keeps on its own
+// while the shared cache is unavailable.
+const loginAttemptsFallbackEntries = 10000
+
+type LoginAttemptsService struct {
+	log   *zap.Logger
+	cache cache.Cache
+	// fallback keeps the counters while the shared cache fails, e.g. with its
+	// circuit open, so logins stay limited, per replica, during an outage.
+	fallback cache.Cache
+	limits   LoginAttemptsLimits
+	now      func() time.Time
+}
+
+func NewLoginAttemptsService(log *zap.Logger, cache cache.Cache, limits LoginAttemptsLimits) *LoginAttemptsService {
+	return &LoginAttemptsService{
+		log:      log,
+		cache:    cache,
+		fallback: memory.NewMemoryCache(loginAttemptsFallbackEntries, log),
+		limits:   limits,
+		now:      time.Now,
+	}
+}
+
+// Attempt counts a login attempt before the credentials are checked and returns
+// how long the client has to wait if it is throttled, in which case the attempt
+// must not be made. The attempt counts as a failure until RegisterSuccess, so
+// concurrent attempts cannot get past the limits. An empty username counts the
+// attempt for the IP only.
+func (s *LoginAttemptsService) Attempt(ctx context.Context, username, ip string) time.Duration {
+	var userDelay time.Duration
+	if username != "" {
+		userDelay = s.attempt(ctx, userLoginFailuresKey, userLoginBlockKey, username, s.limits.MaxAttempts)
+	}
+	ipDelay := s.attempt(ctx, ipLoginFailuresKey, ipLoginBlockKey, ip, s.limits.IPMaxAttempts)
+
+	// A throttled attempt is not made, so it does not count against the limit that let it through.
+	if userDelay > 0 && ipDelay == 0 {
+		s.release(ctx, ipLoginFailuresKey.For(ip))
+	}
+	if ipDelay > 0 && userDelay == 0 && username != "" {
+		s.release(ctx, userLoginFailuresKey.For(username))
+	}
+
+	delay := max(userDelay, ipDelay)
+	if delay > 0 {
+		s.log.Warn("Login attempts throttled",
+			zap.String("username", username),
+			zap.String("ip", ip),
+			zap.Duration("retry_after", delay),
+		)
+	}
+
+	return delay
+}
+
+// RegisterFailure blocks the client after a failed attempt and returns how long
+// it is blocked for. An empty username blocks the IP only.
+func (s *LoginAttemptsService) RegisterFailure(ctx context.Context, username, ip string) time.Duration {
+	var userDelay time.Duration
+	if username != "" {
+		userDelay = s.registerFailure(ctx, userLoginFailuresKey, userLoginBlockKey, username, s.limits.FreeAttempts, s.limits.MaxAttempts)
+	}
+	ipDelay := s.registerFailure(ctx, ipLoginFailuresKey, ipLoginBlockKey, ip, s.limits.IPFreeAttempts, s.limits.IPMaxAttempts)
+
+	return max(userDelay, ipDelay)
+}
+
+// RegisterSuccess clears the failure history of the username and takes the
+// successful attempt back from the IP. The rest of the IP history is kept, so
+// one valid account cannot be used to reset it. An empty username only takes
+// the attempt back from the IP, e.g. when a second factor is still required.
+func (s *LoginAttemptsService) RegisterSuccess(ctx context.Context, username, ip string) {
+	if username != "" {
+		keys := []string{userLoginFailuresKey.For(username), userLoginBlockKey.For(username)}
+		if err := s.cache.Del(ctx, keys...); err != nil {
+			s.log.Error("Failed to reset login attempts",
+				zap.String("username", username),
+				zap.Error(err),
+			)
+		}
+		_ = s.fallback.Del(ctx, keys...)
+	}
+
+	s.release(ctx, ipLoginFailuresKey.For(ip))
+}
+
+func (s *LoginAttemptsService) Unlock(ctx context.Context, input UnlockInput) error {
+	const op = "service.LoginAttempts.Unlock"
+
+	var keys []string
+	if input.Username != "" {
+		keys = append(keys, userLoginFailuresKey.For(input.Username), userLoginBlockKey.For(input.Username))
+	}
+	if input.IP != "" {
+		keys = append(keys, ipLoginFailuresKey.For(input.IP), ipLoginBlockKey.For(input.IP))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_ = s.fallback.Del(ctx, keys...)
+	if err := s.cache.Del(ctx, keys...); err != nil {
+		s.log.Error("Failed to unlock login",
+			zap.String("op", op),
+			zap.String("username", input.Username),
+			zap.String("ip", input.IP),
+			zap.Error(err),
+		)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("Login unlocked",
+		zap.String("username", input.Username),
+		zap.String("ip", input.IP),
+	)
+
+	return nil
+}
+
+// attempt counts the attempt of the id unless the id is blocked and returns
+// how long it is blocked for. Every attempt gets its own count from the atomic
+// increment, so more than maxAttempts attempts lock the id out even when they
+// all arrive before any of them fails.
+func (s *LoginAttemptsService) attempt(ctx context.Context, failuresKey cache.Key[int64], blockKey cache.Key[time.Time], id string, maxAttempts int) time.Duration {
+	counters := s.cache
+	count, err := counters.Incr(ctx, failuresKey.For(id), s.limits.LockoutDuration)
+	if err != nil {
+		s.log.Error("Failed to count login attempt, counting on this replica",
+			zap.String("key", failuresKey.For(id)),
+			zap.Error(err),
+		)
+		counters = s.fallback
+		if count, err = counters.Incr(ctx, failuresKey.For(id), s.limits.LockoutDuration); err != nil {
+			// Logins are not let through uncounted.
+			return s.limits.BaseDelay
+		}
+	}
+
+	if blockedUntil, err := cache.Get(ctx, counters, blockKey, id); err == nil {
+		if delay := blockedUntil.Sub(s.now()); delay > 0 {
+			_, _ = counters.Decr(ctx, failuresKey.For(id))
+			return delay
+		}
+	}
+
+	if count > int64(maxAttempts) {
+		s.block(ctx, counters, blockKey, id, s.limits.LockoutDuration)
+		return s.limits.LockoutDuration
+	}
+
+	return 0
+}
+
+// registerFailure blocks the id with an exponentially growing delay once it
+// has more than freeAttempts failures, and locks it out after maxAttempts
+// failures. The failures expire LockoutDuration after the last attempt.
+func (s *LoginAttemptsService) registerFailure(ctx context.Context, failuresKey cache.Key[int64], blockKey cache.Key[time.Time], id string, freeAttempts, maxAttempts int) time.Duration {
+	counters := s.cache
+	count, err := cache.Get(ctx, counters, failuresKey, id)
+	if err != nil && !errors.Is(err, cache.ErrMiss) {
+		counters = s.fallback
+		count, _ = cache.Get(ctx, counters, failuresKey, id)
+	}
+	failures := int(count)
+
+	var delay time.Duration
+	switch {
+	case failures >= maxAttempts:
+		delay = s.limits.LockoutDuration
+	case failures > freeAttempts:
+		delay = s.limits.BaseDelay << min(failures-freeAttempts-1, 30)
+		delay = min(delay, s.limits.LockoutDuration)
+	}
+	if delay > 0 {
+		s.block(ctx, counters, blockKey, id, delay)
+	}
+
+	return delay
+}
+
+// block stores when the block of the id ends.
+func (s *LoginAttemptsService) block(ctx context.Context, counters cache.Cache, blockKey cache.Key[time.Time], id string, delay time.Duration) {
+	if err := counters.Set(ctx, blockKey.For(id), s.now().Add(delay), delay); err != nil {
+		s.log.Error("Failed to save login block",
+			zap.String("key", blockKey.For(id)),
+			zap.Error(err),
+		)
+	}
+}
+
+// release takes an attempt back from the counter, in the fallback too if the
+// shared cache fails.
+func (s *LoginAttemptsService) release(ctx context.Context, key string) {
+	if _, err := s.cache.Decr(ctx, key); err != nil {
+		_, _ = s.fallback.Decr(ctx, key)
+	}
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/cache/breaker"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// newMemoryCache backs the cache mock with a map, so consecutive calls see each other's writes.
+func newMemoryCache(ctrl *gomock.Controller) (*cache.MockCache, map[string]string) {
+	return newClockedMemoryCache(ctrl, time.Now)
+}
+
+// newClockedMemoryCache is newMemoryCache with entries expiring by the given clock.
+func newClockedMemoryCache(ctrl *gomock.Controller, now func() time.Time) (*cache.MockCache, map[string]string) {
+	store := map[string]string{}
+	expiresAt := map[string]time.Time{}
+	mockCache := cache.NewMockCache(ctrl)
+
+	expire := func(key string, ttl time.Duration) {
+		if ttl > 0 {
+			expiresAt[key] = now().Add(ttl)
+		} else {
+			delete(expiresAt, key)
+		}
+	}
+	load := func(key string) (string, bool) {
+		if deadline, ok := expiresAt[key]; ok && !now().Before(deadline) {
+			delete(store, key)
+			delete(expiresAt, key)
+		}
+		value, ok := store[key]
+		return value, ok
+	}
+
+	mockCache.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key string) (string, error) {
+		value, ok := load(key)
+		if !ok {
+			return "", cache.ErrMiss
+		}
+		return value, nil
+	}).AnyTimes()
+	mockCache.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key string, value interface{}, ttl time.Duration) error {
+		data, err := json.Marshal(value)
+		store[key] = string(data)
+		expire(key, ttl)
+		return err
+	}).AnyTimes()
+	mockCache.EXPECT().SetNewer(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key string, value interface{}, version int64, ttl time.Duration, existing bool) (bool, error) {
+		if current, ok := load(key); ok {
+			var stored struct {
+				Version int64 `json:"version"`
+			}
+			if err := json.Unmarshal([]byte(current), &stored); err == nil && stored.Version >= version {
+				return false, nil
+			}
+		} else if existing {
+			return false, nil
+		}
+		data, err := json.Marshal(value)
+		store[key] = string(data)
+		expire(key, ttl)
+		return err == nil, err
+	}).AnyTimes()
+	mockCache.EXPECT().Incr(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key string, ttl time.Duration) (int64, error) {
+		var count int64
+		if value, ok := load(key); ok {
+			if err := json.Unmarshal([]byte(value), &count); err != nil {
+				return 0, err
+			}
+		}
+		count++
+		store[key] = strconv.FormatInt(count, 10)
+		expire(key, ttl)
+		return count, nil
+	}).AnyTimes()
+	mockCache.EXPECT().Decr(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key string) (int64, error) {
+		var count int64
+		value, ok := load(key)
+		if !ok {
+			return 0, nil
+		}
+		if err := json.Unmarshal([]byte(value), &count); err != nil {
+			return 0, err
+		}
+		if count > 0 {
+			count--
+			store[key] = strconv.FormatInt(count, 10)
+		}
+		return count, nil
+	}).AnyTimes()
+	mockCache.EXPECT().Del(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, keys ...string) error {
+		for _, key := range keys {
+			delete(store, key)
+			delete(expiresAt, key)
+		}
+		return nil
+	}).AnyTimes()
+
+	return mockCache, store
+}
+
+func TestLoginAttemptsService(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	limits := LoginAttemptsLimits{
+		FreeAttempts:    2,
+		MaxAttempts:     5,
+		IPFreeAttempts:  4,
+		IPMaxAttempts:   8,
+		BaseDelay:       time.Second,
+		LockoutDuration: time.Minute,
+	}
+
+	newService := func() (*LoginAttemptsService, *time.Time) {
+		now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+		clock := func() time.Time { return now }
+		mockCache, _ := newClockedMemoryCache(ctrl, clock)
+		service := NewLoginAttemptsService(zap.NewNop(), mockCache, limits)
+		service.now = clock
+		return service, &now
+	}
+
+	// fail makes a failed login attempt and returns how long the client is blocked for.
+	fail := func(service *LoginAttemptsService, username, ip string) time.Duration {
+		if delay := service.Attempt(ctx, username, ip); delay > 0 {
+			return delay
+		}
+		return service.RegisterFailure(ctx, username, ip)
+	}
+	// waitOut advances the clock past a block shorter than a lockout.
+	waitOut := func(now *time.Time, delay time.Duration) {
+		if delay < limits.LockoutDuration {
+			*now = now.Add(delay)
+		}
+	}
+
+	t.Run("Exponential backoff and lockout", func(t *testing.T) {
+		service, now := newService()
+
+		delays := []time.Duration{}
+		for i := 0; i < 5; i++ {
+			delay := fail(service, "user1", "10.0.0.1")
+			delays = append(delays, delay)
+			waitOut(now, delay)
+		}
+
+		assert.Equal(t, []time.Duration{0, 0, time.Second, 2 * time.Second, time.Minute}, delays)
+		assert.Equal(t, time.Minute, service.Attempt(ctx, "user1", "10.0.0.1"))
+	})
+
+	t.Run("Block expires", func(t *testing.T) {
+		service, now := newService()
+
+		for i := 0; i < 3; i++ {
+			fail(service, "user1", "10.0.0.1")
+		}
+		assert.Equal(t, time.Second, service.Attempt(ctx, "user1", "10.0.0.1"))
+
+		*now = now.Add(time.Second)
+		assert.Zero(t, service.Attempt(ctx, "user1", "10.0.0.1"))
+
+		// Failures are forgotten once the lockout duration has passed since the last attempt.
+		*now = now.Add(2 * time.Minute)
+		assert.Zero(t, fail(service, "user1", "10.0.0.1"))
+	})
+
+	t.Run("IP is throttled across usernames", func(t *testing.T) {
+		service, _ := newService()
+
+		var delay time.Duration
+		for _, username := range []string{"a", "b", "c", "d", "e"} {
+			delay = fail(service, username, "10.0.0.1")
+		}
+
+		assert.Equal(t, time.Second, delay)
+		assert.Equal(t, time.Second, service.Attempt(ctx, "f", "10.0.0.1"))
+		assert.Zero(t, service.Attempt(ctx, "f", "10.0.0.2"))
+	})
+
+	t.Run("Success resets username only", func(t *testing.T) {
+		service, now := newService()
+
+		for i := 0; i < 5; i++ {
+			waitOut(now, fail(service, "user1", "10.0.0.1"))
+		}
+		service.RegisterSuccess(ctx, "user1", "10.0.0.2")
+
+		assert.Zero(t, service.Attempt(ctx, "user1", "10.0.0.2"))
+		assert.Equal(t, time.Second, service.Attempt(ctx, "user1", "10.0.0.1"))
+	})
+
+	t.Run("Successful logins are not counted against the IP", func(t *testing.T) {
+		service, _ := newService()
+
+		for _, username := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"} {
+			assert.Zero(t, service.Attempt(ctx, username, "10.0.0.1"))
+			service.RegisterSuccess(ctx, username, "10.0.0.1")
+		}
+
+		assert.Zero(t, fail(service, "j", "10.0.0.1"))
+	})
+
+	t.Run("Admin unlock", func(t *testing.T) {
+		service, now := newService()
+
+		for i := 0; i < 5; i++ {
+			waitOut(now, fail(service, "user1", "10.0.0.1"))
+		}
+		// The user is locked out, so the IP failures come from other usernames.
+		*now = now.Add(time.Second)
+		for _, username := range []string{"a", "b", "c"} {
+			waitOut(now, fail(service, username, "10.0.0.1"))
+		}
+
+		assert.NoError(t, service.Unlock(ctx, UnlockInput{Username: "user1"}))
+		assert.Equal(t, time.Minute, service.Attempt(ctx, "user1", "10.0.0.1"))
+
+		assert.NoError(t, service.Unlock(ctx, UnlockInput{IP: "10.0.0.1"}))
+		assert.Zero(t, service.Attempt(ctx, "user1", "10.0.0.1"))
+	})
+
+	t.Run("Concurrent attempts are all counted", func(t *testing.T) {
+		service, _ := newService()
+
+		// Attempts made together, before any of them failed, are counted one by
+		// one, and the one beyond the maximum locks the user out.
+		for i := 0; i < 5; i++ {
+			assert.Zero(t, service.Attempt(ctx, "user1", "10.0.0.1"))
+		}
+		assert.Equal(t, time.Minute, service.Attempt(ctx, "user1", "10.0.0.1"))
+		assert.Equal(t, time.Minute, service.Attempt(ctx, "user1", "10.0.0.2"))
+	})
+
+	t.Run("Attempts are counted on the replica while the cache is unavailable", func(t *testing.T) {
+		unavailable := cache.NewMockCache(ctrl)
+		unavailable.EXPECT().Incr(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), breaker.ErrOpen).AnyTimes()
+		unavailable.EXPECT().Decr(gomock.Any(), gomock.Any()).Return(int64(0), breaker.ErrOpen).AnyTimes()
+		unavailable.EXPECT().Get(gomock.Any(), gomock.Any()).Return("", breaker.ErrOpen).AnyTimes()
+		service := NewLoginAttemptsService(zap.NewNop(), unavailable, limits)
+
+		for i := 0; i < 5; i++ {
+			assert.Zero(t, service.Attempt(ctx, "user1", "10.0.0.1"))
+		}
+		assert.Equal(t, time.Minute, service.Attempt(ctx, "user1", "10.0.0.1"))
+	})
+}
@@ -5,8 +5,13 @@
 package service
 
 import (
+	cache "avito-internship/internal/cache"
+	loader "avito-internship/internal/cache/loader"
+	entity "avito-internship/internal/entity"
+	jwt "avito-internship/internal/utils/jwt"
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,10 +40,10 @@ func (m *MockAuth) EXPECT() *MockAuthMockRecorder {
 }
 
 // Authorization mocks base method.
-func (m *MockAuth) Authorization(ctx context.Context, username, password string) (string, error) {
+func (m *MockAuth) Authorization(ctx context.Context, username, password string) (AuthorizationOutput, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Authorization", ctx, username, password)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(AuthorizationOutput)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -49,34 +54,93 @@ func (mr *MockAuthMockRecorder) Authorization(ctx, username, password interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorization", reflect.TypeOf((*MockAuth)(nil).Authorization), ctx, username, password)
 }
 
-// ValidateToken mocks base method.
-func (m *MockAuth) ValidateToken(token string) (string, error) {
+// ChangePassword mocks base method.
+func (m *MockAuth) ChangePassword(ctx context.Context, input ChangePasswordInput) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAuthMockRecorder) ChangePassword(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAuth)(nil).ChangePassword), ctx, input)
+}
+
+// JWKS mocks base method.
+func (m *MockAuth) JWKS() jwt.JWKS {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(jwt.JWKS)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockAuthMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockAuth)(nil).JWKS))
+}
+
+// Register mocks base method.
+func (m *MockAuth) Register(ctx context.Context, username, password string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ValidateToken", token)
+	ret := m.ctrl.Call(m, "Register", ctx, username, password)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// Register indicates an expected call of Register.
+func (mr *MockAuthMockRecorder) Register(ctx, username, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockAuth)(nil).Register), ctx, username, password)
+}
+
+// ValidateToken mocks base method.
+func (m *MockAuth) ValidateToken(ctx context.Context, token string) (AccessToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateToken", ctx, token)
+	ret0, _ := ret[0].(AccessToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // ValidateToken indicates an expected call of ValidateToken.
-func (mr *MockAuthMockRecorder) ValidateToken(token interface{}) *gomock.Call {
+func (mr *MockAuthMockRecorder) ValidateToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockAuth)(nil).ValidateToken), ctx, token)
+}
+
+// createUser mocks base method.
+func (m *MockAuth) createUser(ctx context.Context, username, password, op string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "createUser", ctx, username, password, op)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// createUser indicates an expected call of createUser.
+func (mr *MockAuthMockRecorder) createUser(ctx, username, password, op interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockAuth)(nil).ValidateToken), token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "createUser", reflect.TypeOf((*MockAuth)(nil).createUser), ctx, username, password, op)
 }
 
 // generateToken mocks base method.
-func (m *MockAuth) generateToken(username, op string) (string, error) {
+func (m *MockAuth) generateToken(ctx context.Context, username, op string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "generateToken", username, op)
+	ret := m.ctrl.Call(m, "generateToken", ctx, username, op)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // generateToken indicates an expected call of generateToken.
-func (mr *MockAuthMockRecorder) generateToken(username, op interface{}) *gomock.Call {
+func (mr *MockAuthMockRecorder) generateToken(ctx, username, op interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "generateToken", reflect.TypeOf((*MockAuth)(nil).generateToken), username, op)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "generateToken", reflect.TypeOf((*MockAuth)(nil).generateToken), ctx, username, op)
 }
 
 // handleUserNotFound mocks base method.
@@ -94,105 +158,1058 @@ func (mr *MockAuthMockRecorder) handleUserNotFound(ctx, username, password, op i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "handleUserNotFound", reflect.TypeOf((*MockAuth)(nil).handleUserNotFound), ctx, username, password, op)
 }
 
-// MockUser is a mock of User interface.
-type MockUser struct {
+// MockSession is a mock of Session interface.
+type MockSession struct {
 	ctrl     *gomock.Controller
-	recorder *MockUserMockRecorder
+	recorder *MockSessionMockRecorder
 }
 
-// MockUserMockRecorder is the mock recorder for MockUser.
-type MockUserMockRecorder struct {
-	mock *MockUser
+// MockSessionMockRecorder is the mock recorder for MockSession.
+type MockSessionMockRecorder struct {
+	mock *MockSession
 }
 
-// NewMockUser creates a new mock instance.
-func NewMockUser(ctrl *gomock.Controller) *MockUser {
-	mock := &MockUser{ctrl: ctrl}
-	mock.recorder = &MockUserMockRecorder{mock}
+// NewMockSession creates a new mock instance.
+func NewMockSession(ctrl *gomock.Controller) *MockSession {
+	mock := &MockSession{ctrl: ctrl}
+	mock.recorder = &MockSessionMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockUser) EXPECT() *MockUserMockRecorder {
+func (m *MockSession) EXPECT() *MockSessionMockRecorder {
 	return m.recorder
 }
 
-// CreateUser mocks base method.
-func (m *MockUser) CreateUser(ctx context.Context, input UserCreateInput) error {
+// Check mocks base method.
+func (m *MockSession) Check(ctx context.Context, username, id string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", ctx, input)
+	ret := m.ctrl.Call(m, "Check", ctx, username, id)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// CreateUser indicates an expected call of CreateUser.
-func (mr *MockUserMockRecorder) CreateUser(ctx, input interface{}) *gomock.Call {
+// Check indicates an expected call of Check.
+func (mr *MockSessionMockRecorder) Check(ctx, username, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUser)(nil).CreateUser), ctx, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockSession)(nil).Check), ctx, username, id)
 }
 
-// RetrieveUserInfo mocks base method.
-func (m *MockUser) RetrieveUserInfo(ctx context.Context, input RetrieveUserInfoInput) (RetrieveUserInfoOutput, error) {
+// List mocks base method.
+func (m *MockSession) List(ctx context.Context, username string) ([]entity.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RetrieveUserInfo", ctx, input)
-	ret0, _ := ret[0].(RetrieveUserInfoOutput)
+	ret := m.ctrl.Call(m, "List", ctx, username)
+	ret0, _ := ret[0].([]entity.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// RetrieveUserInfo indicates an expected call of RetrieveUserInfo.
-func (mr *MockUserMockRecorder) RetrieveUserInfo(ctx, input interface{}) *gomock.Call {
+// List indicates an expected call of List.
+func (mr *MockSessionMockRecorder) List(ctx, username interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveUserInfo", reflect.TypeOf((*MockUser)(nil).RetrieveUserInfo), ctx, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockSession)(nil).List), ctx, username)
 }
 
-// MockOperation is a mock of Operation interface.
-type MockOperation struct {
+// Revoke mocks base method.
+func (m *MockSession) Revoke(ctx context.Context, input RevokeSessionInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockSessionMockRecorder) Revoke(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockSession)(nil).Revoke), ctx, input)
+}
+
+// RevokeAll mocks base method.
+func (m *MockSession) RevokeAll(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAll", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAll indicates an expected call of RevokeAll.
+func (mr *MockSessionMockRecorder) RevokeAll(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAll", reflect.TypeOf((*MockSession)(nil).RevokeAll), ctx, username)
+}
+
+// RevokeOthers mocks base method.
+func (m *MockSession) RevokeOthers(ctx context.Context, input RevokeSessionInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOthers", ctx, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeOthers indicates an expected call of RevokeOthers.
+func (mr *MockSessionMockRecorder) RevokeOthers(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOthers", reflect.TypeOf((*MockSession)(nil).RevokeOthers), ctx, input)
+}
+
+// Start mocks base method.
+func (m *MockSession) Start(ctx context.Context, username string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Start", ctx, username)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockSessionMockRecorder) Start(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockSession)(nil).Start), ctx, username)
+}
+
+// MockTwoFactor is a mock of TwoFactor interface.
+type MockTwoFactor struct {
 	ctrl     *gomock.Controller
-	recorder *MockOperationMockRecorder
+	recorder *MockTwoFactorMockRecorder
 }
 
-// MockOperationMockRecorder is the mock recorder for MockOperation.
-type MockOperationMockRecorder struct {
-	mock *MockOperation
+// MockTwoFactorMockRecorder is the mock recorder for MockTwoFactor.
+type MockTwoFactorMockRecorder struct {
+	mock *MockTwoFactor
 }
 
-// NewMockOperation creates a new mock instance.
-func NewMockOperation(ctrl *gomock.Controller) *MockOperation {
-	mock := &MockOperation{ctrl: ctrl}
-	mock.recorder = &MockOperationMockRecorder{mock}
+// NewMockTwoFactor creates a new mock instance.
+func NewMockTwoFactor(ctrl *gomock.Controller) *MockTwoFactor {
+	mock := &MockTwoFactor{ctrl: ctrl}
+	mock.recorder = &MockTwoFactorMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockOperation) EXPECT() *MockOperationMockRecorder {
+func (m *MockTwoFactor) EXPECT() *MockTwoFactorMockRecorder {
 	return m.recorder
 }
 
-// PurchaseProduct mocks base method.
-func (m *MockOperation) PurchaseProduct(ctx context.Context, input PurchaseProductInput) error {
+// CompleteLogin mocks base method.
+func (m *MockTwoFactor) CompleteLogin(ctx context.Context, input CompleteLoginInput) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PurchaseProduct", ctx, input)
+	ret := m.ctrl.Call(m, "CompleteLogin", ctx, input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteLogin indicates an expected call of CompleteLogin.
+func (mr *MockTwoFactorMockRecorder) CompleteLogin(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteLogin", reflect.TypeOf((*MockTwoFactor)(nil).CompleteLogin), ctx, input)
+}
+
+// Confirm mocks base method.
+func (m *MockTwoFactor) Confirm(ctx context.Context, input TwoFactorCodeInput) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Confirm", ctx, input)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Confirm indicates an expected call of Confirm.
+func (mr *MockTwoFactorMockRecorder) Confirm(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Confirm", reflect.TypeOf((*MockTwoFactor)(nil).Confirm), ctx, input)
+}
+
+// Enroll mocks base method.
+func (m *MockTwoFactor) Enroll(ctx context.Context, username string) (TwoFactorEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enroll", ctx, username)
+	ret0, _ := ret[0].(TwoFactorEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Enroll indicates an expected call of Enroll.
+func (mr *MockTwoFactorMockRecorder) Enroll(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enroll", reflect.TypeOf((*MockTwoFactor)(nil).Enroll), ctx, username)
+}
+
+// VerifyChallenge mocks base method.
+func (m *MockTwoFactor) VerifyChallenge(ctx context.Context, challengeToken string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyChallenge", ctx, challengeToken)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyChallenge indicates an expected call of VerifyChallenge.
+func (mr *MockTwoFactorMockRecorder) VerifyChallenge(ctx, challengeToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyChallenge", reflect.TypeOf((*MockTwoFactor)(nil).VerifyChallenge), ctx, challengeToken)
+}
+
+// MockAPIKey is a mock of APIKey interface.
+type MockAPIKey struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyMockRecorder
+}
+
+// MockAPIKeyMockRecorder is the mock recorder for MockAPIKey.
+type MockAPIKeyMockRecorder struct {
+	mock *MockAPIKey
+}
+
+// NewMockAPIKey creates a new mock instance.
+func NewMockAPIKey(ctrl *gomock.Controller) *MockAPIKey {
+	mock := &MockAPIKey{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKey) EXPECT() *MockAPIKeyMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAPIKey) Authenticate(ctx context.Context, key string) (entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, key)
+	ret0, _ := ret[0].(entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAPIKeyMockRecorder) Authenticate(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAPIKey)(nil).Authenticate), ctx, key)
+}
+
+// Create mocks base method.
+func (m *MockAPIKey) Create(ctx context.Context, input CreateAPIKeyInput) (CreateAPIKeyOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, input)
+	ret0, _ := ret[0].(CreateAPIKeyOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockAPIKeyMockRecorder) Create(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockAPIKey)(nil).Create), ctx, input)
+}
+
+// List mocks base method.
+func (m *MockAPIKey) List(ctx context.Context, username string) ([]entity.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, username)
+	ret0, _ := ret[0].([]entity.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockAPIKeyMockRecorder) List(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockAPIKey)(nil).List), ctx, username)
+}
+
+// Revoke mocks base method.
+func (m *MockAPIKey) Revoke(ctx context.Context, input RevokeAPIKeyInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, input)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// PurchaseProduct indicates an expected call of PurchaseProduct.
-func (mr *MockOperationMockRecorder) PurchaseProduct(ctx, input interface{}) *gomock.Call {
+// Revoke indicates an expected call of Revoke.
+func (mr *MockAPIKeyMockRecorder) Revoke(ctx, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurchaseProduct", reflect.TypeOf((*MockOperation)(nil).PurchaseProduct), ctx, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockAPIKey)(nil).Revoke), ctx, input)
 }
 
-// TransferFunds mocks base method.
-func (m *MockOperation) TransferFunds(ctx context.Context, input TransferFundsInput) error {
+// MockOIDC is a mock of OIDC interface.
+type MockOIDC struct {
+	ctrl     *gomock.Controller
+	recorder *MockOIDCMockRecorder
+}
+
+// MockOIDCMockRecorder is the mock recorder for MockOIDC.
+type MockOIDCMockRecorder struct {
+	mock *MockOIDC
+}
+
+// NewMockOIDC creates a new mock instance.
+func NewMockOIDC(ctrl *gomock.Controller) *MockOIDC {
+	mock := &MockOIDC{ctrl: ctrl}
+	mock.recorder = &MockOIDCMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOIDC) EXPECT() *MockOIDCMockRecorder {
+	return m.recorder
+}
+
+// Callback mocks base method.
+func (m *MockOIDC) Callback(ctx context.Context, input OIDCCallbackInput) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TransferFunds", ctx, input)
+	ret := m.ctrl.Call(m, "Callback", ctx, input)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Callback indicates an expected call of Callback.
+func (mr *MockOIDCMockRecorder) Callback(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Callback", reflect.TypeOf((*MockOIDC)(nil).Callback), ctx, input)
+}
+
+// LoginURL mocks base method.
+func (m *MockOIDC) LoginURL(ctx context.Context) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginURL", ctx)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginURL indicates an expected call of LoginURL.
+func (mr *MockOIDCMockRecorder) LoginURL(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginURL", reflect.TypeOf((*MockOIDC)(nil).LoginURL), ctx)
+}
+
+// MockLoginAttempts is a mock of LoginAttempts interface.
+type MockLoginAttempts struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginAttemptsMockRecorder
+}
+
+// MockLoginAttemptsMockRecorder is the mock recorder for MockLoginAttempts.
+type MockLoginAttemptsMockRecorder struct {
+	mock *MockLoginAttempts
+}
+
+// NewMockLoginAttempts creates a new mock instance.
+func NewMockLoginAttempts(ctrl *gomock.Controller) *MockLoginAttempts {
+	mock := &MockLoginAttempts{ctrl: ctrl}
+	mock.recorder = &MockLoginAttemptsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginAttempts) EXPECT() *MockLoginAttemptsMockRecorder {
+	return m.recorder
+}
+
+// Attempt mocks base method.
+func (m *MockLoginAttempts) Attempt(ctx context.Context, username, ip string) time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Attempt", ctx, username, ip)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// Attempt indicates an expected call of Attempt.
+func (mr *MockLoginAttemptsMockRecorder) Attempt(ctx, username, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Attempt", reflect.TypeOf((*MockLoginAttempts)(nil).Attempt), ctx, username, ip)
+}
+
+// RegisterFailure mocks base method.
+func (m *MockLoginAttempts) RegisterFailure(ctx context.Context, username, ip string) time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterFailure", ctx, username, ip)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// RegisterFailure indicates an expected call of RegisterFailure.
+func (mr *MockLoginAttemptsMockRecorder) RegisterFailure(ctx, username, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterFailure", reflect.TypeOf((*MockLoginAttempts)(nil).RegisterFailure), ctx, username, ip)
+}
+
+// RegisterSuccess mocks base method.
+func (m *MockLoginAttempts) RegisterSuccess(ctx context.Context, username, ip string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RegisterSuccess", ctx, username, ip)
+}
+
+// RegisterSuccess indicates an expected call of RegisterSuccess.
+func (mr *MockLoginAttemptsMockRecorder) RegisterSuccess(ctx, username, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterSuccess", reflect.TypeOf((*MockLoginAttempts)(nil).RegisterSuccess), ctx, username, ip)
+}
+
+// Unlock mocks base method.
+func (m *MockLoginAttempts) Unlock(ctx context.Context, input UnlockInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx, input)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// TransferFunds indicates an expected call of TransferFunds.
-func (mr *MockOperationMockRecorder) TransferFunds(ctx, input interface{}) *gomock.Call {
+// Unlock indicates an expected call of Unlock.
+func (mr *MockLoginAttemptsMockRecorder) Unlock(ctx, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferFunds", reflect.TypeOf((*MockOperation)(nil).TransferFunds), ctx, input)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockLoginAttempts)(nil).Unlock), ctx, input)
+}
+
+// MockUser is a mock of User interface.
+type MockUser struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserMockRecorder
+}
+
+// MockUserMockRecorder is the mock recorder for MockUser.
+type MockUserMockRecorder struct {
+	mock *MockUser
+}
+
+// NewMockUser creates a new mock instance.
+func NewMockUser(ctrl *gomock.Controller) *MockUser {
+	mock := &MockUser{ctrl: ctrl}
+	mock.recorder = &MockUserMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUser) EXPECT() *MockUserMockRecorder {
+	return m.recorder
+}
+
+// CreateUser mocks base method.
+func (m *MockUser) CreateUser(ctx context.Context, input UserCreateInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockUserMockRecorder) CreateUser(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUser)(nil).CreateUser), ctx, input)
+}
+
+// InfoCacheStats mocks base method.
+func (m *MockUser) InfoCacheStats() loader.Stats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InfoCacheStats")
+	ret0, _ := ret[0].(loader.Stats)
+	return ret0
+}
+
+// InfoCacheStats indicates an expected call of InfoCacheStats.
+func (mr *MockUserMockRecorder) InfoCacheStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InfoCacheStats", reflect.TypeOf((*MockUser)(nil).InfoCacheStats))
+}
+
+// InvalidateInfo mocks base method.
+func (m *MockUser) InvalidateInfo(ctx context.Context, usernames ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range usernames {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "InvalidateInfo", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateInfo indicates an expected call of InvalidateInfo.
+func (mr *MockUserMockRecorder) InvalidateInfo(ctx interface{}, usernames ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, usernames...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateInfo", reflect.TypeOf((*MockUser)(nil).InvalidateInfo), varargs...)
+}
+
+// RetrieveUserInfo mocks base method.
+func (m *MockUser) RetrieveUserInfo(ctx context.Context, input RetrieveUserInfoInput) (RetrieveUserInfoOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetrieveUserInfo", ctx, input)
+	ret0, _ := ret[0].(RetrieveUserInfoOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetrieveUserInfo indicates an expected call of RetrieveUserInfo.
+func (mr *MockUserMockRecorder) RetrieveUserInfo(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveUserInfo", reflect.TypeOf((*MockUser)(nil).RetrieveUserInfo), ctx, input)
+}
+
+// UpdateInfo mocks base method.
+func (m *MockUser) UpdateInfo(ctx context.Context, username string, version int64, update func(info *RetrieveUserInfoOutput)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateInfo", ctx, username, version, update)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateInfo indicates an expected call of UpdateInfo.
+func (mr *MockUserMockRecorder) UpdateInfo(ctx, username, version, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInfo", reflect.TypeOf((*MockUser)(nil).UpdateInfo), ctx, username, version, update)
+}
+
+// MockOperation is a mock of Operation interface.
+type MockOperation struct {
+	ctrl     *gomock.Controller
+	recorder *MockOperationMockRecorder
+}
+
+// MockOperationMockRecorder is the mock recorder for MockOperation.
+type MockOperationMockRecorder struct {
+	mock *MockOperation
+}
+
+// NewMockOperation creates a new mock instance.
+func NewMockOperation(ctrl *gomock.Controller) *MockOperation {
+	mock := &MockOperation{ctrl: ctrl}
+	mock.recorder = &MockOperationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOperation) EXPECT() *MockOperationMockRecorder {
+	return m.recorder
+}
+
+// PurchaseProduct mocks base method.
+func (m *MockOperation) PurchaseProduct(ctx context.Context, input PurchaseProductInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurchaseProduct", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurchaseProduct indicates an expected call of PurchaseProduct.
+func (mr *MockOperationMockRecorder) PurchaseProduct(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurchaseProduct", reflect.TypeOf((*MockOperation)(nil).PurchaseProduct), ctx, input)
+}
+
+// TransferFunds mocks base method.
+func (m *MockOperation) TransferFunds(ctx context.Context, input TransferFundsInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferFunds", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransferFunds indicates an expected call of TransferFunds.
+func (mr *MockOperationMockRecorder) TransferFunds(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferFunds", reflect.TypeOf((*MockOperation)(nil).TransferFunds), ctx, input)
+}
+
+// MockProduct is a mock of Product interface.
+type MockProduct struct {
+	ctrl     *gomock.Controller
+	recorder *MockProductMockRecorder
+}
+
+// MockProductMockRecorder is the mock recorder for MockProduct.
+type MockProductMockRecorder struct {
+	mock *MockProduct
+}
+
+// NewMockProduct creates a new mock instance.
+func NewMockProduct(ctrl *gomock.Controller) *MockProduct {
+	mock := &MockProduct{ctrl: ctrl}
+	mock.recorder = &MockProductMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProduct) EXPECT() *MockProductMockRecorder {
+	return m.recorder
+}
+
+// AddProductVariant mocks base method.
+func (m *MockProduct) AddProductVariant(ctx context.Context, input AddProductVariantInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddProductVariant", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddProductVariant indicates an expected call of AddProductVariant.
+func (mr *MockProductMockRecorder) AddProductVariant(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddProductVariant", reflect.TypeOf((*MockProduct)(nil).AddProductVariant), ctx, input)
+}
+
+// CatalogCacheStats mocks base method.
+func (m *MockProduct) CatalogCacheStats() loader.Stats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CatalogCacheStats")
+	ret0, _ := ret[0].(loader.Stats)
+	return ret0
+}
+
+// CatalogCacheStats indicates an expected call of CatalogCacheStats.
+func (mr *MockProductMockRecorder) CatalogCacheStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CatalogCacheStats", reflect.TypeOf((*MockProduct)(nil).CatalogCacheStats))
+}
+
+// RestockProduct mocks base method.
+func (m *MockProduct) RestockProduct(ctx context.Context, input RestockProductInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestockProduct", ctx, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestockProduct indicates an expected call of RestockProduct.
+func (mr *MockProductMockRecorder) RestockProduct(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestockProduct", reflect.TypeOf((*MockProduct)(nil).RestockProduct), ctx, input)
+}
+
+// RetrieveStockHistory mocks base method.
+func (m *MockProduct) RetrieveStockHistory(ctx context.Context, product string) ([]entity.StockChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetrieveStockHistory", ctx, product)
+	ret0, _ := ret[0].([]entity.StockChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RetrieveStockHistory indicates an expected call of RetrieveStockHistory.
+func (mr *MockProductMockRecorder) RetrieveStockHistory(ctx, product interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetrieveStockHistory", reflect.TypeOf((*MockProduct)(nil).RetrieveStockHistory), ctx, product)
+}
+
+// SearchProducts mocks base method.
+func (m *MockProduct) SearchProducts(ctx context.Context, input SearchProductsInput) ([]entity.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchProducts", ctx, input)
+	ret0, _ := ret[0].([]entity.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchProducts indicates an expected call of SearchProducts.
+func (mr *MockProductMockRecorder) SearchProducts(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchProducts", reflect.TypeOf((*MockProduct)(nil).SearchProducts), ctx, input)
+}
+
+// SetPrice mocks base method.
+func (m *MockProduct) SetPrice(ctx context.Context, input SetPriceInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPrice", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPrice indicates an expected call of SetPrice.
+func (mr *MockProductMockRecorder) SetPrice(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPrice", reflect.TypeOf((*MockProduct)(nil).SetPrice), ctx, input)
+}
+
+// SetPurchaseLimit mocks base method.
+func (m *MockProduct) SetPurchaseLimit(ctx context.Context, input SetPurchaseLimitInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPurchaseLimit", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPurchaseLimit indicates an expected call of SetPurchaseLimit.
+func (mr *MockProductMockRecorder) SetPurchaseLimit(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPurchaseLimit", reflect.TypeOf((*MockProduct)(nil).SetPurchaseLimit), ctx, input)
+}
+
+// MockPromo is a mock of Promo interface.
+type MockPromo struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromoMockRecorder
+}
+
+// MockPromoMockRecorder is the mock recorder for MockPromo.
+type MockPromoMockRecorder struct {
+	mock *MockPromo
+}
+
+// NewMockPromo creates a new mock instance.
+func NewMockPromo(ctrl *gomock.Controller) *MockPromo {
+	mock := &MockPromo{ctrl: ctrl}
+	mock.recorder = &MockPromoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPromo) EXPECT() *MockPromoMockRecorder {
+	return m.recorder
+}
+
+// CreatePromoCode mocks base method.
+func (m *MockPromo) CreatePromoCode(ctx context.Context, input CreatePromoCodeInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePromoCode", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePromoCode indicates an expected call of CreatePromoCode.
+func (mr *MockPromoMockRecorder) CreatePromoCode(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePromoCode", reflect.TypeOf((*MockPromo)(nil).CreatePromoCode), ctx, input)
+}
+
+// DeactivatePromoCode mocks base method.
+func (m *MockPromo) DeactivatePromoCode(ctx context.Context, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeactivatePromoCode", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeactivatePromoCode indicates an expected call of DeactivatePromoCode.
+func (mr *MockPromoMockRecorder) DeactivatePromoCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivatePromoCode", reflect.TypeOf((*MockPromo)(nil).DeactivatePromoCode), ctx, code)
+}
+
+// ListPromoCodes mocks base method.
+func (m *MockPromo) ListPromoCodes(ctx context.Context) ([]entity.PromoCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPromoCodes", ctx)
+	ret0, _ := ret[0].([]entity.PromoCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPromoCodes indicates an expected call of ListPromoCodes.
+func (mr *MockPromoMockRecorder) ListPromoCodes(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPromoCodes", reflect.TypeOf((*MockPromo)(nil).ListPromoCodes), ctx)
+}
+
+// MockOrder is a mock of Order interface.
+type MockOrder struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderMockRecorder
+}
+
+// MockOrderMockRecorder is the mock recorder for MockOrder.
+type MockOrderMockRecorder struct {
+	mock *MockOrder
+}
+
+// NewMockOrder creates a new mock instance.
+func NewMockOrder(ctrl *gomock.Controller) *MockOrder {
+	mock := &MockOrder{ctrl: ctrl}
+	mock.recorder = &MockOrderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrder) EXPECT() *MockOrderMockRecorder {
+	return m.recorder
+}
+
+// AdvanceOrder mocks base method.
+func (m *MockOrder) AdvanceOrder(ctx context.Context, input AdvanceOrderInput) (entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceOrder", ctx, input)
+	ret0, _ := ret[0].(entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdvanceOrder indicates an expected call of AdvanceOrder.
+func (mr *MockOrderMockRecorder) AdvanceOrder(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceOrder", reflect.TypeOf((*MockOrder)(nil).AdvanceOrder), ctx, input)
+}
+
+// ListOrders mocks base method.
+func (m *MockOrder) ListOrders(ctx context.Context, status string) ([]entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", ctx, status)
+	ret0, _ := ret[0].([]entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockOrderMockRecorder) ListOrders(ctx, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockOrder)(nil).ListOrders), ctx, status)
+}
+
+// ListUserOrders mocks base method.
+func (m *MockOrder) ListUserOrders(ctx context.Context, username string) ([]entity.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserOrders", ctx, username)
+	ret0, _ := ret[0].([]entity.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserOrders indicates an expected call of ListUserOrders.
+func (mr *MockOrderMockRecorder) ListUserOrders(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserOrders", reflect.TypeOf((*MockOrder)(nil).ListUserOrders), ctx, username)
+}
+
+// MockWishlist is a mock of Wishlist interface.
+type MockWishlist struct {
+	ctrl     *gomock.Controller
+	recorder *MockWishlistMockRecorder
+}
+
+// MockWishlistMockRecorder is the mock recorder for MockWishlist.
+type MockWishlistMockRecorder struct {
+	mock *MockWishlist
+}
+
+// NewMockWishlist creates a new mock instance.
+func NewMockWishlist(ctrl *gomock.Controller) *MockWishlist {
+	mock := &MockWishlist{ctrl: ctrl}
+	mock.recorder = &MockWishlistMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWishlist) EXPECT() *MockWishlistMockRecorder {
+	return m.recorder
+}
+
+// AddToWishlist mocks base method.
+func (m *MockWishlist) AddToWishlist(ctx context.Context, input WishlistInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToWishlist", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddToWishlist indicates an expected call of AddToWishlist.
+func (mr *MockWishlistMockRecorder) AddToWishlist(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToWishlist", reflect.TypeOf((*MockWishlist)(nil).AddToWishlist), ctx, input)
+}
+
+// ListNotifications mocks base method.
+func (m *MockWishlist) ListNotifications(ctx context.Context, username string) ([]entity.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotifications", ctx, username)
+	ret0, _ := ret[0].([]entity.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotifications indicates an expected call of ListNotifications.
+func (mr *MockWishlistMockRecorder) ListNotifications(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotifications", reflect.TypeOf((*MockWishlist)(nil).ListNotifications), ctx, username)
+}
+
+// ListWishlist mocks base method.
+func (m *MockWishlist) ListWishlist(ctx context.Context, username string) ([]entity.WishlistItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWishlist", ctx, username)
+	ret0, _ := ret[0].([]entity.WishlistItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWishlist indicates an expected call of ListWishlist.
+func (mr *MockWishlistMockRecorder) ListWishlist(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWishlist", reflect.TypeOf((*MockWishlist)(nil).ListWishlist), ctx, username)
+}
+
+// MarkNotificationsRead mocks base method.
+func (m *MockWishlist) MarkNotificationsRead(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationsRead", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotificationsRead indicates an expected call of MarkNotificationsRead.
+func (mr *MockWishlistMockRecorder) MarkNotificationsRead(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationsRead", reflect.TypeOf((*MockWishlist)(nil).MarkNotificationsRead), ctx, username)
+}
+
+// RemoveFromWishlist mocks base method.
+func (m *MockWishlist) RemoveFromWishlist(ctx context.Context, input WishlistInput) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFromWishlist", ctx, input)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveFromWishlist indicates an expected call of RemoveFromWishlist.
+func (mr *MockWishlistMockRecorder) RemoveFromWishlist(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFromWishlist", reflect.TypeOf((*MockWishlist)(nil).RemoveFromWishlist), ctx, input)
+}
+
+// MockImpersonation is a mock of Impersonation interface.
+type MockImpersonation struct {
+	ctrl     *gomock.Controller
+	recorder *MockImpersonationMockRecorder
+}
+
+// MockImpersonationMockRecorder is the mock recorder for MockImpersonation.
+type MockImpersonationMockRecorder struct {
+	mock *MockImpersonation
+}
+
+// NewMockImpersonation creates a new mock instance.
+func NewMockImpersonation(ctrl *gomock.Controller) *MockImpersonation {
+	mock := &MockImpersonation{ctrl: ctrl}
+	mock.recorder = &MockImpersonationMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImpersonation) EXPECT() *MockImpersonationMockRecorder {
+	return m.recorder
+}
+
+// Audit mocks base method.
+func (m *MockImpersonation) Audit(ctx context.Context, event entity.ImpersonationEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Audit", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Audit indicates an expected call of Audit.
+func (mr *MockImpersonationMockRecorder) Audit(ctx, event interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Audit", reflect.TypeOf((*MockImpersonation)(nil).Audit), ctx, event)
+}
+
+// Start mocks base method.
+func (m *MockImpersonation) Start(ctx context.Context, input StartImpersonationInput) (ImpersonationOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Start", ctx, input)
+	ret0, _ := ret[0].(ImpersonationOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockImpersonationMockRecorder) Start(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockImpersonation)(nil).Start), ctx, input)
+}
+
+// MockCacheAdmin is a mock of CacheAdmin interface.
+type MockCacheAdmin struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheAdminMockRecorder
+}
+
+// MockCacheAdminMockRecorder is the mock recorder for MockCacheAdmin.
+type MockCacheAdminMockRecorder struct {
+	mock *MockCacheAdmin
+}
+
+// NewMockCacheAdmin creates a new mock instance.
+func NewMockCacheAdmin(ctrl *gomock.Controller) *MockCacheAdmin {
+	mock := &MockCacheAdmin{ctrl: ctrl}
+	mock.recorder = &MockCacheAdminMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheAdmin) EXPECT() *MockCacheAdminMockRecorder {
+	return m.recorder
+}
+
+// CacheStats mocks base method.
+func (m *MockCacheAdmin) CacheStats() CacheStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CacheStats")
+	ret0, _ := ret[0].(CacheStats)
+	return ret0
+}
+
+// CacheStats indicates an expected call of CacheStats.
+func (mr *MockCacheAdminMockRecorder) CacheStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheStats", reflect.TypeOf((*MockCacheAdmin)(nil).CacheStats))
+}
+
+// EvictCacheEntries mocks base method.
+func (m *MockCacheAdmin) EvictCacheEntries(ctx context.Context, pattern string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EvictCacheEntries", ctx, pattern)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvictCacheEntries indicates an expected call of EvictCacheEntries.
+func (mr *MockCacheAdminMockRecorder) EvictCacheEntries(ctx, pattern interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvictCacheEntries", reflect.TypeOf((*MockCacheAdmin)(nil).EvictCacheEntries), ctx, pattern)
+}
+
+// FlushCache mocks base method.
+func (m *MockCacheAdmin) FlushCache(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushCache", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushCache indicates an expected call of FlushCache.
+func (mr *MockCacheAdminMockRecorder) FlushCache(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushCache", reflect.TypeOf((*MockCacheAdmin)(nil).FlushCache), ctx)
+}
+
+// InspectCacheEntry mocks base method.
+func (m *MockCacheAdmin) InspectCacheEntry(ctx context.Context, key string) (cache.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InspectCacheEntry", ctx, key)
+	ret0, _ := ret[0].(cache.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InspectCacheEntry indicates an expected call of InspectCacheEntry.
+func (mr *MockCacheAdminMockRecorder) InspectCacheEntry(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectCacheEntry", reflect.TypeOf((*MockCacheAdmin)(nil).InspectCacheEntry), ctx, key)
+}
+
+// WarmCache mocks base method.
+func (m *MockCacheAdmin) WarmCache(ctx context.Context, input WarmCacheInput) (WarmCacheOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WarmCache", ctx, input)
+	ret0, _ := ret[0].(WarmCacheOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WarmCache indicates an expected call of WarmCache.
+func (mr *MockCacheAdminMockRecorder) WarmCache(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WarmCache", reflect.TypeOf((*MockCacheAdmin)(nil).WarmCache), ctx, input)
 }
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/entity"
+	"avito-internship/internal/model"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/internal/utils/oidc"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// OIDCService logs users in via a corporate identity provider. Multi-factor
+// authentication is left to the provider, so our own 2FA is not asked for.
+type OIDCService struct {
+	log         *zap.Logger
+	cache       cache.Cache
+	repo        repository.Identity
+	provider    *oidc.Provider
+	sessions    Session
+	emailDomain string
+	bcryptCost  int
+}
+
+// OIDCConfig holds the settings of OIDCService.
+type OIDCConfig struct {
+	Provider *oidc.Provider
+	// Sessions issues the access tokens.
+	Sessions Session
+	// EmailDomain restricts logins to addresses in the domain. It is required,
+	// as the local part of an address is only unique within the domain.
+	EmailDomain string
+	BcryptCost  int
+}
+
+func NewOIDCService(log *zap.Logger, cache cache.Cache, repo repository.Identity, cfg OIDCConfig) *OIDCService {
+	bcryptCost := cfg.BcryptCost
+	if bcryptCost == 0 {
+		bcryptCost = bcrypt.DefaultCost
+	}
+
+	return &OIDCService{
+		log:         log,
+		cache:       cache,
+		repo:        repo,
+		provider:    cfg.Provider,
+		sessions:    cfg.Sessions,
+		emailDomain: strings.ToLower(cfg.EmailDomain),
+		bcryptCost:  bcryptCost,
+	}
+}
+
+// oidcLogin is the state of a login started by LoginURL, kept in the cache until the callback.
+type oidcLogin struct {
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"codeVerifier"`
+}
+
+// LoginURL starts a login and returns the provider page the user is redirected to.
+func (s *OIDCService) LoginURL(ctx context.Context) (string, error) {
+	const op = "service.OIDC.LoginURL"
+
+	var values [3]string
+	for i := range values {
+		value, err := oidc.RandomString()
+		if err != nil {
+			s.log.Error("Failed to generate OIDC login state",
+				zap.String("op", op),
+				zap.Error(err),
+			)
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		values[i] = value
+	}
+	state, login := values[0], oidcLogin{Nonce: values[1], CodeVerifier: values[2]}
+
+	if err := cache.Set(ctx, s.cache, oidcLoginKey, state, login); err != nil {
+		s.log.Error("Failed to save OIDC login state",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.provider.AuthCodeURL(state, login.Nonce, login.CodeVerifier), nil
+}
+
+// Callback completes the login started by LoginURL and returns an access token.
+// The user is looked up by the provider subject; on the first login the user
+// is created, named after the local part of the verified email. A local account
+// already holding the name is never linked, so the login is refused.
+func (s *OIDCService) Callback(ctx context.Context, input OIDCCallbackInput) (string, error) {
+	const op = "service.OIDC.Callback"
+
+	login, err := s.takeLogin(ctx, input.State)
+	if err != nil {
+		s.log.Warn("Unknown OIDC login state",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidOIDCState)
+	}
+
+	rawIDToken, err := s.provider.Exchange(ctx, input.Code, login.CodeVerifier)
+	if err != nil {
+		s.log.Warn("Failed to exchange OIDC authorization code",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, servicerrs.ErrOIDCLoginFailed)
+	}
+
+	idToken, err := s.provider.VerifyIDToken(ctx, rawIDToken, login.Nonce)
+	if err != nil {
+		s.log.Warn("Invalid OIDC ID token",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, servicerrs.ErrOIDCLoginFailed)
+	}
+
+	username, err := s.resolveUser(ctx, idToken)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	token, err := s.sessions.Start(ctx, username)
+	if err != nil {
+		s.log.Error("Failed to start session",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("User successfully authorized via OIDC",
+		zap.String("username", username),
+		zap.String("subject", idToken.Subject),
+	)
+
+	return token, nil
+}
+
+// takeLogin loads the login state and deletes it, so a callback cannot be replayed.
+func (s *OIDCService) takeLogin(ctx context.Context, state string) (oidcLogin, error) {
+	if state == "" {
+		return oidcLogin{}, errors.New("empty state")
+	}
+
+	login, err := cache.Get(ctx, s.cache, oidcLoginKey, state)
+	if err != nil {
+		return oidcLogin{}, err
+	}
+	if err := cache.Del(ctx, s.cache, oidcLoginKey, state); err != nil {
+		return oidcLogin{}, err
+	}
+
+	return login, nil
+}
+
+func (s *OIDCService) resolveUser(ctx context.Context, idToken oidc.IDToken) (string, error) {
+	const op = "service.OIDC.resolveUser"
+	issuer := s.provider.Issuer()
+
+	username, err := s.repo.GetIdentityUsername(ctx, issuer, idToken.Subject)
+	if err == nil {
+		return username, nil
+	}
+	if !errors.Is(err, repoerrs.ErrIdentityNotFound) {
+		s.log.Error("Failed to retrieve identity",
+			zap.String("op", op),
+			zap.String("subject", idToken.Subject),
+			zap.Error(err),
+		)
+		return "", err
+	}
+
+	username, err = s.usernameFromEmail(idToken)
+	if err != nil {
+		s.log.Warn("OIDC email cannot be mapped to a user",
+			zap.String("op", op),
+			zap.String("subject", idToken.Subject),
+			zap.String("email", idToken.Email),
+			zap.Error(err),
+		)
+		return "", err
+	}
+
+	// The user signs in via the provider only, so the password is random and never shown.
+	secret, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(secret), s.bcryptCost)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.repo.CreateIdentityUser(ctx, entity.Identity{
+		Username: username,
+		Issuer:   issuer,
+		Subject:  idToken.Subject,
+		Email:    idToken.Email,
+	}, passwordHash)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrUserAlreadyExists) {
+			s.log.Warn("Username is taken by an unlinked account",
+				zap.String("op", op),
+				zap.String("username", username),
+				zap.String("subject", idToken.Subject),
+			)
+			return "", servicerrs.ErrOIDCUsernameTaken
+		}
+		if errors.Is(err, repoerrs.ErrIdentityConflict) {
+			s.log.Warn("User is linked to another identity",
+				zap.String("op", op),
+				zap.String("username", username),
+				zap.String("subject", idToken.Subject),
+			)
+			return "", servicerrs.ErrIdentityConflict
+		}
+		s.log.Error("Failed to create identity user",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return "", err
+	}
+
+	s.log.Info("Identity user created",
+		zap.String("username", username),
+		zap.String("subject", idToken.Subject),
+	)
+
+	return username, nil
+}
+
+func (s *OIDCService) usernameFromEmail(idToken oidc.IDToken) (string, error) {
+	if idToken.Email == "" || !idToken.EmailVerified {
+		return "", servicerrs.ErrOIDCEmailNotAllowed
+	}
+
+	local, domain, ok := strings.Cut(strings.ToLower(idToken.Email), "@")
+	if !ok || domain != s.emailDomain {
+		return "", servicerrs.ErrOIDCEmailNotAllowed
+	}
+	if !model.ValidUsername(local) {
+		return "", servicerrs.ErrInvalidUsername
+	}
+
+	return local, nil
+}
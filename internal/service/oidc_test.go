@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/internal/utils/jwt"
+	"avito-internship/internal/utils/oidc"
+	"avito-internship/internal/utils/oidc/oidctest"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestOIDCService_Login(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+
+	idp, err := oidctest.NewProvider("shop", "secret")
+	require.NoError(t, err)
+	defer idp.Close()
+
+	provider, err := oidc.NewProvider(ctx, idp.Config("http://localhost:8080/api/oidc/callback"), http.DefaultClient)
+	require.NoError(t, err)
+
+	keys, err := jwt.GenerateKeyRing()
+	require.NoError(t, err)
+
+	mockCache, _ := newMemoryCache(ctrl)
+	mockRepo := repository.NewMockIdentity(ctrl)
+	service := NewOIDCService(zap.NewNop(), mockCache, mockRepo, OIDCConfig{
+		Provider:    provider,
+		Sessions:    newTestSessions(ctrl, keys),
+		EmailDomain: "corp.example",
+		BcryptCost:  bcrypt.MinCost,
+	})
+
+	// login walks through the provider login page and returns the callback parameters.
+	login := func(t *testing.T, user oidctest.User) OIDCCallbackInput {
+		authURL, err := service.LoginURL(ctx)
+		require.NoError(t, err)
+		code, state, err := idp.Login(authURL, user)
+		require.NoError(t, err)
+		return OIDCCallbackInput{Code: code, State: state}
+	}
+
+	alice := oidctest.User{Subject: "sub-alice", Email: "Alice@corp.example", EmailVerified: true}
+
+	tests := []struct {
+		name          string
+		user          oidctest.User
+		mockRepoSetup func()
+		wantUsername  string
+		expectedError error
+	}{
+		{
+			name: "First login creates the user",
+			user: alice,
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().GetIdentityUsername(gomock.Any(), idp.URL, "sub-alice").Return("", repoerrs.ErrIdentityNotFound)
+				mockRepo.EXPECT().CreateIdentityUser(gomock.Any(), entity.Identity{
+					Username: "alice",
+					Issuer:   idp.URL,
+					Subject:  "sub-alice",
+					Email:    "Alice@corp.example",
+				}, gomock.Any()).Return(nil)
+			},
+			wantUsername: "alice",
+		},
+		{
+			name: "Linked user",
+			user: alice,
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().GetIdentityUsername(gomock.Any(), idp.URL, "sub-alice").Return("alice.smith", nil)
+			},
+			wantUsername: "alice.smith",
+		},
+		{
+			name: "Username linked to another identity",
+			user: alice,
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().GetIdentityUsername(gomock.Any(), idp.URL, "sub-alice").Return("", repoerrs.ErrIdentityNotFound)
+				mockRepo.EXPECT().CreateIdentityUser(gomock.Any(), gomock.Any(), gomock.Any()).Return(repoerrs.ErrIdentityConflict)
+			},
+			expectedError: servicerrs.ErrIdentityConflict,
+		},
+		{
+			name: "Username taken by a local account",
+			user: alice,
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().GetIdentityUsername(gomock.Any(), idp.URL, "sub-alice").Return("", repoerrs.ErrIdentityNotFound)
+				mockRepo.EXPECT().CreateIdentityUser(gomock.Any(), gomock.Any(), gomock.Any()).Return(repoerrs.ErrUserAlreadyExists)
+			},
+			expectedError: servicerrs.ErrOIDCUsernameTaken,
+		},
+		{
+			name: "Unverified email",
+			user: oidctest.User{Subject: "sub-bob", Email: "bob@corp.example"},
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().GetIdentityUsername(gomock.Any(), idp.URL, "sub-bob").Return("", repoerrs.ErrIdentityNotFound)
+			},
+			expectedError: servicerrs.ErrOIDCEmailNotAllowed,
+		},
+		{
+			name: "Email outside the domain",
+			user: oidctest.User{Subject: "sub-eve", Email: "eve@evil.example", EmailVerified: true},
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().GetIdentityUsername(gomock.Any(), idp.URL, "sub-eve").Return("", repoerrs.ErrIdentityNotFound)
+			},
+			expectedError: servicerrs.ErrOIDCEmailNotAllowed,
+		},
+		{
+			name: "Email is not a valid username",
+			user: oidctest.User{Subject: "sub-x", Email: "1x@corp.example", EmailVerified: true},
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().GetIdentityUsername(gomock.Any(), idp.URL, "sub-x").Return("", repoerrs.ErrIdentityNotFound)
+			},
+			expectedError: servicerrs.ErrInvalidUsername,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup()
+
+			token, err := service.Callback(ctx, login(t, tt.user))
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			claims, err := keys.ParseToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantUsername, claims.Username)
+		})
+	}
+
+	t.Run("State cannot be replayed", func(t *testing.T) {
+		input := login(t, alice)
+		mockRepo.EXPECT().GetIdentityUsername(gomock.Any(), idp.URL, "sub-alice").Return("alice", nil)
+
+		_, err := service.Callback(ctx, input)
+		require.NoError(t, err)
+
+		_, err = service.Callback(ctx, input)
+		assert.ErrorIs(t, err, servicerrs.ErrInvalidOIDCState)
+	})
+
+	t.Run("Unknown state", func(t *testing.T) {
+		input := login(t, alice)
+		input.State = "forged"
+
+		_, err := service.Callback(ctx, input)
+		assert.ErrorIs(t, err, servicerrs.ErrInvalidOIDCState)
+	})
+
+	t.Run("Invalid code", func(t *testing.T) {
+		input := login(t, alice)
+		input.Code = "forged"
+
+		_, err := service.Callback(ctx, input)
+		assert.ErrorIs(t, err, servicerrs.ErrOIDCLoginFailed)
+	})
+}
@@ -4,8 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
-	"avito-internship/internal/cache"
+	"avito-internship/internal/entity"
 	"avito-internship/internal/repository"
 	"avito-internship/internal/repository/repoerrs"
 	"avito-internship/internal/service/servicerrs"
@@ -13,17 +14,30 @@ import (
 	"go.uber.org/zap"
 )
 
+// promoCodeErrors maps repository errors of promo code validation to service errors.
+var promoCodeErrors = map[error]error{
+	repoerrs.ErrPromoCodeNotFound:      servicerrs.ErrPromoCodeNotFound,
+	repoerrs.ErrPromoCodeInactive:      servicerrs.ErrPromoCodeInactive,
+	repoerrs.ErrPromoCodeNotApplicable: servicerrs.ErrPromoCodeNotApplicable,
+	repoerrs.ErrPromoCodeUsageLimit:    servicerrs.ErrPromoCodeUsageLimit,
+}
+
 type OperationService struct {
-	log   *zap.Logger
-	cache cache.Cache
-	repo  repository.Operation
+	log  *zap.Logger
+	repo repository.Operation
+
+	// users writes the changes made by operations through to the cached user info.
+	users User
+
+	now func() time.Time
 }
 
-func NewOperationService(log *zap.Logger, cache cache.Cache, repo repository.Operation) *OperationService {
+func NewOperationService(log *zap.Logger, repo repository.Operation, users User) *OperationService {
 	return &OperationService{
 		log:   log,
-		cache: cache,
 		repo:  repo,
+		users: users,
+		now:   time.Now,
 	}
 }
 
@@ -32,7 +46,7 @@ func (s *OperationService) TransferFunds(ctx context.Context, input TransferFund
 
 	s.log.Info("attempting to transfer funds")
 
-	err := s.repo.SaveTransfer(ctx, input.Sender, input.Recipient, input.Amount)
+	sender, recipient, err := s.repo.SaveTransfer(ctx, input.Sender, input.Recipient, input.Amount)
 	if err != nil {
 		if errors.Is(err, repoerrs.ErrUserNotFound) {
 			s.log.Error("recipient not found",
@@ -58,15 +72,15 @@ func (s *OperationService) TransferFunds(ctx context.Context, input TransferFund
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	senderCacheKey := fmt.Sprintf("user_info:%s", input.Sender)
-	recipientCacheKey := fmt.Sprintf("user_info:%s", input.Recipient)
-
-	if err := s.cache.Del(ctx, senderCacheKey, recipientCacheKey); err != nil {
-		s.log.Error("failed to invalidate cache",
-			zap.String("op", op),
-			zap.Error(err),
-		)
-	}
+	now := s.now()
+	s.updateUserInfo(ctx, op, input.Sender, sender.Version, func(info *RetrieveUserInfoOutput) {
+		info.Balance = sender.Balance
+		info.TransferOut = addTransfer(info.TransferOut, input.Recipient, input.Amount, now)
+	})
+	s.updateUserInfo(ctx, op, input.Recipient, recipient.Version, func(info *RetrieveUserInfoOutput) {
+		info.Balance = recipient.Balance
+		info.TransferIn = addTransfer(info.TransferIn, input.Sender, input.Amount, now)
+	})
 
 	s.log.Info("transfer successfully completed")
 
@@ -78,7 +92,7 @@ func (s *OperationService) PurchaseProduct(ctx context.Context, input PurchasePr
 
 	s.log.Info("attempting to purchase product")
 
-	err := s.repo.SavePurchase(ctx, input.Username, input.Product)
+	customer, err := s.repo.SavePurchase(ctx, input.Username, input.Product, input.Variant, input.PromoCode, input.Location)
 	if err != nil {
 		if errors.Is(err, repoerrs.ErrUserNotFound) {
 			s.log.Error("Customer not found",
@@ -94,6 +108,21 @@ func (s *OperationService) PurchaseProduct(ctx context.Context, input PurchasePr
 			)
 
 			return fmt.Errorf("%s: %w", op, servicerrs.ErrProductNotFound)
+		} else if errors.Is(err, repoerrs.ErrVariantNotFound) {
+			s.log.Warn("product variant not found",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+				zap.String("variant", input.Variant),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrVariantNotFound)
+		} else if errors.Is(err, repoerrs.ErrVariantRequired) {
+			s.log.Warn("product variant is required",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrVariantRequired)
 		} else if errors.Is(err, repoerrs.ErrInsufficientFunds) {
 			s.log.Warn("insufficient funds",
 				zap.String("op", op),
@@ -101,19 +130,84 @@ func (s *OperationService) PurchaseProduct(ctx context.Context, input PurchasePr
 			)
 
 			return fmt.Errorf("%s: %w", op, servicerrs.ErrInsufficientFunds)
+		} else if errors.Is(err, repoerrs.ErrOutOfStock) {
+			s.log.Warn("product out of stock",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrOutOfStock)
+		} else if errors.Is(err, repoerrs.ErrPurchaseLimitExceeded) {
+			s.log.Warn("purchase limit exceeded",
+				zap.String("op", op),
+				zap.String("customer", input.Username),
+				zap.String("product", input.Product),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrPurchaseLimitExceeded)
 		}
-	}
 
-	customerCacheKey := fmt.Sprintf("user_info:%s", input.Username)
+		for repoErr, serviceErr := range promoCodeErrors {
+			if errors.Is(err, repoErr) {
+				s.log.Warn("promo code rejected",
+					zap.String("op", op),
+					zap.String("customer", input.Username),
+					zap.String("promo_code", input.PromoCode),
+					zap.Error(err),
+				)
+
+				return fmt.Errorf("%s: %w", op, serviceErr)
+			}
+		}
 
-	if err := s.cache.Del(ctx, customerCacheKey); err != nil {
-		s.log.Error("failed to invalidate cache",
+		s.log.Error("failed to save purchase to database",
 			zap.String("op", op),
 			zap.Error(err),
 		)
+
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	s.updateUserInfo(ctx, op, input.Username, customer.Version, func(info *RetrieveUserInfoOutput) {
+		info.Balance = customer.Balance
+		for i := range info.Inventory {
+			if info.Inventory[i].Product == input.Product {
+				info.Inventory[i].Quantity++
+				return
+			}
+		}
+		info.Inventory = append(info.Inventory, entity.Inventory{Product: input.Product, Quantity: 1})
+	})
+
 	s.log.Info("Purchase successfully completed")
 
 	return nil
 }
+
+// updateUserInfo writes the change numbered version, made by an operation,
+// through to the cached user info. The cache only takes it over the info of
+// the previous version, so concurrent operations and reloads of the same user
+// never leave an older info behind.
+func (s *OperationService) updateUserInfo(ctx context.Context, op string, username string, version int64, update func(info *RetrieveUserInfoOutput)) {
+	if err := s.users.UpdateInfo(ctx, username, version, update); err != nil {
+		s.log.Error("failed to update cached user info",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+	}
+}
+
+// addTransfer adds the transfer to the total of the counterparty, as the user
+// info holds one total per counterparty.
+func addTransfer(transfers []entity.Transfer, username string, amount int, at time.Time) []entity.Transfer {
+	for i := range transfers {
+		if transfers[i].Username == username {
+			transfers[i].Amount += amount
+			transfers[i].Count++
+			transfers[i].LastTransferAt = &at
+			return transfers
+		}
+	}
+	return append(transfers, entity.Transfer{Username: username, Amount: amount, Count: 1, LastTransferAt: &at})
+}
@@ -4,8 +4,9 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
-	"avito-internship/internal/cache"
+	"avito-internship/internal/entity"
 	"avito-internship/internal/repository"
 	"avito-internship/internal/repository/repoerrs"
 	"avito-internship/internal/service/servicerrs"
@@ -20,16 +21,16 @@ func TestOperationService_TransferFunds(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := repository.NewMockOperation(ctrl)
-	mockCache := cache.NewMockCache(ctrl)
+	mockUsers := NewMockUser(ctrl)
 	logger := zap.NewNop()
 
-	service := NewOperationService(logger, mockCache, mockRepo)
+	service := NewOperationService(logger, mockRepo, mockUsers)
 
 	tests := []struct {
 		name           string
 		input          TransferFundsInput
 		mockRepoSetup  func(*repository.MockOperation)
-		mockCacheSetup func(*cache.MockCache)
+		mockUsersSetup func(*MockUser)
 		expectedError  error
 	}{
 		{
@@ -42,11 +43,14 @@ func TestOperationService_TransferFunds(t *testing.T) {
 			mockRepoSetup: func(m *repository.MockOperation) {
 				m.EXPECT().
 					SaveTransfer(gomock.Any(), "user1", "user2", 100).
-					Return(nil)
+					Return(entity.BalanceChange{Balance: 900, Version: 5}, entity.BalanceChange{Balance: 1100, Version: 2}, nil)
 			},
-			mockCacheSetup: func(m *cache.MockCache) {
+			mockUsersSetup: func(m *MockUser) {
+				m.EXPECT().
+					UpdateInfo(gomock.Any(), "user1", int64(5), gomock.Any()).
+					Return(nil)
 				m.EXPECT().
-					Del(gomock.Any(), "user_info:user1", "user_info:user2").
+					UpdateInfo(gomock.Any(), "user2", int64(2), gomock.Any()).
 					Return(nil)
 			},
 			expectedError: nil,
@@ -61,9 +65,9 @@ func TestOperationService_TransferFunds(t *testing.T) {
 			mockRepoSetup: func(m *repository.MockOperation) {
 				m.EXPECT().
 					SaveTransfer(gomock.Any(), "user1", "user2", 100).
-					Return(repoerrs.ErrUserNotFound)
+					Return(entity.BalanceChange{}, entity.BalanceChange{}, repoerrs.ErrUserNotFound)
 			},
-			mockCacheSetup: func(m *cache.MockCache) {},
+			mockUsersSetup: func(m *MockUser) {},
 			expectedError:  servicerrs.ErrRecipientNotFound,
 		},
 		{
@@ -76,9 +80,9 @@ func TestOperationService_TransferFunds(t *testing.T) {
 			mockRepoSetup: func(m *repository.MockOperation) {
 				m.EXPECT().
 					SaveTransfer(gomock.Any(), "user1", "user2", 100).
-					Return(repoerrs.ErrInsufficientFunds)
+					Return(entity.BalanceChange{}, entity.BalanceChange{}, repoerrs.ErrInsufficientFunds)
 			},
-			mockCacheSetup: func(m *cache.MockCache) {},
+			mockUsersSetup: func(m *MockUser) {},
 			expectedError:  servicerrs.ErrInsufficientFunds,
 		},
 		{
@@ -91,13 +95,13 @@ func TestOperationService_TransferFunds(t *testing.T) {
 			mockRepoSetup: func(m *repository.MockOperation) {
 				m.EXPECT().
 					SaveTransfer(gomock.Any(), "user1", "user2", 100).
-					Return(errors.New("repository error"))
+					Return(entity.BalanceChange{}, entity.BalanceChange{}, errors.New("repository error"))
 			},
-			mockCacheSetup: func(m *cache.MockCache) {},
+			mockUsersSetup: func(m *MockUser) {},
 			expectedError:  errors.New("repository error"),
 		},
 		{
-			name: "Cache invalidation error",
+			name: "Cache error",
 			input: TransferFundsInput{
 				Sender:    "user1",
 				Recipient: "user2",
@@ -106,11 +110,14 @@ func TestOperationService_TransferFunds(t *testing.T) {
 			mockRepoSetup: func(m *repository.MockOperation) {
 				m.EXPECT().
 					SaveTransfer(gomock.Any(), "user1", "user2", 100).
-					Return(nil)
+					Return(entity.BalanceChange{Balance: 900, Version: 5}, entity.BalanceChange{Balance: 1100, Version: 2}, nil)
 			},
-			mockCacheSetup: func(m *cache.MockCache) {
+			mockUsersSetup: func(m *MockUser) {
+				m.EXPECT().
+					UpdateInfo(gomock.Any(), "user1", int64(5), gomock.Any()).
+					Return(errors.New("cache error"))
 				m.EXPECT().
-					Del(gomock.Any(), "user_info:user1", "user_info:user2").
+					UpdateInfo(gomock.Any(), "user2", int64(2), gomock.Any()).
 					Return(errors.New("cache error"))
 			},
 			expectedError: nil,
@@ -120,7 +127,7 @@ func TestOperationService_TransferFunds(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockRepoSetup(mockRepo)
-			tt.mockCacheSetup(mockCache)
+			tt.mockUsersSetup(mockUsers)
 
 			err := service.TransferFunds(context.Background(), tt.input)
 
@@ -139,16 +146,16 @@ func TestOperationService_PurchaseProduct(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := repository.NewMockOperation(ctrl)
-	mockCache := cache.NewMockCache(ctrl)
+	mockUsers := NewMockUser(ctrl)
 	logger := zap.NewNop()
 
-	service := NewOperationService(logger, mockCache, mockRepo)
+	service := NewOperationService(logger, mockRepo, mockUsers)
 
 	tests := []struct {
 		name           string
 		input          PurchaseProductInput
 		mockRepoSetup  func(*repository.MockOperation)
-		mockCacheSetup func(*cache.MockCache)
+		mockUsersSetup func(*MockUser)
 		expectedError  error
 	}{
 		{
@@ -159,12 +166,12 @@ func TestOperationService_PurchaseProduct(t *testing.T) {
 			},
 			mockRepoSetup: func(m *repository.MockOperation) {
 				m.EXPECT().
-					SavePurchase(gomock.Any(), "user1", "product1").
-					Return(nil)
+					SavePurchase(gomock.Any(), "user1", "product1", "", "", "").
+					Return(entity.BalanceChange{Balance: 900, Version: 5}, nil)
 			},
-			mockCacheSetup: func(m *cache.MockCache) {
+			mockUsersSetup: func(m *MockUser) {
 				m.EXPECT().
-					Del(gomock.Any(), "user_info:user1").
+					UpdateInfo(gomock.Any(), "user1", int64(5), gomock.Any()).
 					Return(nil)
 			},
 			expectedError: nil,
@@ -177,10 +184,10 @@ func TestOperationService_PurchaseProduct(t *testing.T) {
 			},
 			mockRepoSetup: func(m *repository.MockOperation) {
 				m.EXPECT().
-					SavePurchase(gomock.Any(), "user1", "product1").
-					Return(repoerrs.ErrUserNotFound)
+					SavePurchase(gomock.Any(), "user1", "product1", "", "", "").
+					Return(entity.BalanceChange{}, repoerrs.ErrUserNotFound)
 			},
-			mockCacheSetup: func(m *cache.MockCache) {},
+			mockUsersSetup: func(m *MockUser) {},
 			expectedError:  servicerrs.ErrCustomerNotFound,
 		},
 		{
@@ -191,10 +198,10 @@ func TestOperationService_PurchaseProduct(t *testing.T) {
 			},
 			mockRepoSetup: func(m *repository.MockOperation) {
 				m.EXPECT().
-					SavePurchase(gomock.Any(), "user1", "product1").
-					Return(repoerrs.ErrProductNotFound)
+					SavePurchase(gomock.Any(), "user1", "product1", "", "", "").
+					Return(entity.BalanceChange{}, repoerrs.ErrProductNotFound)
 			},
-			mockCacheSetup: func(m *cache.MockCache) {},
+			mockUsersSetup: func(m *MockUser) {},
 			expectedError:  servicerrs.ErrProductNotFound,
 		},
 		{
@@ -205,26 +212,131 @@ func TestOperationService_PurchaseProduct(t *testing.T) {
 			},
 			mockRepoSetup: func(m *repository.MockOperation) {
 				m.EXPECT().
-					SavePurchase(gomock.Any(), "user1", "product1").
-					Return(repoerrs.ErrInsufficientFunds)
+					SavePurchase(gomock.Any(), "user1", "product1", "", "", "").
+					Return(entity.BalanceChange{}, repoerrs.ErrInsufficientFunds)
 			},
-			mockCacheSetup: func(m *cache.MockCache) {},
+			mockUsersSetup: func(m *MockUser) {},
 			expectedError:  servicerrs.ErrInsufficientFunds,
 		},
 		{
-			name: "Cache invalidation error",
+			name: "Out of stock",
 			input: PurchaseProductInput{
 				Username: "user1",
 				Product:  "product1",
 			},
 			mockRepoSetup: func(m *repository.MockOperation) {
 				m.EXPECT().
-					SavePurchase(gomock.Any(), "user1", "product1").
+					SavePurchase(gomock.Any(), "user1", "product1", "", "", "").
+					Return(entity.BalanceChange{}, repoerrs.ErrOutOfStock)
+			},
+			mockUsersSetup: func(m *MockUser) {},
+			expectedError:  servicerrs.ErrOutOfStock,
+		},
+		{
+			name: "Purchase limit exceeded",
+			input: PurchaseProductInput{
+				Username: "user1",
+				Product:  "product1",
+			},
+			mockRepoSetup: func(m *repository.MockOperation) {
+				m.EXPECT().
+					SavePurchase(gomock.Any(), "user1", "product1", "", "", "").
+					Return(entity.BalanceChange{}, repoerrs.ErrPurchaseLimitExceeded)
+			},
+			mockUsersSetup: func(m *MockUser) {},
+			expectedError:  servicerrs.ErrPurchaseLimitExceeded,
+		},
+		{
+			name: "Successful purchase with promo code",
+			input: PurchaseProductInput{
+				Username:  "user1",
+				Product:   "product1",
+				PromoCode: "SALE20",
+			},
+			mockRepoSetup: func(m *repository.MockOperation) {
+				m.EXPECT().
+					SavePurchase(gomock.Any(), "user1", "product1", "", "SALE20", "").
+					Return(entity.BalanceChange{Balance: 900, Version: 5}, nil)
+			},
+			mockUsersSetup: func(m *MockUser) {
+				m.EXPECT().
+					UpdateInfo(gomock.Any(), "user1", int64(5), gomock.Any()).
 					Return(nil)
 			},
-			mockCacheSetup: func(m *cache.MockCache) {
+			expectedError: nil,
+		},
+		{
+			name: "Promo code usage limit reached",
+			input: PurchaseProductInput{
+				Username:  "user1",
+				Product:   "product1",
+				PromoCode: "SALE20",
+			},
+			mockRepoSetup: func(m *repository.MockOperation) {
+				m.EXPECT().
+					SavePurchase(gomock.Any(), "user1", "product1", "", "SALE20", "").
+					Return(entity.BalanceChange{}, repoerrs.ErrPromoCodeUsageLimit)
+			},
+			mockUsersSetup: func(m *MockUser) {},
+			expectedError:  servicerrs.ErrPromoCodeUsageLimit,
+		},
+		{
+			name: "Variant required",
+			input: PurchaseProductInput{
+				Username: "user1",
+				Product:  "product1",
+			},
+			mockRepoSetup: func(m *repository.MockOperation) {
 				m.EXPECT().
-					Del(gomock.Any(), "user_info:user1").
+					SavePurchase(gomock.Any(), "user1", "product1", "", "", "").
+					Return(entity.BalanceChange{}, repoerrs.ErrVariantRequired)
+			},
+			mockUsersSetup: func(m *MockUser) {},
+			expectedError:  servicerrs.ErrVariantRequired,
+		},
+		{
+			name: "Variant not found",
+			input: PurchaseProductInput{
+				Username: "user1",
+				Product:  "product1",
+				Variant:  "XXL",
+			},
+			mockRepoSetup: func(m *repository.MockOperation) {
+				m.EXPECT().
+					SavePurchase(gomock.Any(), "user1", "product1", "XXL", "", "").
+					Return(entity.BalanceChange{}, repoerrs.ErrVariantNotFound)
+			},
+			mockUsersSetup: func(m *MockUser) {},
+			expectedError:  servicerrs.ErrVariantNotFound,
+		},
+		{
+			name: "Repository error",
+			input: PurchaseProductInput{
+				Username: "user1",
+				Product:  "product1",
+			},
+			mockRepoSetup: func(m *repository.MockOperation) {
+				m.EXPECT().
+					SavePurchase(gomock.Any(), "user1", "product1", "", "", "").
+					Return(entity.BalanceChange{}, errors.New("repository error"))
+			},
+			mockUsersSetup: func(m *MockUser) {},
+			expectedError:  errors.New("repository error"),
+		},
+		{
+			name: "Cache error",
+			input: PurchaseProductInput{
+				Username: "user1",
+				Product:  "product1",
+			},
+			mockRepoSetup: func(m *repository.MockOperation) {
+				m.EXPECT().
+					SavePurchase(gomock.Any(), "user1", "product1", "", "", "").
+					Return(entity.BalanceChange{Balance: 900, Version: 5}, nil)
+			},
+			mockUsersSetup: func(m *MockUser) {
+				m.EXPECT().
+					UpdateInfo(gomock.Any(), "user1", int64(5), gomock.Any()).
 					Return(errors.New("cache error"))
 			},
 			expectedError: nil,
@@ -234,7 +346,7 @@ func TestOperationService_PurchaseProduct(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockRepoSetup(mockRepo)
-			tt.mockCacheSetup(mockCache)
+			tt.mockUsersSetup(mockUsers)
 
 			err := service.PurchaseProduct(context.Background(), tt.input)
 
@@ -247,3 +359,61 @@ func TestOperationService_PurchaseProduct(t *testing.T) {
 		})
 	}
 }
+
+func TestOperationService_WritesUserInfoThrough(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockCache, _ := newMemoryCache(ctrl)
+	userRepo := repository.NewMockUser(ctrl)
+	operationRepo := repository.NewMockOperation(ctrl)
+	users := NewUserService(zap.NewNop(), mockCache, userRepo)
+	operations := NewOperationService(zap.NewNop(), operationRepo, users)
+	operations.now = func() time.Time { return time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC) }
+	transferAt := operations.now()
+
+	userRepo.EXPECT().GetInfo(gomock.Any(), "user1").Return(entity.UserInfo{
+		Balance:   1000,
+		Inventory: []entity.Inventory{{Product: "cup", Quantity: 1}},
+		Received:  []entity.Transfer{},
+		Sent:      []entity.Transfer{},
+		Version:   4,
+	}, nil)
+	_, err := users.RetrieveUserInfo(ctx, RetrieveUserInfoInput{Username: "user1"})
+	assert.NoError(t, err)
+
+	// The operations following the cached version are applied without a reload.
+	operationRepo.EXPECT().SaveTransfer(gomock.Any(), "user1", "user2", 100).
+		Return(entity.BalanceChange{Balance: 900, Version: 5}, entity.BalanceChange{Balance: 1100, Version: 2}, nil)
+	assert.NoError(t, operations.TransferFunds(ctx, TransferFundsInput{Sender: "user1", Recipient: "user2", Amount: 100}))
+	operationRepo.EXPECT().SavePurchase(gomock.Any(), "user1", "cup", "", "", "").
+		Return(entity.BalanceChange{Balance: 890, Version: 6}, nil)
+	assert.NoError(t, operations.PurchaseProduct(ctx, PurchaseProductInput{Username: "user1", Product: "cup"}))
+
+	info, err := users.RetrieveUserInfo(ctx, RetrieveUserInfoInput{Username: "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, RetrieveUserInfoOutput{
+		Balance:     890,
+		Inventory:   []entity.Inventory{{Product: "cup", Quantity: 2}},
+		TransferIn:  []entity.Transfer{},
+		TransferOut: []entity.Transfer{{Username: "user2", Amount: 100, Count: 1, LastTransferAt: &transferAt}},
+	}, info)
+
+	// An operation after one the cache missed drops the info, it is loaded again.
+	operationRepo.EXPECT().SavePurchase(gomock.Any(), "user1", "cup", "", "", "").
+		Return(entity.BalanceChange{Balance: 870, Version: 8}, nil)
+	assert.NoError(t, operations.PurchaseProduct(ctx, PurchaseProductInput{Username: "user1", Product: "cup"}))
+
+	userRepo.EXPECT().GetInfo(gomock.Any(), "user1").Return(entity.UserInfo{
+		Balance:   870,
+		Inventory: []entity.Inventory{{Product: "cup", Quantity: 3}},
+		Received:  []entity.Transfer{{Username: "user3", Amount: 10, Count: 1}},
+		Sent:      []entity.Transfer{{Username: "user2", Amount: 100, Count: 1, LastTransferAt: &transferAt}},
+		Version:   8,
+	}, nil)
+	info, err = users.RetrieveUserInfo(ctx, RetrieveUserInfoInput{Username: "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 870, info.Balance)
+	assert.Equal(t, []entity.Transfer{{Username: "user3", Amount: 10, Count: 1}}, info.TransferIn)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/model"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+
+	"go.uber.org/zap"
+)
+
+type OrderService struct {
+	log   *zap.Logger
+	repo  repository.Order
+	users User
+}
+
+func NewOrderService(log *zap.Logger, repo repository.Order, users User) *OrderService {
+	return &OrderService{
+		log:   log,
+		repo:  repo,
+		users: users,
+	}
+}
+
+func (s *OrderService) ListUserOrders(ctx context.Context, username string) ([]entity.Order, error) {
+	const op = "service.OrderService.ListUserOrders"
+
+	s.log.Info("attempting to list user orders", zap.String("username", username))
+
+	orders, err := s.repo.GetUserOrders(ctx, username)
+	if err != nil {
+		s.log.Error("failed to list user orders",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+func (s *OrderService) ListOrders(ctx context.Context, status string) ([]entity.Order, error) {
+	const op = "service.OrderService.ListOrders"
+
+	s.log.Info("attempting to list orders", zap.String("status", status))
+
+	orders, err := s.repo.GetOrders(ctx, status)
+	if err != nil {
+		s.log.Error("failed to list orders",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return orders, nil
+}
+
+func (s *OrderService) AdvanceOrder(ctx context.Context, input AdvanceOrderInput) (entity.Order, error) {
+	const op = "service.OrderService.AdvanceOrder"
+
+	s.log.Info("attempting to advance order",
+		zap.Int("id", input.ID),
+		zap.String("status", input.Status),
+	)
+
+	order, err := s.repo.UpdateOrderStatus(ctx, input.ID, input.Status)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrOrderNotFound) {
+			s.log.Warn("order not found",
+				zap.String("op", op),
+				zap.Int("id", input.ID),
+			)
+
+			return entity.Order{}, fmt.Errorf("%s: %w", op, servicerrs.ErrOrderNotFound)
+		} else if errors.Is(err, repoerrs.ErrInvalidOrderTransition) {
+			s.log.Warn("invalid order status transition",
+				zap.String("op", op),
+				zap.Int("id", input.ID),
+				zap.String("status", input.Status),
+			)
+
+			return entity.Order{}, fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidOrderTransition)
+		}
+
+		s.log.Error("failed to advance order",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return entity.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Cancellation refunds the purchase, so the cached balance and inventory are stale.
+	if order.Status == model.OrderStatusCancelled {
+		if err := s.users.InvalidateInfo(ctx, order.Username); err != nil {
+			s.log.Error("failed to invalidate cache",
+				zap.String("op", op),
+				zap.Error(err),
+			)
+		}
+	}
+
+	s.log.Info("order successfully advanced",
+		zap.Int("id", order.ID),
+		zap.String("status", order.Status),
+	)
+
+	return order, nil
+}
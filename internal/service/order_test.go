@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/model"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestOrderService_AdvanceOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockOrder(ctrl)
+	mockUsers := NewMockUser(ctrl)
+	logger := zap.NewNop()
+
+	service := NewOrderService(logger, mockRepo, mockUsers)
+
+	tests := []struct {
+		name           string
+		input          AdvanceOrderInput
+		mockRepoSetup  func(*repository.MockOrder)
+		mockUsersSetup func(*MockUser)
+		expectedOrder  entity.Order
+		expectedError  error
+	}{
+		{
+			name:  "Order packed",
+			input: AdvanceOrderInput{ID: 1, Status: model.OrderStatusPacked},
+			mockRepoSetup: func(m *repository.MockOrder) {
+				m.EXPECT().
+					UpdateOrderStatus(gomock.Any(), 1, model.OrderStatusPacked).
+					Return(entity.Order{ID: 1, Username: "user1", Status: model.OrderStatusPacked}, nil)
+			},
+			mockUsersSetup: func(m *MockUser) {},
+			expectedOrder:  entity.Order{ID: 1, Username: "user1", Status: model.OrderStatusPacked},
+		},
+		{
+			name:  "Order cancelled",
+			input: AdvanceOrderInput{ID: 1, Status: model.OrderStatusCancelled},
+			mockRepoSetup: func(m *repository.MockOrder) {
+				m.EXPECT().
+					UpdateOrderStatus(gomock.Any(), 1, model.OrderStatusCancelled).
+					Return(entity.Order{ID: 1, Username: "user1", Status: model.OrderStatusCancelled}, nil)
+			},
+			mockUsersSetup: func(m *MockUser) {
+				m.EXPECT().
+					InvalidateInfo(gomock.Any(), "user1").
+					Return(nil)
+			},
+			expectedOrder: entity.Order{ID: 1, Username: "user1", Status: model.OrderStatusCancelled},
+		},
+		{
+			name:  "Cache invalidation error",
+			input: AdvanceOrderInput{ID: 1, Status: model.OrderStatusCancelled},
+			mockRepoSetup: func(m *repository.MockOrder) {
+				m.EXPECT().
+					UpdateOrderStatus(gomock.Any(), 1, model.OrderStatusCancelled).
+					Return(entity.Order{ID: 1, Username: "user1", Status: model.OrderStatusCancelled}, nil)
+			},
+			mockUsersSetup: func(m *MockUser) {
+				m.EXPECT().
+					InvalidateInfo(gomock.Any(), "user1").
+					Return(errors.New("cache error"))
+			},
+			expectedOrder: entity.Order{ID: 1, Username: "user1", Status: model.OrderStatusCancelled},
+		},
+		{
+			name:  "Order not found",
+			input: AdvanceOrderInput{ID: 2, Status: model.OrderStatusPacked},
+			mockRepoSetup: func(m *repository.MockOrder) {
+				m.EXPECT().
+					UpdateOrderStatus(gomock.Any(), 2, model.OrderStatusPacked).
+					Return(entity.Order{}, repoerrs.ErrOrderNotFound)
+			},
+			mockUsersSetup: func(m *MockUser) {},
+			expectedError:  servicerrs.ErrOrderNotFound,
+		},
+		{
+			name:  "Invalid transition",
+			input: AdvanceOrderInput{ID: 1, Status: model.OrderStatusDelivered},
+			mockRepoSetup: func(m *repository.MockOrder) {
+				m.EXPECT().
+					UpdateOrderStatus(gomock.Any(), 1, model.OrderStatusDelivered).
+					Return(entity.Order{}, repoerrs.ErrInvalidOrderTransition)
+			},
+			mockUsersSetup: func(m *MockUser) {},
+			expectedError:  servicerrs.ErrInvalidOrderTransition,
+		},
+		{
+			name:  "Repository error",
+			input: AdvanceOrderInput{ID: 1, Status: model.OrderStatusPacked},
+			mockRepoSetup: func(m *repository.MockOrder) {
+				m.EXPECT().
+					UpdateOrderStatus(gomock.Any(), 1, model.OrderStatusPacked).
+					Return(entity.Order{}, errors.New("repository error"))
+			},
+			mockUsersSetup: func(m *MockUser) {},
+			expectedError:  errors.New("repository error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup(mockRepo)
+			tt.mockUsersSetup(mockUsers)
+
+			order, err := service.AdvanceOrder(context.Background(), tt.input)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedOrder, order)
+			}
+		})
+	}
+}
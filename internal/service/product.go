@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/cache/loader"
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+
+	"go.uber.org/zap"
+)
+
+type ProductService struct {
+	log  *zap.Logger
+	repo repository.Product
+
+	// catalog caches the unfiltered catalog, which every shop page loads.
+	catalog *loader.Loader[[]entity.Product]
+}
+
+func NewProductService(log *zap.Logger, cache cache.Cache, repo repository.Product) *ProductService {
+	return &ProductService{
+		log:     log,
+		repo:    repo,
+		catalog: loader.NewLoader(cache, catalogKey, log, loader.Options{}),
+	}
+}
+
+// CatalogCacheStats returns the hit, miss and coalescing counters of the catalog cache.
+func (s *ProductService) CatalogCacheStats() loader.Stats {
+	return s.catalog.Stats()
+}
+
+func (s *ProductService) RestockProduct(ctx context.Context, input RestockProductInput) (int, error) {
+	const op = "service.ProductService.RestockProduct"
+
+	s.log.Info("attempting to restock product",
+		zap.String("product", input.Product),
+		zap.String("variant", input.Variant),
+		zap.Int("quantity", input.Quantity),
+		zap.String("admin", input.Admin),
+	)
+
+	stock, err := s.repo.Restock(ctx, input.Product, input.Variant, input.Quantity, input.Reason, input.Admin)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrProductNotFound) {
+			s.log.Warn("product not found",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+			)
+
+			return 0, fmt.Errorf("%s: %w", op, servicerrs.ErrProductNotFound)
+		} else if errors.Is(err, repoerrs.ErrVariantNotFound) {
+			s.log.Warn("product variant not found",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+				zap.String("variant", input.Variant),
+			)
+
+			return 0, fmt.Errorf("%s: %w", op, servicerrs.ErrVariantNotFound)
+		} else if errors.Is(err, repoerrs.ErrVariantRequired) {
+			s.log.Warn("product variant is required",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+			)
+
+			return 0, fmt.Errorf("%s: %w", op, servicerrs.ErrVariantRequired)
+		} else if errors.Is(err, repoerrs.ErrNegativeStock) {
+			s.log.Warn("stock cannot be negative",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+				zap.Int("quantity", input.Quantity),
+			)
+
+			return 0, fmt.Errorf("%s: %w", op, servicerrs.ErrNegativeStock)
+		}
+
+		s.log.Error("failed to restock product",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateCatalog(ctx, op)
+
+	s.log.Info("product successfully restocked",
+		zap.String("product", input.Product),
+		zap.Int("stock", stock),
+	)
+
+	return stock, nil
+}
+
+func (s *ProductService) SetPurchaseLimit(ctx context.Context, input SetPurchaseLimitInput) error {
+	const op = "service.ProductService.SetPurchaseLimit"
+
+	s.log.Info("attempting to set purchase limit",
+		zap.String("product", input.Product),
+		zap.Int("limit", input.Limit),
+	)
+
+	// Zero limit removes the cap for the product.
+	var limit *int
+	if input.Limit > 0 {
+		limit = &input.Limit
+	}
+
+	if err := s.repo.SetPurchaseLimit(ctx, input.Product, limit); err != nil {
+		if errors.Is(err, repoerrs.ErrProductNotFound) {
+			s.log.Warn("product not found",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrProductNotFound)
+		}
+
+		s.log.Error("failed to set purchase limit",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("purchase limit successfully set")
+
+	return nil
+}
+
+func (s *ProductService) SetPrice(ctx context.Context, input SetPriceInput) error {
+	const op = "service.ProductService.SetPrice"
+
+	s.log.Info("attempting to set product price",
+		zap.String("product", input.Product),
+		zap.Int("price", input.Price),
+	)
+
+	if err := s.repo.SetPrice(ctx, input.Product, input.Price); err != nil {
+		if errors.Is(err, repoerrs.ErrProductNotFound) {
+			s.log.Warn("product not found",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrProductNotFound)
+		}
+
+		s.log.Error("failed to set product price",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateCatalog(ctx, op)
+
+	s.log.Info("product price successfully set")
+
+	return nil
+}
+
+func (s *ProductService) RetrieveStockHistory(ctx context.Context, product string) ([]entity.StockChange, error) {
+	const op = "service.ProductService.RetrieveStockHistory"
+
+	s.log.Info("attempting to retrieve stock history", zap.String("product", product))
+
+	history, err := s.repo.GetStockHistory(ctx, product)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrProductNotFound) {
+			s.log.Warn("product not found",
+				zap.String("op", op),
+				zap.String("product", product),
+			)
+
+			return nil, fmt.Errorf("%s: %w", op, servicerrs.ErrProductNotFound)
+		}
+
+		s.log.Error("failed to retrieve stock history",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("stock history successfully retrieved")
+
+	return history, nil
+}
+
+func (s *ProductService) AddProductVariant(ctx context.Context, input AddProductVariantInput) error {
+	const op = "service.ProductService.AddProductVariant"
+
+	s.log.Info("attempting to add product variant",
+		zap.String("product", input.Product),
+		zap.String("variant", input.Variant),
+	)
+
+	err := s.repo.AddVariant(ctx, input.Product, entity.ProductVariant{
+		Name:       input.Variant,
+		Attributes: input.Attributes,
+		Stock:      input.Stock,
+	})
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrProductNotFound) {
+			s.log.Warn("product not found",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrProductNotFound)
+		} else if errors.Is(err, repoerrs.ErrVariantAlreadyExists) {
+			s.log.Warn("product variant already exists",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+				zap.String("variant", input.Variant),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrVariantAlreadyExists)
+		}
+
+		s.log.Error("failed to add product variant",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateCatalog(ctx, op)
+
+	s.log.Info("product variant successfully added")
+
+	return nil
+}
+
+func (s *ProductService) SearchProducts(ctx context.Context, input SearchProductsInput) ([]entity.Product, error) {
+	const op = "service.ProductService.SearchProducts"
+
+	s.log.Info("attempting to search products", zap.String("query", input.Query))
+
+	filter := entity.ProductFilter{
+		Query:     input.Query,
+		Category:  input.Category,
+		MinPrice:  input.MinPrice,
+		MaxPrice:  input.MaxPrice,
+		Available: input.Available,
+	}
+
+	var (
+		products []entity.Product
+		err      error
+	)
+	if input == (SearchProductsInput{}) {
+		products, err = s.catalog.Fetch(ctx, catalogID, func(ctx context.Context) ([]entity.Product, error) {
+			return s.repo.SearchProducts(ctx, filter)
+		})
+	} else {
+		products, err = s.repo.SearchProducts(ctx, filter)
+	}
+	if err != nil {
+		s.log.Error("failed to search products",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("products successfully found", zap.Int("count", len(products)))
+
+	return products, nil
+}
+
+// invalidateCatalog drops the cached catalog after a product changed. The
+// change is saved already, so a failure is logged and the catalog is stale
+// until it expires.
+func (s *ProductService) invalidateCatalog(ctx context.Context, op string) {
+	if err := s.catalog.Invalidate(ctx, catalogID); err != nil {
+		s.log.Warn("failed to invalidate cached catalog",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestProductService_RestockProduct(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockProduct(ctrl)
+	logger := zap.NewNop()
+
+	mockCache, _ := newMemoryCache(ctrl)
+	service := NewProductService(logger, mockCache, mockRepo)
+
+	tests := []struct {
+		name          string
+		input         RestockProductInput
+		mockRepoSetup func(*repository.MockProduct)
+		expectedStock int
+		expectedError error
+	}{
+		{
+			name: "Successful restock",
+			input: RestockProductInput{
+				Product:  "powerbank",
+				Quantity: 10,
+				Reason:   "delivery",
+				Admin:    "admin",
+			},
+			mockRepoSetup: func(m *repository.MockProduct) {
+				m.EXPECT().
+					Restock(gomock.Any(), "powerbank", "", 10, "delivery", "admin").
+					Return(15, nil)
+			},
+			expectedStock: 15,
+			expectedError: nil,
+		},
+		{
+			name: "Product not found",
+			input: RestockProductInput{
+				Product:  "unknown",
+				Quantity: 10,
+				Admin:    "admin",
+			},
+			mockRepoSetup: func(m *repository.MockProduct) {
+				m.EXPECT().
+					Restock(gomock.Any(), "unknown", "", 10, "", "admin").
+					Return(0, repoerrs.ErrProductNotFound)
+			},
+			expectedError: servicerrs.ErrProductNotFound,
+		},
+		{
+			name: "Negative stock",
+			input: RestockProductInput{
+				Product:  "powerbank",
+				Quantity: -100,
+				Admin:    "admin",
+			},
+			mockRepoSetup: func(m *repository.MockProduct) {
+				m.EXPECT().
+					Restock(gomock.Any(), "powerbank", "", -100, "", "admin").
+					Return(0, repoerrs.ErrNegativeStock)
+			},
+			expectedError: servicerrs.ErrNegativeStock,
+		},
+		{
+			name: "Repository error",
+			input: RestockProductInput{
+				Product:  "powerbank",
+				Quantity: 10,
+				Admin:    "admin",
+			},
+			mockRepoSetup: func(m *repository.MockProduct) {
+				m.EXPECT().
+					Restock(gomock.Any(), "powerbank", "", 10, "", "admin").
+					Return(0, errors.New("repository error"))
+			},
+			expectedError: errors.New("repository error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup(mockRepo)
+
+			stock, err := service.RestockProduct(context.Background(), tt.input)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedStock, stock)
+			}
+		})
+	}
+}
+
+func TestProductService_SetPurchaseLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockProduct(ctrl)
+	logger := zap.NewNop()
+
+	mockCache, _ := newMemoryCache(ctrl)
+	service := NewProductService(logger, mockCache, mockRepo)
+
+	limit := 2
+
+	tests := []struct {
+		name          string
+		input         SetPurchaseLimitInput
+		mockRepoSetup func(*repository.MockProduct)
+		expectedError error
+	}{
+		{
+			name: "Limit set",
+			input: SetPurchaseLimitInput{
+				Product: "powerbank",
+				Limit:   2,
+			},
+			mockRepoSetup: func(m *repository.MockProduct) {
+				m.EXPECT().
+					SetPurchaseLimit(gomock.Any(), "powerbank", &limit).
+					Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Limit removed",
+			input: SetPurchaseLimitInput{
+				Product: "powerbank",
+				Limit:   0,
+			},
+			mockRepoSetup: func(m *repository.MockProduct) {
+				m.EXPECT().
+					SetPurchaseLimit(gomock.Any(), "powerbank", (*int)(nil)).
+					Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Product not found",
+			input: SetPurchaseLimitInput{
+				Product: "unknown",
+				Limit:   2,
+			},
+			mockRepoSetup: func(m *repository.MockProduct) {
+				m.EXPECT().
+					SetPurchaseLimit(gomock.Any(), "unknown", &limit).
+					Return(repoerrs.ErrProductNotFound)
+			},
+			expectedError: servicerrs.ErrProductNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup(mockRepo)
+
+			err := service.SetPurchaseLimit(context.Background(), tt.input)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProductService_AddProductVariant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockProduct(ctrl)
+	logger := zap.NewNop()
+
+	mockCache, _ := newMemoryCache(ctrl)
+	service := NewProductService(logger, mockCache, mockRepo)
+
+	stock := 5
+
+	tests := []struct {
+		name          string
+		input         AddProductVariantInput
+		mockRepoSetup func(*repository.MockProduct)
+		expectedError error
+	}{
+		{
+			name: "Variant added",
+			input: AddProductVariantInput{
+				Product:    "hoody",
+				Variant:    "XL",
+				Attributes: map[string]string{"size": "XL"},
+				Stock:      &stock,
+			},
+			mockRepoSetup: func(m *repository.MockProduct) {
+				m.EXPECT().
+					AddVariant(gomock.Any(), "hoody", entity.ProductVariant{
+						Name:       "XL",
+						Attributes: map[string]string{"size": "XL"},
+						Stock:      &stock,
+					}).
+					Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Product not found",
+			input: AddProductVariantInput{
+				Product: "unknown",
+				Variant: "XL",
+			},
+			mockRepoSetup: func(m *repository.MockProduct) {
+				m.EXPECT().
+					AddVariant(gomock.Any(), "unknown", entity.ProductVariant{Name: "XL"}).
+					Return(repoerrs.ErrProductNotFound)
+			},
+			expectedError: servicerrs.ErrProductNotFound,
+		},
+		{
+			name: "Variant already exists",
+			input: AddProductVariantInput{
+				Product: "hoody",
+				Variant: "M",
+			},
+			mockRepoSetup: func(m *repository.MockProduct) {
+				m.EXPECT().
+					AddVariant(gomock.Any(), "hoody", entity.ProductVariant{Name: "M"}).
+					Return(repoerrs.ErrVariantAlreadyExists)
+			},
+			expectedError: servicerrs.ErrVariantAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup(mockRepo)
+
+			err := service.AddProductVariant(context.Background(), tt.input)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProductService_SearchProducts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockProduct(ctrl)
+	logger := zap.NewNop()
+
+	mockCache, _ := newMemoryCache(ctrl)
+	service := NewProductService(logger, mockCache, mockRepo)
+
+	maxPrice := 500
+
+	tests := []struct {
+		name             string
+		input            SearchProductsInput
+		mockRepoSetup    func(*repository.MockProduct)
+		expectedProducts []entity.Product
+		expectedError    error
+	}{
+		{
+			name: "Products found",
+			input: SearchProductsInput{
+				Query:     "hoody",
+				Category:  "clothes",
+				MaxPrice:  &maxPrice,
+				Available: true,
+			},
+			mockRepoSetup: func(m *repository.MockProduct) {
+				m.EXPECT().
+					SearchProducts(gomock.Any(), entity.ProductFilter{
+						Query:     "hoody",
+						Category:  "clothes",
+						MaxPrice:  &maxPrice,
+						Available: true,
+					}).
+					Return([]entity.Product{{Name: "hoody", Price: 300, Category: "clothes", Available: true}}, nil)
+			},
+			expectedProducts: []entity.Product{{Name: "hoody", Price: 300, Category: "clothes", Available: true}},
+		},
+		{
+			name:  "Repository error",
+			input: SearchProductsInput{},
+			mockRepoSetup: func(m *repository.MockProduct) {
+				m.EXPECT().
+					SearchProducts(gomock.Any(), entity.ProductFilter{}).
+					Return(nil, errors.New("repository error"))
+			},
+			expectedError: errors.New("repository error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup(mockRepo)
+
+			products, err := service.SearchProducts(context.Background(), tt.input)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedProducts, products)
+			}
+		})
+	}
+}
+
+func TestProductService_CatalogCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockRepo := repository.NewMockProduct(ctrl)
+	mockCache, store := newMemoryCache(ctrl)
+	service := NewProductService(zap.NewNop(), mockCache, mockRepo)
+
+	catalog := []entity.Product{{Name: "hoody", Price: 300, Category: "clothes", Available: true}}
+	restocked := []entity.Product{{Name: "hoody", Price: 300, Category: "clothes", Available: true, Variants: []entity.ProductVariant{}}}
+
+	mockRepo.EXPECT().SearchProducts(gomock.Any(), entity.ProductFilter{}).Return(catalog, nil)
+
+	// The unfiltered catalog is loaded once and served from the cache.
+	for i := 0; i < 2; i++ {
+		products, err := service.SearchProducts(ctx, SearchProductsInput{})
+		assert.NoError(t, err)
+		assert.Equal(t, catalog, products)
+	}
+	assert.Contains(t, store, "catalog:v1:all")
+
+	// Filtered searches are not cached.
+	mockRepo.EXPECT().SearchProducts(gomock.Any(), entity.ProductFilter{Available: true}).Return(catalog, nil).Times(2)
+	for i := 0; i < 2; i++ {
+		_, err := service.SearchProducts(ctx, SearchProductsInput{Available: true})
+		assert.NoError(t, err)
+	}
+
+	// Admin changes drop the cached catalog.
+	mockRepo.EXPECT().Restock(gomock.Any(), "hoody", "", 5, "", "admin").Return(5, nil)
+	_, err := service.RestockProduct(ctx, RestockProductInput{Product: "hoody", Quantity: 5, Admin: "admin"})
+	assert.NoError(t, err)
+	assert.NotContains(t, store, "catalog:v1:all")
+
+	mockRepo.EXPECT().SearchProducts(gomock.Any(), entity.ProductFilter{}).Return(restocked, nil)
+	products, err := service.SearchProducts(ctx, SearchProductsInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, restocked, products)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/model"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+
+	"go.uber.org/zap"
+)
+
+type PromoService struct {
+	log  *zap.Logger
+	repo repository.Promo
+}
+
+func NewPromoService(log *zap.Logger, repo repository.Promo) *PromoService {
+	return &PromoService{
+		log:  log,
+		repo: repo,
+	}
+}
+
+func (s *PromoService) CreatePromoCode(ctx context.Context, input CreatePromoCodeInput) error {
+	const op = "service.PromoService.CreatePromoCode"
+
+	s.log.Info("attempting to create promo code", zap.String("code", input.Code))
+
+	if err := validateDiscount(input); err != nil {
+		s.log.Warn("invalid discount",
+			zap.String("op", op),
+			zap.String("code", input.Code),
+			zap.Error(err),
+		)
+
+		return fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidDiscount)
+	}
+
+	validFrom := input.ValidFrom
+	if validFrom.IsZero() {
+		validFrom = time.Now()
+	}
+
+	err := s.repo.AddPromoCode(ctx, entity.PromoCode{
+		Code:           input.Code,
+		DiscountType:   input.DiscountType,
+		DiscountValue:  input.DiscountValue,
+		Product:        input.Product,
+		Category:       input.Category,
+		ValidFrom:      validFrom,
+		ValidUntil:     input.ValidUntil,
+		MaxUses:        input.MaxUses,
+		MaxUsesPerUser: input.MaxUsesPerUser,
+	})
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrPromoCodeAlreadyExists) {
+			s.log.Warn("promo code already exists",
+				zap.String("op", op),
+				zap.String("code", input.Code),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrPromoCodeAlreadyExists)
+		} else if errors.Is(err, repoerrs.ErrProductNotFound) {
+			s.log.Warn("product not found",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrProductNotFound)
+		} else if errors.Is(err, repoerrs.ErrCategoryNotFound) {
+			s.log.Warn("category not found",
+				zap.String("op", op),
+				zap.String("category", input.Category),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrCategoryNotFound)
+		}
+
+		s.log.Error("failed to create promo code",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("promo code successfully created")
+
+	return nil
+}
+
+func (s *PromoService) ListPromoCodes(ctx context.Context) ([]entity.PromoCode, error) {
+	const op = "service.PromoService.ListPromoCodes"
+
+	s.log.Info("attempting to list promo codes")
+
+	promos, err := s.repo.GetPromoCodes(ctx)
+	if err != nil {
+		s.log.Error("failed to list promo codes",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return promos, nil
+}
+
+func (s *PromoService) DeactivatePromoCode(ctx context.Context, code string) error {
+	const op = "service.PromoService.DeactivatePromoCode"
+
+	s.log.Info("attempting to deactivate promo code", zap.String("code", code))
+
+	if err := s.repo.DeactivatePromoCode(ctx, code); err != nil {
+		if errors.Is(err, repoerrs.ErrPromoCodeNotFound) {
+			s.log.Warn("promo code not found",
+				zap.String("op", op),
+				zap.String("code", code),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrPromoCodeNotFound)
+		}
+
+		s.log.Error("failed to deactivate promo code",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("promo code successfully deactivated")
+
+	return nil
+}
+
+func validateDiscount(input CreatePromoCodeInput) error {
+	if input.DiscountValue <= 0 {
+		return errors.New("discount value must be positive")
+	}
+
+	switch input.DiscountType {
+	case model.DiscountTypePercent:
+		if input.DiscountValue > 100 {
+			return errors.New("percent discount cannot exceed 100")
+		}
+	case model.DiscountTypeFixed:
+	default:
+		return fmt.Errorf("unknown discount type %q", input.DiscountType)
+	}
+
+	if input.ValidUntil != nil && !input.ValidFrom.IsZero() && !input.ValidUntil.After(input.ValidFrom) {
+		return errors.New("validity window is empty")
+	}
+
+	return nil
+}
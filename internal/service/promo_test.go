@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestPromoService_CreatePromoCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockPromo(ctrl)
+	logger := zap.NewNop()
+
+	service := NewPromoService(logger, mockRepo)
+
+	validFrom := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	validUntil := validFrom.Add(7 * 24 * time.Hour)
+
+	tests := []struct {
+		name          string
+		input         CreatePromoCodeInput
+		mockRepoSetup func(*repository.MockPromo)
+		expectedError error
+	}{
+		{
+			name: "Successful creation",
+			input: CreatePromoCodeInput{
+				Code:          "HOODY20",
+				DiscountType:  "percent",
+				DiscountValue: 20,
+				Product:       "hoody",
+				ValidFrom:     validFrom,
+				ValidUntil:    &validUntil,
+			},
+			mockRepoSetup: func(m *repository.MockPromo) {
+				m.EXPECT().
+					AddPromoCode(gomock.Any(), entity.PromoCode{
+						Code:          "HOODY20",
+						DiscountType:  "percent",
+						DiscountValue: 20,
+						Product:       "hoody",
+						ValidFrom:     validFrom,
+						ValidUntil:    &validUntil,
+					}).
+					Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name: "Percent discount over 100",
+			input: CreatePromoCodeInput{
+				Code:          "FREE",
+				DiscountType:  "percent",
+				DiscountValue: 150,
+			},
+			mockRepoSetup: func(m *repository.MockPromo) {},
+			expectedError: servicerrs.ErrInvalidDiscount,
+		},
+		{
+			name: "Empty validity window",
+			input: CreatePromoCodeInput{
+				Code:          "PAST",
+				DiscountType:  "fixed",
+				DiscountValue: 10,
+				ValidFrom:     validUntil,
+				ValidUntil:    &validFrom,
+			},
+			mockRepoSetup: func(m *repository.MockPromo) {},
+			expectedError: servicerrs.ErrInvalidDiscount,
+		},
+		{
+			name: "Promo code already exists",
+			input: CreatePromoCodeInput{
+				Code:          "HOODY20",
+				DiscountType:  "fixed",
+				DiscountValue: 10,
+				ValidFrom:     validFrom,
+			},
+			mockRepoSetup: func(m *repository.MockPromo) {
+				m.EXPECT().
+					AddPromoCode(gomock.Any(), gomock.Any()).
+					Return(repoerrs.ErrPromoCodeAlreadyExists)
+			},
+			expectedError: servicerrs.ErrPromoCodeAlreadyExists,
+		},
+		{
+			name: "Category not found",
+			input: CreatePromoCodeInput{
+				Code:          "FOOD10",
+				DiscountType:  "fixed",
+				DiscountValue: 10,
+				Category:      "food",
+				ValidFrom:     validFrom,
+			},
+			mockRepoSetup: func(m *repository.MockPromo) {
+				m.EXPECT().
+					AddPromoCode(gomock.Any(), gomock.Any()).
+					Return(repoerrs.ErrCategoryNotFound)
+			},
+			expectedError: servicerrs.ErrCategoryNotFound,
+		},
+		{
+			name: "Repository error",
+			input: CreatePromoCodeInput{
+				Code:          "HOODY20",
+				DiscountType:  "fixed",
+				DiscountValue: 10,
+				ValidFrom:     validFrom,
+			},
+			mockRepoSetup: func(m *repository.MockPromo) {
+				m.EXPECT().
+					AddPromoCode(gomock.Any(), gomock.Any()).
+					Return(errors.New("repository error"))
+			},
+			expectedError: errors.New("repository error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup(mockRepo)
+
+			err := service.CreatePromoCode(context.Background(), tt.input)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
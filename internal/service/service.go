@@ -5,17 +5,143 @@ import (
 	"time"
 
 	"avito-internship/internal/cache"
+	"avito-internship/internal/cache/loader"
 	"avito-internship/internal/entity"
 	"avito-internship/internal/repository"
+	"avito-internship/internal/utils/jwt"
+	"avito-internship/internal/utils/oidc"
+	"avito-internship/internal/utils/password"
 
 	"go.uber.org/zap"
 )
 
+// AuthorizationOutput holds an access token, or a challenge token when the
+// user has two-factor authentication enabled.
+type AuthorizationOutput struct {
+	Token             string
+	TwoFactorRequired bool
+}
+
+// AccessToken is the identity carried by a validated access token.
+type AccessToken struct {
+	Username  string
+	SessionID string
+	// Actor is the admin acting on behalf of Username with an impersonation token.
+	Actor string
+	// ReadOnly forbids requests that change anything.
+	ReadOnly bool
+}
+
 type Auth interface {
-	Authorization(ctx context.Context, username, password string) (string, error)
-	ValidateToken(token string) (string, error)
+	Authorization(ctx context.Context, username, password string) (AuthorizationOutput, error)
+	Register(ctx context.Context, username, password string) (string, error)
+	ValidateToken(ctx context.Context, token string) (AccessToken, error)
+	ChangePassword(ctx context.Context, input ChangePasswordInput) (string, error)
+	JWKS() jwt.JWKS
 	handleUserNotFound(ctx context.Context, username, password, op string) (string, error)
-	generateToken(username, op string) (string, error)
+	createUser(ctx context.Context, username, password, op string) (string, error)
+	generateToken(ctx context.Context, username, op string) (string, error)
+}
+
+type RevokeSessionInput struct {
+	Username string
+	ID       string
+}
+
+type Session interface {
+	Start(ctx context.Context, username string) (string, error)
+	Check(ctx context.Context, username, id string) error
+	List(ctx context.Context, username string) ([]entity.Session, error)
+	Revoke(ctx context.Context, input RevokeSessionInput) error
+	RevokeOthers(ctx context.Context, input RevokeSessionInput) (int, error)
+	RevokeAll(ctx context.Context, username string) error
+}
+
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string
+}
+
+type TwoFactorCodeInput struct {
+	Username string
+	Code     string
+}
+
+type CompleteLoginInput struct {
+	ChallengeToken string
+	Code           string
+}
+
+type TwoFactor interface {
+	Enroll(ctx context.Context, username string) (TwoFactorEnrollment, error)
+	Confirm(ctx context.Context, input TwoFactorCodeInput) ([]string, error)
+	VerifyChallenge(ctx context.Context, challengeToken string) (string, error)
+	CompleteLogin(ctx context.Context, input CompleteLoginInput) (string, error)
+}
+
+type CreateAPIKeyInput struct {
+	Username  string
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+// CreateAPIKeyOutput holds the plain key, which is shown to the owner only once.
+type CreateAPIKeyOutput struct {
+	Key    string
+	APIKey entity.APIKey
+}
+
+type RevokeAPIKeyInput struct {
+	Username string
+	ID       int
+}
+
+type APIKey interface {
+	Create(ctx context.Context, input CreateAPIKeyInput) (CreateAPIKeyOutput, error)
+	List(ctx context.Context, username string) ([]entity.APIKey, error)
+	Revoke(ctx context.Context, input RevokeAPIKeyInput) error
+	Authenticate(ctx context.Context, key string) (entity.APIKey, error)
+}
+
+type OIDCCallbackInput struct {
+	Code  string
+	State string
+}
+
+type OIDC interface {
+	LoginURL(ctx context.Context) (string, error)
+	Callback(ctx context.Context, input OIDCCallbackInput) (string, error)
+}
+
+type ChangePasswordInput struct {
+	Username        string
+	CurrentPassword string
+	NewPassword     string
+}
+
+// LoginAttemptsLimits configures login throttling. Failures beyond the free
+// attempts block further logins for BaseDelay, doubling with every failure,
+// and MaxAttempts failures lock the client out for LockoutDuration.
+type LoginAttemptsLimits struct {
+	FreeAttempts    int
+	MaxAttempts     int
+	IPFreeAttempts  int
+	IPMaxAttempts   int
+	BaseDelay       time.Duration
+	LockoutDuration time.Duration
+}
+
+type UnlockInput struct {
+	Username string
+	IP       string
+}
+
+type LoginAttempts interface {
+	Attempt(ctx context.Context, username, ip string) time.Duration
+	RegisterFailure(ctx context.Context, username, ip string) time.Duration
+	RegisterSuccess(ctx context.Context, username, ip string)
+	Unlock(ctx context.Context, input UnlockInput) error
 }
 
 type UserCreateInput struct {
@@ -25,6 +151,8 @@ type UserCreateInput struct {
 
 type RetrieveUserInfoInput struct {
 	Username string
+	// RawHistory lists every transfer instead of the totals per counterparty.
+	RawHistory bool
 }
 
 type RetrieveUserInfoOutput struct {
@@ -37,6 +165,9 @@ type RetrieveUserInfoOutput struct {
 type User interface {
 	CreateUser(ctx context.Context, input UserCreateInput) error
 	RetrieveUserInfo(ctx context.Context, input RetrieveUserInfoInput) (RetrieveUserInfoOutput, error)
+	InvalidateInfo(ctx context.Context, usernames ...string) error
+	UpdateInfo(ctx context.Context, username string, version int64, update func(info *RetrieveUserInfoOutput)) error
+	InfoCacheStats() loader.Stats
 }
 
 type TransferFundsInput struct {
@@ -46,8 +177,11 @@ type TransferFundsInput struct {
 }
 
 type PurchaseProductInput struct {
-	Username string
-	Product  string
+	Username  string
+	Product   string
+	Variant   string
+	PromoCode string
+	Location  string
 }
 
 type Operation interface {
@@ -55,24 +189,208 @@ type Operation interface {
 	PurchaseProduct(ctx context.Context, input PurchaseProductInput) error
 }
 
+type RestockProductInput struct {
+	Product  string
+	Variant  string
+	Quantity int
+	Reason   string
+	Admin    string
+}
+
+type SetPurchaseLimitInput struct {
+	Product string
+	Limit   int
+}
+
+type SetPriceInput struct {
+	Product string
+	Price   int
+}
+
+type AddProductVariantInput struct {
+	Product    string
+	Variant    string
+	Attributes map[string]string
+	Stock      *int
+}
+
+type SearchProductsInput struct {
+	Query     string
+	Category  string
+	MinPrice  *int
+	MaxPrice  *int
+	Available bool
+}
+
+type Product interface {
+	RestockProduct(ctx context.Context, input RestockProductInput) (int, error)
+	SetPurchaseLimit(ctx context.Context, input SetPurchaseLimitInput) error
+	SetPrice(ctx context.Context, input SetPriceInput) error
+	RetrieveStockHistory(ctx context.Context, product string) ([]entity.StockChange, error)
+	AddProductVariant(ctx context.Context, input AddProductVariantInput) error
+	SearchProducts(ctx context.Context, input SearchProductsInput) ([]entity.Product, error)
+	CatalogCacheStats() loader.Stats
+}
+
+type CreatePromoCodeInput struct {
+	Code           string
+	DiscountType   string
+	DiscountValue  int
+	Product        string
+	Category       string
+	ValidFrom      time.Time
+	ValidUntil     *time.Time
+	MaxUses        *int
+	MaxUsesPerUser *int
+}
+
+type Promo interface {
+	CreatePromoCode(ctx context.Context, input CreatePromoCodeInput) error
+	ListPromoCodes(ctx context.Context) ([]entity.PromoCode, error)
+	DeactivatePromoCode(ctx context.Context, code string) error
+}
+
+type AdvanceOrderInput struct {
+	ID     int
+	Status string
+}
+
+type Order interface {
+	ListUserOrders(ctx context.Context, username string) ([]entity.Order, error)
+	ListOrders(ctx context.Context, status string) ([]entity.Order, error)
+	AdvanceOrder(ctx context.Context, input AdvanceOrderInput) (entity.Order, error)
+}
+
+type WishlistInput struct {
+	Username string
+	Product  string
+}
+
+type Wishlist interface {
+	AddToWishlist(ctx context.Context, input WishlistInput) error
+	RemoveFromWishlist(ctx context.Context, input WishlistInput) error
+	ListWishlist(ctx context.Context, username string) ([]entity.WishlistItem, error)
+	ListNotifications(ctx context.Context, username string) ([]entity.Notification, error)
+	MarkNotificationsRead(ctx context.Context, username string) error
+}
+
+type StartImpersonationInput struct {
+	Actor   string
+	Subject string
+	Reason  string
+	// AllowWrite lets the token make changes on behalf of the subject.
+	AllowWrite bool
+}
+
+type ImpersonationOutput struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+type Impersonation interface {
+	Start(ctx context.Context, input StartImpersonationInput) (ImpersonationOutput, error)
+	Audit(ctx context.Context, event entity.ImpersonationEvent) error
+}
+
+type WarmCacheInput struct {
+	// Users is the number of the most active users whose info is preloaded,
+	// zero selects the configured number.
+	Users int
+}
+
+type WarmCacheOutput struct {
+	Users   int
+	Failed  int
+	Catalog bool
+}
+
+type CacheStats struct {
+	UserInfo loader.Stats
+	Catalog  loader.Stats
+}
+
+type CacheAdmin interface {
+	WarmCache(ctx context.Context, input WarmCacheInput) (WarmCacheOutput, error)
+	InspectCacheEntry(ctx context.Context, key string) (cache.Entry, error)
+	EvictCacheEntries(ctx context.Context, pattern string) (int, error)
+	FlushCache(ctx context.Context) error
+	CacheStats() CacheStats
+}
+
 type Services struct {
 	Auth
+	Session
+	LoginAttempts
+	TwoFactor
+	APIKey
+	OIDC
+	Impersonation
 	User
 	Operation
+	Product
+	Promo
+	Order
+	Wishlist
+	CacheAdmin
 }
 
 type ServicesDependencies struct {
-	Log      *zap.Logger
-	Cache    cache.Cache
-	Repos    *repository.Repositories
-	TokenTTL time.Duration
-	Salt     string
+	Log            *zap.Logger
+	Cache          cache.Cache
+	Repos          *repository.Repositories
+	TokenTTL       time.Duration
+	Keys           *jwt.KeyRing
+	AutoRegister   bool
+	PasswordPolicy *password.Policy
+	BcryptCost     int
+	LoginLimits    LoginAttemptsLimits
+	ChallengeTTL   time.Duration
+	TOTPIssuer     string
+	// ImpersonationTTL is the lifetime of impersonation tokens.
+	ImpersonationTTL time.Duration
+	// CacheWarmUp selects what WarmCache preloads.
+	CacheWarmUp CacheWarmUpConfig
+	// OIDCProvider enables login via the identity provider when set.
+	OIDCProvider    *oidc.Provider
+	OIDCEmailDomain string
 }
 
 func NewServices(deps ServicesDependencies) *Services {
-	return &Services{
-		User:      NewUserService(deps.Log, deps.Cache, deps.Repos.User),
-		Auth:      NewAuthService(deps.Log, deps.Repos.User, deps.TokenTTL, deps.Salt),
-		Operation: NewOperationService(deps.Log, deps.Cache, deps.Repos.Operation),
+	sessions := NewSessionService(deps.Log, deps.Cache, deps.Repos.Session, deps.Keys, deps.TokenTTL)
+	users := NewUserService(deps.Log, deps.Cache, deps.Repos.User)
+
+	services := &Services{
+		Session: sessions,
+		User:    users,
+		Auth: NewAuthService(deps.Log, deps.Cache, deps.Repos.User, AuthConfig{
+			Sessions:       sessions,
+			Keys:           deps.Keys,
+			AutoRegister:   deps.AutoRegister,
+			PasswordPolicy: deps.PasswordPolicy,
+			BcryptCost:     deps.BcryptCost,
+			ChallengeTTL:   deps.ChallengeTTL,
+		}),
+		TwoFactor:     NewTwoFactorService(deps.Log, deps.Cache, deps.Repos.TwoFactor, deps.Keys, sessions, deps.TOTPIssuer),
+		LoginAttempts: NewLoginAttemptsService(deps.Log, deps.Cache, deps.LoginLimits),
+		APIKey:        NewAPIKeyService(deps.Log, deps.Repos.APIKey),
+		Impersonation: NewImpersonationService(deps.Log, deps.Repos.Impersonation, deps.Keys, deps.ImpersonationTTL),
+		Operation:     NewOperationService(deps.Log, deps.Repos.Operation, users),
+		Product:       NewProductService(deps.Log, deps.Cache, deps.Repos.Product),
+		Promo:         NewPromoService(deps.Log, deps.Repos.Promo),
+		Order:         NewOrderService(deps.Log, deps.Repos.Order, users),
+		Wishlist:      NewWishlistService(deps.Log, deps.Repos.Wishlist),
 	}
+
+	services.CacheAdmin = NewCacheAdminService(deps.Log, deps.Cache, deps.Repos.User, services.User, services.Product, deps.CacheWarmUp)
+
+	if deps.OIDCProvider != nil {
+		services.OIDC = NewOIDCService(deps.Log, deps.Cache, deps.Repos.Identity, OIDCConfig{
+			Provider:    deps.OIDCProvider,
+			Sessions:    sessions,
+			EmailDomain: deps.OIDCEmailDomain,
+			BcryptCost:  deps.BcryptCost,
+		})
+	}
+
+	return services
 }
@@ -3,12 +3,48 @@ package servicerrs
 import "errors"
 
 var (
-	ErrRecipientNotFound  = errors.New("recipient not found")
-	ErrInsufficientFunds  = errors.New("insufficient funds")
-	ErrCustomerNotFound   = errors.New("customer not found")
-	ErrProductNotFound    = errors.New("product not found")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrInvalidToken       = errors.New("invalid token")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrUserAlreadyExists  = errors.New("user already exists")
+	ErrRecipientNotFound       = errors.New("recipient not found")
+	ErrInsufficientFunds       = errors.New("insufficient funds")
+	ErrCustomerNotFound        = errors.New("customer not found")
+	ErrProductNotFound         = errors.New("product not found")
+	ErrOutOfStock              = errors.New("product out of stock")
+	ErrPurchaseLimitExceeded   = errors.New("purchase limit exceeded")
+	ErrNegativeStock           = errors.New("stock cannot be negative")
+	ErrCategoryNotFound        = errors.New("category not found")
+	ErrVariantNotFound         = errors.New("product variant not found")
+	ErrVariantRequired         = errors.New("product variant is required")
+	ErrVariantAlreadyExists    = errors.New("product variant already exists")
+	ErrPromoCodeNotFound       = errors.New("promo code not found")
+	ErrPromoCodeAlreadyExists  = errors.New("promo code already exists")
+	ErrPromoCodeInactive       = errors.New("promo code is not active")
+	ErrPromoCodeNotApplicable  = errors.New("promo code is not applicable to the product")
+	ErrPromoCodeUsageLimit     = errors.New("promo code usage limit reached")
+	ErrInvalidDiscount         = errors.New("invalid discount")
+	ErrOrderNotFound           = errors.New("order not found")
+	ErrInvalidOrderTransition  = errors.New("invalid order status transition")
+	ErrWishlistItemNotFound    = errors.New("product is not in the wishlist")
+	ErrInvalidCredentials      = errors.New("invalid credentials")
+	ErrInvalidToken            = errors.New("invalid token")
+	ErrUserNotFound            = errors.New("user not found")
+	ErrUserAlreadyExists       = errors.New("user already exists")
+	ErrInvalidUsername         = errors.New("invalid username")
+	ErrPasswordTooShort        = errors.New("password is too short")
+	ErrPasswordTooLong         = errors.New("password is too long")
+	ErrPasswordBreached        = errors.New("password is too common")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication is not enrolled")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidAPIKey           = errors.New("invalid api key")
+	ErrAPIKeyNotFound          = errors.New("api key not found")
+	ErrInvalidScope            = errors.New("invalid scope")
+	ErrInvalidAPIKeyExpiry     = errors.New("api key expiry must be in the future")
+	ErrInvalidOIDCState        = errors.New("invalid or expired login state")
+	ErrOIDCLoginFailed         = errors.New("identity provider login failed")
+	ErrOIDCEmailNotAllowed     = errors.New("email is missing, not verified or not allowed")
+	ErrIdentityConflict        = errors.New("user is linked to another identity")
+	ErrOIDCUsernameTaken       = errors.New("username is taken by an account not linked to the identity")
+	ErrSessionNotFound         = errors.New("session not found")
+	ErrImpersonateSelf         = errors.New("cannot impersonate yourself")
+	ErrCacheEntryNotFound      = errors.New("cache entry not found")
+	ErrCacheUnavailable        = errors.New("cache is unavailable")
 )
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/internal/utils/jwt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// sessionTouchInterval limits how often the last seen time of a session is written.
+	sessionTouchInterval = time.Minute
+	maxUserAgentLength   = 256
+)
+
+// Client describes the device a session is started from.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+type clientKey struct{}
+
+// WithClient attaches the client to ctx, so a session started within it records the device.
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+func clientFromContext(ctx context.Context) Client {
+	client, _ := ctx.Value(clientKey{}).(Client)
+	return client
+}
+
+type SessionService struct {
+	log      *zap.Logger
+	cache    cache.Cache
+	repo     repository.Session
+	keys     *jwt.KeyRing
+	tokenTTL time.Duration
+	now      func() time.Time
+}
+
+func NewSessionService(log *zap.Logger, cache cache.Cache, repo repository.Session, keys *jwt.KeyRing, tokenTTL time.Duration) *SessionService {
+	return &SessionService{
+		log:      log,
+		cache:    cache,
+		repo:     repo,
+		keys:     keys,
+		tokenTTL: tokenTTL,
+		now:      time.Now,
+	}
+}
+
+// sessionState is the part of a session needed to validate its tokens, kept in the cache.
+type sessionState struct {
+	Username   string    `json:"username"`
+	Revoked    bool      `json:"revoked"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+}
+
+// Start creates a session for the client in ctx and returns an access token bound to it.
+func (s *SessionService) Start(ctx context.Context, username string) (string, error) {
+	const op = "service.Session.Start"
+
+	client := clientFromContext(ctx)
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	session := entity.Session{
+		ID:        uuid.NewString(),
+		Username:  username,
+		UserAgent: userAgent,
+		IP:        client.IP,
+		ExpiresAt: s.now().Add(s.tokenTTL),
+	}
+	if err := s.repo.AddSession(ctx, session); err != nil {
+		s.log.Error("Failed to create session",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	token, err := s.keys.NewToken(username, session.ID, s.tokenTTL)
+	if err != nil {
+		s.log.Error("Failed to generate token",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("Session started",
+		zap.String("username", username),
+		zap.String("session_id", session.ID),
+		zap.String("ip", session.IP),
+	)
+
+	return token, nil
+}
+
+// Check fails with ErrInvalidToken unless the session belongs to the user and is active.
+// The state is served from the cache, so most requests do not reach the database.
+func (s *SessionService) Check(ctx context.Context, username, id string) error {
+	const op = "service.Session.Check"
+
+	state, err := s.load(ctx, id)
+	if err != nil {
+		if errors.Is(err, repoerrs.ErrSessionNotFound) {
+			s.log.Warn("Unknown session",
+				zap.String("op", op),
+				zap.String("session_id", id),
+			)
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidToken)
+		}
+		s.log.Error("Failed to retrieve session",
+			zap.String("op", op),
+			zap.String("session_id", id),
+			zap.Error(err),
+		)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := s.now()
+	if state.Username != username || state.Revoked || !state.ExpiresAt.After(now) {
+		s.log.Warn("Session is not active",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.String("session_id", id),
+		)
+		return fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidToken)
+	}
+
+	if now.Sub(state.LastSeenAt) >= sessionTouchInterval {
+		s.touch(ctx, id)
+	}
+
+	return nil
+}
+
+func (s *SessionService) List(ctx context.Context, username string) ([]entity.Session, error) {
+	const op = "service.Session.List"
+
+	sessions, err := s.repo.GetSessions(ctx, username)
+	if err != nil {
+		s.log.Error("Failed to retrieve sessions",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+func (s *SessionService) Revoke(ctx context.Context, input RevokeSessionInput) error {
+	const op = "service.Session.Revoke"
+
+	if err := s.repo.RevokeSession(ctx, input.Username, input.ID); err != nil {
+		if errors.Is(err, repoerrs.ErrSessionNotFound) {
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrSessionNotFound)
+		}
+		s.log.Error("Failed to revoke session",
+			zap.String("op", op),
+			zap.String("username", input.Username),
+			zap.String("session_id", input.ID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.invalidate(ctx, input.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("Session revoked",
+		zap.String("username", input.Username),
+		zap.String("session_id", input.ID),
+	)
+
+	return nil
+}
+
+// RevokeOthers revokes every session of the user except input.ID and returns how many were revoked.
+func (s *SessionService) RevokeOthers(ctx context.Context, input RevokeSessionInput) (int, error) {
+	const op = "service.Session.RevokeOthers"
+
+	ids, err := s.repo.RevokeSessions(ctx, input.Username, input.ID)
+	if err != nil {
+		s.log.Error("Failed to revoke sessions",
+			zap.String("op", op),
+			zap.String("username", input.Username),
+			zap.Error(err),
+		)
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.invalidate(ctx, ids...); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("Sessions revoked",
+		zap.String("username", input.Username),
+		zap.Int("count", len(ids)),
+	)
+
+	return len(ids), nil
+}
+
+// RevokeAll revokes every session of the user.
+func (s *SessionService) RevokeAll(ctx context.Context, username string) error {
+	_, err := s.RevokeOthers(ctx, RevokeSessionInput{Username: username})
+	return err
+}
+
+// invalidate drops the cached state of revoked sessions. A stale entry would
+// keep a revoked session usable, so a failure is returned to the caller.
+func (s *SessionService) invalidate(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := cache.Del(ctx, s.cache, sessionStateKey, ids...); err != nil {
+		s.log.Error("Failed to invalidate cached sessions",
+			zap.Strings("session_ids", ids),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (s *SessionService) load(ctx context.Context, id string) (sessionState, error) {
+	if state, err := cache.Get(ctx, s.cache, sessionStateKey, id); err == nil {
+		return state, nil
+	}
+
+	session, err := s.repo.GetSession(ctx, id)
+	if err != nil {
+		return sessionState{}, err
+	}
+
+	state := sessionState{
+		Username:   session.Username,
+		Revoked:    session.RevokedAt != nil,
+		ExpiresAt:  session.ExpiresAt,
+		LastSeenAt: session.LastSeenAt,
+	}
+	if err := cache.Set(ctx, s.cache, sessionStateKey, id, state); err != nil {
+		s.log.Error("Failed to cache session",
+			zap.String("session_id", id),
+			zap.Error(err),
+		)
+	}
+
+	return state, nil
+}
+
+// touch records the session activity and drops the cached state, which is
+// reloaded with the new time. Writing the state back instead could overwrite
+// a concurrent revocation. Failures are logged only, since the request is valid.
+func (s *SessionService) touch(ctx context.Context, id string) {
+	if err := s.repo.TouchSession(ctx, id); err != nil {
+		s.log.Error("Failed to update session last seen time",
+			zap.String("session_id", id),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if err := cache.Del(ctx, s.cache, sessionStateKey, id); err != nil {
+		s.log.Error("Failed to invalidate cached session",
+			zap.String("session_id", id),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/internal/utils/jwt"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestSessions returns a session service that stores nothing, for tests of services issuing tokens.
+func newTestSessions(ctrl *gomock.Controller, keys *jwt.KeyRing) *SessionService {
+	mockRepo := repository.NewMockSession(ctrl)
+	mockRepo.EXPECT().AddSession(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockRepo.EXPECT().RevokeSessions(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	return NewSessionService(zap.NewNop(), cache.NewMockCache(ctrl), mockRepo, keys, time.Hour)
+}
+
+func TestSessionService_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	keys, err := jwt.GenerateKeyRing()
+	require.NoError(t, err)
+
+	mockRepo := repository.NewMockSession(ctrl)
+	service := NewSessionService(zap.NewNop(), cache.NewMockCache(ctrl), mockRepo, keys, time.Hour)
+	now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	ctx := WithClient(context.Background(), Client{UserAgent: "curl/8.5.0", IP: "10.0.0.1"})
+
+	var session entity.Session
+	mockRepo.EXPECT().AddSession(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, s entity.Session) error {
+		session = s
+		return nil
+	})
+
+	token, err := service.Start(ctx, "user1")
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, session.ID)
+	assert.Equal(t, "user1", session.Username)
+	assert.Equal(t, "curl/8.5.0", session.UserAgent)
+	assert.Equal(t, "10.0.0.1", session.IP)
+	assert.Equal(t, now.Add(time.Hour), session.ExpiresAt)
+
+	claims, err := keys.ParseToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user1", claims.Username)
+	assert.Equal(t, session.ID, claims.ID)
+
+	t.Run("Repository error", func(t *testing.T) {
+		mockRepo.EXPECT().AddSession(gomock.Any(), gomock.Any()).Return(repoerrs.ErrUserNotFound)
+
+		token, err := service.Start(ctx, "user1")
+		assert.ErrorIs(t, err, repoerrs.ErrUserNotFound)
+		assert.Empty(t, token)
+	})
+}
+
+func TestSessionService_Check(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	active := entity.Session{
+		ID:         "session1",
+		Username:   "user1",
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+	revokedAt := now
+	revoked := active
+	revoked.RevokedAt = &revokedAt
+	expired := active
+	expired.ExpiresAt = now
+	idle := active
+	idle.LastSeenAt = now.Add(-time.Hour)
+
+	tests := []struct {
+		name          string
+		username      string
+		mockRepoSetup func(mockRepo *repository.MockSession)
+		expectedError error
+	}{
+		{
+			name:     "Active session",
+			username: "user1",
+			mockRepoSetup: func(mockRepo *repository.MockSession) {
+				mockRepo.EXPECT().GetSession(gomock.Any(), "session1").Return(active, nil)
+			},
+		},
+		{
+			name:     "Session of another user",
+			username: "user2",
+			mockRepoSetup: func(mockRepo *repository.MockSession) {
+				mockRepo.EXPECT().GetSession(gomock.Any(), "session1").Return(active, nil)
+			},
+			expectedError: servicerrs.ErrInvalidToken,
+		},
+		{
+			name:     "Revoked session",
+			username: "user1",
+			mockRepoSetup: func(mockRepo *repository.MockSession) {
+				mockRepo.EXPECT().GetSession(gomock.Any(), "session1").Return(revoked, nil)
+			},
+			expectedError: servicerrs.ErrInvalidToken,
+		},
+		{
+			name:     "Expired session",
+			username: "user1",
+			mockRepoSetup: func(mockRepo *repository.MockSession) {
+				mockRepo.EXPECT().GetSession(gomock.Any(), "session1").Return(expired, nil)
+			},
+			expectedError: servicerrs.ErrInvalidToken,
+		},
+		{
+			name:     "Unknown session",
+			username: "user1",
+			mockRepoSetup: func(mockRepo *repository.MockSession) {
+				mockRepo.EXPECT().GetSession(gomock.Any(), "session1").Return(entity.Session{}, repoerrs.ErrSessionNotFound)
+			},
+			expectedError: servicerrs.ErrInvalidToken,
+		},
+		{
+			name:     "Idle session is touched",
+			username: "user1",
+			mockRepoSetup: func(mockRepo *repository.MockSession) {
+				mockRepo.EXPECT().GetSession(gomock.Any(), "session1").Return(idle, nil)
+				mockRepo.EXPECT().TouchSession(gomock.Any(), "session1").Return(nil)
+			},
+		},
+		{
+			name:     "Repository error",
+			username: "user1",
+			mockRepoSetup: func(mockRepo *repository.MockSession) {
+				mockRepo.EXPECT().GetSession(gomock.Any(), "session1").Return(entity.Session{}, errors.New("repository error"))
+			},
+			expectedError: errors.New("repository error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCache, _ := newMemoryCache(ctrl)
+			mockRepo := repository.NewMockSession(ctrl)
+			service := NewSessionService(zap.NewNop(), mockCache, mockRepo, nil, time.Hour)
+			service.now = func() time.Time { return now }
+			tt.mockRepoSetup(mockRepo)
+
+			err := service.Check(context.Background(), tt.username, "session1")
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("Revocation is seen after the state is cached", func(t *testing.T) {
+		mockCache, store := newMemoryCache(ctrl)
+		mockRepo := repository.NewMockSession(ctrl)
+		service := NewSessionService(zap.NewNop(), mockCache, mockRepo, nil, time.Hour)
+		service.now = func() time.Time { return now }
+
+		mockRepo.EXPECT().GetSession(gomock.Any(), "session1").Return(active, nil)
+		require.NoError(t, service.Check(context.Background(), "user1", "session1"))
+		// The second check is served from the cache.
+		require.NoError(t, service.Check(context.Background(), "user1", "session1"))
+		assert.Contains(t, store, "session:v1:session1")
+
+		mockRepo.EXPECT().RevokeSession(gomock.Any(), "user1", "session1").Return(nil)
+		require.NoError(t, service.Revoke(context.Background(), RevokeSessionInput{Username: "user1", ID: "session1"}))
+		assert.NotContains(t, store, "session:v1:session1")
+
+		mockRepo.EXPECT().GetSession(gomock.Any(), "session1").Return(revoked, nil)
+		assert.ErrorIs(t, service.Check(context.Background(), "user1", "session1"), servicerrs.ErrInvalidToken)
+	})
+}
+
+func TestSessionService_Revoke(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := cache.NewMockCache(ctrl)
+	mockRepo := repository.NewMockSession(ctrl)
+	service := NewSessionService(zap.NewNop(), mockCache, mockRepo, nil, time.Hour)
+	ctx := context.Background()
+
+	t.Run("Unknown session", func(t *testing.T) {
+		mockRepo.EXPECT().RevokeSession(gomock.Any(), "user1", "session1").Return(repoerrs.ErrSessionNotFound)
+
+		err := service.Revoke(ctx, RevokeSessionInput{Username: "user1", ID: "session1"})
+		assert.ErrorIs(t, err, servicerrs.ErrSessionNotFound)
+	})
+
+	t.Run("Cache error", func(t *testing.T) {
+		mockRepo.EXPECT().RevokeSession(gomock.Any(), "user1", "session1").Return(nil)
+		mockCache.EXPECT().Del(gomock.Any(), "session:v1:session1").Return(errors.New("cache error"))
+
+		err := service.Revoke(ctx, RevokeSessionInput{Username: "user1", ID: "session1"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Other sessions", func(t *testing.T) {
+		mockRepo.EXPECT().RevokeSessions(gomock.Any(), "user1", "session1").Return([]string{"session2", "session3"}, nil)
+		mockCache.EXPECT().Del(gomock.Any(), "session:v1:session2", "session:v1:session3").Return(nil)
+
+		count, err := service.RevokeOthers(ctx, RevokeSessionInput{Username: "user1", ID: "session1"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("All sessions", func(t *testing.T) {
+		mockRepo.EXPECT().RevokeSessions(gomock.Any(), "user1", "").Return([]string{"session1"}, nil)
+		mockCache.EXPECT().Del(gomock.Any(), "session:v1:session1").Return(nil)
+
+		assert.NoError(t, service.RevokeAll(ctx, "user1"))
+	})
+}
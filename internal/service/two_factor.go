@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"avito-internship/internal/cache"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/internal/utils/jwt"
+	"avito-internship/internal/utils/totp"
+
+	"go.uber.org/zap"
+)
+
+const (
+	recoveryCodeCount = 10
+	// totpSkew accepts codes from the neighbouring time steps to tolerate clock drift.
+	totpSkew = 1
+	// maxChallengeFailures is the number of wrong codes after which a challenge
+	// token is rejected, so one correct password does not allow guessing codes
+	// for the whole lifetime of the token.
+	maxChallengeFailures = 5
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TwoFactorService struct {
+	log      *zap.Logger
+	cache    cache.Cache
+	repo     repository.TwoFactor
+	keys     *jwt.KeyRing
+	sessions Session
+	issuer   string
+	now      func() time.Time
+}
+
+func NewTwoFactorService(log *zap.Logger, cache cache.Cache, repo repository.TwoFactor, keys *jwt.KeyRing, sessions Session, issuer string) *TwoFactorService {
+	return &TwoFactorService{
+		log:      log,
+		cache:    cache,
+		repo:     repo,
+		keys:     keys,
+		sessions: sessions,
+		issuer:   issuer,
+		now:      time.Now,
+	}
+}
+
+// Enroll generates a new TOTP secret for the user. 2FA stays off until the
+// secret is confirmed with a code from the authenticator app.
+func (s *TwoFactorService) Enroll(ctx context.Context, username string) (TwoFactorEnrollment, error) {
+	const op = "service.TwoFactor.Enroll"
+	s.log.Info("Attempting to enroll user in 2FA", zap.String("username", username))
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		s.log.Error("Failed to generate TOTP secret",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+		return TwoFactorEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.SetTOTPSecret(ctx, username, secret); err != nil {
+		if errors.Is(err, repoerrs.ErrTwoFactorAlreadyEnabled) {
+			s.log.Warn("2FA is already enabled",
+				zap.String("op", op),
+				zap.String("username", username),
+			)
+			return TwoFactorEnrollment{}, fmt.Errorf("%s: %w", op, servicerrs.ErrTwoFactorAlreadyEnabled)
+		}
+		s.log.Error("Failed to save TOTP secret",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return TwoFactorEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, username, secret),
+	}, nil
+}
+
+// Confirm enables 2FA once the user proves the authenticator app is set up
+// and returns the recovery codes. They are stored hashed and shown only once.
+func (s *TwoFactorService) Confirm(ctx context.Context, input TwoFactorCodeInput) ([]string, error) {
+	const op = "service.TwoFactor.Confirm"
+	s.log.Info("Attempting to confirm 2FA", zap.String("username", input.Username))
+
+	twoFactor, err := s.repo.GetTwoFactor(ctx, input.Username)
+	if err != nil {
+		s.log.Error("Failed to retrieve 2FA settings",
+			zap.String("op", op),
+			zap.String("username", input.Username),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if twoFactor.Enabled {
+		return nil, fmt.Errorf("%s: %w", op, servicerrs.ErrTwoFactorAlreadyEnabled)
+	}
+	if twoFactor.Secret == "" {
+		return nil, fmt.Errorf("%s: %w", op, servicerrs.ErrTwoFactorNotEnrolled)
+	}
+
+	step, ok := totp.Verify(twoFactor.Secret, input.Code, s.now(), totpSkew)
+	if !ok {
+		s.log.Warn("Invalid 2FA confirmation code",
+			zap.String("op", op),
+			zap.String("username", input.Username),
+		)
+		return nil, fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidTwoFactorCode)
+	}
+
+	codes, hashes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		s.log.Error("Failed to generate recovery codes",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.EnableTwoFactor(ctx, input.Username, step, hashes); err != nil {
+		if errors.Is(err, repoerrs.ErrTwoFactorAlreadyEnabled) {
+			return nil, fmt.Errorf("%s: %w", op, servicerrs.ErrTwoFactorAlreadyEnabled)
+		}
+		s.log.Error("Failed to enable 2FA",
+			zap.String("op", op),
+			zap.String("username", input.Username),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("2FA enabled", zap.String("username", input.Username))
+
+	return codes, nil
+}
+
+// CompleteLogin exchanges a challenge token issued by AuthService.Authorization
+// and a TOTP or recovery code for an access token.
+func (s *TwoFactorService) CompleteLogin(ctx context.Context, input CompleteLoginInput) (string, error) {
+	const op = "service.TwoFactor.CompleteLogin"
+
+	claims, err := s.parseChallenge(ctx, op, input.ChallengeToken)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	username := claims.Username
+
+	twoFactor, err := s.repo.GetTwoFactor(ctx, username)
+	if err != nil {
+		s.log.Error("Failed to retrieve 2FA settings",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if !twoFactor.Enabled {
+		return "", fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidToken)
+	}
+
+	if err := s.useCode(ctx, username, twoFactor.Secret, input.Code); err != nil {
+		if errors.Is(err, repoerrs.ErrTOTPCodeUsed) || errors.Is(err, repoerrs.ErrRecoveryCodeNotFound) {
+			s.log.Warn("Invalid 2FA code",
+				zap.String("op", op),
+				zap.String("username", username),
+				zap.Error(err),
+			)
+			s.registerChallengeFailure(ctx, op, claims)
+			return "", fmt.Errorf("%s: %w", op, servicerrs.ErrInvalidTwoFactorCode)
+		}
+		s.log.Error("Failed to verify 2FA code",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	token, err := s.sessions.Start(ctx, username)
+	if err != nil {
+		s.log.Error("Failed to start session",
+			zap.String("op", op),
+			zap.String("username", username),
+			zap.Error(err),
+		)
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("User successfully authorized with 2FA", zap.String("username", username))
+
+	return token, nil
+}
+
+// VerifyChallenge returns the user a challenge token was issued to, if the
+// token is still accepted.
+func (s *TwoFactorService) VerifyChallenge(ctx context.Context, challengeToken string) (string, error) {
+	const op = "service.TwoFactor.VerifyChallenge"
+
+	claims, err := s.parseChallenge(ctx, op, challengeToken)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return claims.Username, nil
+}
+
+// parseChallenge verifies a challenge token and rejects it once
+// maxChallengeFailures wrong codes were entered with it. Cache failures are
+// logged and do not reject the token.
+func (s *TwoFactorService) parseChallenge(ctx context.Context, op, challengeToken string) (jwt.Claims, error) {
+	claims, err := s.keys.ParseToken(challengeToken)
+	if err != nil || claims.Purpose != jwt.PurposeTwoFactor {
+		s.log.Warn("Invalid challenge token",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+		return jwt.Claims{}, servicerrs.ErrInvalidToken
+	}
+
+	failures, err := cache.Get(ctx, s.cache, challengeFailuresKey, claims.ID)
+	if err != nil && !errors.Is(err, cache.ErrMiss) {
+		s.log.Error("Failed to retrieve challenge failures",
+			zap.String("op", op),
+			zap.String("username", claims.Username),
+			zap.Error(err),
+		)
+	}
+	if failures >= maxChallengeFailures {
+		s.log.Warn("Challenge token used up by wrong codes",
+			zap.String("op", op),
+			zap.String("username", claims.Username),
+		)
+		return jwt.Claims{}, servicerrs.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// registerChallengeFailure counts a wrong code entered with the challenge
+// token until the token expires.
+func (s *TwoFactorService) registerChallengeFailure(ctx context.Context, op string, claims jwt.Claims) {
+	ttl := time.Until(claims.ExpiresAt)
+	if ttl <= 0 {
+		return
+	}
+
+	if _, err := s.cache.Incr(ctx, challengeFailuresKey.For(claims.ID), ttl); err != nil {
+		s.log.Error("Failed to count challenge failure",
+			zap.String("op", op),
+			zap.String("username", claims.Username),
+			zap.Error(err),
+		)
+	}
+}
+
+// useCode accepts a TOTP code for an unused time step or an unused recovery code.
+func (s *TwoFactorService) useCode(ctx context.Context, username, secret, code string) error {
+	if step, ok := totp.Verify(secret, code, s.now(), totpSkew); ok {
+		return s.repo.UseTOTPStep(ctx, username, step)
+	}
+
+	return s.repo.UseRecoveryCode(ctx, username, hashRecoveryCode(code))
+}
+
+// generateRecoveryCodes returns codes formatted as xxxxx-xxxxx and their hashes.
+func generateRecoveryCodes(n int) ([]string, [][]byte, error) {
+	codes := make([]string, 0, n)
+	hashes := make([][]byte, 0, n)
+
+	for i := 0; i < n; i++ {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))[:10]
+		code = code[:5] + "-" + code[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes, so codes can be typed in any form.
+func hashRecoveryCode(code string) []byte {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return sum[:]
+}
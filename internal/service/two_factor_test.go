@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+	"avito-internship/internal/utils/jwt"
+	"avito-internship/internal/utils/totp"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+func newTestTwoFactorService(t *testing.T, ctrl *gomock.Controller) (*TwoFactorService, *repository.MockTwoFactor, *jwt.KeyRing, time.Time) {
+	mockRepo := repository.NewMockTwoFactor(ctrl)
+	keys, err := jwt.GenerateKeyRing()
+	require.NoError(t, err)
+
+	mockCache, _ := newMemoryCache(ctrl)
+	service := NewTwoFactorService(zap.NewNop(), mockCache, mockRepo, keys, newTestSessions(ctrl, keys), "Avito shop")
+	now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	return service, mockRepo, keys, now
+}
+
+func TestTwoFactorService_Enroll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service, mockRepo, _, _ := newTestTwoFactorService(t, ctrl)
+
+	var stored string
+	mockRepo.EXPECT().
+		SetTOTPSecret(gomock.Any(), "user1", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, secret string) error {
+			stored = secret
+			return nil
+		})
+
+	enrollment, err := service.Enroll(context.Background(), "user1")
+	require.NoError(t, err)
+	assert.Equal(t, stored, enrollment.Secret)
+
+	uri, err := url.Parse(enrollment.URI)
+	require.NoError(t, err)
+	assert.Equal(t, enrollment.Secret, uri.Query().Get("secret"))
+
+	mockRepo.EXPECT().
+		SetTOTPSecret(gomock.Any(), "user1", gomock.Any()).
+		Return(repoerrs.ErrTwoFactorAlreadyEnabled)
+
+	_, err = service.Enroll(context.Background(), "user1")
+	assert.ErrorIs(t, err, servicerrs.ErrTwoFactorAlreadyEnabled)
+}
+
+func TestTwoFactorService_Confirm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service, mockRepo, _, now := newTestTwoFactorService(t, ctrl)
+
+	code, err := totp.Code(testTOTPSecret, totp.Step(now))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		code          string
+		mockRepoSetup func()
+		expectedError error
+	}{
+		{
+			name: "Successful confirmation",
+			code: code,
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().
+					GetTwoFactor(gomock.Any(), "user1").
+					Return(entity.TwoFactor{Secret: testTOTPSecret}, nil)
+				mockRepo.EXPECT().
+					EnableTwoFactor(gomock.Any(), "user1", totp.Step(now), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, _ int64, hashes [][]byte) error {
+						assert.Len(t, hashes, recoveryCodeCount)
+						return nil
+					})
+			},
+		},
+		{
+			name: "Invalid code",
+			code: "000000",
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().
+					GetTwoFactor(gomock.Any(), "user1").
+					Return(entity.TwoFactor{Secret: testTOTPSecret}, nil)
+			},
+			expectedError: servicerrs.ErrInvalidTwoFactorCode,
+		},
+		{
+			name: "Not enrolled",
+			code: code,
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().
+					GetTwoFactor(gomock.Any(), "user1").
+					Return(entity.TwoFactor{}, nil)
+			},
+			expectedError: servicerrs.ErrTwoFactorNotEnrolled,
+		},
+		{
+			name: "Already enabled",
+			code: code,
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().
+					GetTwoFactor(gomock.Any(), "user1").
+					Return(entity.TwoFactor{Secret: testTOTPSecret, Enabled: true}, nil)
+			},
+			expectedError: servicerrs.ErrTwoFactorAlreadyEnabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup()
+
+			codes, err := service.Confirm(context.Background(), TwoFactorCodeInput{Username: "user1", Code: tt.code})
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, codes, recoveryCodeCount)
+			for _, code := range codes {
+				assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code)
+			}
+		})
+	}
+}
+
+func TestTwoFactorService_CompleteLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service, mockRepo, keys, now := newTestTwoFactorService(t, ctrl)
+
+	challenge, err := keys.NewChallengeToken("user1", time.Minute)
+	require.NoError(t, err)
+	accessToken, err := keys.NewToken("user1", "session1", time.Minute)
+	require.NoError(t, err)
+
+	code, err := totp.Code(testTOTPSecret, totp.Step(now))
+	require.NoError(t, err)
+
+	enabled := entity.TwoFactor{Secret: testTOTPSecret, Enabled: true}
+
+	tests := []struct {
+		name          string
+		token         string
+		code          string
+		mockRepoSetup func()
+		expectedError error
+	}{
+		{
+			name:  "TOTP code",
+			token: challenge,
+			code:  code,
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().GetTwoFactor(gomock.Any(), "user1").Return(enabled, nil)
+				mockRepo.EXPECT().UseTOTPStep(gomock.Any(), "user1", totp.Step(now)).Return(nil)
+			},
+		},
+		{
+			name:  "Replayed TOTP code",
+			token: challenge,
+			code:  code,
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().GetTwoFactor(gomock.Any(), "user1").Return(enabled, nil)
+				mockRepo.EXPECT().UseTOTPStep(gomock.Any(), "user1", totp.Step(now)).Return(repoerrs.ErrTOTPCodeUsed)
+			},
+			expectedError: servicerrs.ErrInvalidTwoFactorCode,
+		},
+		{
+			name:  "Recovery code in any case",
+			token: challenge,
+			code:  "ABCDE-FGHIJ",
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().GetTwoFactor(gomock.Any(), "user1").Return(enabled, nil)
+				mockRepo.EXPECT().UseRecoveryCode(gomock.Any(), "user1", hashRecoveryCode("abcdefghij")).Return(nil)
+			},
+		},
+		{
+			name:  "Unknown recovery code",
+			token: challenge,
+			code:  "abcde-fghij",
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().GetTwoFactor(gomock.Any(), "user1").Return(enabled, nil)
+				mockRepo.EXPECT().UseRecoveryCode(gomock.Any(), "user1", gomock.Any()).Return(repoerrs.ErrRecoveryCodeNotFound)
+			},
+			expectedError: servicerrs.ErrInvalidTwoFactorCode,
+		},
+		{
+			name:          "Access token instead of challenge",
+			token:         accessToken,
+			code:          code,
+			mockRepoSetup: func() {},
+			expectedError: servicerrs.ErrInvalidToken,
+		},
+		{
+			name:  "Repository error",
+			token: challenge,
+			code:  code,
+			mockRepoSetup: func() {
+				mockRepo.EXPECT().GetTwoFactor(gomock.Any(), "user1").Return(entity.TwoFactor{}, errors.New("repository error"))
+			},
+			expectedError: errors.New("repository error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup()
+
+			token, err := service.CompleteLogin(context.Background(), CompleteLoginInput{ChallengeToken: tt.token, Code: tt.code})
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.True(t, errors.Is(err, tt.expectedError) || strings.Contains(err.Error(), tt.expectedError.Error()))
+				return
+			}
+			assert.NoError(t, err)
+
+			claims, err := keys.ParseToken(token)
+			assert.NoError(t, err)
+			assert.Equal(t, "user1", claims.Username)
+			assert.Empty(t, claims.Purpose)
+		})
+	}
+}
+
+func TestTwoFactorService_ChallengeFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service, mockRepo, keys, _ := newTestTwoFactorService(t, ctrl)
+	ctx := context.Background()
+
+	challenge, err := keys.NewChallengeToken("user1", time.Minute)
+	require.NoError(t, err)
+	enabled := entity.TwoFactor{Secret: testTOTPSecret, Enabled: true}
+
+	mockRepo.EXPECT().GetTwoFactor(gomock.Any(), "user1").Return(enabled, nil).Times(maxChallengeFailures)
+	mockRepo.EXPECT().UseRecoveryCode(gomock.Any(), "user1", gomock.Any()).Return(repoerrs.ErrRecoveryCodeNotFound).Times(maxChallengeFailures)
+
+	for i := 0; i < maxChallengeFailures; i++ {
+		username, err := service.VerifyChallenge(ctx, challenge)
+		require.NoError(t, err)
+		assert.Equal(t, "user1", username)
+
+		_, err = service.CompleteLogin(ctx, CompleteLoginInput{ChallengeToken: challenge, Code: "abcde-fghij"})
+		assert.ErrorIs(t, err, servicerrs.ErrInvalidTwoFactorCode)
+	}
+
+	_, err = service.VerifyChallenge(ctx, challenge)
+	assert.ErrorIs(t, err, servicerrs.ErrInvalidToken)
+	_, err = service.CompleteLogin(ctx, CompleteLoginInput{ChallengeToken: challenge, Code: "abcde-fghij"})
+	assert.ErrorIs(t, err, servicerrs.ErrInvalidToken)
+
+	// Other challenges of the user are not affected.
+	other, err := keys.NewChallengeToken("user1", time.Minute)
+	require.NoError(t, err)
+	_, err = service.VerifyChallenge(ctx, other)
+	assert.NoError(t, err)
+}
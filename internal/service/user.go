@@ -2,12 +2,11 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 
 	"avito-internship/internal/cache"
-	"avito-internship/internal/entity"
+	"avito-internship/internal/cache/loader"
 	"avito-internship/internal/repository"
 	"avito-internship/internal/repository/repoerrs"
 	"avito-internship/internal/service/servicerrs"
@@ -19,16 +18,40 @@ type UserService struct {
 	Log   *zap.Logger
 	Cache cache.Cache
 	Repo  repository.User
+
+	// infoLoader coalesces concurrent cache misses of user info, so a hot user
+	// expiring does not start a burst of identical GetInfo transactions.
+	infoLoader *loader.Loader[RetrieveUserInfoOutput]
 }
 
 func NewUserService(log *zap.Logger, cache cache.Cache, repo repository.User) *UserService {
 	return &UserService{
-		Log:   log,
-		Cache: cache,
-		Repo:  repo,
+		Log:        log,
+		Cache:      cache,
+		Repo:       repo,
+		infoLoader: loader.NewLoader(cache, userInfoKey, log, loader.Options{}),
 	}
 }
 
+// InvalidateInfo drops the cached info of the users after their balance,
+// inventory or transfers changed. Loads of the info in flight on this replica
+// are not cached, as they may have read it before the change.
+func (s *UserService) InvalidateInfo(ctx context.Context, usernames ...string) error {
+	return s.infoLoader.Invalidate(ctx, usernames...)
+}
+
+// UpdateInfo writes the change numbered version, made by an operation, through
+// to the cached info of the user. update turns the info of the previous
+// version into it. Info that missed an earlier change is dropped instead.
+func (s *UserService) UpdateInfo(ctx context.Context, username string, version int64, update func(info *RetrieveUserInfoOutput)) error {
+	return s.infoLoader.Update(ctx, username, version, update)
+}
+
+// InfoCacheStats returns the hit, miss and coalescing counters of the user info cache.
+func (s *UserService) InfoCacheStats() loader.Stats {
+	return s.infoLoader.Stats()
+}
+
 func (s *UserService) CreateUser(ctx context.Context, input UserCreateInput) error {
 	const op = "service.UserService.CreateUser"
 
@@ -63,18 +86,9 @@ func (s *UserService) RetrieveUserInfo(ctx context.Context, input RetrieveUserIn
 
 	s.Log.Info("Attempting to retrieve info about user")
 
-	cacheKey := fmt.Sprintf("user_info:%s", input.Username)
-
-	var output RetrieveUserInfoOutput
-	cachedData, err := s.Cache.Get(ctx, cacheKey)
-	if err == nil {
-		if err := json.Unmarshal([]byte(cachedData), &output); err == nil {
-			s.Log.Info("Retrieved user info from cache", zap.String("username", input.Username))
-			return output, nil
-		}
-	}
-
-	balance, operations, inventory, err := s.Repo.GetInfo(ctx, input.Username)
+	output, err := s.infoLoader.FetchVersioned(ctx, input.Username, func(ctx context.Context) (RetrieveUserInfoOutput, int64, error) {
+		return s.loadUserInfo(ctx, input.Username)
+	})
 	if err != nil {
 		if errors.Is(err, repoerrs.ErrUserNotFound) {
 			s.Log.Warn("User not found",
@@ -93,39 +107,35 @@ func (s *UserService) RetrieveUserInfo(ctx context.Context, input RetrieveUserIn
 		return RetrieveUserInfoOutput{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var transferIn []entity.Transfer
-	var transferOut []entity.Transfer
-	for _, operation := range operations {
-		if operation.User == input.Username {
-			transfer := entity.Transfer{
-				Username: operation.Counterparty,
-				Amount:   operation.Amount,
-			}
-			transferOut = append(transferOut, transfer)
-		} else {
-			transfer := entity.Transfer{
-				Username: operation.User,
-				Amount:   operation.Amount,
-			}
-			transferIn = append(transferIn, transfer)
-		}
-	}
-
-	output = RetrieveUserInfoOutput{
-		Balance:     balance,
-		Inventory:   inventory,
-		TransferIn:  transferIn,
-		TransferOut: transferOut,
-	}
+	if input.RawHistory {
+		// The raw history is unbounded, so it is read on each request instead of cached.
+		output.TransferIn, output.TransferOut, err = s.Repo.GetTransferHistory(ctx, input.Username)
+		if err != nil {
+			s.Log.Error("Failed to retrieve transfer history",
+				zap.String("op", op),
+				zap.Error(err),
+			)
 
-	if err := s.Cache.Set(ctx, cacheKey, output); err != nil {
-		s.Log.Error("Failed to cache user info",
-			zap.String("op", op),
-			zap.Error(err),
-		)
+			return RetrieveUserInfoOutput{}, fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
 	s.Log.Info("Info successfully retrieved")
 
 	return output, nil
 }
+
+// loadUserInfo reads the user info and its version from the repository.
+func (s *UserService) loadUserInfo(ctx context.Context, username string) (RetrieveUserInfoOutput, int64, error) {
+	info, err := s.Repo.GetInfo(ctx, username)
+	if err != nil {
+		return RetrieveUserInfoOutput{}, 0, err
+	}
+
+	return RetrieveUserInfoOutput{
+		Balance:     info.Balance,
+		Inventory:   info.Inventory,
+		TransferIn:  info.Received,
+		TransferOut: info.Sent,
+	}, info.Version, nil
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"avito-internship/internal/cache"
+	"avito-internship/internal/entity"
 	"avito-internship/internal/repository"
 	"avito-internship/internal/repository/repoerrs"
 	"avito-internship/internal/service/servicerrs"
@@ -92,3 +94,63 @@ func TestUserService_CreateUser(t *testing.T) {
 		})
 	}
 }
+
+func TestUserService_RetrieveUserInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockRepo := repository.NewMockUser(ctrl)
+	mockCache, _ := newMemoryCache(ctrl)
+	service := NewUserService(zap.NewNop(), mockCache, mockRepo)
+
+	t.Run("Info is loaded once and then served from cache", func(t *testing.T) {
+		mockRepo.EXPECT().GetInfo(gomock.Any(), "alice").Return(entity.UserInfo{
+			Balance:   900,
+			Inventory: []entity.Inventory{{Product: "t-shirt", Quantity: 1}},
+			Received:  []entity.Transfer{{Username: "carol", Amount: 50}},
+			Sent:      []entity.Transfer{{Username: "bob", Amount: 100}},
+		}, nil).Times(1)
+
+		expected := RetrieveUserInfoOutput{
+			Balance:     900,
+			Inventory:   []entity.Inventory{{Product: "t-shirt", Quantity: 1}},
+			TransferIn:  []entity.Transfer{{Username: "carol", Amount: 50}},
+			TransferOut: []entity.Transfer{{Username: "bob", Amount: 100}},
+		}
+		for i := 0; i < 2; i++ {
+			output, err := service.RetrieveUserInfo(ctx, RetrieveUserInfoInput{Username: "alice"})
+			assert.NoError(t, err)
+			assert.Equal(t, expected, output)
+		}
+
+		assert.Equal(t, uint64(1), service.InfoCacheStats().Hits)
+	})
+
+	t.Run("Raw history is read past the cache", func(t *testing.T) {
+		at := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+		received := []entity.Transfer{
+			{Username: "carol", Amount: 20, Count: 1, LastTransferAt: &at},
+			{Username: "carol", Amount: 30, Count: 1, LastTransferAt: &at},
+		}
+		mockRepo.EXPECT().GetTransferHistory(gomock.Any(), "alice").Return(received, []entity.Transfer{}, nil)
+
+		output, err := service.RetrieveUserInfo(ctx, RetrieveUserInfoInput{Username: "alice", RawHistory: true})
+		assert.NoError(t, err)
+		assert.Equal(t, 900, output.Balance)
+		assert.Equal(t, received, output.TransferIn)
+		assert.Empty(t, output.TransferOut)
+
+		// The cached totals are left as they are.
+		output, err = service.RetrieveUserInfo(ctx, RetrieveUserInfoInput{Username: "alice"})
+		assert.NoError(t, err)
+		assert.Equal(t, []entity.Transfer{{Username: "carol", Amount: 50}}, output.TransferIn)
+	})
+
+	t.Run("User not found", func(t *testing.T) {
+		mockRepo.EXPECT().GetInfo(gomock.Any(), "ghost").Return(entity.UserInfo{}, repoerrs.ErrUserNotFound)
+
+		_, err := service.RetrieveUserInfo(ctx, RetrieveUserInfoInput{Username: "ghost"})
+		assert.ErrorIs(t, err, servicerrs.ErrUserNotFound)
+	})
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"avito-internship/internal/entity"
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+
+	"go.uber.org/zap"
+)
+
+type WishlistService struct {
+	log  *zap.Logger
+	repo repository.Wishlist
+}
+
+func NewWishlistService(log *zap.Logger, repo repository.Wishlist) *WishlistService {
+	return &WishlistService{
+		log:  log,
+		repo: repo,
+	}
+}
+
+func (s *WishlistService) AddToWishlist(ctx context.Context, input WishlistInput) error {
+	const op = "service.WishlistService.AddToWishlist"
+
+	s.log.Info("attempting to add product to wishlist",
+		zap.String("username", input.Username),
+		zap.String("product", input.Product),
+	)
+
+	if err := s.repo.AddToWishlist(ctx, input.Username, input.Product); err != nil {
+		if errors.Is(err, repoerrs.ErrProductNotFound) {
+			s.log.Warn("product not found",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrProductNotFound)
+		}
+
+		s.log.Error("failed to add product to wishlist",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("product successfully added to wishlist")
+
+	return nil
+}
+
+func (s *WishlistService) RemoveFromWishlist(ctx context.Context, input WishlistInput) error {
+	const op = "service.WishlistService.RemoveFromWishlist"
+
+	s.log.Info("attempting to remove product from wishlist",
+		zap.String("username", input.Username),
+		zap.String("product", input.Product),
+	)
+
+	if err := s.repo.RemoveFromWishlist(ctx, input.Username, input.Product); err != nil {
+		if errors.Is(err, repoerrs.ErrWishlistItemNotFound) {
+			s.log.Warn("product is not in the wishlist",
+				zap.String("op", op),
+				zap.String("product", input.Product),
+			)
+
+			return fmt.Errorf("%s: %w", op, servicerrs.ErrWishlistItemNotFound)
+		}
+
+		s.log.Error("failed to remove product from wishlist",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("product successfully removed from wishlist")
+
+	return nil
+}
+
+func (s *WishlistService) ListWishlist(ctx context.Context, username string) ([]entity.WishlistItem, error) {
+	const op = "service.WishlistService.ListWishlist"
+
+	s.log.Info("attempting to list wishlist", zap.String("username", username))
+
+	wishlist, err := s.repo.GetWishlist(ctx, username)
+	if err != nil {
+		s.log.Error("failed to list wishlist",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return wishlist, nil
+}
+
+func (s *WishlistService) ListNotifications(ctx context.Context, username string) ([]entity.Notification, error) {
+	const op = "service.WishlistService.ListNotifications"
+
+	s.log.Info("attempting to list notifications", zap.String("username", username))
+
+	notifications, err := s.repo.GetNotifications(ctx, username)
+	if err != nil {
+		s.log.Error("failed to list notifications",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notifications, nil
+}
+
+func (s *WishlistService) MarkNotificationsRead(ctx context.Context, username string) error {
+	const op = "service.WishlistService.MarkNotificationsRead"
+
+	s.log.Info("attempting to mark notifications as read", zap.String("username", username))
+
+	if err := s.repo.MarkNotificationsRead(ctx, username); err != nil {
+		s.log.Error("failed to mark notifications as read",
+			zap.String("op", op),
+			zap.Error(err),
+		)
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
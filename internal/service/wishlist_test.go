@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"avito-internship/internal/repository"
+	"avito-internship/internal/repository/repoerrs"
+	"avito-internship/internal/service/servicerrs"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestWishlistService_AddToWishlist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockWishlist(ctrl)
+	logger := zap.NewNop()
+
+	service := NewWishlistService(logger, mockRepo)
+
+	tests := []struct {
+		name          string
+		input         WishlistInput
+		mockRepoSetup func(*repository.MockWishlist)
+		expectedError error
+	}{
+		{
+			name:  "Product added",
+			input: WishlistInput{Username: "user1", Product: "hoody"},
+			mockRepoSetup: func(m *repository.MockWishlist) {
+				m.EXPECT().
+					AddToWishlist(gomock.Any(), "user1", "hoody").
+					Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:  "Product not found",
+			input: WishlistInput{Username: "user1", Product: "unknown"},
+			mockRepoSetup: func(m *repository.MockWishlist) {
+				m.EXPECT().
+					AddToWishlist(gomock.Any(), "user1", "unknown").
+					Return(repoerrs.ErrProductNotFound)
+			},
+			expectedError: servicerrs.ErrProductNotFound,
+		},
+		{
+			name:  "Repository error",
+			input: WishlistInput{Username: "user1", Product: "hoody"},
+			mockRepoSetup: func(m *repository.MockWishlist) {
+				m.EXPECT().
+					AddToWishlist(gomock.Any(), "user1", "hoody").
+					Return(errors.New("repository error"))
+			},
+			expectedError: errors.New("repository error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup(mockRepo)
+
+			err := service.AddToWishlist(context.Background(), tt.input)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWishlistService_RemoveFromWishlist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := repository.NewMockWishlist(ctrl)
+	logger := zap.NewNop()
+
+	service := NewWishlistService(logger, mockRepo)
+
+	tests := []struct {
+		name          string
+		input         WishlistInput
+		mockRepoSetup func(*repository.MockWishlist)
+		expectedError error
+	}{
+		{
+			name:  "Product removed",
+			input: WishlistInput{Username: "user1", Product: "hoody"},
+			mockRepoSetup: func(m *repository.MockWishlist) {
+				m.EXPECT().
+					RemoveFromWishlist(gomock.Any(), "user1", "hoody").
+					Return(nil)
+			},
+			expectedError: nil,
+		},
+		{
+			name:  "Product not in wishlist",
+			input: WishlistInput{Username: "user1", Product: "cup"},
+			mockRepoSetup: func(m *repository.MockWishlist) {
+				m.EXPECT().
+					RemoveFromWishlist(gomock.Any(), "user1", "cup").
+					Return(repoerrs.ErrWishlistItemNotFound)
+			},
+			expectedError: servicerrs.ErrWishlistItemNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockRepoSetup(mockRepo)
+
+			err := service.RemoveFromWishlist(context.Background(), tt.input)
+
+			if tt.expectedError != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
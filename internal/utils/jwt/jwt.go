@@ -2,20 +2,98 @@ package jwt
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-// NewToken creates new JWT token for given user by his username.
-func NewToken(username string, salt string, tokenTTL time.Duration) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
+// PurposeTwoFactor marks a challenge token that only proves the password was
+// checked and must be exchanged for an access token with a second factor.
+const PurposeTwoFactor = "2fa"
+
+// PurposeImpersonation marks a token an admin acts with on behalf of another user.
+const PurposeImpersonation = "impersonation"
+
+// Claims are the verified claims of a token.
+type Claims struct {
+	// ID is the jti claim. Access tokens carry the ID of their session, so
+	// revoking the session revokes the token.
+	ID        string
+	Username  string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// Purpose is empty for access tokens.
+	Purpose string
+	// Actor is the admin acting with an impersonation token, taken from the act claim.
+	Actor string
+	// ReadOnly restricts an impersonation token to requests that change nothing.
+	ReadOnly bool
+}
+
+// Validation restricts the tokens the ring issues and accepts.
+type Validation struct {
+	// Issuer is put into the iss claim and required on parsing. Empty disables the check.
+	Issuer string
+	// Audience is put into the aud claim and required on parsing. Empty disables the check.
+	Audience string
+	// Leeway tolerates clock skew between the issuer and the verifier in exp, nbf and iat.
+	Leeway time.Duration
+}
+
+// SetValidation sets the claims checked by ParseToken and added to new tokens.
+func (k *KeyRing) SetValidation(v Validation) {
+	k.validation = v
+}
+
+// NewToken creates new JWT token for given user by his username within the session.
+// The token is signed with the signing key of the ring and carries its kid.
+func (k *KeyRing) NewToken(username, sessionID string, tokenTTL time.Duration) (string, error) {
+	return k.newToken(username, "", sessionID, tokenTTL, nil)
+}
+
+// NewChallengeToken creates a token with PurposeTwoFactor for the user.
+func (k *KeyRing) NewChallengeToken(username string, tokenTTL time.Duration) (string, error) {
+	return k.newToken(username, PurposeTwoFactor, uuid.NewString(), tokenTTL, nil)
+}
+
+// NewImpersonationToken creates a token with PurposeImpersonation for the
+// subject that names the actor in the act claim of RFC 8693.
+func (k *KeyRing) NewImpersonationToken(subject, actor string, readOnly bool, tokenTTL time.Duration) (string, error) {
+	return k.newToken(subject, PurposeImpersonation, uuid.NewString(), tokenTTL, jwt.MapClaims{
+		"act":       map[string]string{"username": actor},
+		"read_only": readOnly,
+	})
+}
+
+func (k *KeyRing) newToken(username, purpose, id string, tokenTTL time.Duration, extra jwt.MapClaims) (string, error) {
+	token := jwt.New(k.signingKey.method)
+	token.Header["kid"] = k.signingKey.kid
 
 	claims := token.Claims.(jwt.MapClaims)
+	now := time.Now()
+	claims["jti"] = id
 	claims["username"] = username
-	claims["exp"] = time.Now().Add(tokenTTL).Unix()
+	// iat keeps milliseconds, so tokens issued right after a password change
+	// are told apart from the ones it revokes.
+	claims["iat"] = float64(now.UnixMilli()) / 1000
+	claims["nbf"] = now.Unix()
+	claims["exp"] = now.Add(tokenTTL).Unix()
+	if k.validation.Issuer != "" {
+		claims["iss"] = k.validation.Issuer
+	}
+	if k.validation.Audience != "" {
+		claims["aud"] = k.validation.Audience
+	}
+	if purpose != "" {
+		claims["purpose"] = purpose
+	}
+	for name, value := range extra {
+		claims[name] = value
+	}
 
-	tokenString, err := token.SignedString([]byte(salt))
+	tokenString, err := token.SignedString(k.signer)
 	if err != nil {
 		return "", err
 	}
@@ -23,34 +101,87 @@ func NewToken(username string, salt string, tokenTTL time.Duration) (string, err
 	return tokenString, nil
 }
 
-// ParseToken extracts username from token.
-func ParseToken(tokenString string, secretKey string) (string, error) {
+// ParseToken extracts claims from token.
+// The token must be signed by one of the keys of the ring, be issued for the
+// configured issuer and audience and be within its validity period.
+func (k *KeyRing) ParseToken(tokenString string) (Claims, error) {
 	const op = "ParseToken"
 
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(k.validation.Leeway),
+	}
+	if k.validation.Issuer != "" {
+		options = append(options, jwt.WithIssuer(k.validation.Issuer))
+	}
+	if k.validation.Audience != "" {
+		options = append(options, jwt.WithAudience(k.validation.Audience))
+	}
+
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: kid not found in token header", op)
+		}
+
+		key, ok := k.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown kid %q", op, kid)
+		}
+
+		// The algorithm is bound to the key, so a token cannot pick a weaker one.
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, fmt.Errorf("%s: unexpected signing method: %v", op, token.Header["alg"])
 		}
-		return []byte(secretKey), nil
-	})
+
+		return key.public, nil
+	}, options...)
 
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		return Claims{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	if !token.Valid {
-		return "", fmt.Errorf("%s: invalid token", op)
+		return Claims{}, fmt.Errorf("%s: invalid token", op)
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", fmt.Errorf("%s: invalid token claims", op)
+		return Claims{}, fmt.Errorf("%s: invalid token claims", op)
 	}
 
 	username, ok := claims["username"].(string)
 	if !ok {
-		return "", fmt.Errorf("%s: email not found in token", op)
+		return Claims{}, fmt.Errorf("%s: username not found in token", op)
+	}
+
+	// Tokens without iat are treated as issued at the epoch, so any revocation covers them.
+	// The claim is read directly because the library truncates it to seconds.
+	issuedAt := time.Unix(0, 0)
+	if iat, ok := claims["iat"].(float64); ok {
+		issuedAt = time.UnixMilli(int64(math.Round(iat * 1000)))
+	}
+
+	// exp is required by the parser.
+	exp, _ := claims.GetExpirationTime()
+
+	id, _ := claims["jti"].(string)
+	purpose, _ := claims["purpose"].(string)
+	readOnly, _ := claims["read_only"].(bool)
+	var actor string
+	if act, ok := claims["act"].(map[string]interface{}); ok {
+		actor, _ = act["username"].(string)
 	}
 
-	return username, nil
+	return Claims{
+		ID:        id,
+		Username:  username,
+		IssuedAt:  issuedAt,
+		ExpiresAt: exp.Time,
+		Purpose:   purpose,
+		Actor:     actor,
+		ReadOnly:  readOnly,
+	}, nil
 }
@@ -1,62 +1,325 @@
 package jwt
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_ParseToken(t *testing.T) {
-	secretKey := "supersecretkey"
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
 
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["username"] = "test_username"
-	claims["exp"] = time.Now().Add(24 * time.Hour).Unix()
-	tokenString, err := token.SignedString([]byte(secretKey))
-	assert.NoError(t, err)
+	rsaRing, err := NewKeyRing(rsaKey)
+	require.NoError(t, err)
+	edRing, err := NewKeyRing(edKey)
+	require.NoError(t, err)
+	oldRing, err := NewKeyRing(oldKey)
+	require.NoError(t, err)
+
+	// After a rotation the new ring still accepts tokens signed with the old key.
+	rotatedRing, err := NewKeyRing(edKey)
+	require.NoError(t, err)
+	require.NoError(t, rotatedRing.AddVerificationKey(oldKey.Public()))
+
+	rsaToken, err := rsaRing.NewToken("test_username", "session", time.Hour)
+	require.NoError(t, err)
+	edToken, err := edRing.NewToken("test_username", "session", time.Hour)
+	require.NoError(t, err)
+	oldToken, err := oldRing.NewToken("test_username", "session", time.Hour)
+	require.NoError(t, err)
+	expiredToken, err := edRing.NewToken("test_username", "session", -time.Hour)
+	require.NoError(t, err)
+
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"username": "test_username",
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	})
+	hmacToken.Header["kid"] = edRing.SigningKeyID()
+	hmacTokenString, err := hmacToken.SignedString([]byte("supersecretkey"))
+	require.NoError(t, err)
+
+	noKidToken, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+		"username": "test_username",
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	}).SignedString(edKey)
+	require.NoError(t, err)
 
 	tests := []struct {
 		name         string
+		ring         *KeyRing
 		tokenString  string
-		secretKey    string
 		wantUsername string
 		wantErr      bool
 	}{
 		{
-			name:         "Valid token",
-			tokenString:  tokenString,
-			secretKey:    secretKey,
+			name:         "Valid RS256 token",
+			ring:         rsaRing,
+			tokenString:  rsaToken,
+			wantUsername: "test_username",
+		},
+		{
+			name:         "Valid EdDSA token",
+			ring:         edRing,
+			tokenString:  edToken,
+			wantUsername: "test_username",
+		},
+		{
+			name:         "Token signed with rotated key",
+			ring:         rotatedRing,
+			tokenString:  oldToken,
 			wantUsername: "test_username",
-			wantErr:      false,
 		},
 		{
-			name:         "Invalid signature",
-			tokenString:  tokenString,
-			secretKey:    "wrongkey",
-			wantUsername: "",
-			wantErr:      true,
+			name:        "Unknown key",
+			ring:        edRing,
+			tokenString: oldToken,
+			wantErr:     true,
+		},
+		{
+			name:        "Missing kid",
+			ring:        edRing,
+			tokenString: noKidToken,
+			wantErr:     true,
+		},
+		{
+			name:        "HMAC token",
+			ring:        edRing,
+			tokenString: hmacTokenString,
+			wantErr:     true,
+		},
+		{
+			name:        "Expired token",
+			ring:        edRing,
+			tokenString: expiredToken,
+			wantErr:     true,
 		},
 		{
-			name:         "Invalid token format",
-			tokenString:  "invalid.token.format",
-			secretKey:    secretKey,
-			wantUsername: "",
-			wantErr:      true,
+			name:        "Invalid token format",
+			ring:        edRing,
+			tokenString: "invalid.token.format",
+			wantErr:     true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			email, err := ParseToken(tt.tokenString, tt.secretKey)
+			claims, err := tt.ring.ParseToken(tt.tokenString)
 			if tt.wantErr {
 				assert.Error(t, err)
 				return
 			}
 			assert.NoError(t, err)
-			assert.Equal(t, tt.wantUsername, email)
+			assert.Equal(t, tt.wantUsername, claims.Username)
+			assert.Equal(t, "session", claims.ID)
+			assert.WithinDuration(t, time.Now(), claims.IssuedAt, time.Minute)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), claims.ExpiresAt, time.Minute)
 		})
 	}
 }
+
+func Test_ParseToken_IssuedAtMilliseconds(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ring, err := NewKeyRing(key)
+	require.NoError(t, err)
+
+	before := time.Now().Truncate(time.Millisecond)
+	token, err := ring.NewToken("test_username", "session", time.Hour)
+	require.NoError(t, err)
+	after := time.Now()
+
+	claims, err := ring.ParseToken(token)
+	require.NoError(t, err)
+	assert.False(t, claims.IssuedAt.Before(before))
+	assert.False(t, claims.IssuedAt.After(after))
+}
+
+func Test_ParseToken_Validation(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ring, err := NewKeyRing(key)
+	require.NoError(t, err)
+	ring.SetValidation(Validation{Issuer: "avito-shop", Audience: "avito-shop-api", Leeway: 30 * time.Second})
+
+	now := time.Now()
+	valid := jwt.MapClaims{
+		"jti":      "session",
+		"username": "test_username",
+		"iss":      "avito-shop",
+		"aud":      "avito-shop-api",
+		"iat":      now.Unix(),
+		"nbf":      now.Unix(),
+		"exp":      now.Add(time.Hour).Unix(),
+	}
+	with := func(key string, value any) jwt.MapClaims {
+		claims := jwt.MapClaims{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	sign := func(claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+		token.Header["kid"] = ring.SigningKeyID()
+		tokenString, err := token.SignedString(key)
+		require.NoError(t, err)
+		return tokenString
+	}
+
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		wantErr bool
+	}{
+		{name: "Valid claims", claims: valid},
+		{name: "Another issuer", claims: with("iss", "evil"), wantErr: true},
+		{name: "Missing issuer", claims: with("iss", nil), wantErr: true},
+		{name: "Another audience", claims: with("aud", "another-api"), wantErr: true},
+		{name: "Audience list", claims: with("aud", []string{"another-api", "avito-shop-api"})},
+		{name: "Missing expiration", claims: with("exp", nil), wantErr: true},
+		{name: "Expired within leeway", claims: with("exp", now.Add(-10*time.Second).Unix())},
+		{name: "Expired beyond leeway", claims: with("exp", now.Add(-time.Minute).Unix()), wantErr: true},
+		{name: "Not yet valid within leeway", claims: with("nbf", now.Add(10*time.Second).Unix())},
+		{name: "Not yet valid", claims: with("nbf", now.Add(time.Minute).Unix()), wantErr: true},
+		{name: "Issued in the future", claims: with("iat", now.Add(time.Minute).Unix()), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ring.ParseToken(sign(tt.claims))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("Issued tokens carry the claims", func(t *testing.T) {
+		token, err := ring.NewToken("test_username", "session", time.Hour)
+		require.NoError(t, err)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+		require.NoError(t, err)
+		claims := parsed.Claims.(jwt.MapClaims)
+		assert.Equal(t, "avito-shop", claims["iss"])
+		assert.Equal(t, "avito-shop-api", claims["aud"])
+		assert.Equal(t, "session", claims["jti"])
+		assert.Contains(t, claims, "nbf")
+
+		_, err = ring.ParseToken(token)
+		assert.NoError(t, err)
+	})
+}
+
+func Test_LoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaFile := filepath.Join(dir, "rsa.pem")
+	writePEM(t, rsaFile, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+	edFile := filepath.Join(dir, "ed25519.pem")
+	writePEM(t, edFile, "PRIVATE KEY", edDER)
+
+	edPublicDER, err := x509.MarshalPKIXPublicKey(edPublic)
+	require.NoError(t, err)
+	edPublicFile := filepath.Join(dir, "ed25519.pub")
+	writePEM(t, edPublicFile, "PUBLIC KEY", edPublicDER)
+
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	smallFile := filepath.Join(dir, "small.pem")
+	writePEM(t, smallFile, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(smallKey))
+
+	t.Run("Signing and verification keys", func(t *testing.T) {
+		ring, err := LoadKeyRing(rsaFile, []string{edPublicFile})
+		require.NoError(t, err)
+
+		jwks := ring.JWKS()
+		require.Len(t, jwks.Keys, 2)
+		assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+		assert.Equal(t, "RS256", jwks.Keys[0].Alg)
+		assert.Equal(t, ring.SigningKeyID(), jwks.Keys[0].Kid)
+		assert.Equal(t, "AQAB", jwks.Keys[0].E)
+		assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+		assert.Equal(t, "Ed25519", jwks.Keys[1].Crv)
+		assert.Equal(t, "EdDSA", jwks.Keys[1].Alg)
+
+		// The public key file and the private key file describe the same key.
+		edRing, err := LoadKeyRing(edFile, nil)
+		require.NoError(t, err)
+		assert.Equal(t, edRing.SigningKeyID(), jwks.Keys[1].Kid)
+
+		token, err := edRing.NewToken("test_username", "session", time.Hour)
+		require.NoError(t, err)
+		claims, err := ring.ParseToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, "test_username", claims.Username)
+		assert.Empty(t, claims.Purpose)
+
+		challenge, err := edRing.NewChallengeToken("test_username", time.Minute)
+		require.NoError(t, err)
+		claims, err = ring.ParseToken(challenge)
+		require.NoError(t, err)
+		assert.Equal(t, PurposeTwoFactor, claims.Purpose)
+		assert.NotEmpty(t, claims.ID)
+		assert.Empty(t, claims.Actor)
+
+		impersonation, err := edRing.NewImpersonationToken("test_username", "admin", true, time.Minute)
+		require.NoError(t, err)
+		claims, err = ring.ParseToken(impersonation)
+		require.NoError(t, err)
+		assert.Equal(t, "test_username", claims.Username)
+		assert.Equal(t, PurposeImpersonation, claims.Purpose)
+		assert.Equal(t, "admin", claims.Actor)
+		assert.True(t, claims.ReadOnly)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := LoadKeyRing(filepath.Join(dir, "missing.pem"), nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Public key cannot sign", func(t *testing.T) {
+		_, err := LoadKeyRing(edPublicFile, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Weak RSA key", func(t *testing.T) {
+		_, err := LoadKeyRing(smallFile, nil)
+		assert.Error(t, err)
+	})
+}
+
+func writePEM(t *testing.T, file string, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(file, data, 0o600))
+}
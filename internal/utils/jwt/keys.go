@@ -0,0 +1,320 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for signing or verification.
+const minRSAKeyBits = 2048
+
+// KeyRing signs tokens with a single private key and verifies them with any
+// of its public keys, so tokens issued before a key rotation stay valid
+// until the retired key is removed from the ring.
+type KeyRing struct {
+	signingKey verificationKey
+	signer     crypto.Signer
+	keys       map[string]verificationKey
+	order      []string
+	validation Validation
+}
+
+type verificationKey struct {
+	kid    string
+	method jwt.SigningMethod
+	public crypto.PublicKey
+}
+
+// JWKS is a JSON Web Key Set as served from /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a public JSON Web Key. Only RSA and Ed25519 keys are supported.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// PublicKey decodes the key. It is used to verify tokens of other issuers,
+// so the same key size limits apply as for our own keys.
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	var public crypto.PublicKey
+	switch {
+	case j.Kty == "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+		public = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+	case j.Kty == "OKP" && j.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key")
+		}
+		public = ed25519.PublicKey(x)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+
+	if _, err := newVerificationKey(public); err != nil {
+		return nil, err
+	}
+
+	return public, nil
+}
+
+// LoadKeyRing reads a PEM encoded private signing key and any number of
+// additional PEM encoded verification keys (public or private) from files.
+func LoadKeyRing(signingKeyFile string, verificationKeyFiles []string) (*KeyRing, error) {
+	const op = "LoadKeyRing"
+
+	signer, err := readPrivateKey(signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ring, err := NewKeyRing(signer)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, file := range verificationKeyFiles {
+		public, err := readPublicKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := ring.AddVerificationKey(public); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, file, err)
+		}
+	}
+
+	return ring, nil
+}
+
+// GenerateKeyRing creates a key ring with a fresh Ed25519 signing key.
+// Tokens signed by it cannot be verified after the process restarts.
+func GenerateKeyRing() (*KeyRing, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("GenerateKeyRing: %w", err)
+	}
+
+	return NewKeyRing(private)
+}
+
+// NewKeyRing creates a key ring that signs with the given RSA or Ed25519 private key.
+func NewKeyRing(signer crypto.Signer) (*KeyRing, error) {
+	key, err := newVerificationKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyRing{
+		signingKey: key,
+		signer:     signer,
+		keys:       map[string]verificationKey{key.kid: key},
+		order:      []string{key.kid},
+	}, nil
+}
+
+// AddVerificationKey accepts tokens signed by the private counterpart of the key.
+func (k *KeyRing) AddVerificationKey(public crypto.PublicKey) error {
+	key, err := newVerificationKey(public)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := k.keys[key.kid]; !ok {
+		k.keys[key.kid] = key
+		k.order = append(k.order, key.kid)
+	}
+
+	return nil
+}
+
+// SigningKeyID returns the kid of the key new tokens are signed with.
+func (k *KeyRing) SigningKeyID() string {
+	return k.signingKey.kid
+}
+
+// JWKS returns the public keys of the ring, signing key first.
+func (k *KeyRing) JWKS() JWKS {
+	set := JWKS{Keys: make([]JWK, 0, len(k.order))}
+	for _, kid := range k.order {
+		set.Keys = append(set.Keys, k.keys[kid].jwk())
+	}
+
+	return set
+}
+
+func newVerificationKey(public crypto.PublicKey) (verificationKey, error) {
+	var method jwt.SigningMethod
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return verificationKey{}, fmt.Errorf("rsa key must be at least %d bits", minRSAKeyBits)
+		}
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported key type %T", public)
+	}
+
+	key := verificationKey{
+		method: method,
+		public: public,
+	}
+	key.kid = key.thumbprint()
+
+	return key, nil
+}
+
+func (k verificationKey) jwk() JWK {
+	jwk := JWK{
+		Kid: k.kid,
+		Use: "sig",
+		Alg: k.method.Alg(),
+	}
+
+	switch key := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	}
+
+	return jwk
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint, which is stable for a key
+// and therefore used as its kid.
+func (k verificationKey) thumbprint() string {
+	jwk := k.jwk()
+
+	// Required members only, in lexicographic order.
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func readPrivateKey(file string) (crypto.Signer, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unexpected PEM block %q, want a private key", file, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported key type %T", file, key)
+	}
+
+	return signer, nil
+}
+
+func readPublicKey(file string) (crypto.PublicKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		return key, nil
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		return key, nil
+	}
+
+	// Private keys are accepted too, so a retired signing key file can be reused as is.
+	signer, err := readPrivateKey(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return signer.Public(), nil
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New(file + ": no PEM data found")
+	}
+
+	return block, nil
+}
@@ -0,0 +1,287 @@
+// Package oidc implements the OpenID Connect authorization code flow with
+// PKCE against a single identity provider.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	jwtutil "avito-internship/internal/utils/jwt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// jwksRefreshInterval limits how often an unknown kid triggers a JWKS download,
+	// so tokens with random kids cannot be used to flood the provider.
+	jwksRefreshInterval = time.Minute
+	// clockSkew tolerates clock drift between us and the provider.
+	clockSkew = time.Minute
+	// maxResponseSize caps the responses read from the provider.
+	maxResponseSize = 1 << 20
+)
+
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// IDToken holds the verified claims used to identify the user.
+type IDToken struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+type Provider struct {
+	cfg           Config
+	client        *http.Client
+	authURL       string
+	tokenURL      string
+	jwksURL       string
+	mu            sync.Mutex
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// NewProvider reads the provider metadata from the discovery document of the issuer.
+func NewProvider(ctx context.Context, cfg Config, client *http.Client) (*Provider, error) {
+	const op = "oidc.NewProvider"
+
+	var doc discovery
+	wellKnown := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, client, wellKnown, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if doc.Issuer != cfg.Issuer {
+		return nil, fmt.Errorf("%s: issuer %q does not match the configured %q", op, doc.Issuer, cfg.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("%s: incomplete discovery document", op)
+	}
+
+	return &Provider{
+		cfg:      cfg,
+		client:   client,
+		authURL:  doc.AuthorizationEndpoint,
+		tokenURL: doc.TokenEndpoint,
+		jwksURL:  doc.JWKSURI,
+		keys:     map[string]crypto.PublicKey{},
+	}, nil
+}
+
+func (p *Provider) Issuer() string {
+	return p.cfg.Issuer
+}
+
+// AuthCodeURL returns the provider login page URL the user is redirected to.
+func (p *Provider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {"openid email"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(p.authURL, "?") {
+		separator = "&"
+	}
+
+	return p.authURL + separator + params.Encode()
+}
+
+// Exchange redeems the authorization code and returns the raw ID token.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	const op = "oidc.Provider.Exchange"
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&body); err != nil {
+		return "", fmt.Errorf("%s: status %d: %w", op, resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: status %d: %s %s", op, resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%s: no id token in response", op)
+	}
+
+	return body.IDToken, nil
+}
+
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	AuthorizedBy  string `json:"azp"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of the token.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (IDToken, error) {
+	const op = "oidc.Provider.VerifyIDToken"
+
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(rawIDToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return IDToken{}, fmt.Errorf("%s: %w: %w", op, ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" {
+		return IDToken{}, fmt.Errorf("%s: %w: missing subject", op, ErrInvalidIDToken)
+	}
+	if claims.Nonce != nonce {
+		return IDToken{}, fmt.Errorf("%s: %w: nonce mismatch", op, ErrInvalidIDToken)
+	}
+	if claims.AuthorizedBy != "" && claims.AuthorizedBy != p.cfg.ClientID {
+		return IDToken{}, fmt.Errorf("%s: %w: issued to another client", op, ErrInvalidIDToken)
+	}
+
+	return IDToken{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}, nil
+}
+
+// key returns the provider key with the kid, downloading the JWKS again
+// when the kid is unknown, since providers rotate their keys.
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if kid == "" {
+		return nil, errors.New("missing kid")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+
+	var jwks jwtutil.JWKS
+	if err := getJSON(ctx, p.client, p.jwksURL, &jwks); err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	p.keysFetchedAt = time.Now()
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped, the provider may publish several.
+		if public, err := jwk.PublicKey(); err == nil {
+			keys[jwk.Kid] = public
+		}
+	}
+	p.keys = keys
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unknown kid %q", kid)
+}
+
+// RandomString returns a URL-safe random value for state, nonce and PKCE verifiers.
+func RandomString() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// CodeChallenge derives the S256 PKCE challenge from the verifier.
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
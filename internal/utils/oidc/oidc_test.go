@@ -0,0 +1,141 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"avito-internship/internal/utils/oidc"
+	"avito-internship/internal/utils/oidc/oidctest"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const redirectURL = "http://localhost:8080/api/oidc/callback"
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	ctx := context.Background()
+
+	idp, err := oidctest.NewProvider("shop", "secret")
+	require.NoError(t, err)
+	defer idp.Close()
+
+	provider, err := oidc.NewProvider(ctx, idp.Config(redirectURL), http.DefaultClient)
+	require.NoError(t, err)
+
+	user := oidctest.User{Subject: "42", Email: "alice@corp.example", EmailVerified: true}
+
+	t.Run("Valid login", func(t *testing.T) {
+		authURL := provider.AuthCodeURL("state", "nonce", "verifier")
+		code, state, err := idp.Login(authURL, user)
+		require.NoError(t, err)
+		assert.Equal(t, "state", state)
+
+		rawIDToken, err := provider.Exchange(ctx, code, "verifier")
+		require.NoError(t, err)
+
+		idToken, err := provider.VerifyIDToken(ctx, rawIDToken, "nonce")
+		require.NoError(t, err)
+		assert.Equal(t, oidc.IDToken{Subject: "42", Email: "alice@corp.example", EmailVerified: true}, idToken)
+	})
+
+	t.Run("Wrong PKCE verifier", func(t *testing.T) {
+		code, _, err := idp.Login(provider.AuthCodeURL("state", "nonce", "verifier"), user)
+		require.NoError(t, err)
+
+		_, err = provider.Exchange(ctx, code, "another-verifier")
+		assert.Error(t, err)
+	})
+
+	t.Run("Code used twice", func(t *testing.T) {
+		code, _, err := idp.Login(provider.AuthCodeURL("state", "nonce", "verifier"), user)
+		require.NoError(t, err)
+
+		_, err = provider.Exchange(ctx, code, "verifier")
+		require.NoError(t, err)
+		_, err = provider.Exchange(ctx, code, "verifier")
+		assert.Error(t, err)
+	})
+
+	t.Run("Nonce mismatch", func(t *testing.T) {
+		code, _, err := idp.Login(provider.AuthCodeURL("state", "nonce", "verifier"), user)
+		require.NoError(t, err)
+		rawIDToken, err := provider.Exchange(ctx, code, "verifier")
+		require.NoError(t, err)
+
+		_, err = provider.VerifyIDToken(ctx, rawIDToken, "another-nonce")
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+
+	now := time.Now()
+	valid := jwt.MapClaims{
+		"iss":   idp.URL,
+		"sub":   "42",
+		"aud":   "shop",
+		"exp":   now.Add(time.Minute).Unix(),
+		"iat":   now.Unix(),
+		"nonce": "nonce",
+	}
+	with := func(key string, value any) jwt.MapClaims {
+		claims := jwt.MapClaims{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		claims[key] = value
+		return claims
+	}
+
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		wantErr bool
+	}{
+		{name: "Signed claims", claims: valid},
+		{name: "Another issuer", claims: with("iss", "https://evil.example"), wantErr: true},
+		{name: "Another audience", claims: with("aud", "another-client"), wantErr: true},
+		{name: "Another authorized party", claims: with("azp", "another-client"), wantErr: true},
+		{name: "Expired", claims: with("exp", now.Add(-time.Hour).Unix()), wantErr: true},
+		{name: "Missing subject", claims: with("sub", ""), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawIDToken, err := idp.SignIDToken(tt.claims)
+			require.NoError(t, err)
+
+			_, err = provider.VerifyIDToken(ctx, rawIDToken, "nonce")
+			if tt.wantErr {
+				assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("Token signed by another key", func(t *testing.T) {
+		other, err := oidctest.NewProvider("shop", "secret")
+		require.NoError(t, err)
+		defer other.Close()
+
+		rawIDToken, err := other.SignIDToken(valid)
+		require.NoError(t, err)
+
+		_, err = provider.VerifyIDToken(ctx, rawIDToken, "nonce")
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+}
+
+func TestNewProvider_IssuerMismatch(t *testing.T) {
+	idp, err := oidctest.NewProvider("shop", "secret")
+	require.NoError(t, err)
+	defer idp.Close()
+
+	cfg := idp.Config(redirectURL)
+	cfg.Issuer += "/"
+
+	_, err = oidc.NewProvider(context.Background(), cfg, http.DefaultClient)
+	assert.Error(t, err)
+}
@@ -0,0 +1,100 @@
+package password
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxBcryptLength is the number of bytes bcrypt takes into account.
+const MaxBcryptLength = 72
+
+var (
+	ErrTooShort = errors.New("password is too short")
+	ErrTooLong  = errors.New("password is too long")
+	ErrBreached = errors.New("password is too common")
+)
+
+// Policy decides whether a password can be set for an account.
+type Policy struct {
+	minLength int
+	maxLength int
+	breached  map[string]struct{}
+}
+
+// NewPolicy creates a policy that requires at least minLength characters and at
+// most maxLength bytes and rejects the listed breached passwords.
+// maxLength is capped at MaxBcryptLength.
+func NewPolicy(minLength, maxLength int, breached []string) *Policy {
+	if maxLength <= 0 || maxLength > MaxBcryptLength {
+		maxLength = MaxBcryptLength
+	}
+
+	set := make(map[string]struct{}, len(breached))
+	for _, password := range breached {
+		set[password] = struct{}{}
+	}
+
+	return &Policy{
+		minLength: minLength,
+		maxLength: maxLength,
+		breached:  set,
+	}
+}
+
+// LoadPolicy creates a policy with the breached passwords read from a file,
+// one password per line. An empty file name disables the breached list.
+func LoadPolicy(minLength, maxLength int, breachedListFile string) (*Policy, error) {
+	const op = "LoadPolicy"
+
+	if breachedListFile == "" {
+		return NewPolicy(minLength, maxLength, nil), nil
+	}
+
+	file, err := os.Open(breachedListFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer file.Close()
+
+	var breached []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			breached = append(breached, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return NewPolicy(minLength, maxLength, breached), nil
+}
+
+// Validate returns ErrTooShort, ErrTooLong or ErrBreached if the password violates the policy.
+func (p *Policy) Validate(password string) error {
+	if utf8.RuneCountInString(password) < p.minLength {
+		return ErrTooShort
+	}
+	if len(password) > p.maxLength {
+		return ErrTooLong
+	}
+	if _, ok := p.breached[password]; ok {
+		return ErrBreached
+	}
+
+	return nil
+}
+
+// MinLength returns the minimal number of characters in a password.
+func (p *Policy) MinLength() int {
+	return p.minLength
+}
+
+// MaxLength returns the maximal number of bytes in a password.
+func (p *Policy) MaxLength() int {
+	return p.maxLength
+}
@@ -0,0 +1,67 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	policy := NewPolicy(8, 100, []string{"password123", "qwertyuiop"})
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "Valid password",
+			password: "correct horse battery",
+		},
+		{
+			name:     "Length is counted in characters",
+			password: "пароль12",
+		},
+		{
+			name:     "Too short",
+			password: "short",
+			wantErr:  ErrTooShort,
+		},
+		{
+			name:     "Longer than bcrypt accepts",
+			password: strings.Repeat("a", MaxBcryptLength+1),
+			wantErr:  ErrTooLong,
+		},
+		{
+			name:     "Breached password",
+			password: "password123",
+			wantErr:  ErrBreached,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantErr, policy.Validate(tt.password))
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(file, []byte("123456789\r\n\nletmein!!\n"), 0o600))
+
+	policy, err := LoadPolicy(8, 0, file)
+	require.NoError(t, err)
+
+	assert.Equal(t, ErrBreached, policy.Validate("123456789"))
+	assert.Equal(t, ErrBreached, policy.Validate("letmein!!"))
+	assert.NoError(t, policy.Validate("not breached"))
+	assert.Equal(t, MaxBcryptLength, policy.MaxLength())
+
+	_, err = LoadPolicy(8, 0, filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Остаток товара на складе (NULL - без ограничений) и лимит покупок на пользователя
+ALTER TABLE products ADD COLUMN stock INT NULL CHECK (stock >= 0);
+ALTER TABLE products ADD COLUMN purchase_limit INT NULL CHECK (purchase_limit > 0);
+-- Создание таблицы истории изменений остатков
+CREATE TABLE stock_changes (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    delta INT NOT NULL,
+    stock INT NOT NULL, -- остаток после изменения
+    reason VARCHAR NOT NULL DEFAULT '',
+    changed_by INT NULL REFERENCES users(id) DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX idx_stock_changes_product_id ON stock_changes(product_id);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_stock_changes_product_id;
+DROP TABLE IF EXISTS stock_changes;
+ALTER TABLE products DROP COLUMN IF EXISTS purchase_limit;
+ALTER TABLE products DROP COLUMN IF EXISTS stock;
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Создание таблицы категорий товаров
+CREATE TABLE categories (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR NOT NULL UNIQUE
+);
+ALTER TABLE products ADD COLUMN category_id INT NULL REFERENCES categories(id) DEFAULT NULL;
+INSERT INTO categories (name) VALUES ('clothes'), ('accessories'), ('stationery');
+UPDATE products SET category_id = (SELECT id FROM categories WHERE name = 'clothes')
+    WHERE name IN ('t-shirt', 'hoody', 'pink-hoody', 'socks');
+UPDATE products SET category_id = (SELECT id FROM categories WHERE name = 'accessories')
+    WHERE name IN ('cup', 'powerbank', 'umbrella', 'wallet');
+UPDATE products SET category_id = (SELECT id FROM categories WHERE name = 'stationery')
+    WHERE name IN ('book', 'pen');
+-- Создание таблицы промокодов
+CREATE TABLE promo_codes (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR NOT NULL UNIQUE,
+    discount_type VARCHAR NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    discount_value INT NOT NULL CHECK (discount_value > 0),
+    product_id INT NULL REFERENCES products(id) DEFAULT NULL, -- промокод действует только на товар
+    category_id INT NULL REFERENCES categories(id) DEFAULT NULL, -- промокод действует только на категорию
+    valid_from TIMESTAMP NOT NULL DEFAULT NOW(),
+    valid_until TIMESTAMP NULL DEFAULT NULL,
+    max_uses INT NULL CHECK (max_uses > 0),
+    max_uses_per_user INT NULL CHECK (max_uses_per_user > 0),
+    uses INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW(),
+    CHECK (discount_type <> 'percent' OR discount_value <= 100)
+);
+-- Создание таблицы использований промокодов
+CREATE TABLE promo_code_usages (
+    id SERIAL PRIMARY KEY,
+    promo_code_id INT NOT NULL REFERENCES promo_codes(id),
+    user_id INT NOT NULL REFERENCES users(id),
+    operation_id UUID NOT NULL REFERENCES operations(id),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX idx_promo_code_usages_promo_code_id_user_id ON promo_code_usages(promo_code_id, user_id);
+-- Цена без скидки и применённый промокод
+ALTER TABLE operations ADD COLUMN list_price INT NULL DEFAULT NULL;
+ALTER TABLE operations ADD COLUMN promo_code_id INT NULL REFERENCES promo_codes(id) DEFAULT NULL;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE operations DROP COLUMN IF EXISTS promo_code_id;
+ALTER TABLE operations DROP COLUMN IF EXISTS list_price;
+DROP INDEX IF EXISTS idx_promo_code_usages_promo_code_id_user_id;
+DROP TABLE IF EXISTS promo_code_usages;
+DROP TABLE IF EXISTS promo_codes;
+ALTER TABLE products DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS categories;
+-- +goose StatementEnd
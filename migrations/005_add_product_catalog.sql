@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Описание и произвольные атрибуты товаров (размер, цвет и т.д.)
+ALTER TABLE products ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE products ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}';
+-- Полнотекстовый поиск по названию и описанию
+ALTER TABLE products ADD COLUMN search_vector TSVECTOR
+    GENERATED ALWAYS AS (to_tsvector('english', name || ' ' || description)) STORED;
+CREATE INDEX idx_products_search_vector ON products USING GIN(search_vector);
+CREATE INDEX idx_products_category_id ON products(category_id);
+-- Создание таблицы вариантов товаров со своим остатком (NULL - без ограничений)
+CREATE TABLE product_variants (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id),
+    name VARCHAR NOT NULL,
+    attributes JSONB NOT NULL DEFAULT '{}',
+    stock INT NULL CHECK (stock >= 0),
+    UNIQUE (product_id, name)
+);
+-- Вариант товара в покупке и в истории остатков
+ALTER TABLE operations ADD COLUMN variant_id INT NULL REFERENCES product_variants(id) DEFAULT NULL;
+ALTER TABLE stock_changes ADD COLUMN variant_id INT NULL REFERENCES product_variants(id) DEFAULT NULL;
+-- Заполнение каталога
+UPDATE products SET description = 'Cotton t-shirt with the Avito logo', attributes = '{"color": "white"}' WHERE name = 't-shirt';
+UPDATE products SET description = 'Ceramic coffee cup', attributes = '{"color": "white", "volume": "350ml"}' WHERE name = 'cup';
+UPDATE products SET description = 'Notebook for ideas and meeting notes' WHERE name = 'book';
+UPDATE products SET description = 'Ballpoint pen', attributes = '{"color": "blue"}' WHERE name = 'pen';
+UPDATE products SET description = 'Portable charger for phones and laptops', attributes = '{"capacity": "10000mAh"}' WHERE name = 'powerbank';
+UPDATE products SET description = 'Warm hooded sweatshirt', attributes = '{"color": "black"}' WHERE name = 'hoody';
+UPDATE products SET description = 'Automatic folding umbrella', attributes = '{"color": "black"}' WHERE name = 'umbrella';
+UPDATE products SET description = 'Cotton socks with a pattern' WHERE name = 'socks';
+UPDATE products SET description = 'Leather wallet', attributes = '{"color": "brown"}' WHERE name = 'wallet';
+UPDATE products SET description = 'Limited edition hooded sweatshirt', attributes = '{"color": "pink"}' WHERE name = 'pink-hoody';
+INSERT INTO product_variants (product_id, name, attributes)
+SELECT p.id, v.name, jsonb_build_object('size', v.name)
+FROM products p CROSS JOIN (VALUES ('S'), ('M'), ('L')) AS v(name)
+WHERE p.name IN ('t-shirt', 'hoody', 'pink-hoody');
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE stock_changes DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE operations DROP COLUMN IF EXISTS variant_id;
+DROP TABLE IF EXISTS product_variants;
+DROP INDEX IF EXISTS idx_products_category_id;
+DROP INDEX IF EXISTS idx_products_search_vector;
+ALTER TABLE products DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE products DROP COLUMN IF EXISTS attributes;
+ALTER TABLE products DROP COLUMN IF EXISTS description;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Возврат средств при отмене заказа
+ALTER TABLE operations DROP CONSTRAINT IF EXISTS operations_type_check;
+ALTER TABLE operations ADD CONSTRAINT operations_type_check CHECK (type IN ('purchase', 'transfer', 'refund'));
+-- Создание таблицы заказов на выдачу купленного мерча
+CREATE TABLE orders (
+    id SERIAL PRIMARY KEY,
+    operation_id UUID NOT NULL UNIQUE REFERENCES operations(id), -- покупка, по которой создан заказ
+    user_id INT NOT NULL REFERENCES users(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    variant_id INT NULL REFERENCES product_variants(id) DEFAULT NULL,
+    status VARCHAR NOT NULL DEFAULT 'placed' CHECK (status IN ('placed', 'packed', 'delivered', 'cancelled')),
+    location VARCHAR NOT NULL DEFAULT '', -- место выдачи
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_orders_user_id ON orders(user_id);
+CREATE INDEX idx_orders_status ON orders(status);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_orders_status;
+DROP INDEX IF EXISTS idx_orders_user_id;
+DROP TABLE IF EXISTS orders;
+DELETE FROM operations WHERE type = 'refund';
+ALTER TABLE operations DROP CONSTRAINT IF EXISTS operations_type_check;
+ALTER TABLE operations ADD CONSTRAINT operations_type_check CHECK (type IN ('purchase', 'transfer'));
+-- +goose StatementEnd
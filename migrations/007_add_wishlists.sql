@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Создание таблицы списков желаемого
+CREATE TABLE wishlists (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, product_id)
+);
+CREATE INDEX idx_wishlists_product_id ON wishlists(product_id);
+-- Создание таблицы уведомлений по товарам из списка желаемого
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    product_id INT NOT NULL REFERENCES products(id),
+    type VARCHAR NOT NULL CHECK (type IN ('back_in_stock', 'price_drop', 'affordable')),
+    price INT NOT NULL, -- цена товара на момент уведомления
+    read_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_notifications_user_id ON notifications(user_id);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_notifications_user_id;
+DROP TABLE IF EXISTS notifications;
+DROP INDEX IF EXISTS idx_wishlists_product_id;
+DROP TABLE IF EXISTS wishlists;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Время смены пароля: токены, выпущенные раньше, считаются отозванными
+ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMPTZ NULL DEFAULT NULL;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS password_changed_at;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Секрет TOTP, признак включения 2FA и последний использованный временной шаг (защита от повтора кода)
+ALTER TABLE users
+    ADD COLUMN totp_secret VARCHAR NULL DEFAULT NULL,
+    ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN totp_last_step BIGINT NULL DEFAULT NULL;
+-- Создание таблицы одноразовых кодов восстановления (хранятся только хеши)
+CREATE TABLE recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    code_hash BYTEA NOT NULL,
+    used_at TIMESTAMPTZ NULL DEFAULT NULL,
+    UNIQUE (user_id, code_hash)
+);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS recovery_codes;
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_last_step,
+    DROP COLUMN IF EXISTS totp_enabled,
+    DROP COLUMN IF EXISTS totp_secret;
+-- +goose StatementEnd
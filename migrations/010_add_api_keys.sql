@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Создание таблицы API-ключей для интеграций (хранятся только префикс и хеш ключа)
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    name VARCHAR NOT NULL,
+    prefix VARCHAR NOT NULL UNIQUE,
+    key_hash BYTEA NOT NULL,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMPTZ NULL DEFAULT NULL,
+    last_used_at TIMESTAMPTZ NULL DEFAULT NULL,
+    revoked_at TIMESTAMPTZ NULL DEFAULT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Создание таблицы внешних учётных записей (вход через корпоративного OIDC-провайдера)
+CREATE TABLE user_identities (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    issuer VARCHAR NOT NULL,
+    subject VARCHAR NOT NULL,
+    email VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (issuer, subject),
+    UNIQUE (user_id, issuer) -- у пользователя не больше одной учётной записи у провайдера
+);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_identities;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Создание таблицы сессий: каждый выданный токен доступа привязан к сессии
+CREATE TABLE sessions (
+    id UUID PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    user_agent VARCHAR NOT NULL DEFAULT '',
+    ip VARCHAR NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NULL DEFAULT NULL
+);
+CREATE INDEX idx_sessions_user_id ON sessions(user_id);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_sessions_user_id;
+DROP TABLE IF EXISTS sessions;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Журнал аудита имперсонации: выдача токенов и каждый запрос, выполненный от имени пользователя
+CREATE TABLE impersonation_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INT NOT NULL REFERENCES users(id),
+    subject_id INT NOT NULL REFERENCES users(id),
+    action VARCHAR NOT NULL,
+    method VARCHAR NOT NULL DEFAULT '',
+    path VARCHAR NOT NULL DEFAULT '',
+    reason VARCHAR NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_impersonation_log_subject_id ON impersonation_log(subject_id);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_impersonation_log_subject_id;
+DROP TABLE IF EXISTS impersonation_log;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Очередь инвалидаций кэша, которые не удалось выполнить из-за недоступности Redis
+CREATE TABLE cache_invalidations (
+    id BIGSERIAL PRIMARY KEY,
+    key VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS cache_invalidations;
+-- +goose StatementEnd
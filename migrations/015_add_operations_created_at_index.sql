@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Индекс для выборки самых активных пользователей при прогреве кэша
+CREATE INDEX idx_operations_created_at ON operations(created_at);
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_operations_created_at;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Суммы переводов пользователя по каждому контрагенту, отдельно отправленные и полученные.
+-- Обновляется в транзакции перевода, при создании заполняется по уже совершённым переводам
+CREATE TABLE transfer_totals (
+    user_id INT NOT NULL REFERENCES users(id),
+    counterparty_id INT NOT NULL REFERENCES users(id),
+    direction VARCHAR NOT NULL CHECK (direction IN ('sent', 'received')),
+    amount BIGINT NOT NULL,
+    PRIMARY KEY (user_id, direction, counterparty_id)
+);
+INSERT INTO transfer_totals (user_id, counterparty_id, direction, amount)
+SELECT user_id, counterparty_id, 'sent', SUM(amount)
+FROM operations
+WHERE type = 'transfer'
+GROUP BY user_id, counterparty_id
+UNION ALL
+SELECT counterparty_id, user_id, 'received', SUM(amount)
+FROM operations
+WHERE type = 'transfer'
+GROUP BY counterparty_id, user_id;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS transfer_totals;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Число переводов и время последнего перевода по каждому контрагенту.
+-- Для уже накопленных сумм заполняются по совершённым переводам
+ALTER TABLE transfer_totals
+    ADD COLUMN count INT NOT NULL DEFAULT 0,
+    ADD COLUMN last_transfer_at TIMESTAMP NULL;
+UPDATE transfer_totals t
+SET count = o.count, last_transfer_at = o.last_transfer_at
+FROM (
+    SELECT user_id, counterparty_id, COUNT(*) AS count, MAX(created_at) AS last_transfer_at
+    FROM operations
+    WHERE type = 'transfer'
+    GROUP BY user_id, counterparty_id
+) o
+WHERE t.direction = 'sent' AND t.user_id = o.user_id AND t.counterparty_id = o.counterparty_id;
+UPDATE transfer_totals t
+SET count = o.count, last_transfer_at = o.last_transfer_at
+FROM (
+    SELECT user_id, counterparty_id, COUNT(*) AS count, MAX(created_at) AS last_transfer_at
+    FROM operations
+    WHERE type = 'transfer'
+    GROUP BY user_id, counterparty_id
+) o
+WHERE t.direction = 'received' AND t.user_id = o.counterparty_id AND t.counterparty_id = o.user_id;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transfer_totals
+    DROP COLUMN IF EXISTS last_transfer_at,
+    DROP COLUMN IF EXISTS count;
+-- +goose StatementEnd
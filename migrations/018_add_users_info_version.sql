@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Версия сведений о пользователе: растёт при каждом изменении баланса,
+-- инвентаря или переводов, чтобы кэш не перезаписывался устаревшими данными
+ALTER TABLE users ADD COLUMN info_version BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS info_version;
+-- +goose StatementEnd